@@ -8,6 +8,7 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/centraunit/cu-firecracker-cms-starter/internal/errors"
 	"github.com/centraunit/cu-firecracker-cms-starter/internal/logger"
@@ -22,7 +23,7 @@ var pluginCmd = &cobra.Command{
 	Long: `Plugin management commands for building, validating, and packaging plugins.
 
 Available subcommands:
-• build   - Build a plugin into a bootable ext4 filesystem
+• build   - Build a plugin into a bootable ext4 or squashfs filesystem
 • validate - Validate a plugin directory and manifest
 • info    - Show information about a plugin`,
 }
@@ -30,17 +31,18 @@ Available subcommands:
 // buildCmd represents the plugin build command
 var buildCmd = &cobra.Command{
 	Use:   "build",
-	Short: "Build plugin into bootable ext4",
-	Long: `Build a plugin from source into a bootable ext4 filesystem packaged in a ZIP file.
+	Short: "Build plugin into bootable rootfs image",
+	Long: `Build a plugin from source into a bootable filesystem packaged in a ZIP file.
 
 This command will:
 • Validate the plugin directory and manifest
 • Build a Docker image from the plugin
-• Export the filesystem to an ext4 image
+• Export the filesystem to an ext4 image, or a read-only squashfs image
+  when plugin.json sets "rootfs_type": "squashfs"
 • Package everything into a ZIP file ready for CMS upload
 
 The resulting ZIP file contains:
-• rootfs.ext4 - The bootable filesystem
+• rootfs.ext4 or rootfs.squashfs - The bootable filesystem
 • plugin.json - The plugin manifest`,
 	RunE:         runPluginBuild,
 	SilenceUsage: true,
@@ -77,7 +79,7 @@ This will show:
 func init() {
 	// Build command flags
 	buildCmd.Flags().String("plugin", "", "Plugin directory (required)")
-	buildCmd.Flags().Int("size", 200, "Ext4 filesystem size in MB (200-800)")
+	buildCmd.Flags().Int("size", 200, "Ext4 filesystem size in MB (200-800, ignored for squashfs)")
 	buildCmd.MarkFlagRequired("plugin")
 
 	// Validate command flags
@@ -118,7 +120,7 @@ func runPluginBuild(cmd *cobra.Command, args []string) error {
 
 	// Success output like the original
 	fmt.Printf("✅ Plugin packaged successfully: %s\n", result.ZipPath)
-	fmt.Printf("📁 ZIP contains: rootfs.ext4 + plugin.json\n")
+	fmt.Printf("📁 ZIP contains: %s + plugin.json\n", filepath.Base(result.RootfsPath))
 	fmt.Printf("📤 Ready to upload to CMS!\n")
 
 	return nil
@@ -12,6 +12,7 @@ import (
 
 	"github.com/centraunit/cu-firecracker-cms-starter/internal/config"
 	"github.com/centraunit/cu-firecracker-cms-starter/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms-starter/internal/version"
 	"github.com/spf13/cobra"
 )
 
@@ -45,6 +46,9 @@ func init() {
 	// Initialize configuration
 	cfg = config.NewConfig()
 
+	// Enables --version
+	rootCmd.Version = version.String()
+
 	// Global flags
 	rootCmd.PersistentFlags().BoolVarP(&cfg.Verbose, "verbose", "v", false, "Enable verbose output")
 	rootCmd.PersistentFlags().BoolVar(&cfg.Debug, "debug", false, "Enable debug logging")
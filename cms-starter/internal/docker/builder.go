@@ -7,10 +7,14 @@
 package docker
 
 import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"time"
 
 	"github.com/centraunit/cu-firecracker-cms-starter/internal/errors"
 	"github.com/centraunit/cu-firecracker-cms-starter/internal/logger"
@@ -90,6 +94,53 @@ func (b *Builder) ImageExists(imageName string) bool {
 	return err == nil
 }
 
+// ExposedPorts returns the ports an image declares with EXPOSE (e.g. "80/tcp")
+func (b *Builder) ExposedPorts(imageName string) ([]string, error) {
+	output, err := exec.Command("docker", "image", "inspect", "--format", "{{json .Config.ExposedPorts}}", imageName).Output()
+	if err != nil {
+		return nil, errors.WrapDockerError(err, "exposed_ports",
+			fmt.Sprintf("failed to inspect image %s", imageName))
+	}
+
+	var exposed map[string]struct{}
+	if err := json.Unmarshal(output, &exposed); err != nil {
+		return nil, errors.WrapDockerError(err, "exposed_ports",
+			"failed to parse image exposed ports")
+	}
+
+	ports := make([]string, 0, len(exposed))
+	for port := range exposed {
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// ImageFileMode returns the mode of a file inside an image, following symlinks.
+// The file is read from a created (never started) container, so the image does
+// not need a shell.
+func (b *Builder) ImageFileMode(imageName, path string) (os.FileMode, error) {
+	containerName := fmt.Sprintf("chk-%d", time.Now().UnixNano())
+	if err := exec.Command("docker", "create", "--name", containerName, imageName).Run(); err != nil {
+		return 0, errors.WrapDockerError(err, "image_file_mode",
+			"failed to create container for inspection")
+	}
+	defer exec.Command("docker", "rm", containerName).Run()
+
+	output, err := exec.Command("docker", "cp", "-L", containerName+":"+path, "-").Output()
+	if err != nil {
+		return 0, errors.WrapDockerError(err, "image_file_mode",
+			fmt.Sprintf("%s not found in image", path))
+	}
+
+	header, err := tar.NewReader(bytes.NewReader(output)).Next()
+	if err != nil {
+		return 0, errors.WrapDockerError(err, "image_file_mode",
+			fmt.Sprintf("failed to read %s from image", path))
+	}
+
+	return header.FileInfo().Mode(), nil
+}
+
 // GetImageSize returns the size of a Docker image in bytes
 func (b *Builder) GetImageSize(imageName string) (int64, error) {
 	// This would require parsing docker inspect output
@@ -77,7 +77,7 @@ func (b *DefaultBuilder) Build(config *BuildConfig) (*BuildResult, error) {
 	// Generate build artifacts paths
 	buildName := fmt.Sprintf("%s-%s", SanitizeName(manifest.Name), manifest.Version)
 	imageName := "plugin-" + buildName
-	rootfsPath := filepath.Join(config.OutputDir, "rootfs.ext4")
+	rootfsPath := filepath.Join(config.OutputDir, manifest.RootfsFileName())
 	manifestPath := filepath.Join(config.OutputDir, "plugin.json")
 	zipPath := filepath.Join(config.OutputDir, buildName+".zip")
 
@@ -101,9 +101,17 @@ func (b *DefaultBuilder) Build(config *BuildConfig) (*BuildResult, error) {
 		}()
 	}
 
+	// Check the image can actually boot into a plugin server
+	b.logger.Debug("Verifying plugin guest contract")
+	if err := b.verifyGuestContract(imageName, manifest); err != nil {
+		result.Success = false
+		result.Error = err.Error()
+		return result, err
+	}
+
 	// Export rootfs
 	b.logger.Debug("Exporting plugin rootfs")
-	if err := b.exportRootfs(imageName, rootfsPath, config.Size); err != nil {
+	if err := b.exportRootfs(imageName, rootfsPath, manifest.EffectiveRootfsType(), config.Size); err != nil {
 		result.Success = false
 		result.Error = err.Error()
 		return result, err
@@ -170,8 +178,41 @@ func (b *DefaultBuilder) ValidateConfig(config *BuildConfig) error {
 	return nil
 }
 
-// exportRootfs exports the Docker container filesystem to an ext4 image
-func (b *DefaultBuilder) exportRootfs(imageName, outputPath string, sizeMB int) error {
+// verifyGuestContract checks that the image contains an executable entrypoint
+// and exposes the port the CMS connects to
+func (b *DefaultBuilder) verifyGuestContract(imageName string, manifest *Manifest) error {
+	entrypoint := manifest.EffectiveEntrypoint()
+
+	mode, err := b.builder.ImageFileMode(imageName, entrypoint)
+	if err != nil || !mode.IsRegular() || mode.Perm()&0111 == 0 {
+		return errors.New(errors.ErrTypeValidation, "verify_guest_contract",
+			fmt.Sprintf("image has no executable entrypoint at %s.\n"+
+				"💡 Solution: the VM boots %s, which must start the plugin HTTP server.\n"+
+				"   Create it in the Dockerfile (see plugins/python-plugin/Dockerfile) and chmod +x it,\n"+
+				"   or set \"entrypoint\" in plugin.json to the program that does", entrypoint, entrypoint))
+	}
+
+	port := fmt.Sprintf("%d/tcp", manifest.EffectivePort())
+	ports, err := b.builder.ExposedPorts(imageName)
+	if err != nil {
+		return err
+	}
+	for _, exposed := range ports {
+		if exposed == port {
+			return nil
+		}
+	}
+
+	return errors.New(errors.ErrTypeValidation, "verify_guest_contract",
+		fmt.Sprintf("image does not expose port %d.\n"+
+			"💡 Solution: add \"EXPOSE %d\" to the Dockerfile and make sure the plugin\n"+
+			"   HTTP server listens on port %d; the CMS sends /health and actions there",
+			manifest.EffectivePort(), manifest.EffectivePort(), manifest.EffectivePort()))
+}
+
+// exportRootfs exports the Docker container filesystem to an ext4 or squashfs image.
+// sizeMB only applies to ext4; squashfs images are sized to their contents.
+func (b *DefaultBuilder) exportRootfs(imageName, outputPath, rootfsType string, sizeMB int) error {
 	// Create container name for export
 	containerName := "exp-" + strings.ReplaceAll(imageName, "/", "_")
 
@@ -185,6 +226,18 @@ func (b *DefaultBuilder) exportRootfs(imageName, outputPath string, sizeMB int)
 	}
 	defer exec.Command("docker", "rm", containerName).Run()
 
+	if rootfsType == RootfsTypeSquashfs {
+		if err := b.createSquashfsFilesystem(containerName, outputPath); err != nil {
+			return err
+		}
+
+		b.logger.WithFields(logger.Fields{
+			"path": outputPath,
+		}).Info("Rootfs exported successfully")
+
+		return nil
+	}
+
 	// Create empty ext4 filesystem
 	b.logger.WithFields(logger.Fields{
 		"size_mb": sizeMB,
@@ -224,6 +277,59 @@ func (b *DefaultBuilder) createExt4Filesystem(path string, sizeMB int) error {
 	return nil
 }
 
+// createSquashfsFilesystem packs the container contents into a squashfs image
+func (b *DefaultBuilder) createSquashfsFilesystem(containerName, path string) error {
+	if _, err := exec.LookPath("mksquashfs"); err != nil {
+		return errors.New(errors.ErrTypeFileSystem, "create_squashfs",
+			"mksquashfs not found.\n"+
+				"💡 Solution: install squashfs-tools (e.g. apt install squashfs-tools)")
+	}
+
+	// Extract into a staging directory first; sudo keeps file ownership intact
+	tmpDir, err := os.MkdirTemp("", "cms-squashfs-")
+	if err != nil {
+		return errors.WrapFileSystemError(err, "create_squashfs",
+			"failed to create temporary directory")
+	}
+	defer exec.Command("sudo", "rm", "-rf", tmpDir).Run()
+
+	exportCmd := exec.Command("docker", "export", containerName)
+	tarCmd := exec.Command("sudo", "tar", "-xf", "-", "-C", tmpDir)
+	tarCmd.Stdin, _ = exportCmd.StdoutPipe()
+
+	var stderr bytes.Buffer
+	tarCmd.Stderr = &stderr
+
+	if err := tarCmd.Start(); err != nil {
+		return errors.WrapDockerError(err, "create_squashfs",
+			"failed to start extraction")
+	}
+
+	if err := exportCmd.Run(); err != nil {
+		return errors.WrapDockerError(err, "create_squashfs",
+			"failed to export container")
+	}
+
+	if err := tarCmd.Wait(); err != nil {
+		return errors.WrapFileSystemError(err, "create_squashfs",
+			fmt.Sprintf("failed to extract container contents. Error details: %s", stderr.String()))
+	}
+
+	os.Remove(path)
+	stderr.Reset()
+	mksquashfsCmd := exec.Command("sudo", "mksquashfs", tmpDir, path, "-noappend", "-quiet")
+	mksquashfsCmd.Stderr = &stderr
+	if err := mksquashfsCmd.Run(); err != nil {
+		return errors.WrapFileSystemError(err, "create_squashfs",
+			fmt.Sprintf("failed to create squashfs image. Error details: %s", stderr.String()))
+	}
+
+	// mksquashfs ran as root; hand the image back to the current user
+	exec.Command("sudo", "chown", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()), path).Run()
+
+	return nil
+}
+
 // extractContainerToFilesystem extracts container contents to a mounted filesystem
 func (b *DefaultBuilder) extractContainerToFilesystem(containerName, filesystemPath string) error {
 	// Create temporary mount point
@@ -92,8 +92,8 @@ func (m *DefaultManager) CreateZip(zipPath, rootfsPath, manifestPath string) err
 	zipWriter := zip.NewWriter(zipFile)
 	defer zipWriter.Close()
 
-	// Add rootfs.ext4
-	if err := m.addFileToZip(zipWriter, rootfsPath, "rootfs.ext4"); err != nil {
+	// Add the rootfs under its own name (rootfs.ext4 or rootfs.squashfs)
+	if err := m.addFileToZip(zipWriter, rootfsPath, filepath.Base(rootfsPath)); err != nil {
 		return errors.Wrap(err, errors.ErrTypeFileSystem, "create_zip",
 			"failed to add rootfs to ZIP")
 	}
@@ -132,7 +132,7 @@ func (m *DefaultManager) ExtractZip(zipPath, destDir string) error {
 	}
 
 	// Track required files
-	hasRootfs := false
+	rootfsName := ""
 	hasManifest := false
 
 	for _, file := range reader.File {
@@ -142,31 +142,38 @@ func (m *DefaultManager) ExtractZip(zipPath, destDir string) error {
 				fmt.Sprintf("invalid file path in ZIP: %s", file.Name))
 		}
 
+		isRootfs := file.Name == "rootfs."+RootfsTypeExt4 || file.Name == "rootfs."+RootfsTypeSquashfs
+
 		// Only extract required files
-		if file.Name != "rootfs.ext4" && file.Name != "plugin.json" {
+		if !isRootfs && file.Name != "plugin.json" {
 			m.logger.WithFields(logger.Fields{
 				"file": file.Name,
 			}).Debug("Skipping non-required file in ZIP")
 			continue
 		}
 
+		if isRootfs && rootfsName != "" {
+			return errors.NewValidationError("extract_zip",
+				fmt.Sprintf("plugin ZIP contains both %s and %s", rootfsName, file.Name))
+		}
+
 		destPath := filepath.Join(destDir, file.Name)
 		if err := m.extractFileFromZip(file, destPath); err != nil {
 			return errors.Wrap(err, errors.ErrTypeFileSystem, "extract_zip",
 				fmt.Sprintf("failed to extract file: %s", file.Name))
 		}
 
-		if file.Name == "rootfs.ext4" {
-			hasRootfs = true
-		} else if file.Name == "plugin.json" {
+		if isRootfs {
+			rootfsName = file.Name
+		} else {
 			hasManifest = true
 		}
 	}
 
 	// Validate that required files were present
-	if !hasRootfs {
+	if rootfsName == "" {
 		return errors.NewValidationError("extract_zip",
-			"rootfs.ext4 not found in plugin ZIP")
+			"rootfs.ext4 or rootfs.squashfs not found in plugin ZIP")
 	}
 	if !hasManifest {
 		return errors.NewValidationError("extract_zip",
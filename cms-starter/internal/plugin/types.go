@@ -19,6 +19,54 @@ type Manifest struct {
 	Author      string                 `json:"author"`
 	Runtime     string                 `json:"runtime"`
 	Actions     map[string]interface{} `json:"actions"`
+
+	// Guest contract - the program the VM boots and the port it serves HTTP on
+	Entrypoint string `json:"entrypoint,omitempty"`
+	Port       int    `json:"port,omitempty"`
+
+	// Filesystem of the packaged rootfs image (ext4 or squashfs)
+	RootfsType string `json:"rootfs_type,omitempty"`
+}
+
+// Guest contract defaults expected by the CMS
+const (
+	DefaultEntrypoint = "/sbin/init"
+	DefaultPort       = 80
+)
+
+// Rootfs filesystem types supported by the CMS
+const (
+	RootfsTypeExt4     = "ext4"     // Writable image of a fixed size
+	RootfsTypeSquashfs = "squashfs" // Compressed, read-only image
+)
+
+// EffectiveEntrypoint returns the declared entrypoint or the default
+func (m *Manifest) EffectiveEntrypoint() string {
+	if m.Entrypoint == "" {
+		return DefaultEntrypoint
+	}
+	return m.Entrypoint
+}
+
+// EffectivePort returns the declared port or the default
+func (m *Manifest) EffectivePort() int {
+	if m.Port == 0 {
+		return DefaultPort
+	}
+	return m.Port
+}
+
+// EffectiveRootfsType returns the declared rootfs type or ext4
+func (m *Manifest) EffectiveRootfsType() string {
+	if m.RootfsType == "" {
+		return RootfsTypeExt4
+	}
+	return m.RootfsType
+}
+
+// RootfsFileName returns the name of the rootfs image in the plugin ZIP
+func (m *Manifest) RootfsFileName() string {
+	return "rootfs." + m.EffectiveRootfsType()
 }
 
 // BuildConfig represents plugin build configuration
@@ -66,6 +66,19 @@ func (v *DefaultValidator) ValidateManifest(manifest *Manifest) error {
 		}
 	}
 
+	// Validate the guest contract
+	if err := v.validateGuestContract(manifest); err != nil {
+		return err
+	}
+
+	switch manifest.EffectiveRootfsType() {
+	case RootfsTypeExt4, RootfsTypeSquashfs:
+	default:
+		return errors.NewValidationError("validate_manifest",
+			fmt.Sprintf("unsupported rootfs_type: %s (supported: %s, %s)",
+				manifest.RootfsType, RootfsTypeExt4, RootfsTypeSquashfs))
+	}
+
 	return nil
 }
 
@@ -140,6 +153,22 @@ func (v *DefaultValidator) validateVersionFormat(version string) error {
 	return nil
 }
 
+// validateGuestContract validates the declared entrypoint and port
+func (v *DefaultValidator) validateGuestContract(manifest *Manifest) error {
+	if !strings.HasPrefix(manifest.EffectiveEntrypoint(), "/") {
+		return errors.NewValidationError("validate_guest_contract",
+			fmt.Sprintf("entrypoint must be an absolute path in the image, got %q", manifest.Entrypoint))
+	}
+
+	if manifest.EffectivePort() != DefaultPort {
+		return errors.NewValidationError("validate_guest_contract",
+			fmt.Sprintf("port %d is not supported: the CMS reaches plugins on port %d, "+
+				"so the plugin HTTP server must listen there", manifest.Port, DefaultPort))
+	}
+
+	return nil
+}
+
 // validateRuntime validates the runtime specification
 func (v *DefaultValidator) validateRuntime(runtime string) error {
 	validRuntimes := []string{"python", "node", "php", "go", "rust", "java"}
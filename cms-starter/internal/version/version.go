@@ -0,0 +1,21 @@
+/*
+ * Firecracker CMS - Starter Build Information
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package version
+
+import "fmt"
+
+// Set at build time with -ldflags "-X github.com/centraunit/cu-firecracker-cms-starter/internal/version.Version=..."
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// String formats the build information for --version
+func String() string {
+	return fmt.Sprintf("%s (commit %s, built %s)", Version, Commit, BuildDate)
+}
@@ -8,10 +8,121 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/textproto"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 )
 
+// maxServerTimeoutSec caps the HTTP server timeouts at one day
+const maxServerTimeoutSec = 24 * 60 * 60
+
+// Instance ID schemes
+const (
+	InstanceIDSchemeUnique = "unique" // A distinct ID per VM
+	InstanceIDSchemeSlug   = "slug"   // The plugin slug, one VM per plugin
+)
+
+// IP allocation strategies for plugin VMs
+const (
+	IPAllocationSequential = "sequential" // Next free address after the last one handed out
+	IPAllocationRandom     = "random"     // Any free address, so freed ones are rarely reused soon
+	IPAllocationSticky     = "sticky"     // The plugin's last address if free, else sequential
+)
+
+// Action execution modes
+const (
+	ExecutionModeBroadcast    = "broadcast"     // Run every matching plugin
+	ExecutionModeFirstSuccess = "first-success" // Stop after the first successful plugin
+	ExecutionModeAny          = "any"           // Stop after the first plugin that responds
+)
+
+// IsValidExecutionMode reports whether mode names an execution mode
+func IsValidExecutionMode(mode string) bool {
+	switch mode {
+	case ExecutionModeBroadcast, ExecutionModeFirstSuccess, ExecutionModeAny:
+		return true
+	}
+	return false
+}
+
+// ParseHookExecutionModes parses "hook=mode" pairs separated by commas
+func ParseHookExecutionModes(value string) (map[string]string, error) {
+	modes := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		hook, mode, found := strings.Cut(pair, "=")
+		hook, mode = strings.TrimSpace(hook), strings.TrimSpace(mode)
+		if !found || hook == "" {
+			return nil, fmt.Errorf("invalid hook execution mode %q, expected hook=mode", pair)
+		}
+		if !IsValidExecutionMode(mode) {
+			return nil, fmt.Errorf("invalid execution mode %q for hook %s (must be broadcast, first-success or any)", mode, hook)
+		}
+		modes[hook] = mode
+	}
+	return modes, nil
+}
+
+// ParsePayloadInjectFields parses "field=value" pairs separated by commas
+func ParsePayloadInjectFields(value string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		field, fieldValue, found := strings.Cut(pair, "=")
+		field = strings.TrimSpace(field)
+		if !found || field == "" {
+			return nil, fmt.Errorf("invalid payload field %q, expected field=value", pair)
+		}
+		fields[field] = strings.TrimSpace(fieldValue)
+	}
+	return fields, nil
+}
+
+// ParsePayloadHeaderFields parses "Header=field" pairs separated by commas,
+// keyed by the canonical header name
+func ParsePayloadHeaderFields(value string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		header, field, found := strings.Cut(pair, "=")
+		header, field = strings.TrimSpace(header), strings.TrimSpace(field)
+		if !found || header == "" || field == "" {
+			return nil, fmt.Errorf("invalid payload header mapping %q, expected Header=field", pair)
+		}
+		fields[textproto.CanonicalMIMEHeaderKey(header)] = field
+	}
+	return fields, nil
+}
+
+// ParseCallbackAllowlist parses comma-separated callback hosts; a "*." prefix
+// matches any subdomain, e.g. "*.hooks.example.com"
+func ParseCallbackAllowlist(value string) ([]string, error) {
+	var hosts []string
+	for _, host := range strings.Split(value, ",") {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" {
+			continue
+		}
+		if strings.ContainsAny(host, "/:@") || host == "*." || strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			return nil, fmt.Errorf("invalid callback host %q, expected a host name like hooks.example.com or *.example.com", host)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
 // Config holds all CMS configuration
 type Config struct {
 	// Server configuration
@@ -20,6 +131,15 @@ type Config struct {
 	Debug  bool   `json:"debug"`
 	LogDir string `json:"log_dir"`
 
+	// HTTP server timeouts in seconds
+	ReadTimeoutSec  int `json:"read_timeout_sec"`
+	WriteTimeoutSec int `json:"write_timeout_sec"` // Bounds plugin uploads and export downloads
+	IdleTimeoutSec  int `json:"idle_timeout_sec"`
+
+	// Logging configuration
+	LogFormat          string `json:"log_format"`           // "json" or "text"
+	LogComponentLevels string `json:"log_component_levels"` // e.g. "firecracker=warn,plugin=debug"
+
 	// Mode configuration
 	Mode    string `json:"mode"`    // "development", "production", "test"
 	Verbose bool   `json:"verbose"` // Verbose logging
@@ -27,14 +147,208 @@ type Config struct {
 	// Data directories
 	DataDir     string `json:"data_dir"`
 	PluginsDir  string `json:"plugins_dir"`
-	SnapshotDir string `json:"snapshot_dir"`
+	SnapshotDir string `json:"snapshot_dir"` // May live on separate storage from DataDir
+
+	// Permissions of data directories the CMS creates at startup
+	DataDirMode uint32 `json:"data_dir_mode"`
+
+	// Disk space kept free when creating snapshots
+	SnapshotReserveMB int `json:"snapshot_reserve_mb"`
+
+	// Uploads and snapshots that would leave less free disk are refused, 0 disables
+	MinFreeDiskMB int `json:"min_free_disk_mb"`
+
+	// Re-snapshot long-lived warm instances so recovery resumes recent state, 0 disables
+	SnapshotRefreshIntervalSec int `json:"snapshot_refresh_interval_sec"`
+
+	// Take a new full snapshot once a differential one exceeds this percentage
+	// of guest memory, 0 disables
+	SnapshotRebasePercent int `json:"snapshot_rebase_percent"`
+
+	// Take a new full snapshot after this many differential ones were merged
+	// into it, 0 disables
+	SnapshotMaxDiffs int `json:"snapshot_max_diffs"`
 
 	// Firecracker configuration
 	FirecrackerPath string `json:"firecracker_path"`
 	KernelPath      string `json:"kernel_path"`
 
+	// Jailer configuration - run Firecracker chrooted as an unprivileged user
+	JailerEnabled       bool   `json:"jailer_enabled"`
+	JailerPath          string `json:"jailer_path"`
+	JailerUID           int    `json:"jailer_uid"`
+	JailerGID           int    `json:"jailer_gid"`
+	JailerChrootBaseDir string `json:"jailer_chroot_base_dir"` // Must share a filesystem with kernel, plugins and snapshots
+
 	// VM Pool configuration
-	PrewarmPoolSize int `json:"prewarm_pool_size"`
+	PrewarmPoolSize     int `json:"prewarm_pool_size"`    // Running VMs per plugin
+	MaxWarmInstances    int `json:"max_warm_instances"`   // Running VMs across plugins, 0 for no cap
+	SnapshotConcurrency int `json:"snapshot_concurrency"` // Parallel snapshots for snapshot-all
+
+	// Guest memory running VMs may use, sizing the pool to the host
+	WarmMemoryBudgetMB      int `json:"warm_memory_budget_mb"`      // 0 for no budget
+	WarmMemoryBudgetPercent int `json:"warm_memory_budget_percent"` // Share of host memory, used without an MB budget
+
+	// How VM instance IDs are derived from plugin slugs
+	InstanceIDScheme string `json:"instance_id_scheme"` // "unique" or "slug"
+
+	// How plugin VM addresses are picked from the subnet
+	IPAllocationStrategy string `json:"ip_allocation_strategy"` // sequential, random or sticky
+
+	// Guest memory reclaimed by the balloon device while a warm VM is parked, 0 disables
+	BalloonTargetMB int `json:"balloon_target_mb"`
+
+	// Background health checking
+	HealthCheckIntervalSec  int `json:"health_check_interval_sec"`
+	HealthFailureThreshold  int `json:"health_failure_threshold"`  // Consecutive failures before unhealthy
+	HealthRecoveryThreshold int `json:"health_recovery_threshold"` // Consecutive successes before healthy
+
+	// Resource usage sampling
+	UsageSampleIntervalSec int `json:"usage_sample_interval_sec"`
+	UsageHistorySize       int `json:"usage_history_size"` // Samples kept per plugin
+
+	// Plugin bridge lifecycle
+	BridgeCreate   bool `json:"bridge_create"`   // Create and configure fcnetbridge0 at startup if missing
+	BridgeTeardown bool `json:"bridge_teardown"` // Delete the bridge on shutdown if the CMS created it
+
+	// Outbound NAT configuration
+	NATEnabled   bool   `json:"nat_enabled"`   // Masquerade the plugin subnet
+	NATInterface string `json:"nat_interface"` // Host interface for outbound traffic
+	GuestDNS     string `json:"guest_dns"`     // Comma-separated resolvers for VMs, host resolvers if empty
+
+	// Skip IPs that still answer on the bridge when allocating
+	IPLivenessCheck bool `json:"ip_liveness_check"`
+
+	// Push wall-clock time to guests resumed after a pause or from a snapshot
+	GuestTimeSync bool `json:"guest_time_sync"`
+
+	// Guest kernel console verbosity, quiet outside development mode
+	GuestKernelLogLevel int  `json:"guest_kernel_loglevel"` // 0-7, -1 keeps the kernel default
+	GuestQuietBoot      bool `json:"guest_quiet_boot"`      // Pass "quiet" on the kernel command line
+
+	// Guest identity, the hostname is the prefixed plugin slug
+	GuestHostnamePrefix string `json:"guest_hostname_prefix"`
+	GuestMMDS           bool   `json:"guest_mmds"` // Expose the plugin and instance through MMDS
+
+	// Longest kernel command line a VM is booted with, including the terminating NUL
+	KernelCmdlineMaxBytes int `json:"kernel_cmdline_max_bytes"`
+
+	// Plugin upload limits
+	MinRootfsSizeMB int `json:"min_rootfs_size_mb"`
+	MaxRootfsSizeMB int `json:"max_rootfs_size_mb"`
+	MaxUploadSizeMB int `json:"max_upload_size_mb"` // Whole upload request, checked before it reaches disk
+
+	// How long an upload waits for another upload of the same plugin to finish
+	UploadLockTimeoutSec int `json:"upload_lock_timeout_sec"` // 0 waits indefinitely
+
+	// Reject uploads whose hooks overlap active plugins unless a priority is declared
+	RequireHookPriority bool `json:"require_hook_priority"`
+
+	// Probe every declared action endpoint when validating plugins, not just /health
+	DeepValidation bool `json:"deep_validation"`
+
+	// Repeated health and selftest calls before a new rootfs is installed
+	InstallBurstCount      int `json:"install_burst_count"`       // 0 disables the burst
+	InstallBurstDurationMs int `json:"install_burst_duration_ms"` // Spread of the calls, 0 runs them back to back
+
+	// Register the built-in echo plugin, which answers without a VM
+	DiagnosticsEnabled bool `json:"diagnostics_enabled"`
+
+	// Refuse to start when a critical startup self-check fails
+	SelfCheckStrict bool `json:"selfcheck_strict"`
+
+	// Wrap non-JSON plugin responses in the result instead of failing the call
+	PluginRawResponses bool `json:"plugin_raw_responses"`
+
+	// Bytes of a streamed JSON lines response aggregated before it is truncated,
+	// and the largest raw non-JSON response accepted
+	PluginStreamMaxBytes int64 `json:"plugin_stream_max_bytes"`
+
+	// Streaming proxy to plugin VMs
+	ProxyMaxBodyMB  int `json:"proxy_max_body_mb"`
+	ProxyTimeoutSec int `json:"proxy_timeout_sec"`
+
+	// Longest wait for in-flight executions when deactivating a plugin, 0 tears down at once
+	PluginDrainTimeoutSec int `json:"plugin_drain_timeout_sec"`
+
+	// Longest wait for a booting plugin VM to answer /health before it counts as a boot timeout
+	PluginBootTimeoutSec int `json:"plugin_boot_timeout_sec"`
+
+	// Isolation tier of plugins that declare none, and the least isolated tier
+	// any plugin may run in
+	DefaultIsolationTier string `json:"default_isolation_tier"`
+	MinIsolationTier     string `json:"min_isolation_tier"`
+
+	// Range of plugin priorities; higher priorities execute first
+	PluginPriorityMin int `json:"plugin_priority_min"`
+	PluginPriorityMax int `json:"plugin_priority_max"`
+
+	// Most additional network interfaces a plugin may declare
+	MaxPluginInterfaces int `json:"max_plugin_interfaces"`
+
+	// Additional interfaces are addressed from their own /24 on their own bridge
+	ExtraInterfaceSubnet string `json:"extra_interface_subnet"`
+	ExtraInterfaceBridge string `json:"extra_interface_bridge"`
+
+	// TAP device tuning, 0 keeps the kernel defaults
+	TapMTU        int `json:"tap_mtu"`        // Passed to the guest as CMS_MTU, which its init must apply
+	TapTxQueueLen int `json:"tap_txqueuelen"` // Host-side transmit queue length
+
+	// Retries of VM starts failing for transient host reasons (tap, IP, socket)
+	VMStartRetries        int `json:"vm_start_retries"`          // 0 disables retries
+	VMStartRetryBackoffMs int `json:"vm_start_retry_backoff_ms"` // Doubled after every attempt
+
+	// How long a freshly booted VM's address may take to answer before the start fails
+	VMReadyTimeoutMs int `json:"vm_ready_timeout_ms"` // 0 returns as soon as Firecracker starts
+
+	// How long a resumed VM may take to accept connections before dispatch
+	ResumeReadyTimeoutMs int `json:"resume_ready_timeout_ms"` // 0 dispatches without probing
+
+	// Warm-hit ratio below which a plugin is flagged in the execution metrics
+	WarmHitRatioWarnPercent int `json:"warm_hit_ratio_warn_percent"` // 0 never flags
+
+	// Total time an action execution may take across all its plugins
+	ExecutionBudgetMs int `json:"execution_budget_ms"` // 0 leaves executions unbounded
+
+	// Logging of execution results - applied to logs only, not to API responses
+	LogResultMaxBytes int    `json:"log_result_max_bytes"` // 0 disables result logging
+	LogRedactFields   string `json:"log_redact_fields"`    // Comma-separated field names, case-insensitive
+	DebugPlugins      string `json:"debug_plugins"`        // Comma-separated slugs logged in full, like a plugin's debug setting
+
+	// Per-caller execution permissions, unrestricted when empty
+	CallerACLFile string `json:"caller_acl_file"`
+
+	// Bearer token for admin-only endpoints such as the config dump, disabled when empty
+	AdminToken string `json:"admin_token"`
+
+	// Execution modes of hooks not run in broadcast mode, as hook=mode pairs
+	HookExecutionModes string `json:"hook_execution_modes"`
+
+	// Fields added to every execution payload, overriding the caller's
+	PayloadInjectFields string `json:"payload_inject_fields"` // field=value pairs
+	PayloadHeaderFields string `json:"payload_header_fields"` // Header=field pairs copied from the request
+
+	// Asynchronous executions delivering results to a callback URL
+	CallbackAllowlist string `json:"callback_allowlist"` // Permitted callback hosts, callbacks are off if empty
+	CallbackRetries   int    `json:"callback_retries"`   // Redeliveries after a failed callback
+
+	MaxAsyncExecutions int `json:"max_async_executions"` // Executions running or delivering their callback at once
+
+	// Webhook receiving every plugin lifecycle event, off if empty
+	EventWebhookURL string `json:"event_webhook_url"`
+
+	// Mutual TLS with plugins that declare mtls, opt-in
+	PluginMTLS             bool `json:"plugin_mtls"`
+	PluginCertValidityDays int  `json:"plugin_cert_validity_days"` // Lifetime of issued certificates
+
+	// Retention of the queryable execution history
+	ExecutionHistoryMaxRecords  int `json:"execution_history_max_records"`   // 0 disables the history
+	ExecutionHistoryMaxAgeHours int `json:"execution_history_max_age_hours"` // 0 keeps records until the count limit
+
+	// Per-plugin circuit breaker, opened by failures among recent executions
+	CircuitBreakerFailures        int `json:"circuit_breaker_failures"`         // 0 disables the breaker
+	CircuitBreakerWindow          int `json:"circuit_breaker_window"`           // Recent executions considered
+	CircuitBreakerCooldownSeconds int `json:"circuit_breaker_cooldown_seconds"` // Open time before a probe execution
 }
 
 // NewConfig creates a new configuration with sensible defaults
@@ -46,6 +360,14 @@ func NewConfig() *Config {
 		Debug:  false,
 		LogDir: "/app/data/logs",
 
+		// HTTP server timeout defaults
+		ReadTimeoutSec:  30,
+		WriteTimeoutSec: 30,
+		IdleTimeoutSec:  120,
+
+		// Logging defaults
+		LogFormat: "json",
+
 		// Mode defaults
 		Mode:    "production", // Default to production
 		Verbose: false,
@@ -54,13 +376,153 @@ func NewConfig() *Config {
 		DataDir:     "/app/data",
 		PluginsDir:  "/app/data/plugins",
 		SnapshotDir: "/app/data/snapshots",
+		DataDirMode: 0755,
+
+		// Snapshot defaults - leave room for logs and registry writes
+		SnapshotReserveMB: 64,
+		MinFreeDiskMB:     256,
+
+		// Snapshot refresh is opt-in
+		SnapshotRefreshIntervalSec: 0,
+
+		// Past half of memory a diff plus merge costs more than a full snapshot
+		SnapshotRebasePercent: 50,
+
+		// Consolidate long differential chains
+		SnapshotMaxDiffs: 10,
 
 		// Firecracker defaults
 		FirecrackerPath: "/usr/local/bin/firecracker",
 		KernelPath:      "/opt/kernel/vmlinux",
 
+		// Jailer defaults - disabled, Firecracker runs directly
+		JailerEnabled:       false,
+		JailerPath:          "/usr/local/bin/jailer",
+		JailerUID:           1000,
+		JailerGID:           1000,
+		JailerChrootBaseDir: "/app/data/jailer",
+
 		// VM Pool defaults - configurable, not hardcoded!
-		PrewarmPoolSize: 10, // Default to 10, but can be overridden
+		PrewarmPoolSize:     10, // Default to 10, but can be overridden
+		MaxWarmInstances:    0,  // No global cap
+		SnapshotConcurrency: 2,
+		InstanceIDScheme:    InstanceIDSchemeUnique,
+		BalloonTargetMB:     0,
+
+		// Allocation default - the historical sequential order
+		IPAllocationStrategy: IPAllocationSequential,
+
+		// Health check defaults
+		HealthCheckIntervalSec:  30,
+		HealthFailureThreshold:  3,
+		HealthRecoveryThreshold: 2,
+
+		// Resource usage defaults - one hour of history at 30s intervals
+		UsageSampleIntervalSec: 30,
+		UsageHistorySize:       120,
+
+		// Bridge defaults - create if missing, keep it across restarts
+		BridgeCreate:   true,
+		BridgeTeardown: false,
+
+		// Outbound NAT defaults - disabled unless explicitly enabled
+		NATEnabled:   false,
+		NATInterface: "eth0",
+
+		// IP liveness check defaults - enabled, reads the host neighbor table
+		IPLivenessCheck: true,
+
+		// Guest time sync defaults - enabled, plugins without the endpoint ignore it
+		GuestTimeSync: true,
+
+		// Guest boot defaults - errors only; development mode boots verbosely
+		GuestKernelLogLevel: 3,
+		GuestQuietBoot:      true,
+
+		// Guest identity defaults - plain slug hostnames, metadata where supported
+		GuestMMDS: true,
+
+		// Kernel command line default - the x86 COMMAND_LINE_SIZE
+		KernelCmdlineMaxBytes: 2048,
+
+		// Plugin upload defaults - match the starter's build limits
+		MinRootfsSizeMB: 200,
+		MaxRootfsSizeMB: 800,
+		MaxUploadSizeMB: 1024, // Largest rootfs plus manifest and ZIP overhead
+
+		// Queued uploads of one plugin wait out a typical validation
+		UploadLockTimeoutSec: 300,
+
+		// Install burst defaults - disabled, a single health check validates
+		InstallBurstCount:      0,
+		InstallBurstDurationMs: 2000,
+
+		// Proxy defaults - large uploads, bounded duration
+		ProxyMaxBodyMB:  100,
+		ProxyTimeoutSec: 300,
+
+		// Drain defaults - covers typical action requests
+		PluginDrainTimeoutSec: 30,
+
+		// Boot timeout default - ample for a cold guest kernel and plugin server
+		PluginBootTimeoutSec: 15,
+
+		// Isolation defaults - plugins cannot declare themselves trusted
+		DefaultIsolationTier: "standard",
+		MinIsolationTier:     "standard",
+
+		// Priority bounds - room for coarse tiers with gaps between them
+		PluginPriorityMin: -1000,
+		PluginPriorityMax: 1000,
+
+		// Misconfigured hosts fail at startup rather than on the first plugin
+		SelfCheckStrict: true,
+
+		// Text and binary plugins work out of the box
+		PluginRawResponses: true,
+
+		// Streamed results are cut off at 16MB
+		PluginStreamMaxBytes: 16 << 20,
+
+		// Room for separate management and data NICs next to eth0
+		MaxPluginInterfaces:  2,
+		ExtraInterfaceSubnet: "192.168.128.0/24",
+		ExtraInterfaceBridge: "fcnetbridge1",
+
+		// Callback defaults - ride out a brief receiver outage
+		CallbackRetries:    3,
+		MaxAsyncExecutions: 64,
+
+		// Plugin certificates are reissued on boot shortly before expiry
+		PluginCertValidityDays: 365,
+
+		// Execution history defaults - a week, bounded in size
+		ExecutionHistoryMaxRecords:  10000,
+		ExecutionHistoryMaxAgeHours: 168,
+
+		// Circuit breaker defaults - 5 failures in the last 20 executions, 30s cooldown
+		CircuitBreakerFailures:        5,
+		CircuitBreakerWindow:          20,
+		CircuitBreakerCooldownSeconds: 30,
+
+		// VM start retry defaults - ride out brief host contention
+		VMStartRetries:        2,
+		VMStartRetryBackoffMs: 250,
+
+		// Boot readiness default - the kernel brings eth0 up well within a second
+		VMReadyTimeoutMs: 10000,
+
+		// Readiness probe default - resumed guests normally answer within milliseconds
+		ResumeReadyTimeoutMs: 2000,
+
+		// Flag plugins missing the pool on more than one execution in ten
+		WarmHitRatioWarnPercent: 90,
+
+		// No execution budget by default - each plugin request has its own timeout
+		ExecutionBudgetMs: 0,
+
+		// Result logging defaults - off, since results may carry personal data
+		LogResultMaxBytes: 0,
 	}
 }
 
@@ -74,6 +536,24 @@ func (c *Config) LoadFromEnv() error {
 		c.Host = host
 	}
 
+	if readTimeout := os.Getenv("CMS_READ_TIMEOUT"); readTimeout != "" {
+		if val, err := strconv.Atoi(readTimeout); err == nil {
+			c.ReadTimeoutSec = val
+		}
+	}
+
+	if writeTimeout := os.Getenv("CMS_WRITE_TIMEOUT"); writeTimeout != "" {
+		if val, err := strconv.Atoi(writeTimeout); err == nil {
+			c.WriteTimeoutSec = val
+		}
+	}
+
+	if idleTimeout := os.Getenv("CMS_IDLE_TIMEOUT"); idleTimeout != "" {
+		if val, err := strconv.Atoi(idleTimeout); err == nil {
+			c.IdleTimeoutSec = val
+		}
+	}
+
 	if debug := os.Getenv("CMS_DEBUG"); debug == "true" || debug == "1" {
 		c.Debug = true
 	}
@@ -82,6 +562,36 @@ func (c *Config) LoadFromEnv() error {
 		c.Mode = mode
 	}
 
+	// Full guest boot output helps when developing plugins
+	if c.IsDevelopmentMode() {
+		c.GuestKernelLogLevel = -1
+		c.GuestQuietBoot = false
+	}
+
+	if kernelLogLevel := os.Getenv("CMS_GUEST_KERNEL_LOGLEVEL"); kernelLogLevel != "" {
+		if val, err := strconv.Atoi(kernelLogLevel); err == nil {
+			c.GuestKernelLogLevel = val
+		}
+	}
+
+	if quietBoot := os.Getenv("CMS_GUEST_QUIET_BOOT"); quietBoot != "" {
+		c.GuestQuietBoot = quietBoot == "true" || quietBoot == "1"
+	}
+
+	if hostnamePrefix := os.Getenv("CMS_GUEST_HOSTNAME_PREFIX"); hostnamePrefix != "" {
+		c.GuestHostnamePrefix = hostnamePrefix
+	}
+
+	if guestMMDS := os.Getenv("CMS_GUEST_MMDS"); guestMMDS == "false" || guestMMDS == "0" {
+		c.GuestMMDS = false
+	}
+
+	if cmdlineMax := os.Getenv("CMS_KERNEL_CMDLINE_MAX_BYTES"); cmdlineMax != "" {
+		if val, err := strconv.Atoi(cmdlineMax); err == nil {
+			c.KernelCmdlineMaxBytes = val
+		}
+	}
+
 	if verbose := os.Getenv("CMS_VERBOSE"); verbose == "true" || verbose == "1" {
 		c.Verbose = true
 	}
@@ -94,6 +604,14 @@ func (c *Config) LoadFromEnv() error {
 		c.LogDir = logDir
 	}
 
+	if logFormat := os.Getenv("CMS_LOG_FORMAT"); logFormat != "" {
+		c.LogFormat = logFormat
+	}
+
+	if logLevels := os.Getenv("CMS_LOG_LEVELS"); logLevels != "" {
+		c.LogComponentLevels = logLevels
+	}
+
 	if pluginsDir := os.Getenv("CMS_PLUGINS_DIR"); pluginsDir != "" {
 		c.PluginsDir = pluginsDir
 	}
@@ -102,6 +620,43 @@ func (c *Config) LoadFromEnv() error {
 		c.SnapshotDir = snapshotDir
 	}
 
+	// Octal, e.g. 0750
+	if dirMode := os.Getenv("CMS_DATA_DIR_MODE"); dirMode != "" {
+		if val, err := strconv.ParseUint(dirMode, 8, 32); err == nil {
+			c.DataDirMode = uint32(val)
+		}
+	}
+
+	if minFree := os.Getenv("CMS_MIN_FREE_DISK_MB"); minFree != "" {
+		if val, err := strconv.Atoi(minFree); err == nil && val >= 0 {
+			c.MinFreeDiskMB = val
+		}
+	}
+
+	if reserve := os.Getenv("CMS_SNAPSHOT_RESERVE_MB"); reserve != "" {
+		if val, err := strconv.Atoi(reserve); err == nil && val >= 0 {
+			c.SnapshotReserveMB = val
+		}
+	}
+
+	if refreshInterval := os.Getenv("CMS_SNAPSHOT_REFRESH_INTERVAL"); refreshInterval != "" {
+		if val, err := strconv.Atoi(refreshInterval); err == nil && val >= 0 {
+			c.SnapshotRefreshIntervalSec = val
+		}
+	}
+
+	if rebasePercent := os.Getenv("CMS_SNAPSHOT_REBASE_PERCENT"); rebasePercent != "" {
+		if val, err := strconv.Atoi(rebasePercent); err == nil && val >= 0 {
+			c.SnapshotRebasePercent = val
+		}
+	}
+
+	if maxDiffs := os.Getenv("CMS_SNAPSHOT_MAX_DIFFS"); maxDiffs != "" {
+		if val, err := strconv.Atoi(maxDiffs); err == nil && val >= 0 {
+			c.SnapshotMaxDiffs = val
+		}
+	}
+
 	if firecrackerPath := os.Getenv("FIRECRACKER_PATH"); firecrackerPath != "" {
 		c.FirecrackerPath = firecrackerPath
 	}
@@ -110,6 +665,30 @@ func (c *Config) LoadFromEnv() error {
 		c.KernelPath = kernelPath
 	}
 
+	if jailerEnabled := os.Getenv("CMS_JAILER_ENABLED"); jailerEnabled == "true" || jailerEnabled == "1" {
+		c.JailerEnabled = true
+	}
+
+	if jailerPath := os.Getenv("JAILER_PATH"); jailerPath != "" {
+		c.JailerPath = jailerPath
+	}
+
+	if uid := os.Getenv("CMS_JAILER_UID"); uid != "" {
+		if val, err := strconv.Atoi(uid); err == nil && val >= 0 {
+			c.JailerUID = val
+		}
+	}
+
+	if gid := os.Getenv("CMS_JAILER_GID"); gid != "" {
+		if val, err := strconv.Atoi(gid); err == nil && val >= 0 {
+			c.JailerGID = val
+		}
+	}
+
+	if chrootBase := os.Getenv("CMS_JAILER_CHROOT_BASE"); chrootBase != "" {
+		c.JailerChrootBaseDir = chrootBase
+	}
+
 	// Parse PrewarmPoolSize from environment
 	if poolSize := os.Getenv("CMS_PREWARM_POOL_SIZE"); poolSize != "" {
 		if val, err := strconv.Atoi(poolSize); err == nil && val > 0 {
@@ -117,6 +696,364 @@ func (c *Config) LoadFromEnv() error {
 		}
 	}
 
+	if maxWarm := os.Getenv("CMS_MAX_WARM_INSTANCES"); maxWarm != "" {
+		if val, err := strconv.Atoi(maxWarm); err == nil {
+			c.MaxWarmInstances = val
+		}
+	}
+
+	if memoryBudget := os.Getenv("CMS_WARM_MEMORY_BUDGET_MB"); memoryBudget != "" {
+		if val, err := strconv.Atoi(memoryBudget); err == nil {
+			c.WarmMemoryBudgetMB = val
+		}
+	}
+
+	if memoryPercent := os.Getenv("CMS_WARM_MEMORY_BUDGET_PERCENT"); memoryPercent != "" {
+		if val, err := strconv.Atoi(memoryPercent); err == nil {
+			c.WarmMemoryBudgetPercent = val
+		}
+	}
+
+	if idScheme := os.Getenv("CMS_INSTANCE_ID_SCHEME"); idScheme != "" {
+		c.InstanceIDScheme = idScheme
+	}
+
+	if ipStrategy := os.Getenv("CMS_IP_ALLOCATION_STRATEGY"); ipStrategy != "" {
+		c.IPAllocationStrategy = ipStrategy
+	}
+
+	if balloonTarget := os.Getenv("CMS_BALLOON_TARGET_MB"); balloonTarget != "" {
+		if val, err := strconv.Atoi(balloonTarget); err == nil && val >= 0 {
+			c.BalloonTargetMB = val
+		}
+	}
+
+	if concurrency := os.Getenv("CMS_SNAPSHOT_CONCURRENCY"); concurrency != "" {
+		if val, err := strconv.Atoi(concurrency); err == nil && val > 0 {
+			c.SnapshotConcurrency = val
+		}
+	}
+
+	if interval := os.Getenv("CMS_HEALTH_CHECK_INTERVAL"); interval != "" {
+		if val, err := strconv.Atoi(interval); err == nil && val > 0 {
+			c.HealthCheckIntervalSec = val
+		}
+	}
+
+	if threshold := os.Getenv("CMS_HEALTH_FAILURE_THRESHOLD"); threshold != "" {
+		if val, err := strconv.Atoi(threshold); err == nil && val > 0 {
+			c.HealthFailureThreshold = val
+		}
+	}
+
+	if threshold := os.Getenv("CMS_HEALTH_RECOVERY_THRESHOLD"); threshold != "" {
+		if val, err := strconv.Atoi(threshold); err == nil && val > 0 {
+			c.HealthRecoveryThreshold = val
+		}
+	}
+
+	if interval := os.Getenv("CMS_USAGE_SAMPLE_INTERVAL"); interval != "" {
+		if val, err := strconv.Atoi(interval); err == nil && val > 0 {
+			c.UsageSampleIntervalSec = val
+		}
+	}
+
+	if historySize := os.Getenv("CMS_USAGE_HISTORY_SIZE"); historySize != "" {
+		if val, err := strconv.Atoi(historySize); err == nil && val > 0 {
+			c.UsageHistorySize = val
+		}
+	}
+
+	if bridgeCreate := os.Getenv("CMS_BRIDGE_CREATE"); bridgeCreate == "false" || bridgeCreate == "0" {
+		c.BridgeCreate = false
+	}
+
+	if bridgeTeardown := os.Getenv("CMS_BRIDGE_TEARDOWN"); bridgeTeardown == "true" || bridgeTeardown == "1" {
+		c.BridgeTeardown = true
+	}
+
+	if natEnabled := os.Getenv("CMS_NAT_ENABLED"); natEnabled == "true" || natEnabled == "1" {
+		c.NATEnabled = true
+	}
+
+	if natInterface := os.Getenv("CMS_NAT_INTERFACE"); natInterface != "" {
+		c.NATInterface = natInterface
+	}
+
+	if guestDNS := os.Getenv("CMS_GUEST_DNS"); guestDNS != "" {
+		c.GuestDNS = guestDNS
+	}
+
+	if livenessCheck := os.Getenv("CMS_IP_LIVENESS_CHECK"); livenessCheck == "false" || livenessCheck == "0" {
+		c.IPLivenessCheck = false
+	}
+
+	if timeSync := os.Getenv("CMS_GUEST_TIME_SYNC"); timeSync == "false" || timeSync == "0" {
+		c.GuestTimeSync = false
+	}
+
+	if requirePriority := os.Getenv("CMS_REQUIRE_HOOK_PRIORITY"); requirePriority == "true" || requirePriority == "1" {
+		c.RequireHookPriority = true
+	}
+
+	if deepValidation := os.Getenv("CMS_DEEP_VALIDATION"); deepValidation == "true" || deepValidation == "1" {
+		c.DeepValidation = true
+	}
+
+	if burstCount := os.Getenv("CMS_INSTALL_BURST_COUNT"); burstCount != "" {
+		if val, err := strconv.Atoi(burstCount); err == nil {
+			c.InstallBurstCount = val
+		}
+	}
+
+	if burstDuration := os.Getenv("CMS_INSTALL_BURST_DURATION_MS"); burstDuration != "" {
+		if val, err := strconv.Atoi(burstDuration); err == nil {
+			c.InstallBurstDurationMs = val
+		}
+	}
+
+	if diagnostics := os.Getenv("CMS_DIAGNOSTICS_ENABLED"); diagnostics == "true" || diagnostics == "1" {
+		c.DiagnosticsEnabled = true
+	}
+
+	if selfCheckStrict := os.Getenv("CMS_SELFCHECK_STRICT"); selfCheckStrict == "false" || selfCheckStrict == "0" {
+		c.SelfCheckStrict = false
+	}
+
+	if rawResponses := os.Getenv("CMS_PLUGIN_RAW_RESPONSES"); rawResponses == "false" || rawResponses == "0" {
+		c.PluginRawResponses = false
+	}
+
+	if streamMax := os.Getenv("CMS_PLUGIN_STREAM_MAX_BYTES"); streamMax != "" {
+		if val, err := strconv.ParseInt(streamMax, 10, 64); err == nil {
+			c.PluginStreamMaxBytes = val
+		}
+	}
+
+	if minSize := os.Getenv("CMS_MIN_ROOTFS_SIZE_MB"); minSize != "" {
+		if val, err := strconv.Atoi(minSize); err == nil && val > 0 {
+			c.MinRootfsSizeMB = val
+		}
+	}
+
+	if maxSize := os.Getenv("CMS_MAX_ROOTFS_SIZE_MB"); maxSize != "" {
+		if val, err := strconv.Atoi(maxSize); err == nil && val > 0 {
+			c.MaxRootfsSizeMB = val
+		}
+	}
+
+	if maxUpload := os.Getenv("CMS_MAX_UPLOAD_SIZE_MB"); maxUpload != "" {
+		if val, err := strconv.Atoi(maxUpload); err == nil && val > 0 {
+			c.MaxUploadSizeMB = val
+		}
+	}
+
+	if lockTimeout := os.Getenv("CMS_UPLOAD_LOCK_TIMEOUT_SEC"); lockTimeout != "" {
+		if val, err := strconv.Atoi(lockTimeout); err == nil {
+			c.UploadLockTimeoutSec = val
+		}
+	}
+
+	if proxyMaxBody := os.Getenv("CMS_PROXY_MAX_BODY_MB"); proxyMaxBody != "" {
+		if val, err := strconv.Atoi(proxyMaxBody); err == nil && val > 0 {
+			c.ProxyMaxBodyMB = val
+		}
+	}
+
+	if proxyTimeout := os.Getenv("CMS_PROXY_TIMEOUT"); proxyTimeout != "" {
+		if val, err := strconv.Atoi(proxyTimeout); err == nil && val > 0 {
+			c.ProxyTimeoutSec = val
+		}
+	}
+
+	if drainTimeout := os.Getenv("CMS_PLUGIN_DRAIN_TIMEOUT"); drainTimeout != "" {
+		if val, err := strconv.Atoi(drainTimeout); err == nil && val >= 0 {
+			c.PluginDrainTimeoutSec = val
+		}
+	}
+
+	if bootTimeout := os.Getenv("CMS_PLUGIN_BOOT_TIMEOUT"); bootTimeout != "" {
+		if val, err := strconv.Atoi(bootTimeout); err == nil && val > 0 {
+			c.PluginBootTimeoutSec = val
+		}
+	}
+
+	if defaultTier := os.Getenv("CMS_DEFAULT_ISOLATION_TIER"); defaultTier != "" {
+		c.DefaultIsolationTier = defaultTier
+	}
+
+	if minTier := os.Getenv("CMS_MIN_ISOLATION_TIER"); minTier != "" {
+		c.MinIsolationTier = minTier
+	}
+
+	if priorityMin := os.Getenv("CMS_PLUGIN_PRIORITY_MIN"); priorityMin != "" {
+		if val, err := strconv.Atoi(priorityMin); err == nil {
+			c.PluginPriorityMin = val
+		}
+	}
+
+	if priorityMax := os.Getenv("CMS_PLUGIN_PRIORITY_MAX"); priorityMax != "" {
+		if val, err := strconv.Atoi(priorityMax); err == nil {
+			c.PluginPriorityMax = val
+		}
+	}
+
+	if maxInterfaces := os.Getenv("CMS_MAX_PLUGIN_INTERFACES"); maxInterfaces != "" {
+		if val, err := strconv.Atoi(maxInterfaces); err == nil && val >= 0 {
+			c.MaxPluginInterfaces = val
+		}
+	}
+
+	if subnet := os.Getenv("CMS_EXTRA_INTERFACE_SUBNET"); subnet != "" {
+		c.ExtraInterfaceSubnet = subnet
+	}
+
+	if bridge := os.Getenv("CMS_EXTRA_INTERFACE_BRIDGE"); bridge != "" {
+		c.ExtraInterfaceBridge = bridge
+	}
+
+	if tapMTU := os.Getenv("CMS_TAP_MTU"); tapMTU != "" {
+		if val, err := strconv.Atoi(tapMTU); err == nil {
+			c.TapMTU = val
+		}
+	}
+
+	if txQueueLen := os.Getenv("CMS_TAP_TXQUEUELEN"); txQueueLen != "" {
+		if val, err := strconv.Atoi(txQueueLen); err == nil {
+			c.TapTxQueueLen = val
+		}
+	}
+
+	if startRetries := os.Getenv("CMS_VM_START_RETRIES"); startRetries != "" {
+		if val, err := strconv.Atoi(startRetries); err == nil {
+			c.VMStartRetries = val
+		}
+	}
+
+	if retryBackoff := os.Getenv("CMS_VM_START_RETRY_BACKOFF_MS"); retryBackoff != "" {
+		if val, err := strconv.Atoi(retryBackoff); err == nil {
+			c.VMStartRetryBackoffMs = val
+		}
+	}
+
+	if bootReady := os.Getenv("CMS_VM_READY_TIMEOUT_MS"); bootReady != "" {
+		if val, err := strconv.Atoi(bootReady); err == nil {
+			c.VMReadyTimeoutMs = val
+		}
+	}
+
+	if readyTimeout := os.Getenv("CMS_RESUME_READY_TIMEOUT_MS"); readyTimeout != "" {
+		if val, err := strconv.Atoi(readyTimeout); err == nil {
+			c.ResumeReadyTimeoutMs = val
+		}
+	}
+
+	if warmHitWarn := os.Getenv("CMS_WARM_HIT_RATIO_WARN_PERCENT"); warmHitWarn != "" {
+		if val, err := strconv.Atoi(warmHitWarn); err == nil {
+			c.WarmHitRatioWarnPercent = val
+		}
+	}
+
+	if budget := os.Getenv("CMS_EXECUTION_BUDGET_MS"); budget != "" {
+		if val, err := strconv.Atoi(budget); err == nil {
+			c.ExecutionBudgetMs = val
+		}
+	}
+
+	if resultMaxBytes := os.Getenv("CMS_LOG_RESULT_MAX_BYTES"); resultMaxBytes != "" {
+		if val, err := strconv.Atoi(resultMaxBytes); err == nil && val >= 0 {
+			c.LogResultMaxBytes = val
+		}
+	}
+
+	if redactFields := os.Getenv("CMS_LOG_REDACT_FIELDS"); redactFields != "" {
+		c.LogRedactFields = redactFields
+	}
+
+	if debugPlugins := os.Getenv("CMS_DEBUG_PLUGINS"); debugPlugins != "" {
+		c.DebugPlugins = debugPlugins
+	}
+
+	if aclFile := os.Getenv("CMS_CALLER_ACL_FILE"); aclFile != "" {
+		c.CallerACLFile = aclFile
+	}
+
+	if adminToken := os.Getenv("CMS_ADMIN_TOKEN"); adminToken != "" {
+		c.AdminToken = adminToken
+	}
+
+	if hookModes := os.Getenv("CMS_HOOK_EXECUTION_MODES"); hookModes != "" {
+		c.HookExecutionModes = hookModes
+	}
+
+	if injectFields := os.Getenv("CMS_PAYLOAD_INJECT_FIELDS"); injectFields != "" {
+		c.PayloadInjectFields = injectFields
+	}
+
+	if headerFields := os.Getenv("CMS_PAYLOAD_HEADER_FIELDS"); headerFields != "" {
+		c.PayloadHeaderFields = headerFields
+	}
+
+	if callbackHosts := os.Getenv("CMS_CALLBACK_ALLOWLIST"); callbackHosts != "" {
+		c.CallbackAllowlist = callbackHosts
+	}
+
+	if webhookURL := os.Getenv("CMS_EVENT_WEBHOOK_URL"); webhookURL != "" {
+		c.EventWebhookURL = webhookURL
+	}
+
+	if callbackRetries := os.Getenv("CMS_CALLBACK_RETRIES"); callbackRetries != "" {
+		if val, err := strconv.Atoi(callbackRetries); err == nil {
+			c.CallbackRetries = val
+		}
+	}
+
+	if maxAsync := os.Getenv("CMS_MAX_ASYNC_EXECUTIONS"); maxAsync != "" {
+		if val, err := strconv.Atoi(maxAsync); err == nil {
+			c.MaxAsyncExecutions = val
+		}
+	}
+
+	if pluginMTLS := os.Getenv("CMS_PLUGIN_MTLS"); pluginMTLS == "true" || pluginMTLS == "1" {
+		c.PluginMTLS = true
+	}
+
+	if certValidity := os.Getenv("CMS_PLUGIN_CERT_VALIDITY_DAYS"); certValidity != "" {
+		if val, err := strconv.Atoi(certValidity); err == nil {
+			c.PluginCertValidityDays = val
+		}
+	}
+
+	if historyRecords := os.Getenv("CMS_EXECUTION_HISTORY_MAX_RECORDS"); historyRecords != "" {
+		if val, err := strconv.Atoi(historyRecords); err == nil {
+			c.ExecutionHistoryMaxRecords = val
+		}
+	}
+
+	if historyAge := os.Getenv("CMS_EXECUTION_HISTORY_MAX_AGE_HOURS"); historyAge != "" {
+		if val, err := strconv.Atoi(historyAge); err == nil {
+			c.ExecutionHistoryMaxAgeHours = val
+		}
+	}
+
+	if breakerFailures := os.Getenv("CMS_CIRCUIT_BREAKER_FAILURES"); breakerFailures != "" {
+		if val, err := strconv.Atoi(breakerFailures); err == nil {
+			c.CircuitBreakerFailures = val
+		}
+	}
+
+	if breakerWindow := os.Getenv("CMS_CIRCUIT_BREAKER_WINDOW"); breakerWindow != "" {
+		if val, err := strconv.Atoi(breakerWindow); err == nil {
+			c.CircuitBreakerWindow = val
+		}
+	}
+
+	if breakerCooldown := os.Getenv("CMS_CIRCUIT_BREAKER_COOLDOWN_SECONDS"); breakerCooldown != "" {
+		if val, err := strconv.Atoi(breakerCooldown); err == nil {
+			c.CircuitBreakerCooldownSeconds = val
+		}
+	}
+
 	return nil
 }
 
@@ -126,14 +1063,312 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("port cannot be empty")
 	}
 
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("port must be a number between 1 and 65535, got %q", c.Port)
+	}
+
+	// Empty binds all interfaces; otherwise an IP or a plain host name
+	if c.Host != "" && net.ParseIP(c.Host) == nil && strings.ContainsAny(c.Host, ":/[] ") {
+		return fmt.Errorf("invalid host %q, expected an IP address or host name", c.Host)
+	}
+
+	if c.ReadTimeoutSec <= 0 || c.ReadTimeoutSec > maxServerTimeoutSec {
+		return fmt.Errorf("read timeout must be between 1 and %d seconds", maxServerTimeoutSec)
+	}
+
+	if c.WriteTimeoutSec <= 0 || c.WriteTimeoutSec > maxServerTimeoutSec {
+		return fmt.Errorf("write timeout must be between 1 and %d seconds", maxServerTimeoutSec)
+	}
+
+	if c.IdleTimeoutSec <= 0 || c.IdleTimeoutSec > maxServerTimeoutSec {
+		return fmt.Errorf("idle timeout must be between 1 and %d seconds", maxServerTimeoutSec)
+	}
+
 	if c.DataDir == "" {
 		return fmt.Errorf("data directory cannot be empty")
 	}
 
+	if c.DataDirMode&^0777 != 0 || c.DataDirMode&0700 != 0700 {
+		return fmt.Errorf("data directory mode must be a permission mode granting the owner rwx, got %#o", c.DataDirMode)
+	}
+
+	if c.LogFormat != "json" && c.LogFormat != "text" {
+		return fmt.Errorf("log format must be json or text")
+	}
+
+	if c.JailerEnabled && (c.JailerPath == "" || c.JailerChrootBaseDir == "") {
+		return fmt.Errorf("jailer path and chroot base directory are required when the jailer is enabled")
+	}
+
 	if c.PrewarmPoolSize <= 0 {
 		return fmt.Errorf("prewarm pool size must be positive")
 	}
 
+	if c.MaxWarmInstances < 0 {
+		return fmt.Errorf("max warm instances cannot be negative")
+	}
+
+	if c.WarmMemoryBudgetMB < 0 {
+		return fmt.Errorf("warm memory budget cannot be negative")
+	}
+
+	if c.WarmMemoryBudgetPercent < 0 || c.WarmMemoryBudgetPercent > 100 {
+		return fmt.Errorf("warm memory budget percent must be between 0 and 100, got %d", c.WarmMemoryBudgetPercent)
+	}
+
+	if c.SnapshotConcurrency <= 0 {
+		return fmt.Errorf("snapshot concurrency must be positive")
+	}
+
+	if _, err := ParseHookExecutionModes(c.HookExecutionModes); err != nil {
+		return err
+	}
+
+	if _, err := ParsePayloadInjectFields(c.PayloadInjectFields); err != nil {
+		return err
+	}
+
+	if _, err := ParsePayloadHeaderFields(c.PayloadHeaderFields); err != nil {
+		return err
+	}
+
+	if _, err := ParseCallbackAllowlist(c.CallbackAllowlist); err != nil {
+		return err
+	}
+
+	if c.CallbackRetries < 0 || c.CallbackRetries > 10 {
+		return fmt.Errorf("callback retries must be between 0 and 10, got %d", c.CallbackRetries)
+	}
+
+	if c.MaxAsyncExecutions < 1 || c.MaxAsyncExecutions > 10000 {
+		return fmt.Errorf("max async executions must be between 1 and 10000, got %d", c.MaxAsyncExecutions)
+	}
+
+	if c.EventWebhookURL != "" {
+		parsed, err := url.Parse(c.EventWebhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid event webhook URL %q: must be an absolute http or https URL", c.EventWebhookURL)
+		}
+	}
+
+	if c.PluginStreamMaxBytes <= 0 {
+		return fmt.Errorf("plugin stream max bytes must be positive")
+	}
+
+	// Certificates must outlive the renewal window, or every boot reissues them
+	if c.PluginCertValidityDays < 8 || c.PluginCertValidityDays > 3650 {
+		return fmt.Errorf("plugin certificate validity must be between 8 and 3650 days, got %d", c.PluginCertValidityDays)
+	}
+
+	// The whole history is kept in memory
+	if c.ExecutionHistoryMaxRecords < 0 || c.ExecutionHistoryMaxRecords > 1000000 {
+		return fmt.Errorf("execution history max records must be between 0 and 1000000, got %d", c.ExecutionHistoryMaxRecords)
+	}
+
+	if c.ExecutionHistoryMaxAgeHours < 0 {
+		return fmt.Errorf("execution history max age cannot be negative")
+	}
+
+	if c.CircuitBreakerFailures < 0 {
+		return fmt.Errorf("circuit breaker failures cannot be negative")
+	}
+
+	if c.CircuitBreakerFailures > 0 {
+		if c.CircuitBreakerWindow < c.CircuitBreakerFailures || c.CircuitBreakerWindow > 1000 {
+			return fmt.Errorf("circuit breaker window must be between %d and 1000, got %d", c.CircuitBreakerFailures, c.CircuitBreakerWindow)
+		}
+		if c.CircuitBreakerCooldownSeconds <= 0 {
+			return fmt.Errorf("circuit breaker cooldown must be positive")
+		}
+	}
+
+	if c.InstanceIDScheme != InstanceIDSchemeUnique && c.InstanceIDScheme != InstanceIDSchemeSlug {
+		return fmt.Errorf("instance ID scheme must be %q or %q", InstanceIDSchemeUnique, InstanceIDSchemeSlug)
+	}
+
+	switch c.IPAllocationStrategy {
+	case IPAllocationSequential, IPAllocationRandom, IPAllocationSticky:
+	default:
+		return fmt.Errorf("IP allocation strategy must be %q, %q or %q", IPAllocationSequential, IPAllocationRandom, IPAllocationSticky)
+	}
+
+	if c.BalloonTargetMB < 0 {
+		return fmt.Errorf("balloon target cannot be negative")
+	}
+
+	if c.HealthCheckIntervalSec <= 0 || c.HealthFailureThreshold <= 0 || c.HealthRecoveryThreshold <= 0 {
+		return fmt.Errorf("health check interval and thresholds must be positive")
+	}
+
+	if c.UsageSampleIntervalSec <= 0 || c.UsageHistorySize <= 0 {
+		return fmt.Errorf("usage sample interval and history size must be positive")
+	}
+
+	if c.NATEnabled && c.NATInterface == "" {
+		return fmt.Errorf("NAT interface cannot be empty when NAT is enabled")
+	}
+
+	if c.GuestKernelLogLevel < -1 || c.GuestKernelLogLevel > 7 {
+		return fmt.Errorf("guest kernel loglevel must be between 0 and 7, or -1 for the kernel default")
+	}
+
+	if c.KernelCmdlineMaxBytes < 256 || c.KernelCmdlineMaxBytes > 65536 {
+		return fmt.Errorf("kernel command line limit must be between 256 and 65536 bytes, got %d", c.KernelCmdlineMaxBytes)
+	}
+
+	// Slugs are at most 50 characters and hostname labels 63
+	if len(c.GuestHostnamePrefix) > 12 {
+		return fmt.Errorf("guest hostname prefix too long (max 12 characters)")
+	}
+	for _, char := range c.GuestHostnamePrefix {
+		if !((char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') || char == '-') {
+			return fmt.Errorf("guest hostname prefix may only contain lowercase letters, numbers and hyphens")
+		}
+	}
+
+	if c.GuestDNS != "" {
+		servers := strings.Split(c.GuestDNS, ",")
+		if len(servers) > 3 {
+			return fmt.Errorf("at most 3 guest DNS servers are supported")
+		}
+		for _, server := range servers {
+			if ip := net.ParseIP(strings.TrimSpace(server)); ip == nil || ip.To4() == nil {
+				return fmt.Errorf("invalid guest DNS server: %q", server)
+			}
+		}
+	}
+
+	if c.MinRootfsSizeMB <= 0 {
+		return fmt.Errorf("minimum rootfs size must be positive")
+	}
+
+	if c.MaxRootfsSizeMB < c.MinRootfsSizeMB {
+		return fmt.Errorf("maximum rootfs size (%dMB) cannot be below minimum (%dMB)", c.MaxRootfsSizeMB, c.MinRootfsSizeMB)
+	}
+
+	if c.MaxUploadSizeMB <= 0 {
+		return fmt.Errorf("maximum upload size must be positive")
+	}
+
+	if c.UploadLockTimeoutSec < 0 {
+		return fmt.Errorf("upload lock timeout cannot be negative, got %d", c.UploadLockTimeoutSec)
+	}
+
+	if c.InstallBurstCount < 0 || c.InstallBurstCount > 100 {
+		return fmt.Errorf("install burst count must be between 0 and 100, got %d", c.InstallBurstCount)
+	}
+
+	if c.InstallBurstDurationMs < 0 || c.InstallBurstDurationMs > 60000 {
+		return fmt.Errorf("install burst duration must be between 0 and 60000 ms, got %d", c.InstallBurstDurationMs)
+	}
+
+	if c.ProxyMaxBodyMB <= 0 {
+		return fmt.Errorf("proxy max body size must be positive")
+	}
+
+	if c.ProxyTimeoutSec <= 0 {
+		return fmt.Errorf("proxy timeout must be positive")
+	}
+
+	if c.PluginDrainTimeoutSec < 0 {
+		return fmt.Errorf("plugin drain timeout cannot be negative")
+	}
+
+	if c.PluginBootTimeoutSec <= 0 || c.PluginBootTimeoutSec > 600 {
+		return fmt.Errorf("plugin boot timeout must be between 1 and 600 seconds, got %d", c.PluginBootTimeoutSec)
+	}
+
+	for _, tier := range []string{c.DefaultIsolationTier, c.MinIsolationTier} {
+		if tier != "trusted" && tier != "standard" && tier != "untrusted" {
+			return fmt.Errorf("isolation tier must be trusted, standard or untrusted, got %q", tier)
+		}
+		// The untrusted tier always runs jailed
+		if tier == "untrusted" && !c.JailerEnabled {
+			return fmt.Errorf("isolation tier untrusted requires the jailer (set CMS_JAILER_ENABLED=true)")
+		}
+	}
+
+	// Priority 0 is the default of manifests that declare none
+	if c.PluginPriorityMin > 0 || c.PluginPriorityMax < 0 {
+		return fmt.Errorf("plugin priority range must include 0, got %d to %d", c.PluginPriorityMin, c.PluginPriorityMax)
+	}
+
+	if c.MaxPluginInterfaces < 0 || c.MaxPluginInterfaces > 8 {
+		return fmt.Errorf("max plugin interfaces must be between 0 and 8, got %d", c.MaxPluginInterfaces)
+	}
+
+	// Host numbers are handed out like on the plugin subnet, so it is a /24 too
+	ip, subnet, err := net.ParseCIDR(c.ExtraInterfaceSubnet)
+	if err != nil || ip.To4() == nil || !ip.Equal(subnet.IP) {
+		return fmt.Errorf("extra interface subnet must be an IPv4 network address like 192.168.128.0/24, got %q", c.ExtraInterfaceSubnet)
+	}
+	if ones, _ := subnet.Mask.Size(); ones != 24 {
+		return fmt.Errorf("extra interface subnet must be a /24, got %q", c.ExtraInterfaceSubnet)
+	}
+	if subnet.Contains(net.IPv4(192, 168, 127, 0)) {
+		return fmt.Errorf("extra interface subnet %s overlaps the plugin subnet 192.168.127.0/24", c.ExtraInterfaceSubnet)
+	}
+	if c.ExtraInterfaceBridge == "" || len(c.ExtraInterfaceBridge) > 15 || c.ExtraInterfaceBridge == "fcnetbridge0" {
+		return fmt.Errorf("extra interface bridge must be a name of 1-15 characters other than fcnetbridge0, got %q", c.ExtraInterfaceBridge)
+	}
+
+	// 576 is the smallest MTU every IPv4 host must accept, 9000 common jumbo frames
+	if c.TapMTU != 0 && (c.TapMTU < 576 || c.TapMTU > 9000) {
+		return fmt.Errorf("TAP MTU must be between 576 and 9000, got %d", c.TapMTU)
+	}
+
+	if c.TapTxQueueLen < 0 || c.TapTxQueueLen > 100000 {
+		return fmt.Errorf("TAP txqueuelen must be between 0 and 100000, got %d", c.TapTxQueueLen)
+	}
+
+	if c.VMStartRetries < 0 || c.VMStartRetries > 10 {
+		return fmt.Errorf("VM start retries must be between 0 and 10, got %d", c.VMStartRetries)
+	}
+
+	if c.VMStartRetryBackoffMs < 0 {
+		return fmt.Errorf("VM start retry backoff cannot be negative")
+	}
+
+	if c.VMReadyTimeoutMs < 0 || c.VMReadyTimeoutMs > 120000 {
+		return fmt.Errorf("VM ready timeout must be between 0 and 120000 ms, got %d", c.VMReadyTimeoutMs)
+	}
+
+	if c.ResumeReadyTimeoutMs < 0 || c.ResumeReadyTimeoutMs > 60000 {
+		return fmt.Errorf("resume ready timeout must be between 0 and 60000 ms, got %d", c.ResumeReadyTimeoutMs)
+	}
+
+	if c.WarmHitRatioWarnPercent < 0 || c.WarmHitRatioWarnPercent > 100 {
+		return fmt.Errorf("warm hit ratio warning must be between 0 and 100 percent, got %d", c.WarmHitRatioWarnPercent)
+	}
+
+	if c.ExecutionBudgetMs < 0 || c.ExecutionBudgetMs > 600000 {
+		return fmt.Errorf("execution budget must be between 0 and 600000 ms, got %d", c.ExecutionBudgetMs)
+	}
+
+	if c.SnapshotReserveMB < 0 {
+		return fmt.Errorf("snapshot reserve cannot be negative")
+	}
+
+	if c.MinFreeDiskMB < 0 {
+		return fmt.Errorf("minimum free disk cannot be negative")
+	}
+
+	if c.SnapshotRefreshIntervalSec < 0 {
+		return fmt.Errorf("snapshot refresh interval cannot be negative")
+	}
+
+	if c.SnapshotRebasePercent < 0 || c.SnapshotRebasePercent > 100 {
+		return fmt.Errorf("snapshot rebase percent must be between 0 and 100")
+	}
+
+	if c.SnapshotMaxDiffs < 0 {
+		return fmt.Errorf("snapshot max diffs cannot be negative")
+	}
+
+	if c.LogResultMaxBytes < 0 {
+		return fmt.Errorf("log result max bytes cannot be negative")
+	}
+
 	return nil
 }
 
@@ -178,3 +1413,24 @@ func (c *Config) GetModeString() string {
 		return "production"
 	}
 }
+
+// ListenAddr returns the address the HTTP server binds to
+func (c *Config) ListenAddr() string {
+	return net.JoinHostPort(c.Host, c.Port)
+}
+
+// redactedValue replaces secrets in Redacted
+const redactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the configuration with secrets masked, safe to expose
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	if redacted.AdminToken != "" {
+		redacted.AdminToken = redactedValue
+	}
+	// Webhook URLs often carry a token
+	if redacted.EventWebhookURL != "" {
+		redacted.EventWebhookURL = redactedValue
+	}
+	return &redacted
+}
@@ -0,0 +1,70 @@
+/*
+ * Firecracker CMS - Configuration Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package config
+
+import "testing"
+
+func TestNewConfigValidates(t *testing.T) {
+	if err := NewConfig().Validate(); err != nil {
+		t.Fatalf("default config does not validate: %v", err)
+	}
+}
+
+func TestResultLoggingOffByDefault(t *testing.T) {
+	if got := NewConfig().LogResultMaxBytes; got != 0 {
+		t.Fatalf("LogResultMaxBytes = %d, want 0 (off)", got)
+	}
+
+	t.Setenv("CMS_LOG_RESULT_MAX_BYTES", "512")
+	c := NewConfig()
+	if err := c.LoadFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if c.LogResultMaxBytes != 512 {
+		t.Fatalf("LogResultMaxBytes = %d, want 512 from the environment", c.LogResultMaxBytes)
+	}
+}
+
+func TestUntrustedTierRequiresJailer(t *testing.T) {
+	c := NewConfig()
+	c.MinIsolationTier = "untrusted"
+	if err := c.Validate(); err == nil {
+		t.Fatalf("untrusted minimum tier validated without the jailer")
+	}
+
+	c.JailerEnabled = true
+	if err := c.Validate(); err != nil {
+		t.Fatalf("untrusted minimum tier with the jailer: %v", err)
+	}
+}
+
+func TestExtraInterfaceNetworkValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		subnet string
+		bridge string
+		valid  bool
+	}{
+		{"defaults", "192.168.128.0/24", "fcnetbridge1", true},
+		{"not a /24", "10.0.0.0/16", "fcnetbridge1", false},
+		{"host address", "192.168.128.1/24", "fcnetbridge1", false},
+		{"plugin subnet", "192.168.127.0/24", "fcnetbridge1", false},
+		{"plugin bridge", "192.168.128.0/24", "fcnetbridge0", false},
+		{"bridge name too long", "192.168.128.0/24", "fcnetbridge-extra", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfig()
+			c.ExtraInterfaceSubnet = tt.subnet
+			c.ExtraInterfaceBridge = tt.bridge
+			if err := c.Validate(); (err == nil) != tt.valid {
+				t.Fatalf("Validate() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -22,6 +22,8 @@ const (
 	ErrTypeNetwork     ErrorType = "network"
 	ErrTypeFileSystem  ErrorType = "filesystem"
 	ErrTypeTimeout     ErrorType = "timeout"
+	ErrTypeRateLimit   ErrorType = "rate_limit"
+	ErrTypeCircuitOpen ErrorType = "circuit_open"
 	ErrTypeInternal    ErrorType = "internal"
 )
 
@@ -156,6 +158,15 @@ func WrapTimeoutError(err error, operation, message string) *CMSError {
 	return Wrap(err, ErrTypeTimeout, operation, message)
 }
 
+// Rate limit error constructors
+func NewRateLimitError(operation, message string) *CMSError {
+	return New(ErrTypeRateLimit, operation, message)
+}
+
+func WrapRateLimitError(err error, operation, message string) *CMSError {
+	return Wrap(err, ErrTypeRateLimit, operation, message)
+}
+
 // Internal error constructors
 func NewInternalError(operation, message string) *CMSError {
 	return New(ErrTypeInternal, operation, message)
@@ -11,6 +11,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -20,6 +22,11 @@ import (
 type Logger struct {
 	*logrus.Logger
 	debug bool
+
+	// Per-component level overrides and the loggers created for them
+	componentLevels map[string]logrus.Level
+	components      map[string]*Logger
+	componentsMutex sync.Mutex
 }
 
 // Fields represents structured logging fields
@@ -27,8 +34,10 @@ type Fields = logrus.Fields
 
 var defaultLogger *Logger
 
-// Init initializes the default logger with the specified configuration
-func Init(level string, logDir string) error {
+// Init initializes the default logger with the specified configuration.
+// format is "json" or "text"; componentLevels overrides the level for named
+// components, e.g. "firecracker=warn,plugin=debug".
+func Init(level, logDir, format, componentLevels string) error {
 	logger := logrus.New()
 
 	// Set log level
@@ -59,19 +68,59 @@ func Init(level string, logDir string) error {
 		logger.SetOutput(os.Stdout)
 	}
 
-	// Use JSON format for structured logging in production
-	logger.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat: time.RFC3339,
-	})
+	switch format {
+	case "", "json":
+		// Use JSON format for structured logging in production
+		logger.SetFormatter(&logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339,
+		})
+	case "text":
+		// Human-readable output for local development
+		logger.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp:   true,
+			TimestampFormat: time.RFC3339,
+		})
+	default:
+		return fmt.Errorf("invalid log format %s (expected json or text)", format)
+	}
+
+	levels, err := ParseComponentLevels(componentLevels)
+	if err != nil {
+		return err
+	}
 
 	defaultLogger = &Logger{
-		Logger: logger,
-		debug:  logLevel == logrus.DebugLevel,
+		Logger:          logger,
+		debug:           logLevel == logrus.DebugLevel,
+		componentLevels: levels,
 	}
 
 	return nil
 }
 
+// ParseComponentLevels parses a comma separated list of component=level pairs
+func ParseComponentLevels(spec string) (map[string]logrus.Level, error) {
+	levels := make(map[string]logrus.Level)
+	if strings.TrimSpace(spec) == "" {
+		return levels, nil
+	}
+
+	for _, pair := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid component log level %q (expected component=level)", pair)
+		}
+
+		level, err := logrus.ParseLevel(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level for component %s: %w", parts[0], err)
+		}
+		levels[parts[0]] = level
+	}
+
+	return levels, nil
+}
+
 // GetDefault returns the default logger instance
 func GetDefault() *Logger {
 	if defaultLogger == nil {
@@ -88,9 +137,67 @@ func (l *Logger) WithFields(fields Fields) *logrus.Entry {
 	return l.Logger.WithFields(logrus.Fields(fields))
 }
 
+// Component returns a logger for a named component. Entries carry a component
+// field and honor any per-component level override.
+func (l *Logger) Component(component string) *Logger {
+	l.componentsMutex.Lock()
+	defer l.componentsMutex.Unlock()
+
+	if l.components == nil {
+		l.components = make(map[string]*Logger)
+	}
+	if existing, exists := l.components[component]; exists {
+		return existing
+	}
+
+	level := l.Logger.GetLevel()
+	if override, exists := l.componentLevels[component]; exists {
+		level = override
+	}
+
+	// Child logger shares output and formatting but has its own level and hooks
+	hooks := make(logrus.LevelHooks)
+	for hookLevel, levelHooks := range l.Logger.Hooks {
+		hooks[hookLevel] = append(hooks[hookLevel], levelHooks...)
+	}
+	child := &logrus.Logger{
+		Out:          l.Logger.Out,
+		Formatter:    l.Logger.Formatter,
+		Hooks:        hooks,
+		Level:        level,
+		ExitFunc:     l.Logger.ExitFunc,
+		ReportCaller: l.Logger.ReportCaller,
+	}
+	child.AddHook(componentHook(component))
+
+	componentLogger := &Logger{
+		Logger: child,
+		debug:  level >= logrus.DebugLevel,
+	}
+	l.components[component] = componentLogger
+
+	return componentLogger
+}
+
 // WithComponent creates a logger entry with a component field
 func (l *Logger) WithComponent(component string) *logrus.Entry {
-	return l.Logger.WithField("component", component)
+	return l.Component(component).Logger.WithField("component", component)
+}
+
+// componentHook tags every entry with the component name
+type componentHook string
+
+// Levels implements logrus.Hook
+func (h componentHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h componentHook) Fire(entry *logrus.Entry) error {
+	if _, exists := entry.Data["component"]; !exists {
+		entry.Data["component"] = string(h)
+	}
+	return nil
 }
 
 // WithRequest creates a logger entry with request context
@@ -7,15 +7,19 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
 // HTTPResponse represents a standardized API response
 type HTTPResponse struct {
-	Success   bool        `json:"success"`
-	Data      interface{} `json:"data,omitempty"`
-	Error     string      `json:"error,omitempty"`
-	Timestamp string      `json:"timestamp"`
+	Success   bool              `json:"success"`
+	Data      interface{}       `json:"data,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Warnings  []string          `json:"warnings,omitempty"`
+	Errors    []ValidationError `json:"errors,omitempty"` // Field-level validation failures
+	Timestamp string            `json:"timestamp"`
 }
 
 // ValidationError represents input validation errors
@@ -24,6 +28,24 @@ type ValidationError struct {
 	Message string `json:"message"`
 }
 
+// ValidationErrors collects every validation failure of an input so they can
+// be reported together
+type ValidationErrors []ValidationError
+
+// Add records a validation failure for field
+func (e *ValidationErrors) Add(field, format string, args ...interface{}) {
+	*e = append(*e, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Error joins the failures into a single message
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, validationError := range e {
+		messages[i] = fmt.Sprintf("%s: %s", validationError.Field, validationError.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
 // HealthCheckResponse represents the health check response
 type HealthCheckResponse struct {
 	Status        string                 `json:"status"`
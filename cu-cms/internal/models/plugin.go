@@ -24,20 +24,137 @@ type Plugin struct {
 	UpdatedAt   time.Time               `json:"updated_at"`
 	Status      string                  `json:"status"` // installed, active, failed
 	Health      PluginHealth            `json:"health"`
-	Actions     map[string]PluginAction `json:"actions"`  // action_name -> PluginAction
-	Priority    int                     `json:"priority"` // Execution order for same action
+	Actions     map[string]PluginAction `json:"actions"`               // action_name -> PluginAction
+	Priority    int                     `json:"priority"`              // Execution order for same action
+	SelfTest    *PluginSelfTest         `json:"selftest,omitempty"`    // Optional validation call
+	Entrypoint  string                  `json:"entrypoint,omitempty"`  // Guest init program, /sbin/init if empty
+	RootfsType  string                  `json:"rootfs_type,omitempty"` // ext4 (default) or squashfs
+	RootfsHash  string                  `json:"rootfs_hash,omitempty"` // SHA-256 of a shared read-only image
+
+	// RestartPolicy controls automatic recovery of the warm instance
+	RestartPolicy string `json:"restart_policy,omitempty"` // always, on-failure, never (default always)
+
+	// IsolationTier selects a bundle of security settings for the plugin's VMs
+	IsolationTier string `json:"isolation_tier,omitempty"` // trusted, standard, untrusted (default from config)
+
+	// MaxExecutionsPerSecond throttles executions of this plugin, 0 is unlimited
+	MaxExecutionsPerSecond float64 `json:"max_executions_per_second,omitempty"`
+
+	// Tags group plugins, e.g. "billing", for listing and scoped execution
+	Tags []string `json:"tags,omitempty"`
+
+	// NetworkInterfaces are additional guest NICs (eth1, eth2, ...) beyond eth0
+	NetworkInterfaces []PluginNetworkInterface `json:"network_interfaces,omitempty"`
+
+	// Requires lists host capabilities the plugin cannot run without
+	Requires []string `json:"requires,omitempty"`
+
+	// Metrics is the guest endpoint serving the plugin's own metrics, if any
+	Metrics *PluginMetricsEndpoint `json:"metrics,omitempty"`
+
+	// DeepValidation probes every action endpoint when the plugin is validated
+	DeepValidation bool `json:"deep_validation,omitempty"`
+
+	// OpenAPI is the guest path serving the plugin's OpenAPI document, if any
+	OpenAPI string `json:"openapi,omitempty"`
+
+	// MTLS means the plugin serves HTTPS with the certificate the CMS issues it
+	// and requires the CMS client certificate
+	MTLS bool `json:"mtls,omitempty"`
+
+	// Lifecycle hooks for plugin setup and teardown, e.g. database migrations
+	Install   *PluginLifecycleHook `json:"install,omitempty"`   // Called after every successful upload
+	Uninstall *PluginLifecycleHook `json:"uninstall,omitempty"` // Called before the plugin is deleted
+	Cancel    *PluginLifecycleHook `json:"cancel,omitempty"`    // Called when an execution times out
+
+	// Operational settings - editable without re-uploading the plugin
+	Env             map[string]string `json:"env,omitempty"`              // Environment passed to the plugin
+	Resources       PluginResources   `json:"resources"`                  // VM resource limits
+	Disabled        bool              `json:"disabled,omitempty"`         // Excluded from action execution
+	AllowEgress     bool              `json:"allow_egress,omitempty"`     // Outbound internet access via NAT
+	NeedsResnapshot bool              `json:"needs_resnapshot,omitempty"` // Snapshot is stale and must be recreated
+	Debug           bool              `json:"debug,omitempty"`            // Log full execution payloads and responses
+
+	// ActionUsage counts invocations per action; flushed from memory periodically
+	ActionUsage map[string]ActionUsage `json:"action_usage,omitempty"`
+
+	// SnapshotRefreshedAt is when the warm instance was last re-snapshotted in the background
+	SnapshotRefreshedAt *time.Time `json:"snapshot_refreshed_at,omitempty"`
+
+	// SnapshotDiffCount is how many differential snapshots were merged into the
+	// full snapshot since it was last taken
+	SnapshotDiffCount int `json:"snapshot_diff_count,omitempty"`
 
 	// Network configuration - persistent across activations
 	AssignedIP string `json:"assigned_ip,omitempty"` // Assigned IP address
 	TapDevice  string `json:"tap_device,omitempty"`  // TAP device name
 }
 
+// PluginResources represents the VM resource limits for a plugin
+type PluginResources struct {
+	VcpuCount  int64 `json:"vcpu_count,omitempty"`   // 0 uses the default
+	MemSizeMib int64 `json:"mem_size_mib,omitempty"` // 0 uses the default
+}
+
+// PluginMetadataUpdate represents a partial update of a plugin's mutable metadata
+type PluginMetadataUpdate struct {
+	Description *string           `json:"description,omitempty"`
+	Priority    *int              `json:"priority,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	Resources   *PluginResources  `json:"resources,omitempty"`
+	Enabled     *bool             `json:"enabled,omitempty"`
+	Debug       *bool             `json:"debug,omitempty"`
+}
+
+// PluginCloneRequest represents a request to duplicate a plugin under a new slug
+type PluginCloneRequest struct {
+	TargetSlug string   `json:"target_slug"`
+	Name       string   `json:"name,omitempty"` // Keeps the source name if empty
+	Tags       []string `json:"tags,omitempty"` // Replaces the source tags if set
+}
+
+// HookOverlap describes another active plugin handling the same hook
+type HookOverlap struct {
+	Hook       string `json:"hook"`
+	PluginSlug string `json:"plugin_slug"`
+	Priority   int    `json:"priority"`
+}
+
+// ImportResult reports the outcome of importing one plugin from an export archive
+type ImportResult struct {
+	PluginSlug   string `json:"plugin_slug"`
+	Status       string `json:"status"` // imported, skipped, failed
+	Error        string `json:"error,omitempty"`
+	IPReassigned bool   `json:"ip_reassigned"`
+	Activated    bool   `json:"activated"`
+}
+
+// ActionUsage represents how often and how recently an action was invoked
+type ActionUsage struct {
+	Invocations   int64      `json:"invocations"`
+	LastInvokedAt *time.Time `json:"last_invoked_at,omitempty"`
+}
+
+// PluginNetworkInterface is an additional NIC declared by name in plugin.json.
+// The host side is assigned when a VM of the plugin first starts.
+type PluginNetworkInterface struct {
+	Name       string `json:"name"` // e.g. "mgmt", passed to the guest
+	TapDevice  string `json:"tap_device,omitempty"`
+	AssignedIP string `json:"assigned_ip,omitempty"`
+	MacAddress string `json:"mac_address,omitempty"`
+}
+
 // PluginHealth represents plugin health status
 type PluginHealth struct {
 	Status       string    `json:"status"` // healthy, unhealthy, unknown
 	LastCheck    time.Time `json:"last_check"`
 	Message      string    `json:"message"`
 	ResponseTime int64     `json:"response_time_ms"`
+
+	// Debounce state of the background health checker
+	ConsecutiveFailures  int       `json:"consecutive_failures,omitempty"`
+	ConsecutiveSuccesses int       `json:"consecutive_successes,omitempty"`
+	LastTransition       time.Time `json:"last_transition,omitempty"`
 }
 
 // PluginAction represents an action hook that a plugin provides
@@ -48,6 +165,68 @@ type PluginAction struct {
 	Method      string   `json:"method"`   // HTTP method
 	Endpoint    string   `json:"endpoint"` // Plugin endpoint
 	Priority    int      `json:"priority"` // Execution order
+
+	// TestPayload is sent to the endpoint during deep validation, if declared
+	TestPayload map[string]interface{} `json:"test_payload,omitempty"`
+
+	// TimeoutMs bounds a request to the endpoint, 10s if unset
+	TimeoutMs int `json:"timeout_ms,omitempty"`
+}
+
+// PluginActionDefaults are manifest-level settings inherited by actions that
+// leave them unset, e.g. for plugins serving every action on one endpoint
+type PluginActionDefaults struct {
+	Method    string `json:"method"`
+	Endpoint  string `json:"endpoint"`
+	TimeoutMs int    `json:"timeout_ms,omitempty"`
+}
+
+// Apply fills the unset method, endpoint and timeout of an action
+func (d *PluginActionDefaults) Apply(action PluginAction) PluginAction {
+	if d == nil {
+		return action
+	}
+	if action.Method == "" {
+		action.Method = d.Method
+	}
+	if action.Endpoint == "" {
+		action.Endpoint = d.Endpoint
+	}
+	if action.TimeoutMs == 0 {
+		action.TimeoutMs = d.TimeoutMs
+	}
+	return action
+}
+
+// PluginMetricsEndpoint represents a manifest-declared metrics endpoint in the guest
+type PluginMetricsEndpoint struct {
+	Path string `json:"path"`           // HTTP path, e.g. /metrics
+	Port int    `json:"port,omitempty"` // Guest port, 80 if unset
+}
+
+// EffectivePort returns the guest port serving the metrics
+func (m *PluginMetricsEndpoint) EffectivePort() int {
+	if m.Port == 0 {
+		return 80
+	}
+	return m.Port
+}
+
+// PluginSelfTest represents an optional manifest-declared call made during
+// validation; the response must contain every field in Expect
+type PluginSelfTest struct {
+	Method   string                 `json:"method"`   // HTTP method (default POST)
+	Endpoint string                 `json:"endpoint"` // Plugin endpoint
+	Payload  map[string]interface{} `json:"payload,omitempty"`
+	Expect   map[string]interface{} `json:"expect"` // Expected subset of the response
+}
+
+// PluginLifecycleHook represents a manifest-declared endpoint called when the
+// plugin is installed or uninstalled, or an execution of it times out
+type PluginLifecycleHook struct {
+	Method    string `json:"method"`               // HTTP method (default POST)
+	Endpoint  string `json:"endpoint"`             // Plugin endpoint
+	TimeoutMs int    `json:"timeout_ms,omitempty"` // 10s if unset
 }
 
 // ActionExecutionResult represents the result of plugin action execution
@@ -59,13 +238,72 @@ type ActionExecutionResult struct {
 	ExecutionTime time.Duration `json:"execution_time_ms"`
 }
 
+// SnapshotResult represents the outcome of snapshotting a plugin's warm instance
+type SnapshotResult struct {
+	PluginSlug string `json:"plugin_slug"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// ResourceSample represents a point-in-time resource usage sample of a plugin VM
+type ResourceSample struct {
+	Timestamp  time.Time `json:"timestamp"`
+	CPUPercent float64   `json:"cpu_percent"`
+	MemoryMB   float64   `json:"memory_mb"`
+}
+
+// ResourceUsageSummary represents rolled-up resource usage of a plugin
+type ResourceUsageSummary struct {
+	Samples       int64     `json:"samples"`
+	AvgCPUPercent float64   `json:"avg_cpu_percent"`
+	MaxCPUPercent float64   `json:"max_cpu_percent"`
+	AvgMemoryMB   float64   `json:"avg_memory_mb"`
+	MaxMemoryMB   float64   `json:"max_memory_mb"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
 // PluginStatus constants
 const (
 	PluginStatusInstalled = "installed"
 	PluginStatusActive    = "active"
+	PluginStatusDraining  = "draining" // Deactivating, waiting for in-flight executions
 	PluginStatusFailed    = "failed"
 )
 
+// RestartPolicy constants
+const (
+	RestartPolicyAlways    = "always"     // Replace crashed and unhealthy instances, keep one warm
+	RestartPolicyOnFailure = "on-failure" // Replace crashed instances only
+	RestartPolicyNever     = "never"      // Only mark the plugin unhealthy
+)
+
+// Isolation tiers, from least to most isolated
+const (
+	IsolationTierTrusted   = "trusted"   // First-party: no jailer, egress allowed
+	IsolationTierStandard  = "standard"  // Settings as configured and declared
+	IsolationTierUntrusted = "untrusted" // Third-party: jailer, no egress, capped resources, read-only rootfs
+)
+
+// IsolationTierRank orders tiers by strictness, -1 for unknown tiers
+func IsolationTierRank(tier string) int {
+	switch tier {
+	case IsolationTierTrusted:
+		return 0
+	case IsolationTierStandard:
+		return 1
+	case IsolationTierUntrusted:
+		return 2
+	}
+	return -1
+}
+
+// Rootfs filesystem types
+const (
+	RootfsTypeExt4     = "ext4"     // Writable image
+	RootfsTypeSquashfs = "squashfs" // Compressed, read-only image
+)
+
 // PluginHealthStatus constants
 const (
 	HealthStatusHealthy   = "healthy"
@@ -94,6 +332,11 @@ func NewPlugin(slug, name, version string) *Plugin {
 
 // UpdateHealth updates the plugin health status
 func (p *Plugin) UpdateHealth(status, message string, responseTime int64) {
+	if p.Health.Status != status {
+		p.Health.LastTransition = time.Now()
+	}
+	p.Health.ConsecutiveFailures = 0
+	p.Health.ConsecutiveSuccesses = 0
 	p.Health.Status = status
 	p.Health.Message = message
 	p.Health.ResponseTime = responseTime
@@ -101,12 +344,87 @@ func (p *Plugin) UpdateHealth(status, message string, responseTime int64) {
 	p.UpdatedAt = time.Now()
 }
 
+// RecordHealthCheck folds a periodic health check result into the plugin health.
+// The status only flips to unhealthy after failureThreshold consecutive failures
+// and back to healthy after recoveryThreshold consecutive successes.
+// It returns true when the status changed.
+func (p *Plugin) RecordHealthCheck(healthy bool, message string, responseTime int64, failureThreshold, recoveryThreshold int) bool {
+	now := time.Now()
+	p.Health.LastCheck = now
+	p.Health.ResponseTime = responseTime
+
+	if healthy {
+		p.Health.ConsecutiveFailures = 0
+		p.Health.ConsecutiveSuccesses++
+		if p.Health.Status == HealthStatusHealthy || p.Health.ConsecutiveSuccesses < recoveryThreshold {
+			return false
+		}
+		p.Health.Status = HealthStatusHealthy
+	} else {
+		p.Health.ConsecutiveSuccesses = 0
+		p.Health.ConsecutiveFailures++
+		if p.Health.Status == HealthStatusUnhealthy || p.Health.ConsecutiveFailures < failureThreshold {
+			return false
+		}
+		p.Health.Status = HealthStatusUnhealthy
+	}
+
+	p.Health.Message = message
+	p.Health.LastTransition = now
+	p.UpdatedAt = now
+	return true
+}
+
 // SetStatus sets the plugin status and updates the timestamp
 func (p *Plugin) SetStatus(status string) {
 	p.Status = status
 	p.UpdatedAt = time.Now()
 }
 
+// EffectiveRestartPolicy returns the restart policy, defaulting to always
+func (p *Plugin) EffectiveRestartPolicy() string {
+	if p.RestartPolicy == "" {
+		return RestartPolicyAlways
+	}
+	return p.RestartPolicy
+}
+
+// IsValidRestartPolicy reports whether policy is a known restart policy or empty
+func IsValidRestartPolicy(policy string) bool {
+	switch policy {
+	case "", RestartPolicyAlways, RestartPolicyOnFailure, RestartPolicyNever:
+		return true
+	}
+	return false
+}
+
+// EffectiveRootfsType returns the rootfs filesystem type, ext4 if unset
+func (p *Plugin) EffectiveRootfsType() string {
+	if p.RootfsType == "" {
+		return RootfsTypeExt4
+	}
+	return p.RootfsType
+}
+
+// RootfsReadOnly reports whether the rootfs must be attached read-only
+func (p *Plugin) RootfsReadOnly() bool {
+	return p.EffectiveRootfsType() == RootfsTypeSquashfs
+}
+
+// IsValidRootfsType reports whether fsType is a supported rootfs type or empty
+func IsValidRootfsType(fsType string) bool {
+	switch fsType {
+	case "", RootfsTypeExt4, RootfsTypeSquashfs:
+		return true
+	}
+	return false
+}
+
+// RootfsFileName returns the name of a rootfs image of fsType in a plugin ZIP
+func RootfsFileName(fsType string) string {
+	return "rootfs." + fsType
+}
+
 // IsActive returns true if the plugin is active
 func (p *Plugin) IsActive() bool {
 	return p.Status == PluginStatusActive
@@ -117,6 +435,47 @@ func (p *Plugin) IsInstalled() bool {
 	return p.Status == PluginStatusInstalled
 }
 
+// IsEnabled returns true if the plugin takes part in action execution
+func (p *Plugin) IsEnabled() bool {
+	return !p.Disabled
+}
+
+// HasTag reports whether the plugin is tagged with tag
+func (p *Plugin) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidTag reports whether tag is 1-32 lowercase letters, digits, hyphens or underscores
+func IsValidTag(tag string) bool {
+	if tag == "" || len(tag) > 32 {
+		return false
+	}
+	for _, r := range tag {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// IsValidInterfaceName reports whether name is 1-15 lowercase letters, digits or hyphens
+func IsValidInterfaceName(name string) bool {
+	if name == "" || len(name) > 15 {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
 // IsHealthy returns true if the plugin is healthy
 func (p *Plugin) IsHealthy() bool {
 	return p.Health.Status == HealthStatusHealthy
@@ -0,0 +1,182 @@
+/*
+ * Firecracker CMS - Caller Authorization
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// callerPolicy lists the hooks and plugins a caller may invoke. Empty allow
+// lists permit everything; deny lists win over allow lists.
+type callerPolicy struct {
+	Name         string   `json:"name"`
+	Token        string   `json:"token"`
+	AllowHooks   []string `json:"allow_hooks,omitempty"`
+	DenyHooks    []string `json:"deny_hooks,omitempty"`
+	AllowPlugins []string `json:"allow_plugins,omitempty"`
+	DenyPlugins  []string `json:"deny_plugins,omitempty"`
+}
+
+// callerACL holds the policies of all known callers
+type callerACL struct {
+	Callers []*callerPolicy `json:"callers"`
+}
+
+// loadCallerACL reads and validates the caller ACL file
+func loadCallerACL(path string) (*callerACL, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read caller ACL file: %v", err)
+	}
+
+	var acl callerACL
+	if err := json.Unmarshal(data, &acl); err != nil {
+		return nil, fmt.Errorf("failed to parse caller ACL file: %v", err)
+	}
+
+	tokens := make(map[string]bool)
+	for i, caller := range acl.Callers {
+		if caller.Name == "" {
+			return nil, fmt.Errorf("caller %d in ACL file has no name", i)
+		}
+		if caller.Token == "" {
+			return nil, fmt.Errorf("caller %s in ACL file has no token", caller.Name)
+		}
+		if tokens[caller.Token] {
+			return nil, fmt.Errorf("caller %s in ACL file reuses another caller's token", caller.Name)
+		}
+		tokens[caller.Token] = true
+	}
+
+	return &acl, nil
+}
+
+// authenticateAdmin reports whether a request carries the configured admin token
+func (s *Server) authenticateAdmin(r *http.Request) bool {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" || s.config.AdminToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(s.config.AdminToken), []byte(token)) == 1
+}
+
+// requireAdmin rejects requests without the admin token and reports whether
+// the request may proceed. Endpoints guarded by it stay disabled (403) until
+// CMS_ADMIN_TOKEN is set.
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request, endpoint string) bool {
+	if s.config.AdminToken == "" {
+		s.sendErrorResponse(w, fmt.Sprintf("%s is disabled: set CMS_ADMIN_TOKEN to enable it", endpoint), http.StatusForbidden)
+		return false
+	}
+
+	if !s.authenticateAdmin(r) {
+		s.logger.WithFields(logger.Fields{
+			"endpoint":    endpoint,
+			"remote_addr": r.RemoteAddr,
+		}).Warn("Rejected request without valid admin token")
+		s.sendErrorResponse(w, "Missing or invalid admin token", http.StatusUnauthorized)
+		return false
+	}
+
+	return true
+}
+
+// lookup returns the caller owning token, or nil
+func (acl *callerACL) lookup(token string) *callerPolicy {
+	var match *callerPolicy
+	for _, caller := range acl.Callers {
+		// Compare every token in constant time so timing reveals nothing
+		if subtle.ConstantTimeCompare([]byte(caller.Token), []byte(token)) == 1 {
+			match = caller
+		}
+	}
+	return match
+}
+
+// allowsHook reports whether the caller may trigger hook
+func (p *callerPolicy) allowsHook(hook string) bool {
+	return listPermits(p.AllowHooks, p.DenyHooks, hook)
+}
+
+// allowsPlugin reports whether the caller may execute plugin
+func (p *callerPolicy) allowsPlugin(plugin *models.Plugin) bool {
+	return p.allowsSlug(plugin.Slug)
+}
+
+// allowsSlug reports whether the caller may use the plugin with slug
+func (p *callerPolicy) allowsSlug(slug string) bool {
+	return listPermits(p.AllowPlugins, p.DenyPlugins, slug)
+}
+
+// listPermits applies an allow/deny list pair to name
+func listPermits(allow, deny []string, name string) bool {
+	for _, denied := range deny {
+		if denied == name {
+			return false
+		}
+	}
+	if len(allow) == 0 {
+		return true
+	}
+	for _, allowed := range allow {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticateCaller resolves the caller of a request from its bearer token.
+// Without an ACL every request is unrestricted and the caller is nil.
+func (s *Server) authenticateCaller(r *http.Request) (*callerPolicy, bool) {
+	if s.callerACL == nil {
+		return nil, true
+	}
+
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		return nil, false
+	}
+
+	caller := s.callerACL.lookup(token)
+	return caller, caller != nil
+}
+
+// authorizePluginAccess applies the caller ACL to requests reaching into a
+// plugin directly, such as proxied requests and metrics scrapes, and reports
+// whether the request may proceed. The caller token is removed so it is not
+// forwarded to the plugin.
+func (s *Server) authorizePluginAccess(w http.ResponseWriter, r *http.Request, slug string) bool {
+	caller, authenticated := s.authenticateCaller(r)
+	if !authenticated {
+		s.sendErrorResponse(w, "Missing or invalid caller token", http.StatusUnauthorized)
+		return false
+	}
+	if caller == nil {
+		return true
+	}
+
+	if !caller.allowsSlug(slug) {
+		s.logger.WithFields(logger.Fields{
+			"plugin_slug": slug,
+			"caller":      caller.Name,
+		}).Warn("Caller not permitted to access plugin")
+		s.sendErrorResponse(w, "Caller is not permitted to access this plugin", http.StatusForbidden)
+		return false
+	}
+
+	r.Header.Del("Authorization")
+	return true
+}
@@ -0,0 +1,171 @@
+/*
+ * Firecracker CMS - Caller Authorization Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/config"
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminToken string
+		header     string
+		wantOK     bool
+		wantStatus int
+	}{
+		{"no admin token configured", "", "Bearer secret", false, http.StatusForbidden},
+		{"missing token", "secret", "", false, http.StatusUnauthorized},
+		{"wrong token", "secret", "Bearer other", false, http.StatusUnauthorized},
+		{"valid token", "secret", "Bearer secret", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.AdminToken = tt.adminToken
+			s := New(cfg, logger.GetDefault(), nil, nil)
+
+			r := httptest.NewRequest("POST", "/api/instances/blog-1/pause", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			if ok := s.requireAdmin(w, r, "Instance control"); ok != tt.wantOK {
+				t.Fatalf("requireAdmin = %v, want %v", ok, tt.wantOK)
+			}
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAuthorizePluginAccess(t *testing.T) {
+	acl := &callerACL{Callers: []*callerPolicy{
+		{Name: "shop", Token: "s3cret", AllowPlugins: []string{"shop-orders"}},
+	}}
+
+	tests := []struct {
+		name       string
+		acl        *callerACL
+		header     string
+		slug       string
+		wantOK     bool
+		wantStatus int
+	}{
+		{"no ACL", nil, "", "billing", true, http.StatusOK},
+		{"missing token", acl, "", "shop-orders", false, http.StatusUnauthorized},
+		{"unknown token", acl, "Bearer nope", "shop-orders", false, http.StatusUnauthorized},
+		{"plugin off limits", acl, "Bearer s3cret", "billing", false, http.StatusForbidden},
+		{"allowed plugin", acl, "Bearer s3cret", "shop-orders", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(config.NewConfig(), logger.GetDefault(), nil, nil)
+			s.callerACL = tt.acl
+
+			r := httptest.NewRequest("GET", "/api/plugins/"+tt.slug+"/proxy/", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			if ok := s.authorizePluginAccess(w, r, tt.slug); ok != tt.wantOK {
+				t.Fatalf("authorizePluginAccess = %v, want %v", ok, tt.wantOK)
+			}
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantOK && tt.acl != nil && r.Header.Get("Authorization") != "" {
+				t.Fatalf("caller token would be forwarded to the plugin")
+			}
+		})
+	}
+}
+
+func TestExecuteActionAuthenticatesBeforeCallbackCheck(t *testing.T) {
+	s := New(config.NewConfig(), logger.GetDefault(), nil, nil)
+	s.callerACL = &callerACL{Callers: []*callerPolicy{{Name: "shop", Token: "s3cret"}}}
+
+	body := `{"action": "order.created", "callback_url": "https://evil.example.com/hook"}`
+	r := httptest.NewRequest("POST", "/api/execute", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	s.handleExecuteAction(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d before the callback host is checked", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestExecutionHistoryAppliesCallerACL(t *testing.T) {
+	s := New(config.NewConfig(), logger.GetDefault(), nil, nil)
+	s.callerACL = &callerACL{Callers: []*callerPolicy{
+		{Name: "shop", Token: "s3cret", AllowPlugins: []string{"shop-orders"}},
+	}}
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"plugin off limits", "Bearer s3cret", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/executions?plugin=billing", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			s.handleExecutionHistory(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAdminEndpointsRequireAdminToken(t *testing.T) {
+	s := New(config.NewConfig(), logger.GetDefault(), nil, nil)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"force cleanup", "POST", "/api/plugins/blog/force-cleanup", func(w http.ResponseWriter, r *http.Request) { s.handleForceCleanupPlugin(w, r, "blog") }},
+		{"export", "GET", "/api/admin/export", s.handleExportState},
+		{"import", "POST", "/api/admin/import", s.handleImportState},
+		{"self-check", "GET", "/api/system/selfcheck", s.handleSystemSelfCheck},
+		{"maintenance", "POST", "/api/admin/maintenance", s.handleMaintenance},
+		{"config", "GET", "/api/system/config", s.handleSystemConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			tt.handler(w, r)
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("status = %d without CMS_ADMIN_TOKEN, want %d", w.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
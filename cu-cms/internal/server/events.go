@@ -0,0 +1,127 @@
+/*
+ * Firecracker CMS - Lifecycle Event Stream
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms/internal/services"
+)
+
+// eventKeepaliveInterval keeps idle event streams open through proxies
+const eventKeepaliveInterval = 15 * time.Second
+
+// handleEvents streams plugin lifecycle events as server-sent events. Clients
+// reconnecting with Last-Event-ID get the events they missed while those are
+// still kept; ?plugin= and ?type= (comma-separated) filter the stream.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var afterID uint64
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		parsed, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			s.sendErrorResponse(w, fmt.Sprintf("Invalid Last-Event-ID %q", lastID), http.StatusBadRequest)
+			return
+		}
+		afterID = parsed
+	}
+
+	pluginFilter := r.URL.Query().Get("plugin")
+	typeFilter := make(map[string]bool)
+	if types := r.URL.Query().Get("type"); types != "" {
+		for _, eventType := range strings.Split(types, ",") {
+			typeFilter[strings.TrimSpace(eventType)] = true
+		}
+	}
+
+	// The stream outlives the server write timeout
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		s.sendErrorResponse(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, backlog, cancel := s.pluginService.SubscribeEvents(afterID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s.logger.WithFields(logger.Fields{
+		"remote_addr":   r.RemoteAddr,
+		"last_event_id": afterID,
+		"plugin":        pluginFilter,
+	}).Info("Event stream subscriber connected")
+
+	send := func(event services.LifecycleEvent) error {
+		if pluginFilter != "" && event.PluginSlug != pluginFilter {
+			return nil
+		}
+		if len(typeFilter) > 0 && !typeFilter[event.Type] {
+			return nil
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+
+	for _, event := range backlog {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(eventKeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case event, open := <-events:
+			if !open {
+				// Fell behind; the client reconnects with its Last-Event-ID
+				s.logger.WithFields(logger.Fields{
+					"remote_addr": r.RemoteAddr,
+				}).Warn("Event stream subscriber fell behind, disconnecting")
+				return
+			}
+			if err := send(event); err != nil {
+				return
+			}
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			if err := controller.Flush(); err != nil {
+				return
+			}
+		case <-s.shutdown:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,213 @@
+/*
+ * Firecracker CMS - Prometheus Metrics
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/services"
+)
+
+// prometheusWriter renders metrics in the Prometheus text exposition format
+type prometheusWriter struct {
+	w io.Writer
+}
+
+// header writes the HELP and TYPE lines for a metric family
+func (p *prometheusWriter) header(name, help, metricType string) {
+	fmt.Fprintf(p.w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(p.w, "# TYPE %s %s\n", name, metricType)
+}
+
+// sample writes a single sample with optional labels (key/value pairs)
+func (p *prometheusWriter) sample(name string, value float64, labels ...string) {
+	if len(labels) == 0 {
+		fmt.Fprintf(p.w, "%s %g\n", name, value)
+		return
+	}
+
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+	}
+	fmt.Fprintf(p.w, "%s{%s} %g\n", name, strings.Join(pairs, ","), value)
+}
+
+// gauge writes a complete single-sample gauge
+func (p *prometheusWriter) gauge(name, help string, value float64) {
+	p.header(name, help, "gauge")
+	p.sample(name, value)
+}
+
+// sortedKeys returns map keys in a stable order for deterministic output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writePrometheusMetrics renders the CMS metrics in Prometheus format
+func (s *Server) writePrometheusMetrics(w http.ResponseWriter) {
+	plugins, _ := s.pluginService.ListPlugins()
+	vms := s.vmService.ListVMs()
+	execStats := s.pluginService.GetExecutionStats()
+	snapshotStats := s.vmService.GetSnapshotStats()
+	throttleStats := s.pluginService.GetThrottleStats()
+	circuitStats := s.pluginService.GetCircuitStats()
+	poolCapacity := s.vmService.GetPoolCapacity()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+
+	p := &prometheusWriter{w: w}
+
+	p.gauge("cms_plugins_total", "Number of registered plugins.", float64(len(plugins)))
+	p.gauge("cms_instances_total", "Number of running VM instances.", float64(len(vms)))
+	p.gauge("cms_executions_in_flight", "Plugin executions currently in flight.", float64(execStats.InFlight))
+	p.gauge("cms_executions_peak_concurrency", "Peak number of concurrent plugin executions.", float64(execStats.PeakConcurrency))
+	p.gauge("cms_executions_warm_hit_ratio", "Share of executions that found a warm instance.", execStats.WarmHitRatio)
+	p.gauge("cms_warm_memory_budget_mib", "Memory budget of running VMs, 0 when the pool is not sized by memory.", float64(poolCapacity.MemoryBudgetMib))
+	p.gauge("cms_warm_memory_used_mib", "Memory of running VMs including VMM overhead.", float64(poolCapacity.MemoryUsedMib))
+
+	slugs := sortedKeys(execStats.Plugins)
+
+	p.header("cms_plugin_executions_in_flight", "Plugin executions currently in flight per plugin.", "gauge")
+	for _, slug := range slugs {
+		p.sample("cms_plugin_executions_in_flight", float64(execStats.Plugins[slug].InFlight), "plugin", slug)
+	}
+
+	p.header("cms_plugin_executions_peak_concurrency", "Peak concurrent executions per plugin.", "gauge")
+	for _, slug := range slugs {
+		p.sample("cms_plugin_executions_peak_concurrency", float64(execStats.Plugins[slug].PeakConcurrency), "plugin", slug)
+	}
+
+	p.header("cms_plugin_instance_acquire_wait_seconds", "Time spent acquiring a warm instance per plugin.", "summary")
+	for _, slug := range slugs {
+		stats := execStats.Plugins[slug]
+		p.sample("cms_plugin_instance_acquire_wait_seconds_sum", stats.AcquireWaitTotalMs/1000, "plugin", slug)
+		p.sample("cms_plugin_instance_acquire_wait_seconds_count", float64(stats.Acquisitions), "plugin", slug)
+	}
+
+	p.header("cms_plugin_instance_acquire_wait_seconds_max", "Longest wait for a warm instance per plugin.", "gauge")
+	for _, slug := range slugs {
+		p.sample("cms_plugin_instance_acquire_wait_seconds_max", execStats.Plugins[slug].AcquireWaitMaxMs/1000, "plugin", slug)
+	}
+
+	p.header("cms_plugin_warm_hits_total", "Executions that found a warm instance per plugin.", "counter")
+	for _, slug := range slugs {
+		p.sample("cms_plugin_warm_hits_total", float64(execStats.Plugins[slug].WarmHits), "plugin", slug)
+	}
+
+	p.header("cms_plugin_cold_misses_total", "Executions that found no warm instance per plugin.", "counter")
+	for _, slug := range slugs {
+		p.sample("cms_plugin_cold_misses_total", float64(execStats.Plugins[slug].ColdMisses), "plugin", slug)
+	}
+
+	p.header("cms_plugin_warm_hit_ratio", "Share of executions that found a warm instance per plugin.", "gauge")
+	for _, slug := range slugs {
+		p.sample("cms_plugin_warm_hit_ratio", execStats.Plugins[slug].WarmHitRatio, "plugin", slug)
+	}
+
+	snapshotSlugs := sortedKeys(snapshotStats)
+	snapshotOps := func(stats services.PluginSnapshotStats) map[string]services.SnapshotOperationStats {
+		return map[string]services.SnapshotOperationStats{
+			"full":         stats.Full,
+			"differential": stats.Differential,
+			"resume":       stats.Resume,
+		}
+	}
+
+	p.header("cms_plugin_snapshot_duration_seconds", "Snapshot creation (full, differential) and resume time per plugin.", "summary")
+	for _, slug := range snapshotSlugs {
+		ops := snapshotOps(snapshotStats[slug])
+		for _, op := range sortedKeys(ops) {
+			p.sample("cms_plugin_snapshot_duration_seconds_sum", ops[op].TotalMs/1000, "plugin", slug, "operation", op)
+			p.sample("cms_plugin_snapshot_duration_seconds_count", float64(ops[op].Count), "plugin", slug, "operation", op)
+		}
+	}
+
+	p.header("cms_plugin_snapshot_size_bytes", "Size of the most recent snapshot files per plugin and operation.", "gauge")
+	for _, slug := range snapshotSlugs {
+		ops := snapshotOps(snapshotStats[slug])
+		for _, op := range sortedKeys(ops) {
+			p.sample("cms_plugin_snapshot_size_bytes", float64(ops[op].LastSizeBytes), "plugin", slug, "operation", op)
+		}
+	}
+
+	p.header("cms_plugin_snapshot_dirty_ratio", "Share of guest memory carried by the most recent differential snapshot per plugin.", "gauge")
+	for _, slug := range snapshotSlugs {
+		if dirty := snapshotStats[slug].DirtyPages; dirty.Count > 0 {
+			p.sample("cms_plugin_snapshot_dirty_ratio", dirty.LastRatio, "plugin", slug)
+		}
+	}
+
+	p.header("cms_plugin_snapshot_dirty_pages", "Dirty pages in the most recent differential snapshot per plugin.", "gauge")
+	for _, slug := range snapshotSlugs {
+		if dirty := snapshotStats[slug].DirtyPages; dirty.Count > 0 {
+			p.sample("cms_plugin_snapshot_dirty_pages", float64(dirty.LastDirtyPages), "plugin", slug)
+		}
+	}
+
+	p.header("cms_plugin_snapshot_rebase_recommended", "Whether differential snapshots grew past the rebase threshold per plugin.", "gauge")
+	for _, slug := range snapshotSlugs {
+		recommended := 0.0
+		if snapshotStats[slug].DirtyPages.RebaseRecommended {
+			recommended = 1
+		}
+		p.sample("cms_plugin_snapshot_rebase_recommended", recommended, "plugin", slug)
+	}
+
+	throttleSlugs := sortedKeys(throttleStats)
+
+	p.header("cms_plugin_rate_limit_tokens", "Executions a rate limited plugin can start right now.", "gauge")
+	for _, slug := range throttleSlugs {
+		p.sample("cms_plugin_rate_limit_tokens", throttleStats[slug].TokensAvailable, "plugin", slug)
+	}
+
+	p.header("cms_plugin_throttled_total", "Executions rejected by the plugin rate limit.", "counter")
+	for _, slug := range throttleSlugs {
+		p.sample("cms_plugin_throttled_total", float64(throttleStats[slug].ThrottledTotal), "plugin", slug)
+	}
+
+	capacitySlugs := sortedKeys(poolCapacity.Plugins)
+
+	p.header("cms_plugin_pool_capacity", "Running VMs a plugin may have after the memory budget.", "gauge")
+	for _, slug := range capacitySlugs {
+		p.sample("cms_plugin_pool_capacity", float64(poolCapacity.Plugins[slug].MaxInstances), "plugin", slug)
+	}
+
+	circuitSlugs := sortedKeys(circuitStats)
+
+	p.header("cms_plugin_circuit_open", "Whether the plugin circuit breaker rejects executions (1 open, 0.5 half-open, 0 closed).", "gauge")
+	for _, slug := range circuitSlugs {
+		open := 0.0
+		switch circuitStats[slug].State {
+		case services.CircuitOpen:
+			open = 1
+		case services.CircuitHalfOpen:
+			open = 0.5
+		}
+		p.sample("cms_plugin_circuit_open", open, "plugin", slug)
+	}
+
+	p.header("cms_plugin_circuit_opened_total", "Times the plugin circuit breaker opened.", "counter")
+	for _, slug := range circuitSlugs {
+		p.sample("cms_plugin_circuit_opened_total", float64(circuitStats[slug].OpenedTotal), "plugin", slug)
+	}
+
+	p.header("cms_plugin_circuit_rejected_total", "Executions rejected by an open plugin circuit.", "counter")
+	for _, slug := range circuitSlugs {
+		p.sample("cms_plugin_circuit_rejected_total", float64(circuitStats[slug].RejectedTotal), "plugin", slug)
+	}
+}
@@ -9,15 +9,20 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/centraunit/cu-firecracker-cms/internal/config"
+	cms_errors "github.com/centraunit/cu-firecracker-cms/internal/errors"
 	"github.com/centraunit/cu-firecracker-cms/internal/logger"
 	"github.com/centraunit/cu-firecracker-cms/internal/models"
 	"github.com/centraunit/cu-firecracker-cms/internal/services"
+	"github.com/centraunit/cu-firecracker-cms/internal/version"
 )
 
 // Server represents the HTTP server
@@ -27,6 +32,10 @@ type Server struct {
 	vmService     *services.VMService
 	pluginService *services.PluginService
 	server        *http.Server
+	callerACL     *callerACL // nil when execution is unrestricted
+
+	// Closed on shutdown to end long-lived event streams
+	shutdown chan struct{}
 }
 
 // New creates a new server instance
@@ -36,11 +45,24 @@ func New(cfg *config.Config, log *logger.Logger, vmService *services.VMService,
 		logger:        log,
 		vmService:     vmService,
 		pluginService: pluginService,
+		shutdown:      make(chan struct{}),
 	}
 }
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
+	if s.config.CallerACLFile != "" {
+		acl, err := loadCallerACL(s.config.CallerACLFile)
+		if err != nil {
+			return err
+		}
+		s.callerACL = acl
+
+		s.logger.WithFields(logger.Fields{
+			"callers": len(acl.Callers),
+		}).Info("Caller authorization enabled for action execution")
+	}
+
 	mux := http.NewServeMux()
 
 	// Add middleware
@@ -52,24 +74,54 @@ func (s *Server) Start() error {
 
 	// Action execution endpoint
 	mux.HandleFunc("/api/execute", s.handleExecuteAction)
+	mux.HandleFunc("/api/executions", s.handleExecutionHistory)
+
+	// Plugin lifecycle event stream
+	mux.HandleFunc("/api/events", s.handleEvents)
 
 	// Health and metrics
 	mux.HandleFunc("/health", s.handleHealthCheck)
 	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/version", s.handleVersion)
+
+	// Individual VM instance control
+	mux.HandleFunc("/api/instances/", s.handleInstanceControl)
+
+	// System information
+	mux.HandleFunc("/api/system/info", s.handleSystemInfo)
+	mux.HandleFunc("/api/system/config", s.handleSystemConfig)
+	mux.HandleFunc("/api/system/selfcheck", s.handleSystemSelfCheck)
+	mux.HandleFunc("/api/system/tls", s.handleSystemTLS)
+
+	// Administrative operations
+	mux.HandleFunc("/api/admin/snapshot-all", s.handleSnapshotAll)
+	mux.HandleFunc("/api/admin/export", s.handleExportState)
+	mux.HandleFunc("/api/admin/import", s.handleImportState)
+	mux.HandleFunc("/api/admin/maintenance", s.handleMaintenance)
 
 	s.server = &http.Server{
-		Addr:         ":" + s.config.Port,
+		Addr:         s.config.ListenAddr(),
 		Handler:      handler,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		ReadTimeout:  time.Duration(s.config.ReadTimeoutSec) * time.Second,
+		WriteTimeout: time.Duration(s.config.WriteTimeoutSec) * time.Second,
+		IdleTimeout:  time.Duration(s.config.IdleTimeoutSec) * time.Second,
+	}
+	s.server.RegisterOnShutdown(func() { close(s.shutdown) })
+
+	// Bind before logging so an unusable host or port fails loudly at startup
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", s.server.Addr, err)
 	}
 
 	s.logger.WithFields(logger.Fields{
-		"port": s.config.Port,
+		"address":       listener.Addr().String(),
+		"read_timeout":  s.config.ReadTimeoutSec,
+		"write_timeout": s.config.WriteTimeoutSec,
+		"idle_timeout":  s.config.IdleTimeoutSec,
 	}).Info("Starting CMS server")
 
-	return s.server.ListenAndServe()
+	return s.server.Serve(listener)
 }
 
 // Stop gracefully stops the server
@@ -117,8 +169,8 @@ func (s *Server) recoveryMiddleware(next http.Handler) http.Handler {
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-CMS-Raw-Response, X-Deadline, X-Timeout-Ms, X-Execution-Budget-Ms, X-Correlation-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -170,6 +222,54 @@ func (s *Server) handlePluginBySlug(w http.ResponseWriter, r *http.Request) {
 				s.handleDeactivatePlugin(w, r, slug)
 				return
 			}
+		case "stats":
+			if r.Method == "GET" {
+				s.handlePluginStats(w, r, slug)
+				return
+			}
+		case "status":
+			if r.Method == "GET" {
+				s.handlePluginStatus(w, r, slug)
+				return
+			}
+		case "proxy":
+			s.handlePluginProxy(w, r, slug)
+			return
+		case "metrics":
+			if r.Method == "GET" {
+				s.handlePluginMetrics(w, r, slug)
+				return
+			}
+		case "openapi":
+			if r.Method == "GET" {
+				s.handlePluginOpenAPI(w, r, slug)
+				return
+			}
+		case "priority":
+			if r.Method == "PATCH" {
+				s.handleUpdatePluginPriority(w, r, slug)
+				return
+			}
+		case "debug":
+			if r.Method == "PATCH" {
+				s.handleUpdatePluginDebug(w, r, slug)
+				return
+			}
+		case "rotate-cert":
+			if r.Method == "POST" {
+				s.handleRotatePluginCert(w, r, slug)
+				return
+			}
+		case "force-cleanup":
+			if r.Method == "POST" {
+				s.handleForceCleanupPlugin(w, r, slug)
+				return
+			}
+		case "clone":
+			if r.Method == "POST" {
+				s.handleClonePlugin(w, r, slug)
+				return
+			}
 		}
 		s.sendErrorResponse(w, "Invalid action", http.StatusBadRequest)
 		return
@@ -179,6 +279,8 @@ func (s *Server) handlePluginBySlug(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
 		s.handleGetPlugin(w, r, slug)
+	case "PUT":
+		s.handleUpdatePlugin(w, r, slug)
 	case "DELETE":
 		s.handleDeletePlugin(w, r, slug)
 	default:
@@ -186,6 +288,94 @@ func (s *Server) handlePluginBySlug(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) handlePluginProxy(w http.ResponseWriter, r *http.Request, slug string) {
+	if !s.authorizePluginAccess(w, r, slug) {
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/plugins/"+slug+"/proxy")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	// Large transfers outlast the server timeouts, bound them by the proxy timeout
+	deadline := time.Now().Add(time.Duration(s.config.ProxyTimeoutSec) * time.Second)
+	controller := http.NewResponseController(w)
+	controller.SetReadDeadline(deadline)
+	controller.SetWriteDeadline(deadline)
+
+	if err := s.pluginService.ProxyRequest(slug, path, w, r); err != nil {
+		s.logger.WithFields(logger.Fields{
+			"plugin_slug": slug,
+			"path":        path,
+			"error":       err,
+		}).Warn("Failed to proxy request to plugin")
+
+		status := http.StatusInternalServerError
+		switch cms_errors.GetType(err) {
+		case cms_errors.ErrTypeValidation:
+			status = http.StatusConflict
+			if _, getErr := s.pluginService.GetPlugin(slug); getErr != nil {
+				status = http.StatusNotFound
+			}
+		case cms_errors.ErrTypeRateLimit:
+			status = http.StatusTooManyRequests
+		case cms_errors.ErrTypeVM:
+			status = http.StatusServiceUnavailable
+		}
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to proxy request: %v", err), status)
+	}
+}
+
+func (s *Server) handlePluginMetrics(w http.ResponseWriter, r *http.Request, slug string) {
+	if !s.authorizePluginAccess(w, r, slug) {
+		return
+	}
+
+	metrics, err := s.pluginService.ScrapePluginMetrics(slug)
+	if err != nil {
+		status := http.StatusBadGateway
+		switch cms_errors.GetType(err) {
+		case cms_errors.ErrTypeValidation:
+			status = http.StatusConflict
+			if _, getErr := s.pluginService.GetPlugin(slug); getErr != nil {
+				status = http.StatusNotFound
+			}
+		case cms_errors.ErrTypeVM:
+			status = http.StatusServiceUnavailable
+		}
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to get plugin metrics: %v", err), status)
+		return
+	}
+
+	// Serve the plugin's own format, typically Prometheus text
+	contentType := metrics.ContentType
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Scraped-At", metrics.ScrapedAt.UTC().Format(time.RFC3339))
+	w.WriteHeader(http.StatusOK)
+	w.Write(metrics.Body)
+}
+
+func (s *Server) handlePluginOpenAPI(w http.ResponseWriter, r *http.Request, slug string) {
+	spec, err := s.pluginService.GetPluginOpenAPI(slug)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if cms_errors.GetType(err) == cms_errors.ErrTypeValidation {
+			status = http.StatusNotFound
+		}
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to get plugin OpenAPI document: %v", err), status)
+		return
+	}
+
+	// Serve the document as the plugin published it
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(spec)
+}
+
 func (s *Server) handleListPlugins(w http.ResponseWriter, r *http.Request) {
 	s.logger.Debug("Handling list plugins request")
 
@@ -198,6 +388,25 @@ func (s *Server) handleListPlugins(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Optionally keep only the plugins with a given tag
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		tagged := make([]*models.Plugin, 0, len(plugins))
+		for _, plugin := range plugins {
+			if plugin.HasTag(tag) {
+				tagged = append(tagged, plugin)
+			}
+		}
+		plugins = tagged
+	}
+
+	// Plugins are ordered by slug unless another sort key is requested
+	if sortKey := r.URL.Query().Get("sort"); sortKey != "" {
+		if err := services.SortPlugins(plugins, sortKey); err != nil {
+			s.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	s.logger.WithFields(logger.Fields{
 		"count": len(plugins),
 	}).Info("Listed plugins")
@@ -208,8 +417,26 @@ func (s *Server) handleListPlugins(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleUploadPlugin(w http.ResponseWriter, r *http.Request) {
 	s.logger.Debug("Handling plugin upload request")
 
+	// Reject oversized uploads before they are buffered to disk
+	maxUploadBytes := int64(s.config.MaxUploadSizeMB) << 20
+	tooLargeMessage := fmt.Sprintf("Plugin upload exceeds the maximum size of %dMB", s.config.MaxUploadSizeMB)
+	if r.ContentLength > maxUploadBytes {
+		s.sendErrorResponse(w, tooLargeMessage, http.StatusRequestEntityTooLarge)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+
 	// Parse multipart form
-	if err := r.ParseMultipartForm(32 << 20); err != nil { // 32MB max
+	if err := r.ParseMultipartForm(32 << 20); err != nil { // 32MB in memory, the rest in temp files
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			s.logger.WithFields(logger.Fields{
+				"limit_mb": s.config.MaxUploadSizeMB,
+			}).Warn("Rejected oversized plugin upload")
+			s.sendErrorResponse(w, tooLargeMessage, http.StatusRequestEntityTooLarge)
+			return
+		}
+
 		s.logger.WithFields(logger.Fields{
 			"error": err,
 		}).Error("Failed to parse multipart form")
@@ -233,7 +460,7 @@ func (s *Server) handleUploadPlugin(w http.ResponseWriter, r *http.Request) {
 		s.logger.WithFields(logger.Fields{
 			"filename": header.Filename,
 		}).Error("Invalid file type")
-		s.sendErrorResponse(w, "Plugin must be a ZIP file containing rootfs.ext4 and plugin.json", http.StatusBadRequest)
+		s.sendErrorResponse(w, "Plugin must be a ZIP file containing plugin.json and rootfs.ext4 or rootfs.squashfs", http.StatusBadRequest)
 		return
 	}
 
@@ -254,6 +481,22 @@ func (s *Server) handleUploadPlugin(w http.ResponseWriter, r *http.Request) {
 		s.logger.WithFields(logger.Fields{
 			"error": err,
 		}).Error("Failed to upload plugin")
+
+		var validationErrors models.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			s.sendValidationErrorResponse(w, fmt.Sprintf("Invalid plugin: %d validation errors", len(validationErrors)), validationErrors)
+			return
+		}
+
+		if errors.Is(err, services.ErrUploadInProgress) {
+			s.sendErrorResponse(w, fmt.Sprintf("Failed to upload plugin: %v", err), http.StatusConflict)
+			return
+		}
+		if cms_errors.GetType(err) == cms_errors.ErrTypeFileSystem {
+			s.sendErrorResponse(w, fmt.Sprintf("Failed to upload plugin: %v", err), http.StatusInsufficientStorage)
+			return
+		}
+
 		s.sendErrorResponse(w, fmt.Sprintf("Failed to upload plugin: %v", err), http.StatusBadRequest)
 		return
 	}
@@ -264,7 +507,14 @@ func (s *Server) handleUploadPlugin(w http.ResponseWriter, r *http.Request) {
 		"version":     plugin.Version,
 	}).Info("Plugin uploaded successfully")
 
-	s.sendSuccessResponse(w, plugin, http.StatusCreated)
+	// Surface hook overlaps, which are only ordered by priority
+	var warnings []string
+	for _, overlap := range s.pluginService.HookOverlaps(plugin.Slug) {
+		warnings = append(warnings, fmt.Sprintf("hook %s is also handled by active plugin %s (priority %d); this plugin has priority %d",
+			overlap.Hook, overlap.PluginSlug, overlap.Priority, plugin.Priority))
+	}
+
+	s.sendSuccessResponseWithWarnings(w, plugin, warnings, http.StatusCreated)
 }
 
 func (s *Server) handleGetPlugin(w http.ResponseWriter, r *http.Request, slug string) {
@@ -291,6 +541,185 @@ func (s *Server) handleGetPlugin(w http.ResponseWriter, r *http.Request, slug st
 	s.sendSuccessResponse(w, plugin, http.StatusOK)
 }
 
+func (s *Server) handleUpdatePlugin(w http.ResponseWriter, r *http.Request, slug string) {
+	s.logger.WithFields(logger.Fields{
+		"plugin_slug": slug,
+	}).Debug("Handling update plugin request")
+
+	var update models.PluginMetadataUpdate
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields() // Reject immutable or unknown fields
+	if err := decoder.Decode(&update); err != nil {
+		s.logger.WithFields(logger.Fields{
+			"plugin_slug": slug,
+			"error":       err,
+		}).Error("Failed to parse update plugin request body")
+		s.sendErrorResponse(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.pluginService.GetPlugin(slug); err != nil {
+		s.sendErrorResponse(w, "Plugin not found", http.StatusNotFound)
+		return
+	}
+
+	plugin, err := s.pluginService.UpdatePluginMetadata(slug, &update)
+	if err != nil {
+		s.logger.WithFields(logger.Fields{
+			"plugin_slug": slug,
+			"error":       err,
+		}).Error("Failed to update plugin")
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to update plugin: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	s.logger.WithFields(logger.Fields{
+		"plugin_slug": slug,
+	}).Info("Plugin metadata updated successfully")
+
+	s.sendSuccessResponse(w, plugin, http.StatusOK)
+}
+
+// handleUpdatePluginPriority changes a plugin's execution priority; the next
+// execution uses the new order
+func (s *Server) handleUpdatePluginPriority(w http.ResponseWriter, r *http.Request, slug string) {
+	var requestBody struct {
+		Priority *int `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Priority == nil {
+		s.sendErrorResponse(w, "Request body must be {\"priority\": <integer>}", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.pluginService.GetPlugin(slug); err != nil {
+		s.sendErrorResponse(w, "Plugin not found", http.StatusNotFound)
+		return
+	}
+
+	plugin, err := s.pluginService.UpdatePluginMetadata(slug, &models.PluginMetadataUpdate{Priority: requestBody.Priority})
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to update priority: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	s.logger.WithFields(logger.Fields{
+		"plugin_slug": slug,
+		"priority":    plugin.Priority,
+	}).Info("Plugin priority updated")
+
+	s.sendSuccessResponse(w, plugin, http.StatusOK)
+}
+
+// handleUpdatePluginDebug turns full payload and response logging of a plugin's
+// executions on or off
+func (s *Server) handleUpdatePluginDebug(w http.ResponseWriter, r *http.Request, slug string) {
+	var requestBody struct {
+		Debug *bool `json:"debug"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Debug == nil {
+		s.sendErrorResponse(w, "Request body must be {\"debug\": <boolean>}", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.pluginService.GetPlugin(slug); err != nil {
+		s.sendErrorResponse(w, "Plugin not found", http.StatusNotFound)
+		return
+	}
+
+	plugin, err := s.pluginService.UpdatePluginMetadata(slug, &models.PluginMetadataUpdate{Debug: requestBody.Debug})
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to update debug logging: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	s.logger.WithFields(logger.Fields{
+		"plugin_slug": slug,
+		"debug":       plugin.Debug,
+	}).Info("Plugin debug logging updated")
+
+	s.sendSuccessResponse(w, plugin, http.StatusOK)
+}
+
+// handleClonePlugin duplicates a plugin under the target slug in the request body
+func (s *Server) handleClonePlugin(w http.ResponseWriter, r *http.Request, slug string) {
+	var request models.PluginCloneRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.TargetSlug == "" {
+		s.sendErrorResponse(w, "Request body must be {\"target_slug\": \"<slug>\"}", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.pluginService.GetPlugin(slug); err != nil {
+		s.sendErrorResponse(w, "Plugin not found", http.StatusNotFound)
+		return
+	}
+
+	plugin, err := s.pluginService.ClonePlugin(slug, &request)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrPluginExists) || errors.Is(err, services.ErrUploadInProgress) || errors.Is(err, services.ErrCloneSourceRunning) {
+			status = http.StatusConflict
+		} else if cms_errors.GetType(err) == cms_errors.ErrTypeFileSystem {
+			status = http.StatusInsufficientStorage
+		}
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to clone plugin: %v", err), status)
+		return
+	}
+
+	s.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"source_slug": slug,
+	}).Info("Plugin cloned successfully")
+
+	s.sendSuccessResponse(w, plugin, http.StatusCreated)
+}
+
+// handleRotatePluginCert discards the certificate of a plugin using mutual
+// TLS; a new one is issued when the plugin next boots fresh. Requires the
+// admin token.
+func (s *Server) handleRotatePluginCert(w http.ResponseWriter, r *http.Request, slug string) {
+	if !s.requireAdmin(w, r, "Certificate rotation") {
+		return
+	}
+
+	if _, err := s.pluginService.GetPlugin(slug); err != nil {
+		s.sendErrorResponse(w, "Plugin not found", http.StatusNotFound)
+		return
+	}
+
+	plugin, err := s.pluginService.RotatePluginCert(slug)
+	if errors.Is(err, services.ErrPluginTLSDisabled) {
+		s.sendErrorResponse(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to rotate certificate: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	s.sendSuccessResponse(w, plugin, http.StatusOK)
+}
+
+// handleForceCleanupPlugin frees every resource of a wedged plugin. It
+// requires the admin token and is disabled until one is configured.
+func (s *Server) handleForceCleanupPlugin(w http.ResponseWriter, r *http.Request, slug string) {
+	if !s.requireAdmin(w, r, "Force cleanup") {
+		return
+	}
+
+	if _, err := s.pluginService.GetPlugin(slug); err != nil {
+		s.sendErrorResponse(w, "Plugin not found", http.StatusNotFound)
+		return
+	}
+
+	report, err := s.pluginService.ForceCleanupPlugin(slug)
+	if err != nil {
+		s.sendErrorResponse(w, fmt.Sprintf("Force cleanup failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.sendSuccessResponse(w, report, http.StatusOK)
+}
+
 func (s *Server) handleDeletePlugin(w http.ResponseWriter, r *http.Request, slug string) {
 	s.logger.WithFields(logger.Fields{
 		"plugin_slug": slug,
@@ -324,7 +753,13 @@ func (s *Server) handleActivatePlugin(w http.ResponseWriter, r *http.Request, sl
 			"plugin_slug": slug,
 			"error":       err,
 		}).Error("Failed to activate plugin")
-		s.sendErrorResponse(w, fmt.Sprintf("Failed to activate plugin: %v", err), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrMaintenanceMode) {
+			status = http.StatusServiceUnavailable
+		} else if errors.Is(err, services.ErrPluginDraining) {
+			status = http.StatusConflict
+		}
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to activate plugin: %v", err), status)
 		return
 	}
 
@@ -346,7 +781,11 @@ func (s *Server) handleDeactivatePlugin(w http.ResponseWriter, r *http.Request,
 			"plugin_slug": slug,
 			"error":       err,
 		}).Error("Failed to deactivate plugin")
-		s.sendErrorResponse(w, fmt.Sprintf("Failed to deactivate plugin: %v", err), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrPluginDraining) {
+			status = http.StatusConflict
+		}
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to deactivate plugin: %v", err), status)
 		return
 	}
 
@@ -357,6 +796,47 @@ func (s *Server) handleDeactivatePlugin(w http.ResponseWriter, r *http.Request,
 	s.sendSuccessResponse(w, plugin, http.StatusOK)
 }
 
+func (s *Server) handlePluginStats(w http.ResponseWriter, r *http.Request, slug string) {
+	if _, err := s.pluginService.GetPlugin(slug); err != nil {
+		s.sendErrorResponse(w, "Plugin not found", http.StatusNotFound)
+		return
+	}
+
+	// Optional window such as "15m"; defaults to the whole buffered history
+	var window time.Duration
+	if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+		parsed, err := time.ParseDuration(windowStr)
+		if err != nil || parsed <= 0 {
+			s.sendErrorResponse(w, "Invalid window duration", http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	samples, summary := s.vmService.GetResourceUsage(slug, window)
+
+	response := map[string]interface{}{
+		"plugin_slug": slug,
+		"window":      window.String(),
+		"samples":     samples,
+		"summary":     summary,
+	}
+
+	s.sendSuccessResponse(w, response, http.StatusOK)
+}
+
+// handlePluginStatus returns a plugin's registry entry combined with the live
+// state of its warm instance
+func (s *Server) handlePluginStatus(w http.ResponseWriter, r *http.Request, slug string) {
+	status, err := s.pluginService.GetPluginStatus(slug)
+	if err != nil {
+		s.sendErrorResponse(w, "Plugin not found", http.StatusNotFound)
+		return
+	}
+
+	s.sendSuccessResponse(w, status, http.StatusOK)
+}
+
 func (s *Server) handleExecuteAction(w http.ResponseWriter, r *http.Request) {
 	s.logger.Debug("Handling execute action request")
 
@@ -369,6 +849,11 @@ func (s *Server) handleExecuteAction(w http.ResponseWriter, r *http.Request) {
 	var requestBody struct {
 		Action  string                 `json:"action"`
 		Payload map[string]interface{} `json:"payload"`
+		Mode    string                 `json:"mode"` // Overrides the hook's execution mode
+		Tag     string                 `json:"tag"`  // Only runs plugins with this tag
+
+		// Runs the action in the background and POSTs the outcome here
+		CallbackURL string `json:"callback_url"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
@@ -384,12 +869,85 @@ func (s *Server) handleExecuteAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if requestBody.Mode != "" && !config.IsValidExecutionMode(requestBody.Mode) {
+		s.sendErrorResponse(w, "Invalid mode (must be broadcast, first-success or any)", http.StatusBadRequest)
+		return
+	}
+
+	caller, authenticated := s.authenticateCaller(r)
+	if !authenticated {
+		s.sendErrorResponse(w, "Missing or invalid caller token", http.StatusUnauthorized)
+		return
+	}
+
+	// Only authenticated callers learn which callback hosts are allowed
+	if requestBody.CallbackURL != "" {
+		if err := s.pluginService.ValidateCallbackURL(requestBody.CallbackURL); err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, services.ErrCallbackNotAllowed) || errors.Is(err, services.ErrCallbacksDisabled) {
+				status = http.StatusForbidden
+			}
+			s.sendErrorResponse(w, err.Error(), status)
+			return
+		}
+	}
+
+	opts := services.ExecutionOptions{Headers: r.Header, Mode: requestBody.Mode, Tag: requestBody.Tag}
+	callerName := ""
+	if caller != nil {
+		callerName = caller.Name
+		if !caller.allowsHook(requestBody.Action) {
+			s.logger.WithFields(logger.Fields{
+				"action": requestBody.Action,
+				"caller": callerName,
+			}).Warn("Caller not permitted to trigger action")
+			s.sendErrorResponse(w, "Caller is not permitted to trigger this action", http.StatusForbidden)
+			return
+		}
+		opts.Filter = caller.allowsPlugin
+	}
+
 	s.logger.WithFields(logger.Fields{
 		"action": requestBody.Action,
+		"caller": callerName,
 	}).Debug("Executing action")
 
+	if requestBody.CallbackURL != "" {
+		correlationID := r.Header.Get(services.CorrelationIDHeader)
+		if correlationID == "" {
+			correlationID = services.NewCorrelationID()
+		}
+
+		if err := s.pluginService.ExecuteActionAsync(correlationID, requestBody.CallbackURL, requestBody.Action, requestBody.Payload, s.vmService, opts); err != nil {
+			s.sendErrorResponse(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+
+		s.logger.WithFields(logger.Fields{
+			"action":         requestBody.Action,
+			"caller":         callerName,
+			"correlation_id": correlationID,
+		}).Info("Accepted asynchronous execution")
+
+		w.Header().Set(services.CorrelationIDHeader, correlationID)
+		s.sendSuccessResponse(w, map[string]interface{}{
+			"action_hook":    requestBody.Action,
+			"correlation_id": correlationID,
+			"status":         "accepted",
+		}, http.StatusAccepted)
+		return
+	}
+
 	// Execute action using plugin service
-	results, err := s.pluginService.ExecuteAction(requestBody.Action, requestBody.Payload, s.vmService)
+	results, err := s.pluginService.ExecuteActionFiltered(requestBody.Action, requestBody.Payload, s.vmService, opts)
+	if errors.Is(err, services.ErrNoPermittedPlugins) {
+		s.logger.WithFields(logger.Fields{
+			"action": requestBody.Action,
+			"caller": callerName,
+		}).Warn("Caller not permitted to invoke any plugin for action")
+		s.sendErrorResponse(w, "Caller is not permitted to invoke any plugin for this action", http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		s.logger.WithFields(logger.Fields{
 			"action": requestBody.Action,
@@ -399,16 +957,59 @@ func (s *Server) handleExecuteAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Single-target executions can answer with the plugin's own response
+	if rawResponseRequested(r) {
+		if executed, ok := results["results"].([]map[string]interface{}); ok && len(executed) == 1 {
+			s.sendRawResult(w, executed[0])
+			return
+		}
+	}
+
 	response := map[string]interface{}{
 		"action_hook":      requestBody.Action,
 		"executed_plugins": len(results),
 		"results":          results,
 		"timestamp":        time.Now().Format(time.RFC3339),
 	}
+	if requestBody.Tag != "" {
+		response["tag"] = requestBody.Tag
+	}
 
 	s.sendSuccessResponse(w, response, http.StatusOK)
 }
 
+// rawResponseRequested reports whether the caller asked for the plugin's
+// response without the result envelope, via ?raw=true or X-CMS-Raw-Response: true
+func rawResponseRequested(r *http.Request) bool {
+	return r.URL.Query().Get("raw") == "true" || r.Header.Get("X-CMS-Raw-Response") == "true"
+}
+
+// sendRawResult writes a single plugin's result as the response body. Failures
+// keep the standard error envelope, answered with 502.
+func (s *Server) sendRawResult(w http.ResponseWriter, result map[string]interface{}) {
+	if slug, ok := result["plugin_slug"].(string); ok {
+		w.Header().Set("X-CMS-Plugin", slug)
+	}
+
+	if success, _ := result["success"].(bool); !success {
+		message := "Plugin execution failed"
+		if body, ok := result["result"].(map[string]interface{}); ok {
+			if errMessage, ok := body["error"].(string); ok {
+				message = errMessage
+			}
+		}
+		if errType, ok := result["error_type"]; ok {
+			w.Header().Set("X-CMS-Error-Type", fmt.Sprint(errType))
+		}
+		s.sendErrorResponse(w, message, http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result["result"])
+}
+
 func (s *Server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	plugins, _ := s.pluginService.ListPlugins()
 
@@ -420,34 +1021,391 @@ func (s *Server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	maintenance := s.vmService.MaintenanceStatus()
+
+	// Low disk refuses uploads and snapshots but keeps executions running
+	disk := s.vmService.DiskUsage()
+	status := "healthy"
+	for _, usage := range disk {
+		if usage.Low {
+			status = "degraded"
+		}
+	}
+	if maintenance.Enabled {
+		status = "maintenance"
+	}
+
 	health := map[string]interface{}{
-		"status":         "healthy",
+		"status":         status,
 		"total_plugins":  totalPlugins,
 		"active_plugins": activePlugins,
 		"vm_instances":   len(s.vmService.ListVMs()),
+		"maintenance":    maintenance,
+		"disk":           disk,
 	}
 
 	s.sendSuccessResponse(w, health, http.StatusOK)
 }
 
+// handleVersion reports the version, git commit and build date of the running CMS
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.sendSuccessResponse(w, version.Get(), http.StatusOK)
+}
+
 func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	// Prometheus text format on request, JSON otherwise
+	if r.URL.Query().Get("format") == "prometheus" {
+		s.writePrometheusMetrics(w)
+		return
+	}
+
 	plugins, _ := s.pluginService.ListPlugins()
 	vms := s.vmService.ListVMs()
 
 	metrics := map[string]interface{}{
-		"plugins_total":   len(plugins),
-		"instances_total": len(vms),
+		"plugins_total":    len(plugins),
+		"instances_total":  len(vms),
+		"instances":        vms,
+		"executions":       s.pluginService.GetExecutionStats(),
+		"snapshots":        s.vmService.GetSnapshotStats(),
+		"throttling":       s.pluginService.GetThrottleStats(),
+		"circuit_breakers": s.pluginService.GetCircuitStats(),
+		"pool_capacity":    s.vmService.GetPoolCapacity(),
 	}
 
 	s.sendSuccessResponse(w, metrics, http.StatusOK)
 }
 
+func (s *Server) handleSystemInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	info := map[string]interface{}{
+		"mode":         s.config.GetModeString(),
+		"capabilities": s.vmService.Capabilities(),
+	}
+
+	s.sendSuccessResponse(w, info, http.StatusOK)
+}
+
+// handleSystemSelfCheck returns the report of the startup self-check, which
+// names host paths and is therefore admin only
+func (s *Server) handleSystemSelfCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.requireAdmin(w, r, "Self-check") {
+		return
+	}
+
+	s.sendSuccessResponse(w, s.vmService.SelfCheck(), http.StatusOK)
+}
+
+// handleExecutionHistory returns recorded executions, newest first, filtered
+// by plugin, hook, correlation_id, success and a since/until time range and
+// paged with limit and the before cursor
+func (s *Server) handleExecutionHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	caller, authenticated := s.authenticateCaller(r)
+	if !authenticated {
+		s.sendErrorResponse(w, "Missing or invalid caller token", http.StatusUnauthorized)
+		return
+	}
+
+	params := r.URL.Query()
+	query := services.ExecutionHistoryQuery{
+		PluginSlug:    params.Get("plugin"),
+		ActionHook:    params.Get("hook"),
+		CorrelationID: params.Get("correlation_id"),
+	}
+
+	// Callers only see the executions of plugins they may use
+	if caller != nil {
+		if query.PluginSlug != "" && !caller.allowsSlug(query.PluginSlug) {
+			s.sendErrorResponse(w, "Caller is not permitted to access this plugin", http.StatusForbidden)
+			return
+		}
+		query.AllowPlugin = caller.allowsSlug
+	}
+
+	if successStr := params.Get("success"); successStr != "" {
+		success, err := strconv.ParseBool(successStr)
+		if err != nil {
+			s.sendErrorResponse(w, "Invalid success filter, expected true or false", http.StatusBadRequest)
+			return
+		}
+		query.Success = &success
+	}
+
+	for name, target := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		if value := params.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				s.sendErrorResponse(w, fmt.Sprintf("Invalid %s time, expected RFC 3339", name), http.StatusBadRequest)
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	if limitStr := params.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			s.sendErrorResponse(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
+	}
+
+	if beforeStr := params.Get("before"); beforeStr != "" {
+		before, err := strconv.ParseUint(beforeStr, 10, 64)
+		if err != nil {
+			s.sendErrorResponse(w, "Invalid before cursor", http.StatusBadRequest)
+			return
+		}
+		query.Before = before
+	}
+
+	page, err := s.pluginService.QueryExecutionHistory(query)
+	if err != nil {
+		s.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	s.sendSuccessResponse(w, page, http.StatusOK)
+}
+
+// handleSystemTLS returns the plugin CA certificate and the certificates it
+// issued. Requires the admin token.
+func (s *Server) handleSystemTLS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.requireAdmin(w, r, "TLS endpoint") {
+		return
+	}
+
+	info, err := s.vmService.PluginTLSInfo()
+	if err != nil {
+		s.sendErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	s.sendSuccessResponse(w, info, http.StatusOK)
+}
+
+// handleInstanceControl pauses or resumes a single VM instance via
+// POST /api/instances/{id}/pause and POST /api/instances/{id}/resume.
+// Requires the admin token.
+func (s *Server) handleInstanceControl(w http.ResponseWriter, r *http.Request) {
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 || pathParts[2] == "" {
+		s.sendErrorResponse(w, "Invalid URL format", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != "POST" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.requireAdmin(w, r, "Instance control") {
+		return
+	}
+
+	instanceID := pathParts[2]
+	var pause bool
+	switch pathParts[3] {
+	case "pause":
+		pause = true
+	case "resume":
+		pause = false
+	default:
+		s.sendErrorResponse(w, "Unknown instance operation (must be pause or resume)", http.StatusNotFound)
+		return
+	}
+
+	state, err := s.pluginService.SetInstancePaused(instanceID, pause)
+	if err != nil {
+		s.logger.WithFields(logger.Fields{
+			"instance_id": instanceID,
+			"operation":   pathParts[3],
+			"error":       err,
+		}).Error("Failed to change instance state")
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrInstanceNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrInstanceBusy), errors.Is(err, services.ErrSnapshotsUnsupported):
+			status = http.StatusConflict
+		}
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to %s instance: %v", pathParts[3], err), status)
+		return
+	}
+
+	s.sendSuccessResponse(w, state, http.StatusOK)
+}
+
+// handleSystemConfig returns the effective configuration with secrets redacted
+func (s *Server) handleSystemConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.requireAdmin(w, r, "Config endpoint") {
+		return
+	}
+
+	s.sendSuccessResponse(w, s.config.Redacted(), http.StatusOK)
+}
+
+func (s *Server) handleSnapshotAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	results := s.pluginService.SnapshotAllActive()
+
+	failed := 0
+	for _, result := range results {
+		if !result.Success {
+			failed++
+		}
+	}
+
+	response := map[string]interface{}{
+		"total":     len(results),
+		"failed":    failed,
+		"results":   results,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	s.sendSuccessResponse(w, response, http.StatusOK)
+}
+
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Maintenance mode suspends recovery and activations for every plugin
+	if !s.requireAdmin(w, r, "Maintenance mode") {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		s.sendSuccessResponse(w, s.vmService.MaintenanceStatus(), http.StatusOK)
+	case "POST":
+		var requestBody struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Enabled == nil {
+			s.sendErrorResponse(w, "Request body must be {\"enabled\": true|false}", http.StatusBadRequest)
+			return
+		}
+
+		s.sendSuccessResponse(w, s.vmService.SetMaintenanceMode(*requestBody.Enabled), http.StatusOK)
+	}
+}
+
+func (s *Server) handleExportState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Exports carry every plugin's env
+	if !s.requireAdmin(w, r, "State export") {
+		return
+	}
+
+	// Exports can be far larger than the server write timeout allows
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	filename := fmt.Sprintf("cms-export-%s.tar.gz", time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	// Headers are already sent, so a failure can only be logged
+	if err := s.pluginService.ExportState(w); err != nil {
+		s.logger.WithFields(logger.Fields{
+			"error": err,
+		}).Error("Failed to export plugin state")
+	}
+}
+
+func (s *Server) handleImportState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		s.sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.requireAdmin(w, r, "State import") {
+		return
+	}
+
+	// Uploading and re-validating every plugin outlasts the server timeouts
+	controller := http.NewResponseController(w)
+	controller.SetReadDeadline(time.Time{})
+	controller.SetWriteDeadline(time.Time{})
+
+	results, err := s.pluginService.ImportState(r.Body)
+	if err != nil {
+		s.logger.WithFields(logger.Fields{
+			"error": err,
+		}).Error("Failed to import plugin state")
+		s.sendErrorResponse(w, fmt.Sprintf("Failed to import: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	counts := map[string]int{}
+	for _, result := range results {
+		counts[result.Status]++
+	}
+
+	response := map[string]interface{}{
+		"total":     len(results),
+		"imported":  counts[services.ImportStatusImported],
+		"skipped":   counts[services.ImportStatusSkipped],
+		"failed":    counts[services.ImportStatusFailed],
+		"results":   results,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	s.sendSuccessResponse(w, response, http.StatusOK)
+}
+
 // Response helper functions
 
 func (s *Server) sendSuccessResponse(w http.ResponseWriter, data interface{}, statusCode int) {
+	s.sendSuccessResponseWithWarnings(w, data, nil, statusCode)
+}
+
+func (s *Server) sendSuccessResponseWithWarnings(w http.ResponseWriter, data interface{}, warnings []string, statusCode int) {
 	response := models.HTTPResponse{
 		Success:   true,
 		Data:      data,
+		Warnings:  warnings,
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
@@ -468,6 +1426,20 @@ func (s *Server) sendErrorResponse(w http.ResponseWriter, message string, status
 	json.NewEncoder(w).Encode(response)
 }
 
+// sendValidationErrorResponse answers 400 with every field-level validation failure
+func (s *Server) sendValidationErrorResponse(w http.ResponseWriter, message string, validationErrors []models.ValidationError) {
+	response := models.HTTPResponse{
+		Success:   false,
+		Error:     message,
+		Errors:    validationErrors,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(response)
+}
+
 // responseWriter wrapper to capture status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -478,3 +1450,8 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
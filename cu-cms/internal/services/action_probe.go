@@ -0,0 +1,94 @@
+/*
+ * Firecracker CMS - Action Endpoint Probing
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// actionProbeTimeout bounds a single OPTIONS probe of an action endpoint
+const actionProbeTimeout = 2 * time.Second
+
+// deepValidationEnabled reports whether action endpoints are probed when validating a plugin
+func (ps *PluginService) deepValidationEnabled(plugin *models.Plugin) bool {
+	return ps.config.DeepValidation || plugin.DeepValidation
+}
+
+// probeActionEndpoints checks that every declared action endpoint responds.
+// Actions with a test payload are invoked with it and must succeed; the others
+// get an OPTIONS request and only fail when the endpoint is unreachable or 404.
+func (ps *PluginService) probeActionEndpoints(plugin *models.Plugin, vmIP string) error {
+	names := make([]string, 0, len(plugin.Actions))
+	for name := range plugin.Actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failures []string
+	for _, name := range names {
+		action := plugin.Actions[name]
+		url := pluginURL(plugin, vmIP, action.Endpoint)
+
+		var err error
+		if action.TestPayload != nil {
+			method := action.Method
+			if method == "" {
+				method = "POST"
+			}
+			_, err = ps.makeHTTPRequest(method, url, action.TestPayload)
+		} else {
+			err = ps.probeActionEndpoint(url)
+		}
+
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s (%s): %v", name, action.Endpoint, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("unreachable action endpoints: %s", strings.Join(failures, "; "))
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"actions":     len(names),
+	}).Info("All action endpoints responded")
+
+	return nil
+}
+
+// probeActionEndpoint sends an OPTIONS request to an action endpoint. Any
+// answer but 404 shows a route exists, as servers may reject the method itself.
+func (ps *PluginService) probeActionEndpoint(url string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), actionProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "OPTIONS", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := ps.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+/*
+ * Firecracker CMS - Action Usage Tracking
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// actionUsageFlushInterval is how often action counters are persisted
+const actionUsageFlushInterval = time.Minute
+
+// actionUsageTracker counts action invocations in memory between flushes, so
+// executions never wait on the plugin registry
+type actionUsageTracker struct {
+	mutex   sync.Mutex
+	pending map[string]map[string]models.ActionUsage // plugin slug -> action -> usage since last flush
+}
+
+// newActionUsageTracker creates an empty action usage tracker
+func newActionUsageTracker() *actionUsageTracker {
+	return &actionUsageTracker{
+		pending: make(map[string]map[string]models.ActionUsage),
+	}
+}
+
+// invoked records an invocation of a plugin action
+func (t *actionUsageTracker) invoked(pluginSlug, actionName string, at time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	actions, exists := t.pending[pluginSlug]
+	if !exists {
+		actions = make(map[string]models.ActionUsage)
+		t.pending[pluginSlug] = actions
+	}
+
+	usage := actions[actionName]
+	usage.Invocations++
+	usage.LastInvokedAt = &at
+	actions[actionName] = usage
+}
+
+// drain returns the invocations recorded since the last drain
+func (t *actionUsageTracker) drain() map[string]map[string]models.ActionUsage {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	pending := t.pending
+	t.pending = make(map[string]map[string]models.ActionUsage)
+	return pending
+}
+
+// actionUsageFlusher periodically persists action invocation counters
+func (ps *PluginService) actionUsageFlusher() {
+	ticker := time.NewTicker(actionUsageFlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ps.FlushActionUsage()
+	}
+}
+
+// FlushActionUsage adds the invocations counted in memory to the plugins'
+// persisted action usage
+func (ps *PluginService) FlushActionUsage() {
+	pending := ps.actionUsage.drain()
+	if len(pending) == 0 {
+		return
+	}
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	for slug, actions := range pending {
+		// Counts of plugins deleted meanwhile are dropped
+		plugin, exists := ps.plugins[slug]
+		if !exists {
+			continue
+		}
+
+		if plugin.ActionUsage == nil {
+			plugin.ActionUsage = make(map[string]models.ActionUsage)
+		}
+		for actionName, delta := range actions {
+			usage := plugin.ActionUsage[actionName]
+			usage.Invocations += delta.Invocations
+			usage.LastInvokedAt = delta.LastInvokedAt
+			plugin.ActionUsage[actionName] = usage
+		}
+	}
+
+	if err := ps.savePluginsUnsafe(); err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"error": err,
+		}).Error("Failed to save action usage")
+	}
+}
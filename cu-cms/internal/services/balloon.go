@@ -0,0 +1,146 @@
+/*
+ * Firecracker CMS - Warm Instance Memory Ballooning
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"time"
+
+	firecracker "github.com/firecracker-microvm/firecracker-go-sdk"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// balloonGuestFloorMib is guest memory the balloon never reclaims
+const balloonGuestFloorMib = 64
+
+// balloonSettleTimeout bounds the wait for the guest driver to inflate the
+// balloon before the VM is paused; a paused guest cannot hand pages back
+const balloonSettleTimeout = time.Second
+
+// balloonPollInterval is how often the balloon statistics are read while waiting
+const balloonPollInterval = 25 * time.Millisecond
+
+// balloonStatsIntervalSeconds enables the balloon statistics, whose actual
+// size shows how far the guest has inflated it
+const balloonStatsIntervalSeconds = 1
+
+// balloonRequestTimeout bounds a balloon API call
+const balloonRequestTimeout = 2 * time.Second
+
+// balloonHandler adds a deflated balloon to a fresh VM before boot. It deflates
+// on guest OOM so a parked target can never starve the guest.
+func balloonHandler() firecracker.Handler {
+	return firecracker.NewCreateBalloonHandler(0, true, balloonStatsIntervalSeconds)
+}
+
+// machineBalloon reports whether a started machine has a balloon device and
+// whether it is inflated. VMs restored from snapshots only have one if the
+// snapshotted VM had it, inflated if that VM was parked when snapshotted.
+func machineBalloon(machine *firecracker.Machine) (present, inflated bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), balloonRequestTimeout)
+	defer cancel()
+
+	balloon, err := machine.GetBalloonConfig(ctx)
+	if err != nil {
+		return false, false
+	}
+	return true, firecracker.Int64Value(balloon.AmountMib) > 0
+}
+
+// balloonTargetMib returns how much memory to reclaim from a parked instance,
+// capped so the guest keeps at least balloonGuestFloorMib
+func (vm *VMService) balloonTargetMib(instance *PrewarmInstance) int64 {
+	target := int64(vm.config.BalloonTargetMB)
+	if limit := firecracker.Int64Value(instance.Machine.Cfg.MachineCfg.MemSizeMib) - balloonGuestFloorMib; target > limit {
+		target = limit
+	}
+	if target < 0 {
+		return 0
+	}
+	return target
+}
+
+// inflateBalloon reclaims guest memory from an instance about to be parked.
+// Failures only cost density, so they are logged and otherwise ignored.
+func (vm *VMService) inflateBalloon(instance *PrewarmInstance) {
+	if !instance.Balloon {
+		return
+	}
+
+	target := vm.balloonTargetMib(instance)
+	if target == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), balloonRequestTimeout)
+	defer cancel()
+
+	if err := instance.Machine.UpdateBalloon(ctx, target); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"instance_id": instance.InstanceID,
+			"target_mib":  target,
+			"error":       err,
+		}).Warn("Failed to inflate balloon")
+		return
+	}
+
+	vm.waitBalloonSettled(instance, target)
+	instance.balloonInflated.Store(true)
+
+	vm.logger.WithFields(logger.Fields{
+		"instance_id": instance.InstanceID,
+		"target_mib":  target,
+	}).Debug("Balloon inflated for parked VM")
+}
+
+// deflateBalloon returns reclaimed memory to a resumed instance before it serves
+func (vm *VMService) deflateBalloon(instance *PrewarmInstance) {
+	if !instance.balloonInflated.Load() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), balloonRequestTimeout)
+	defer cancel()
+
+	if err := instance.Machine.UpdateBalloon(ctx, 0); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"instance_id": instance.InstanceID,
+			"error":       err,
+		}).Warn("Failed to deflate balloon")
+		return
+	}
+	instance.balloonInflated.Store(false)
+
+	vm.logger.WithFields(logger.Fields{
+		"instance_id": instance.InstanceID,
+	}).Debug("Balloon deflated for resumed VM")
+}
+
+// waitBalloonSettled polls the balloon statistics until the guest has inflated
+// the balloon to target or balloonSettleTimeout passes. VMs restored from
+// snapshots taken without statistics are not waited for.
+func (vm *VMService) waitBalloonSettled(instance *PrewarmInstance, target int64) {
+	deadline := time.Now().Add(balloonSettleTimeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), balloonRequestTimeout)
+		stats, err := instance.Machine.GetBalloonStats(ctx)
+		cancel()
+		if err != nil || firecracker.Int64Value(stats.ActualMib) >= target {
+			return
+		}
+		if time.Now().After(deadline) {
+			vm.logger.WithFields(logger.Fields{
+				"instance_id": instance.InstanceID,
+				"target_mib":  target,
+				"actual_mib":  firecracker.Int64Value(stats.ActualMib),
+			}).Debug("Balloon not fully inflated before pausing")
+			return
+		}
+		time.Sleep(balloonPollInterval)
+	}
+}
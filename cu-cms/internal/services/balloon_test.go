@@ -0,0 +1,170 @@
+/*
+ * Firecracker CMS - Warm Instance Memory Ballooning Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	firecracker "github.com/firecracker-microvm/firecracker-go-sdk"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeBalloonAPI serves the balloon endpoints of the Firecracker API on a unix
+// socket and records the amounts patched. With stats, the guest reaches the
+// patched amount after settlePolls statistics reads.
+type fakeBalloonAPI struct {
+	mutex       sync.Mutex
+	amount      int64
+	present     bool
+	patched     []int64
+	stats       bool
+	settlePolls int
+	polls       int
+}
+
+func (f *fakeBalloonAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if r.URL.Path == "/balloon/statistics" && f.present && f.stats {
+		f.polls++
+		actual := int64(0)
+		if f.polls > f.settlePolls {
+			actual = f.amount
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int64{
+			"target_mib": f.amount, "target_pages": f.amount * 256,
+			"actual_mib": actual, "actual_pages": actual * 256,
+		})
+		return
+	}
+
+	if r.URL.Path != "/balloon" || !f.present {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"fault_message": "no balloon"}`)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"amount_mib": f.amount, "deflate_on_oom": true})
+	case "PATCH":
+		var update struct {
+			AmountMib int64 `json:"amount_mib"`
+		}
+		json.NewDecoder(r.Body).Decode(&update)
+		f.amount = update.AmountMib
+		f.patched = append(f.patched, update.AmountMib)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// newFakeBalloonMachine returns a machine talking to api
+func newFakeBalloonMachine(t *testing.T, api *fakeBalloonAPI) *firecracker.Machine {
+	socketPath := filepath.Join(t.TempDir(), "fc.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: api}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	machine, err := firecracker.NewMachine(context.Background(), firecracker.Config{SocketPath: socketPath},
+		firecracker.WithLogger(logrus.NewEntry(log)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return machine
+}
+
+func TestMachineBalloonDetectsRestoredInflation(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          *fakeBalloonAPI
+		wantPresent  bool
+		wantInflated bool
+	}{
+		{"no balloon", &fakeBalloonAPI{}, false, false},
+		{"deflated", &fakeBalloonAPI{present: true}, true, false},
+		{"snapshotted while parked", &fakeBalloonAPI{present: true, amount: 256}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			present, inflated := machineBalloon(newFakeBalloonMachine(t, tt.api))
+			if present != tt.wantPresent || inflated != tt.wantInflated {
+				t.Fatalf("machineBalloon = (%v, %v), want (%v, %v)", present, inflated, tt.wantPresent, tt.wantInflated)
+			}
+		})
+	}
+}
+
+func TestDeflateBalloonAfterRestore(t *testing.T) {
+	api := &fakeBalloonAPI{present: true, amount: 256}
+	machine := newFakeBalloonMachine(t, api)
+
+	vm := newTestVMService()
+	vm.config.BalloonTargetMB = 0 // Ballooning turned off since the snapshot
+	present, inflated := machineBalloon(machine)
+	instance := &PrewarmInstance{InstanceID: "blog-1", Machine: machine, Balloon: present}
+	instance.balloonInflated.Store(inflated)
+
+	vm.deflateBalloon(instance)
+
+	if instance.balloonInflated.Load() || api.amount != 0 {
+		t.Fatalf("restored balloon not deflated: inflated=%v amount=%d", instance.balloonInflated.Load(), api.amount)
+	}
+
+	// With ballooning off, parking must not inflate it again
+	vm.inflateBalloon(instance)
+	if len(api.patched) != 1 {
+		t.Fatalf("balloon patched %v, want only the deflate", api.patched)
+	}
+}
+
+func TestInflateBalloonWaitsForGuest(t *testing.T) {
+	tests := []struct {
+		name      string
+		api       *fakeBalloonAPI
+		wantPolls int
+	}{
+		{"statistics", &fakeBalloonAPI{present: true, stats: true, settlePolls: 3}, 4},
+		{"no statistics", &fakeBalloonAPI{present: true}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine := newFakeBalloonMachine(t, tt.api)
+			machine.Cfg.MachineCfg.MemSizeMib = firecracker.Int64(512)
+
+			vm := newTestVMService()
+			vm.config.BalloonTargetMB = 256
+			instance := &PrewarmInstance{InstanceID: "blog-1", Machine: machine, Balloon: true}
+
+			vm.inflateBalloon(instance)
+
+			if !instance.balloonInflated.Load() || tt.api.amount != 256 {
+				t.Fatalf("balloon not inflated: inflated=%v amount=%d", instance.balloonInflated.Load(), tt.api.amount)
+			}
+			if tt.api.polls != tt.wantPolls {
+				t.Fatalf("statistics read %d times, want %d", tt.api.polls, tt.wantPolls)
+			}
+		})
+	}
+}
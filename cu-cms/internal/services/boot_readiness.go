@@ -0,0 +1,60 @@
+/*
+ * Firecracker CMS - Boot Network Readiness
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Pause between reachability probes of a booting guest
+const bootReadyProbeInterval = 50 * time.Millisecond
+
+// ErrGuestUnreachable is returned when a freshly booted VM's address never
+// answers within CMS_VM_READY_TIMEOUT_MS
+var ErrGuestUnreachable = errors.New("guest network unreachable")
+
+// waitGuestReachable blocks until the guest network stack answers on ip: a TCP
+// connect to the plugin port that is accepted or refused proves the guest
+// resolved ARP and is up, even if the plugin server has not started yet.
+// A zero timeout skips the gate.
+func (vm *VMService) waitGuestReachable(ip string) error {
+	if vm.config.VMReadyTimeoutMs == 0 {
+		return nil
+	}
+
+	timeout := time.Duration(vm.config.VMReadyTimeoutMs) * time.Millisecond
+	start := time.Now()
+	deadline := start.Add(timeout)
+
+	address := net.JoinHostPort(ip, "80")
+	attempts := 0
+	var lastErr error
+	for remaining := time.Until(deadline); remaining > 0; remaining = time.Until(deadline) {
+		attempts++
+		conn, err := net.DialTimeout("tcp", address, min(remaining, time.Second))
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return nil
+		}
+		lastErr = err
+
+		if time.Until(deadline) <= bootReadyProbeInterval {
+			break
+		}
+		time.Sleep(bootReadyProbeInterval)
+	}
+
+	return fmt.Errorf("%w: %s did not answer within %v (%d attempts): %v",
+		ErrGuestUnreachable, ip, time.Since(start).Round(time.Millisecond), attempts, lastErr)
+}
@@ -0,0 +1,142 @@
+/*
+ * Firecracker CMS - Plugin Bridge
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// pluginBridge is the host bridge plugin TAP devices are attached to
+const pluginBridge = "fcnetbridge0"
+
+// bridgeGatewayCIDR is the bridge address, the default gateway of plugin VMs
+const bridgeGatewayCIDR = "192.168.127.1/24"
+
+// bridgeExists reports whether the plugin bridge is present
+func bridgeExists() bool {
+	return linkExists(pluginBridge)
+}
+
+// linkExists reports whether a network link is present
+func linkExists(name string) bool {
+	return exec.Command("ip", "link", "show", name).Run() == nil
+}
+
+// extraBridgeGatewayCIDR is the host address on the bridge of additional interfaces
+func (vm *VMService) extraBridgeGatewayCIDR() string {
+	return subnetHostIP(vm.config.ExtraInterfaceSubnet, 1) + "/24"
+}
+
+// setupBridge creates the plugin bridge and the bridge of additional
+// interfaces if they are missing and makes sure they have their host address
+// and are up. Bridges the host already provides are left as configured apart
+// from that.
+func (vm *VMService) setupBridge() error {
+	if !vm.config.BridgeCreate {
+		return nil
+	}
+
+	created, err := vm.ensureBridge(pluginBridge, bridgeGatewayCIDR)
+	if err != nil {
+		return err
+	}
+	vm.bridgeCreated = created
+
+	if vm.config.MaxPluginInterfaces > 0 {
+		created, err := vm.ensureBridge(vm.config.ExtraInterfaceBridge, vm.extraBridgeGatewayCIDR())
+		if err != nil {
+			return err
+		}
+		vm.extraBridgeCreated = created
+	}
+
+	return nil
+}
+
+// ensureBridge creates a bridge if it is missing, assigns it address and brings
+// it up, reporting whether it was created
+func (vm *VMService) ensureBridge(bridge, address string) (bool, error) {
+	created := false
+	if !linkExists(bridge) {
+		if err := runIP("link", "add", bridge, "type", "bridge"); err != nil {
+			return false, fmt.Errorf("failed to create bridge %s: %v", bridge, err)
+		}
+		created = true
+
+		// A new bridge takes the MTU of its first port, so set it up front
+		if vm.config.TapMTU > 0 {
+			if err := runIP("link", "set", bridge, "mtu", strconv.Itoa(vm.config.TapMTU)); err != nil {
+				return created, fmt.Errorf("failed to set bridge %s MTU: %v", bridge, err)
+			}
+		}
+	}
+
+	output, err := exec.Command("ip", "-o", "addr", "show", "dev", bridge).Output()
+	if err != nil {
+		return created, fmt.Errorf("failed to read bridge %s addresses: %v", bridge, err)
+	}
+	if !strings.Contains(string(output), " "+address+" ") {
+		if err := runIP("addr", "add", address, "dev", bridge); err != nil {
+			return created, fmt.Errorf("failed to assign %s to bridge %s: %v", address, bridge, err)
+		}
+	}
+
+	if err := runIP("link", "set", bridge, "up"); err != nil {
+		return created, fmt.Errorf("failed to bring bridge %s up: %v", bridge, err)
+	}
+
+	vm.logger.WithFields(logger.Fields{
+		"bridge":  bridge,
+		"address": address,
+		"created": created,
+	}).Info("Plugin bridge ready")
+
+	return created, nil
+}
+
+// teardownBridge deletes the bridges on shutdown, only those the CMS created
+func (vm *VMService) teardownBridge() {
+	if !vm.config.BridgeTeardown {
+		return
+	}
+
+	if vm.bridgeCreated && vm.deleteBridge(pluginBridge) {
+		vm.bridgeCreated = false
+	}
+	if vm.extraBridgeCreated && vm.deleteBridge(vm.config.ExtraInterfaceBridge) {
+		vm.extraBridgeCreated = false
+	}
+}
+
+// deleteBridge deletes a bridge, reporting whether it succeeded
+func (vm *VMService) deleteBridge(bridge string) bool {
+	if err := runIP("link", "delete", bridge, "type", "bridge"); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"bridge": bridge,
+			"error":  err,
+		}).Warn("Failed to delete plugin bridge")
+		return false
+	}
+
+	vm.logger.WithFields(logger.Fields{
+		"bridge": bridge,
+	}).Info("Plugin bridge deleted")
+	return true
+}
+
+// runIP runs an ip command, including its output in any error
+func runIP(args ...string) error {
+	if output, err := exec.Command("ip", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
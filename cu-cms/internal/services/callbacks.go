@@ -0,0 +1,206 @@
+/*
+ * Firecracker CMS - Asynchronous Execution Callbacks
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// CorrelationIDHeader identifies an asynchronous execution to its caller
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// callbackTimeout bounds a single callback delivery
+const callbackTimeout = 10 * time.Second
+
+// ErrCallbacksDisabled is returned for callback URLs when no callback hosts are allowed
+var ErrCallbacksDisabled = errors.New("callbacks are disabled (CMS_CALLBACK_ALLOWLIST is empty)")
+
+// ErrCallbackNotAllowed is returned for callback URLs whose host is not allowlisted
+var ErrCallbackNotAllowed = errors.New("callback host is not allowed")
+
+// ErrTooManyAsyncExecutions is returned when CMS_MAX_ASYNC_EXECUTIONS executions
+// are already running or delivering their callback
+var ErrTooManyAsyncExecutions = errors.New("too many asynchronous executions in progress")
+
+// newCallbackClient creates the client delivering callbacks. Redirects are not
+// followed, so an allowlisted host cannot forward results elsewhere.
+func newCallbackClient() *http.Client {
+	return &http.Client{
+		Timeout: callbackTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// NewCorrelationID returns a random ID for an asynchronous execution
+func NewCorrelationID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// ValidateCallbackURL checks that a callback URL is http(s) and its host is allowlisted
+func (ps *PluginService) ValidateCallbackURL(rawURL string) error {
+	if len(ps.callbackHosts) == 0 {
+		return ErrCallbacksDisabled
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid callback URL %q: must be an absolute http or https URL", rawURL)
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	for _, allowed := range ps.callbackHosts {
+		if suffix, wildcard := strings.CutPrefix(allowed, "*."); wildcard {
+			if strings.HasSuffix(host, "."+suffix) {
+				return nil
+			}
+		} else if host == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrCallbackNotAllowed, host)
+}
+
+// ExecuteActionAsync runs an execution in the background and POSTs its outcome
+// to callbackURL, which must have passed ValidateCallbackURL. It returns
+// ErrTooManyAsyncExecutions instead of starting it when no slot is free.
+func (ps *PluginService) ExecuteActionAsync(correlationID, callbackURL, actionHook string, payload map[string]interface{}, vmService *VMService, opts ExecutionOptions) error {
+	select {
+	case ps.asyncSlots <- struct{}{}:
+	default:
+		return ErrTooManyAsyncExecutions
+	}
+	ps.asyncExecutions.Add(1)
+
+	// The caller's request is gone by the time the execution reads its headers
+	headers := opts.Headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	headers.Set(CorrelationIDHeader, correlationID)
+	opts.Headers = headers
+
+	go func() {
+		defer ps.asyncExecutions.Done()
+		defer func() { <-ps.asyncSlots }()
+
+		results, err := ps.ExecuteActionFiltered(actionHook, payload, vmService, opts)
+
+		body := map[string]interface{}{
+			"correlation_id": correlationID,
+			"action_hook":    actionHook,
+			"success":        err == nil,
+			"timestamp":      time.Now().Format(time.RFC3339),
+		}
+		if err != nil {
+			body["error"] = err.Error()
+		} else {
+			body["results"] = results
+		}
+
+		ps.deliverCallback(correlationID, callbackURL, body)
+	}()
+	return nil
+}
+
+// WaitAsyncExecutions waits until the asynchronous executions and their
+// callbacks finish, or ctx is done. It returns how many are still running.
+func (ps *PluginService) WaitAsyncExecutions(ctx context.Context) int {
+	done := make(chan struct{})
+	go func() {
+		ps.asyncExecutions.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return 0
+	case <-ctx.Done():
+		return len(ps.asyncSlots)
+	}
+}
+
+// deliverCallback POSTs a result to a callback URL, retrying failed deliveries
+// with exponential backoff. Any 2xx response counts as delivered.
+func (ps *PluginService) deliverCallback(correlationID, callbackURL string, body map[string]interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"correlation_id": correlationID,
+			"error":          err,
+		}).Error("Failed to encode callback body")
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		err := ps.postCallback(correlationID, callbackURL, data)
+		if err == nil {
+			ps.logger.WithFields(logger.Fields{
+				"correlation_id": correlationID,
+				"attempt":        attempt + 1,
+			}).Info("Execution callback delivered")
+			return
+		}
+
+		if attempt >= ps.config.CallbackRetries {
+			ps.logger.WithFields(logger.Fields{
+				"correlation_id": correlationID,
+				"callback_url":   callbackURL,
+				"attempts":       attempt + 1,
+				"error":          err,
+			}).Error("Execution callback failed, giving up")
+			return
+		}
+
+		ps.logger.WithFields(logger.Fields{
+			"correlation_id": correlationID,
+			"attempt":        attempt + 1,
+			"backoff_ms":     backoff.Milliseconds(),
+			"error":          err,
+		}).Warn("Execution callback failed, retrying")
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// postCallback makes a single callback delivery attempt
+func (ps *PluginService) postCallback(correlationID, callbackURL string, data []byte) error {
+	req, err := http.NewRequest("POST", callbackURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(CorrelationIDHeader, correlationID)
+
+	resp, err := ps.callbackClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+/*
+ * Firecracker CMS - Asynchronous Execution Callback Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestExecuteActionAsyncBounded(t *testing.T) {
+	ps := newTestPluginService(t)
+	ps.asyncSlots = make(chan struct{}, 1)
+	ps.asyncSlots <- struct{}{}
+
+	err := ps.ExecuteActionAsync("id", "http://hooks.example.com/", "order.created", nil, ps.vmService, ExecutionOptions{Headers: http.Header{}})
+	if !errors.Is(err, ErrTooManyAsyncExecutions) {
+		t.Fatalf("ExecuteActionAsync with no free slot = %v, want ErrTooManyAsyncExecutions", err)
+	}
+}
+
+func TestWaitAsyncExecutions(t *testing.T) {
+	ps := newTestPluginService(t)
+	ps.asyncSlots = make(chan struct{}, 2)
+
+	if pending := ps.WaitAsyncExecutions(context.Background()); pending != 0 {
+		t.Fatalf("pending = %d with nothing running, want 0", pending)
+	}
+
+	// Simulate an execution still delivering its callback
+	ps.asyncSlots <- struct{}{}
+	ps.asyncExecutions.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if pending := ps.WaitAsyncExecutions(ctx); pending != 1 {
+		t.Fatalf("pending = %d after the deadline, want 1", pending)
+	}
+
+	<-ps.asyncSlots
+	ps.asyncExecutions.Done()
+	if pending := ps.WaitAsyncExecutions(context.Background()); pending != 0 {
+		t.Fatalf("pending = %d after the execution finished, want 0", pending)
+	}
+}
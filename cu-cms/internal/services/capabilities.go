@@ -0,0 +1,215 @@
+/*
+ * Firecracker CMS - Host Capabilities
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// firecrackerSDKModule is the module path used to look up the linked SDK version
+const firecrackerSDKModule = "github.com/firecracker-microvm/firecracker-go-sdk"
+
+// Minimum Firecracker versions for optional features
+var (
+	minVersionMMDS                  = [3]int{0, 15, 0}
+	minVersionSnapshots             = [3]int{0, 23, 0}
+	minVersionDifferentialSnapshots = [3]int{0, 24, 0}
+	minVersionMMDSv2                = [3]int{1, 1, 0}
+)
+
+// HostCapabilities describes the Firecracker and KVM features available on the host
+type HostCapabilities struct {
+	FirecrackerVersion    string    `json:"firecracker_version"`
+	SDKVersion            string    `json:"sdk_version"`
+	KVMAvailable          bool      `json:"kvm_available"`
+	Snapshots             bool      `json:"snapshots"`
+	DifferentialSnapshots bool      `json:"differential_snapshots"`
+	DirtyPageTracking     bool      `json:"dirty_page_tracking"`
+	MMDS                  bool      `json:"mmds"`
+	MMDSv2                bool      `json:"mmds_v2"`
+	NestedVirtualization  bool      `json:"nested_virtualization"`
+	ProbedAt              time.Time `json:"probed_at"`
+}
+
+// Capabilities returns the host capabilities detected at startup
+func (vm *VMService) Capabilities() HostCapabilities {
+	return vm.capabilities
+}
+
+// SnapshotsSupported reports whether the Firecracker binary can snapshot and
+// pause VMs. Without it plugins run in always-warm mode: the booted VM is kept
+// running between executions instead of being paused and snapshotted.
+func (vm *VMService) SnapshotsSupported() bool {
+	return vm.capabilities.Snapshots
+}
+
+// probeCapabilities detects the Firecracker binary version and the features it supports
+func (vm *VMService) probeCapabilities() HostCapabilities {
+	caps := HostCapabilities{
+		SDKVersion: sdkVersion(),
+		ProbedAt:   time.Now(),
+	}
+
+	// KVM is required for any microVM to boot
+	if _, err := os.Stat("/dev/kvm"); err == nil {
+		caps.KVMAvailable = true
+	}
+	caps.NestedVirtualization = nestedVirtualizationEnabled()
+
+	output, err := exec.Command(vm.firecrackerPath, "--version").Output()
+	if err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"firecracker_path": vm.firecrackerPath,
+			"error":            err,
+		}).Warn("Failed to detect Firecracker version, optional features disabled")
+		return caps
+	}
+
+	version, ok := parseFirecrackerVersion(string(output))
+	if !ok {
+		vm.logger.WithFields(logger.Fields{
+			"output": strings.TrimSpace(string(output)),
+		}).Warn("Unrecognized Firecracker version output, optional features disabled")
+		return caps
+	}
+
+	caps.FirecrackerVersion = fmt.Sprintf("%d.%d.%d", version[0], version[1], version[2])
+	caps.MMDS = versionAtLeast(version, minVersionMMDS)
+	caps.Snapshots = versionAtLeast(version, minVersionSnapshots)
+	caps.DifferentialSnapshots = versionAtLeast(version, minVersionDifferentialSnapshots)
+	caps.DirtyPageTracking = caps.DifferentialSnapshots // Introduced together with diff snapshots
+	caps.MMDSv2 = versionAtLeast(version, minVersionMMDSv2)
+
+	return caps
+}
+
+// parseFirecrackerVersion extracts the semantic version from `firecracker --version` output
+func parseFirecrackerVersion(output string) ([3]int, bool) {
+	var version [3]int
+
+	match := regexp.MustCompile(`v?(\d+)\.(\d+)\.(\d+)`).FindStringSubmatch(output)
+	if match == nil {
+		return version, false
+	}
+
+	for i := 0; i < 3; i++ {
+		version[i], _ = strconv.Atoi(match[i+1])
+	}
+
+	return version, true
+}
+
+// versionAtLeast returns true if version >= minimum
+func versionAtLeast(version, minimum [3]int) bool {
+	for i := 0; i < 3; i++ {
+		if version[i] != minimum[i] {
+			return version[i] > minimum[i]
+		}
+	}
+	return true
+}
+
+// sdkVersion returns the linked firecracker-go-sdk module version
+func sdkVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path == firecrackerSDKModule {
+			return dep.Version
+		}
+	}
+
+	return "unknown"
+}
+
+// kernelModuleCapabilityPrefix prefixes required capabilities naming a kernel module
+const kernelModuleCapabilityPrefix = "kernel_module:"
+
+// capabilityChecks maps the capability names plugins may require to the probe results
+var capabilityChecks = map[string]func(HostCapabilities) bool{
+	"kvm":                    func(c HostCapabilities) bool { return c.KVMAvailable },
+	"snapshots":              func(c HostCapabilities) bool { return c.Snapshots },
+	"differential_snapshots": func(c HostCapabilities) bool { return c.DifferentialSnapshots },
+	"dirty_page_tracking":    func(c HostCapabilities) bool { return c.DirtyPageTracking },
+	"mmds":                   func(c HostCapabilities) bool { return c.MMDS },
+	"mmds_v2":                func(c HostCapabilities) bool { return c.MMDSv2 },
+	"nested_virtualization":  func(c HostCapabilities) bool { return c.NestedVirtualization },
+}
+
+// isKnownCapability reports whether a plugin may require the named capability
+func isKnownCapability(name string) bool {
+	if module, ok := strings.CutPrefix(name, kernelModuleCapabilityPrefix); ok {
+		return module != "" && !strings.ContainsAny(module, "/.")
+	}
+	_, ok := capabilityChecks[name]
+	return ok
+}
+
+// knownCapabilityNames returns the fixed capability names in sorted order
+func knownCapabilityNames() []string {
+	names := make([]string, 0, len(capabilityChecks))
+	for name := range capabilityChecks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Missing returns the required capabilities the host does not provide. Kernel
+// modules are checked live, since they can be loaded after startup.
+func (caps HostCapabilities) Missing(requires []string) []string {
+	var missing []string
+	for _, name := range requires {
+		if module, ok := strings.CutPrefix(name, kernelModuleCapabilityPrefix); ok {
+			if !kernelModuleLoaded(module) {
+				missing = append(missing, name)
+			}
+			continue
+		}
+		if check, ok := capabilityChecks[name]; !ok || !check(caps) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// kernelModuleLoaded reports whether a kernel module is loaded or built in
+func kernelModuleLoaded(module string) bool {
+	_, err := os.Stat(filepath.Join("/sys/module", module))
+	return err == nil
+}
+
+// nestedVirtualizationEnabled reports whether KVM exposes virtualization to guests
+func nestedVirtualizationEnabled() bool {
+	for _, param := range []string{
+		"/sys/module/kvm_intel/parameters/nested",
+		"/sys/module/kvm_amd/parameters/nested",
+	} {
+		data, err := os.ReadFile(param)
+		if err != nil {
+			continue
+		}
+		if value := strings.TrimSpace(string(data)); value == "Y" || value == "1" {
+			return true
+		}
+	}
+	return false
+}
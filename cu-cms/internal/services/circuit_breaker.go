@@ -0,0 +1,286 @@
+/*
+ * Firecracker CMS - Plugin Circuit Breaker
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"sync"
+	"time"
+
+	cms_errors "github.com/centraunit/cu-firecracker-cms/internal/errors"
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"    // Executions reach the plugin
+	CircuitOpen     = "open"      // Executions fast-fail until the cooldown ends
+	CircuitHalfOpen = "half_open" // A single probe execution decides whether to close
+)
+
+// PluginCircuitStats represents the circuit breaker state of a plugin
+type PluginCircuitStats struct {
+	State            string     `json:"state"`
+	RecentExecutions int        `json:"recent_executions"` // Counted outcomes in the window
+	RecentFailures   int        `json:"recent_failures"`
+	OpenedAt         *time.Time `json:"opened_at,omitempty"`
+	ProbeAt          *time.Time `json:"probe_at,omitempty"` // When an open circuit admits a probe
+	OpenedTotal      int64      `json:"opened_total"`
+	RejectedTotal    int64      `json:"rejected_total"`
+}
+
+// breakerOutcome is the result of an execution as seen by the circuit breaker
+type breakerOutcome int
+
+const (
+	breakerSuccess breakerOutcome = iota
+	breakerFailure
+	breakerNeutral // The plugin was not at fault, e.g. an unknown action or a 4xx answer
+)
+
+// breakerOutcomeOf classifies a failed execution. Client errors answered by the
+// plugin say nothing about its health.
+func breakerOutcomeOf(errType cms_errors.ErrorType) breakerOutcome {
+	if errType == cms_errors.ErrTypeHTTP {
+		return breakerNeutral
+	}
+	return breakerFailure
+}
+
+// circuitBreaker tracks the outcomes of a plugin's recent executions in a ring
+type circuitBreaker struct {
+	outcomes  []bool // true for a failure
+	next      int
+	count     int
+	failures  int
+	state     string
+	openedAt  time.Time
+	probing   bool
+	probeFrom time.Time
+	opened    int64
+	rejected  int64
+}
+
+// newCircuitBreaker creates a closed breaker over the given window
+func newCircuitBreaker(window int) *circuitBreaker {
+	return &circuitBreaker{
+		outcomes: make([]bool, window),
+		state:    CircuitClosed,
+	}
+}
+
+// push records an outcome, dropping the oldest once the window is full
+func (b *circuitBreaker) push(failed bool) {
+	if b.count == len(b.outcomes) {
+		if b.outcomes[b.next] {
+			b.failures--
+		}
+	} else {
+		b.count++
+	}
+	b.outcomes[b.next] = failed
+	if failed {
+		b.failures++
+	}
+	b.next = (b.next + 1) % len(b.outcomes)
+}
+
+// pluginCircuitBreakers keeps a circuit breaker per plugin that has executed
+type pluginCircuitBreakers struct {
+	mutex    sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// newPluginCircuitBreakers creates an empty set of breakers
+func newPluginCircuitBreakers() *pluginCircuitBreakers {
+	return &pluginCircuitBreakers{
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+// breakerUnsafe returns the breaker of a plugin, starting a fresh one when the
+// window size changed. Caller must hold the mutex.
+func (c *pluginCircuitBreakers) breakerUnsafe(pluginSlug string, window int) *circuitBreaker {
+	breaker, exists := c.breakers[pluginSlug]
+	if !exists || len(breaker.outcomes) != window {
+		breaker = newCircuitBreaker(window)
+		c.breakers[pluginSlug] = breaker
+	}
+	return breaker
+}
+
+// allow reports whether a plugin may execute now. Once the cooldown of an open
+// circuit ends a single probe is let through; a probe that never reports back
+// is replaced after another cooldown.
+func (c *pluginCircuitBreakers) allow(pluginSlug string, window int, cooldown time.Duration) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	breaker := c.breakerUnsafe(pluginSlug, window)
+	now := time.Now()
+
+	switch breaker.state {
+	case CircuitClosed:
+		return true
+	case CircuitOpen:
+		if now.Sub(breaker.openedAt) < cooldown {
+			breaker.rejected++
+			return false
+		}
+		breaker.state = CircuitHalfOpen
+	}
+
+	if breaker.probing && now.Sub(breaker.probeFrom) < cooldown {
+		breaker.rejected++
+		return false
+	}
+	breaker.probing = true
+	breaker.probeFrom = now
+	return true
+}
+
+// record adds an execution outcome and returns the resulting state and whether
+// it changed. Outcomes of executions allowed before the circuit opened are ignored.
+func (c *pluginCircuitBreakers) record(pluginSlug string, outcome breakerOutcome, failures, window int) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	breaker := c.breakerUnsafe(pluginSlug, window)
+
+	switch breaker.state {
+	case CircuitHalfOpen:
+		if !breaker.probing {
+			return breaker.state, false
+		}
+		breaker.probing = false
+		switch outcome {
+		case breakerSuccess:
+			opened, rejected := breaker.opened, breaker.rejected
+			*breaker = *newCircuitBreaker(window)
+			breaker.opened, breaker.rejected = opened, rejected
+		case breakerFailure:
+			breaker.state = CircuitOpen
+			breaker.openedAt = time.Now()
+		default:
+			// Let the next execution probe instead
+			return breaker.state, false
+		}
+		return breaker.state, true
+
+	case CircuitClosed:
+		if outcome == breakerNeutral {
+			return breaker.state, false
+		}
+		breaker.push(outcome == breakerFailure)
+		if breaker.failures >= failures {
+			breaker.state = CircuitOpen
+			breaker.openedAt = time.Now()
+			breaker.opened++
+			return breaker.state, true
+		}
+	}
+
+	return breaker.state, false
+}
+
+// reset forgets the history of a plugin, closing its circuit
+func (c *pluginCircuitBreakers) reset(pluginSlug string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.breakers, pluginSlug)
+}
+
+// stats returns the state of one plugin's breaker
+func (b *circuitBreaker) stats(cooldown time.Duration) PluginCircuitStats {
+	stats := PluginCircuitStats{
+		State:            b.state,
+		RecentExecutions: b.count,
+		RecentFailures:   b.failures,
+		OpenedTotal:      b.opened,
+		RejectedTotal:    b.rejected,
+	}
+	if b.state != CircuitClosed {
+		openedAt := b.openedAt
+		probeAt := b.openedAt.Add(cooldown)
+		stats.OpenedAt = &openedAt
+		stats.ProbeAt = &probeAt
+	}
+	return stats
+}
+
+// snapshot returns the current state of every breaker
+func (c *pluginCircuitBreakers) snapshot(cooldown time.Duration) map[string]PluginCircuitStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := make(map[string]PluginCircuitStats, len(c.breakers))
+	for slug, breaker := range c.breakers {
+		stats[slug] = breaker.stats(cooldown)
+	}
+	return stats
+}
+
+// pluginStats returns the state of a plugin's breaker, or nil if it has none
+func (c *pluginCircuitBreakers) pluginStats(pluginSlug string, cooldown time.Duration) *PluginCircuitStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	breaker, exists := c.breakers[pluginSlug]
+	if !exists {
+		return nil
+	}
+	stats := breaker.stats(cooldown)
+	return &stats
+}
+
+// circuitBreakerEnabled reports whether executions go through the breaker
+func (ps *PluginService) circuitBreakerEnabled() bool {
+	return ps.config.CircuitBreakerFailures > 0
+}
+
+// circuitBreakerCooldown returns how long an open circuit rejects executions
+func (ps *PluginService) circuitBreakerCooldown() time.Duration {
+	return time.Duration(ps.config.CircuitBreakerCooldownSeconds) * time.Second
+}
+
+// allowExecution reports whether the circuit of a plugin lets an execution through
+func (ps *PluginService) allowExecution(pluginSlug string) bool {
+	if !ps.circuitBreakerEnabled() {
+		return true
+	}
+	return ps.circuitBreakers.allow(pluginSlug, ps.config.CircuitBreakerWindow, ps.circuitBreakerCooldown())
+}
+
+// recordExecutionOutcome feeds an execution outcome to the plugin's breaker and
+// logs circuit state changes
+func (ps *PluginService) recordExecutionOutcome(pluginSlug string, outcome breakerOutcome) {
+	if !ps.circuitBreakerEnabled() {
+		return
+	}
+
+	state, changed := ps.circuitBreakers.record(pluginSlug, outcome,
+		ps.config.CircuitBreakerFailures, ps.config.CircuitBreakerWindow)
+	if !changed {
+		return
+	}
+
+	fields := logger.Fields{
+		"plugin_slug": pluginSlug,
+		"state":       state,
+	}
+	if state == CircuitOpen {
+		fields["cooldown"] = ps.circuitBreakerCooldown().String()
+		ps.logger.WithFields(fields).Warn("Plugin circuit opened, executions will fail fast")
+		return
+	}
+	ps.logger.WithFields(fields).Info("Plugin circuit closed after a successful probe")
+}
+
+// GetCircuitStats returns the circuit breaker state of plugins that have executed
+func (ps *PluginService) GetCircuitStats() map[string]PluginCircuitStats {
+	return ps.circuitBreakers.snapshot(ps.circuitBreakerCooldown())
+}
@@ -0,0 +1,68 @@
+/*
+ * Firecracker CMS - Firecracker Crash Detection
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// InstanceExitHandler is called when the Firecracker process of a pooled
+// instance exits without StopVM or Shutdown stopping it
+type InstanceExitHandler func(instance *PrewarmInstance, cause error)
+
+// SetInstanceExitHandler registers the handler for unexpected instance exits.
+// Without one, crashed instances are only cleaned up.
+func (vm *VMService) SetInstanceExitHandler(handler InstanceExitHandler) {
+	vm.poolMutex.Lock()
+	defer vm.poolMutex.Unlock()
+
+	vm.exitHandler = handler
+}
+
+// watchInstance blocks until the Firecracker process of an instance exits and
+// handles the exit at once if nothing asked the instance to stop
+func (vm *VMService) watchInstance(instance *PrewarmInstance) {
+	waitErr := instance.Machine.Wait(context.Background())
+
+	vm.poolMutex.Lock()
+	expected := instance.stopping || vm.prewarmPool[instance.InstanceID] != instance
+	handler := vm.exitHandler
+	vm.poolMutex.Unlock()
+
+	if expected {
+		return
+	}
+
+	cause := fmt.Errorf("firecracker process exited unexpectedly")
+	if waitErr != nil {
+		cause = fmt.Errorf("firecracker process exited unexpectedly: %v", waitErr)
+	}
+
+	vm.logger.WithFields(logger.Fields{
+		"instance_id": instance.InstanceID,
+		"plugin_slug": instance.PluginSlug,
+		"uptime":      time.Since(instance.CreatedAt).String(),
+		"error":       waitErr,
+	}).Error("Firecracker process crashed")
+
+	if handler != nil {
+		handler(instance, cause)
+		return
+	}
+
+	// Free the IP and pool entry of the dead instance
+	if err := vm.StopVM(instance.InstanceID); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"instance_id": instance.InstanceID,
+			"error":       err,
+		}).Error("Failed to clean up crashed instance")
+	}
+}
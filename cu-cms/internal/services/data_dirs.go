@@ -0,0 +1,84 @@
+/*
+ * Firecracker CMS - Data Directory Checks
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	cms_errors "github.com/centraunit/cu-firecracker-cms/internal/errors"
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// requiredDataDirs returns the directories the CMS writes to while running
+func (vm *VMService) requiredDataDirs() []string {
+	dirs := []string{
+		vm.config.DataDir,
+		filepath.Join(vm.config.DataDir, "plugins"),
+		vm.snapshotDir,
+		"/tmp/firecracker", // API sockets of unjailed VMs
+	}
+	if vm.config.LogDir != "" {
+		dirs = append(dirs, vm.config.LogDir)
+	}
+	if vm.config.JailerEnabled {
+		dirs = append(dirs, vm.config.JailerChrootBaseDir)
+	}
+	return dirs
+}
+
+// checkDataDirs creates the required directories with the configured mode and
+// verifies the process can write to each, so a volume owned by another user
+// fails at startup instead of on the first registry or snapshot write
+func (vm *VMService) checkDataDirs() error {
+	mode := os.FileMode(vm.config.DataDirMode)
+	uid := os.Geteuid()
+
+	var failures []string
+	for _, dir := range vm.requiredDataDirs() {
+		if err := os.MkdirAll(dir, mode); err != nil {
+			failures = append(failures, fmt.Sprintf("%s (cannot create: %v)", dir, err))
+			continue
+		}
+
+		probe, err := os.CreateTemp(dir, ".write-test-")
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s (%s)", dir, describeDirOwnership(dir)))
+			continue
+		}
+		probe.Close()
+		os.Remove(probe.Name())
+	}
+
+	if len(failures) > 0 {
+		vm.logger.WithFields(logger.Fields{
+			"uid":         uid,
+			"directories": failures,
+		}).Error("Data directories are not writable")
+		return cms_errors.NewFileSystemError("check_data_dirs",
+			fmt.Sprintf("directories not writable by uid %d: %s", uid, strings.Join(failures, "; "))).
+			WithContext("uid", uid)
+	}
+
+	return nil
+}
+
+// describeDirOwnership reports the owner and mode of a directory, which
+// usually explains why it cannot be written
+func describeDirOwnership(dir string) string {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err.Error()
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fmt.Sprintf("owned by uid %d gid %d, mode %#o", stat.Uid, stat.Gid, info.Mode().Perm())
+	}
+	return fmt.Sprintf("mode %#o", info.Mode().Perm())
+}
@@ -0,0 +1,84 @@
+/*
+ * Firecracker CMS - Deadline Propagation
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// Headers carrying a deadline, both from callers to the CMS and from the CMS
+// to plugin VMs
+const (
+	DeadlineHeader  = "X-Deadline"   // Absolute deadline, RFC 3339
+	TimeoutMsHeader = "X-Timeout-Ms" // Remaining time in milliseconds
+)
+
+// ExecutionBudgetHeader lowers the configured execution budget for one request
+const ExecutionBudgetHeader = "X-Execution-Budget-Ms"
+
+// maxActionTimeout bounds the timeout_ms a manifest may declare for an action
+const maxActionTimeout = 5 * time.Minute
+
+// callerDeadline returns the deadline a caller set on an execution request,
+// or the zero time if it set none. X-Timeout-Ms wins over X-Deadline.
+func callerDeadline(headers http.Header, now time.Time) time.Time {
+	if value := headers.Get(TimeoutMsHeader); value != "" {
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms >= 0 {
+			return now.Add(time.Duration(ms) * time.Millisecond)
+		}
+	}
+	if value := headers.Get(DeadlineHeader); value != "" {
+		if deadline, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return deadline
+		}
+	}
+	return time.Time{}
+}
+
+// executionBudget returns the total time an execution may take: the configured
+// budget, or the caller's X-Execution-Budget-Ms if shorter. Zero means none.
+func executionBudget(configuredMs int, headers http.Header) time.Duration {
+	budget := time.Duration(configuredMs) * time.Millisecond
+	if value := headers.Get(ExecutionBudgetHeader); value != "" {
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms > 0 {
+			requested := time.Duration(ms) * time.Millisecond
+			if budget == 0 || requested < budget {
+				budget = requested
+			}
+		}
+	}
+	return budget
+}
+
+// actionDeadline returns the deadline of a request to an action: its own
+// timeout from now, cut short by the caller's deadline if that comes first
+func actionDeadline(action *models.PluginAction, caller time.Time, now time.Time) time.Time {
+	timeout := pluginRequestTimeout
+	if action.TimeoutMs > 0 {
+		timeout = time.Duration(action.TimeoutMs) * time.Millisecond
+	}
+
+	deadline := now.Add(timeout)
+	if !caller.IsZero() && caller.Before(deadline) {
+		return caller
+	}
+	return deadline
+}
+
+// setDeadlineHeaders tells the plugin when the CMS stops waiting for it
+func setDeadlineHeaders(req *http.Request, deadline time.Time) {
+	remaining := time.Until(deadline).Milliseconds()
+	if remaining < 0 {
+		remaining = 0
+	}
+	req.Header.Set(DeadlineHeader, deadline.UTC().Format(time.RFC3339Nano))
+	req.Header.Set(TimeoutMsHeader, strconv.FormatInt(remaining, 10))
+}
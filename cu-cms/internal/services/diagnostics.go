@@ -0,0 +1,62 @@
+/*
+ * Firecracker CMS - Built-in Diagnostic Plugin
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// DiagnosticEchoHook is the hook answered by the built-in echo plugin
+const DiagnosticEchoHook = "cms.diagnostics.echo"
+
+// diagnosticEchoPlugin is a virtual plugin handled in-process, without a VM.
+// It is matched by identity, so it never collides with an uploaded plugin.
+var diagnosticEchoPlugin = &models.Plugin{
+	Slug:    "cms-echo",
+	Name:    "CMS Echo",
+	Runtime: "builtin",
+	Status:  "active",
+	Actions: map[string]models.PluginAction{
+		"echo": {
+			Name:        "echo",
+			Description: "Echoes the payload and reports timing",
+			Hooks:       []string{DiagnosticEchoHook},
+		},
+	},
+}
+
+// diagnosticTargets adds the built-in echo plugin to the targets of its hook
+// when diagnostics are enabled
+func (ps *PluginService) diagnosticTargets(actionHook string, targetPlugins []*models.Plugin) []*models.Plugin {
+	if !ps.config.DiagnosticsEnabled || actionHook != DiagnosticEchoHook {
+		return targetPlugins
+	}
+	return append(targetPlugins, diagnosticEchoPlugin)
+}
+
+// executeEcho answers the echo hook in-process with the payload it received
+func (ps *PluginService) executeEcho(actionHook string, payload map[string]interface{}, startTime time.Time) map[string]interface{} {
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": diagnosticEchoPlugin.Slug,
+		"action_hook": actionHook,
+	}).Info("Executing built-in echo plugin")
+
+	return map[string]interface{}{
+		"plugin_slug": diagnosticEchoPlugin.Slug,
+		"success":     true,
+		"result": map[string]interface{}{
+			"hook":        actionHook,
+			"payload":     payload,
+			"received_at": startTime.Format(time.RFC3339Nano),
+			"latency_us":  time.Since(startTime).Microseconds(),
+		},
+		"execution_time_ms": int(time.Since(startTime).Milliseconds()),
+	}
+}
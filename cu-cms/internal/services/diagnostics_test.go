@@ -0,0 +1,33 @@
+/*
+ * Firecracker CMS - Pipeline Diagnostics Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+func TestEchoPluginHonorsCallerFilter(t *testing.T) {
+	ps := newTestPluginService(t)
+	ps.config.DiagnosticsEnabled = true
+
+	deny := func(plugin *models.Plugin) bool { return plugin.Slug != diagnosticEchoPlugin.Slug }
+	if _, err := ps.ExecuteActionFiltered(DiagnosticEchoHook, nil, ps.vmService, ExecutionOptions{Filter: deny}); !errors.Is(err, ErrNoPermittedPlugins) {
+		t.Fatalf("echo with a denying filter: err = %v, want %v", err, ErrNoPermittedPlugins)
+	}
+
+	allow := func(plugin *models.Plugin) bool { return true }
+	results, err := ps.ExecuteActionFiltered(DiagnosticEchoHook, nil, ps.vmService, ExecutionOptions{Filter: allow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if executed := results["executed_plugins"]; executed != 1 {
+		t.Fatalf("executed_plugins = %v, want 1", executed)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Firecracker CMS - Free Disk Guard
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"syscall"
+
+	cms_errors "github.com/centraunit/cu-firecracker-cms/internal/errors"
+)
+
+// DiskUsage reports the free space of a data directory's filesystem
+type DiskUsage struct {
+	Path      string `json:"path"`
+	FreeMB    int64  `json:"free_mb"`
+	TotalMB   int64  `json:"total_mb"`
+	MinFreeMB int    `json:"min_free_mb"`
+	Low       bool   `json:"low"` // Below the minimum, uploads and snapshots are refused
+	Error     string `json:"error,omitempty"`
+}
+
+// diskSpaceMB returns the free (for unprivileged writers) and total MB of the
+// filesystem holding dir
+func diskSpaceMB(dir string) (int64, int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize) >> 20, int64(stat.Blocks) * int64(stat.Bsize) >> 20, nil
+}
+
+// checkMinFreeDisk refuses an operation about to write writeBytes to dir when
+// that would leave less than minFreeMB free. reclaimBytes is space the
+// operation frees first, e.g. a file it replaces.
+func checkMinFreeDisk(dir string, writeBytes, reclaimBytes int64, minFreeMB int, operation string) error {
+	if minFreeMB == 0 {
+		return nil
+	}
+
+	freeMB, _, err := diskSpaceMB(dir)
+	if err != nil {
+		return cms_errors.WrapFileSystemError(err, operation, "failed to check free disk space in "+dir)
+	}
+
+	leftMB := freeMB + reclaimBytes>>20 - writeBytes>>20
+	if leftMB < int64(minFreeMB) {
+		return cms_errors.NewFileSystemError(operation, fmt.Sprintf(
+			"insufficient disk space in %s: %dMB free, writing %dMB would leave %dMB, below the %dMB minimum (CMS_MIN_FREE_DISK_MB)",
+			dir, freeMB, writeBytes>>20, leftMB, minFreeMB))
+	}
+	return nil
+}
+
+// DiskUsage returns the free space of the data and snapshot directories
+func (vm *VMService) DiskUsage() []DiskUsage {
+	dirs := []string{vm.config.DataDir}
+	if vm.snapshotDir != vm.config.DataDir {
+		dirs = append(dirs, vm.snapshotDir)
+	}
+
+	usage := make([]DiskUsage, 0, len(dirs))
+	for _, dir := range dirs {
+		entry := DiskUsage{Path: dir, MinFreeMB: vm.config.MinFreeDiskMB}
+		freeMB, totalMB, err := diskSpaceMB(dir)
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.FreeMB = freeMB
+			entry.TotalMB = totalMB
+			entry.Low = freeMB < int64(vm.config.MinFreeDiskMB)
+		}
+		usage = append(usage, entry)
+	}
+	return usage
+}
@@ -0,0 +1,114 @@
+/*
+ * Firecracker CMS - Guest DNS Configuration
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// hostResolvConf is read when no guest DNS servers are configured
+const hostResolvConf = "/etc/resolv.conf"
+
+// defaultGuestEntrypoint is the program the guest kernel boots unless the
+// manifest declares another
+const defaultGuestEntrypoint = "/sbin/init"
+
+// resolveGuestDNS returns the configured guest resolvers, falling back to the
+// host's own resolvers that are reachable from a VM
+func (vm *VMService) resolveGuestDNS() []string {
+	if vm.config.GuestDNS != "" {
+		var servers []string
+		for _, server := range strings.Split(vm.config.GuestDNS, ",") {
+			servers = append(servers, strings.TrimSpace(server))
+		}
+		return servers
+	}
+
+	return hostNameservers(hostResolvConf)
+}
+
+// hostNameservers parses IPv4 nameservers from a resolv.conf, skipping loopback
+// stub resolvers (such as systemd-resolved) that a guest cannot reach
+func hostNameservers(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+
+		ip := net.ParseIP(fields[1])
+		if ip == nil || ip.To4() == nil || ip.IsLoopback() {
+			continue
+		}
+
+		servers = append(servers, ip.String())
+		if len(servers) == 3 {
+			break
+		}
+	}
+
+	return servers
+}
+
+// guestKernelArgs builds the kernel command line for a VM. The hostname and DNS
+// servers are set in the ip= parameter (exposed by the kernel in /proc/net/pnp) and passed to
+// the guest init as the CMS_DNS environment variable for writing resolv.conf.
+// A non-default entrypoint is booted via init=. Console verbosity follows
+// the guest kernel loglevel and quiet boot settings. Read-only rootfs images
+// are mounted ro with their filesystem type. Additional interfaces are passed
+// to the guest init as CMS_IFACES for it to configure, and a tuned TAP MTU
+// as CMS_MTU so the guest NICs match the host side.
+func (vm *VMService) guestKernelArgs(ip string, extra []models.PluginNetworkInterface, plugin *models.Plugin, readOnly bool) string {
+	var dns0, dns1 string
+	if len(vm.guestDNS) > 0 {
+		dns0 = vm.guestDNS[0]
+	}
+	if len(vm.guestDNS) > 1 {
+		dns1 = vm.guestDNS[1]
+	}
+
+	args := fmt.Sprintf("console=ttyS0 reboot=k panic=1 pci=off ip=%s::192.168.127.1:255.255.255.0:%s:eth0:off:%s:%s",
+		ip, vm.guestHostname(plugin.Slug), dns0, dns1)
+	if vm.config.GuestKernelLogLevel >= 0 {
+		args += fmt.Sprintf(" loglevel=%d", vm.config.GuestKernelLogLevel)
+	}
+	if vm.config.GuestQuietBoot {
+		args += " quiet"
+	}
+	if readOnly {
+		args += " ro rootfstype=" + plugin.EffectiveRootfsType()
+	}
+	if plugin.Entrypoint != "" && plugin.Entrypoint != defaultGuestEntrypoint {
+		args += " init=" + plugin.Entrypoint
+	}
+	if len(vm.guestDNS) > 0 {
+		args += " CMS_DNS=" + strings.Join(vm.guestDNS, ",")
+	}
+	if len(extra) > 0 {
+		args += " CMS_IFACES=" + guestInterfacesArg(extra)
+	}
+	if vm.config.TapMTU > 0 {
+		args += fmt.Sprintf(" CMS_MTU=%d", vm.config.TapMTU)
+	}
+	args += guestEnvArgs(plugin.Env)
+
+	return args
+}
@@ -0,0 +1,215 @@
+/*
+ * Firecracker CMS - Plugin Lifecycle Events
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// Plugin lifecycle event types
+const (
+	EventPluginInstalled    = "plugin.installed"
+	EventPluginUpdated      = "plugin.updated"
+	EventPluginActivated    = "plugin.activated"
+	EventPluginDeactivated  = "plugin.deactivated"
+	EventPluginDeleted      = "plugin.deleted"
+	EventPluginFailed       = "plugin.failed"    // Validation or install hook failed
+	EventPluginUnhealthy    = "plugin.unhealthy" // Health checks crossed the failure threshold
+	EventPluginRecovered    = "plugin.recovered" // Health checks crossed the recovery threshold
+	EventPluginCrashed      = "plugin.crashed"   // The warm instance's Firecracker process exited
+	EventPluginRestarted    = "plugin.restarted" // A retired warm instance was replaced
+	EventPluginForceCleaned = "plugin.force_cleaned"
+)
+
+// How many recent events are kept for subscribers resuming after a disconnect
+const eventReplaySize = 256
+
+// How many events a subscriber may fall behind before it is disconnected
+const eventSubscriberBuffer = 64
+
+// LifecycleEvent is a plugin state change
+type LifecycleEvent struct {
+	ID         uint64    `json:"id"` // Increasing, restarts with the CMS
+	Type       string    `json:"type"`
+	PluginSlug string    `json:"plugin_slug"`
+	Timestamp  time.Time `json:"timestamp"`
+	Message    string    `json:"message,omitempty"`
+}
+
+// eventBus fans lifecycle events out to subscribers. Publishing never blocks:
+// a subscriber that falls behind has its channel closed and resumes from the
+// replay buffer with the last ID it saw.
+type eventBus struct {
+	mutex       sync.Mutex
+	lastID      uint64
+	recent      []LifecycleEvent
+	subscribers map[chan LifecycleEvent]bool
+}
+
+// newEventBus creates an event bus without subscribers
+func newEventBus() *eventBus {
+	return &eventBus{
+		subscribers: make(map[chan LifecycleEvent]bool),
+	}
+}
+
+// publish assigns the event an ID and hands it to every subscriber
+func (b *eventBus) publish(event LifecycleEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.lastID++
+	event.ID = b.lastID
+
+	b.recent = append(b.recent, event)
+	if len(b.recent) > eventReplaySize {
+		b.recent = b.recent[len(b.recent)-eventReplaySize:]
+	}
+
+	for events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+			delete(b.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// subscribe returns a channel of new events, the kept events after afterID
+// and a function ending the subscription. An afterID of 0, or one from before
+// a CMS restart, replays nothing.
+func (b *eventBus) subscribe(afterID uint64) (<-chan LifecycleEvent, []LifecycleEvent, func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var backlog []LifecycleEvent
+	if afterID > 0 && afterID < b.lastID {
+		for _, event := range b.recent {
+			if event.ID > afterID {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	events := make(chan LifecycleEvent, eventSubscriberBuffer)
+	b.subscribers[events] = true
+
+	cancel := func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		if b.subscribers[events] {
+			delete(b.subscribers, events)
+			close(events)
+		}
+	}
+
+	return events, backlog, cancel
+}
+
+// SubscribeEvents returns lifecycle events as they happen, preceded by the
+// kept events after afterID. The channel is closed when the subscriber falls
+// behind; call cancel once done.
+func (ps *PluginService) SubscribeEvents(afterID uint64) (events <-chan LifecycleEvent, backlog []LifecycleEvent, cancel func()) {
+	return ps.events.subscribe(afterID)
+}
+
+// emitEvent publishes a lifecycle event of a plugin
+func (ps *PluginService) emitEvent(eventType, pluginSlug, message string) {
+	ps.events.publish(LifecycleEvent{
+		Type:       eventType,
+		PluginSlug: pluginSlug,
+		Timestamp:  time.Now(),
+		Message:    message,
+	})
+}
+
+// forwardEvents POSTs every lifecycle event to the configured webhook, in
+// order. A webhook too slow to keep up resumes from the replay buffer; events
+// that fell out of it are lost.
+func (ps *PluginService) forwardEvents(webhookURL string) {
+	var lastID uint64
+	for {
+		events, backlog, cancel := ps.events.subscribe(lastID)
+		for _, event := range backlog {
+			ps.deliverEvent(webhookURL, event)
+			lastID = event.ID
+		}
+		for event := range events {
+			ps.deliverEvent(webhookURL, event)
+			lastID = event.ID
+		}
+		cancel()
+
+		ps.logger.WithFields(logger.Fields{
+			"last_event_id": lastID,
+		}).Warn("Event webhook fell behind, resuming from the replay buffer")
+	}
+}
+
+// deliverEvent POSTs an event to the webhook, retrying like execution
+// callbacks. Any 2xx response counts as delivered.
+func (ps *PluginService) deliverEvent(webhookURL string, event LifecycleEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"event_id": event.ID,
+			"error":    err,
+		}).Error("Failed to encode lifecycle event")
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		err := ps.postEvent(webhookURL, data)
+		if err == nil {
+			return
+		}
+
+		if attempt >= ps.config.CallbackRetries {
+			ps.logger.WithFields(logger.Fields{
+				"event_id":    event.ID,
+				"event_type":  event.Type,
+				"plugin_slug": event.PluginSlug,
+				"attempts":    attempt + 1,
+				"error":       err,
+			}).Error("Event webhook delivery failed, giving up")
+			return
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// postEvent makes a single webhook delivery attempt
+func (ps *PluginService) postEvent(webhookURL string, data []byte) error {
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ps.callbackClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,455 @@
+/*
+ * Firecracker CMS - Execution History
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// executionHistoryFlushInterval is how often new history records are persisted
+const executionHistoryFlushInterval = time.Minute
+
+// Page sizes of history queries
+const (
+	defaultHistoryPageSize = 50
+	maxHistoryPageSize     = 500
+)
+
+// ErrExecutionHistoryDisabled is returned for history queries while
+// CMS_EXECUTION_HISTORY_MAX_RECORDS is 0
+var ErrExecutionHistoryDisabled = errors.New("execution history is disabled")
+
+// ExecutionRecord is the outcome of one plugin execution
+type ExecutionRecord struct {
+	ID            uint64    `json:"id"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	ActionHook    string    `json:"action_hook"`
+	PluginSlug    string    `json:"plugin_slug"`
+	Success       bool      `json:"success"`
+	ErrorType     string    `json:"error_type,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	DurationMs    int64     `json:"duration_ms"`
+	Timestamp     time.Time `json:"timestamp"` // When the execution finished
+}
+
+// ExecutionHistoryQuery filters the execution history. Zero fields match all.
+type ExecutionHistoryQuery struct {
+	PluginSlug    string
+	ActionHook    string
+	CorrelationID string
+	Success       *bool
+	Since         time.Time
+	Until         time.Time
+	Before        uint64 // Cursor: only records with a lower ID
+	Limit         int
+
+	AllowPlugin func(slug string) bool // Caller permission on the record's plugin; nil allows all
+}
+
+// ExecutionHistoryPage is one page of query results, newest first
+type ExecutionHistoryPage struct {
+	Records    []ExecutionRecord `json:"records"`
+	NextCursor uint64            `json:"next_cursor,omitempty"` // Pass as before for the next page
+}
+
+// executionHistory keeps the most recent execution records in ID order with
+// per-plugin, per-hook and per-correlation indexes of record IDs. Record IDs
+// are consecutive, so a record is found by its offset from the oldest one.
+type executionHistory struct {
+	mutex sync.RWMutex
+
+	maxRecords int
+	maxAge     time.Duration
+
+	records []ExecutionRecord
+	nextID  uint64
+
+	byPlugin      map[string][]uint64
+	byHook        map[string][]uint64
+	byCorrelation map[string][]uint64
+
+	dirty bool // Records changed since the last save
+}
+
+// persistedExecutionHistory is the on-disk form of the history
+type persistedExecutionHistory struct {
+	NextID  uint64            `json:"next_id"`
+	Records []ExecutionRecord `json:"records"`
+}
+
+// newExecutionHistory creates an empty history keeping at most maxRecords
+// records no older than maxAge (0 for no age limit)
+func newExecutionHistory(maxRecords int, maxAge time.Duration) *executionHistory {
+	return &executionHistory{
+		maxRecords:    maxRecords,
+		maxAge:        maxAge,
+		nextID:        1,
+		byPlugin:      make(map[string][]uint64),
+		byHook:        make(map[string][]uint64),
+		byCorrelation: make(map[string][]uint64),
+	}
+}
+
+// add appends records, assigning their IDs, and applies the retention
+func (h *executionHistory) add(records []ExecutionRecord) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for _, record := range records {
+		record.ID = h.nextID
+		h.nextID++
+		h.records = append(h.records, record)
+		h.indexUnsafe(record)
+	}
+	h.dirty = true
+
+	h.pruneUnsafe(time.Now())
+}
+
+// indexUnsafe adds a record to the indexes
+// Note: Caller must hold h.mutex
+func (h *executionHistory) indexUnsafe(record ExecutionRecord) {
+	h.byPlugin[record.PluginSlug] = append(h.byPlugin[record.PluginSlug], record.ID)
+	h.byHook[record.ActionHook] = append(h.byHook[record.ActionHook], record.ID)
+	if record.CorrelationID != "" {
+		h.byCorrelation[record.CorrelationID] = append(h.byCorrelation[record.CorrelationID], record.ID)
+	}
+}
+
+// pruneUnsafe drops records beyond the count limit or older than the age limit.
+// Dropped records are the oldest, so their IDs lead their index entries and
+// only those entries are trimmed.
+// Note: Caller must hold h.mutex
+func (h *executionHistory) pruneUnsafe(now time.Time) {
+	drop := 0
+	if len(h.records) > h.maxRecords {
+		drop = len(h.records) - h.maxRecords
+	}
+	if h.maxAge > 0 {
+		cutoff := now.Add(-h.maxAge)
+		for drop < len(h.records) && h.records[drop].Timestamp.Before(cutoff) {
+			drop++
+		}
+	}
+	if drop == 0 {
+		return
+	}
+
+	for _, record := range h.records[:drop] {
+		unindexOldest(h.byPlugin, record.PluginSlug, record.ID)
+		unindexOldest(h.byHook, record.ActionHook, record.ID)
+		if record.CorrelationID != "" {
+			unindexOldest(h.byCorrelation, record.CorrelationID, record.ID)
+		}
+	}
+	h.records = h.records[drop:]
+	h.dirty = true
+}
+
+// unindexOldest removes id, the oldest record ID of key, from an index
+func unindexOldest(index map[string][]uint64, key string, id uint64) {
+	ids := index[key]
+	if len(ids) == 0 || ids[0] != id {
+		return
+	}
+	if len(ids) == 1 {
+		delete(index, key)
+		return
+	}
+	index[key] = ids[1:]
+}
+
+// recordUnsafe returns the record with the given ID
+// Note: Caller must hold h.mutex
+func (h *executionHistory) recordUnsafe(id uint64) *ExecutionRecord {
+	if len(h.records) == 0 || id < h.records[0].ID {
+		return nil
+	}
+	offset := id - h.records[0].ID
+	if offset >= uint64(len(h.records)) {
+		return nil
+	}
+	return &h.records[offset]
+}
+
+// query returns the newest records matching q. The most selective index given
+// in q narrows the candidates; the other filters are checked per record.
+func (h *executionHistory) query(q ExecutionHistoryQuery) ExecutionHistoryPage {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultHistoryPageSize
+	}
+	if limit > maxHistoryPageSize {
+		limit = maxHistoryPageSize
+	}
+
+	var candidates []uint64
+	indexed := false
+	for _, lookup := range []struct {
+		key   string
+		index map[string][]uint64
+	}{
+		{q.CorrelationID, h.byCorrelation},
+		{q.PluginSlug, h.byPlugin},
+		{q.ActionHook, h.byHook},
+	} {
+		if lookup.key == "" {
+			continue
+		}
+		ids := lookup.index[lookup.key]
+		if !indexed || len(ids) < len(candidates) {
+			candidates = ids
+		}
+		indexed = true
+	}
+
+	page := ExecutionHistoryPage{Records: []ExecutionRecord{}}
+
+	// Walk candidates (or all records) from the newest below the cursor
+	next := func(i int) uint64 {
+		if indexed {
+			return candidates[i]
+		}
+		return h.records[i].ID
+	}
+	count := len(h.records)
+	if indexed {
+		count = len(candidates)
+	}
+	start := count
+	if q.Before > 0 {
+		start = sort.Search(count, func(i int) bool { return next(i) >= q.Before })
+	}
+
+	for i := start - 1; i >= 0; i-- {
+		record := h.recordUnsafe(next(i))
+		if record == nil {
+			continue
+		}
+		if !q.Since.IsZero() && record.Timestamp.Before(q.Since) {
+			// Records are in time order, so nothing older can match
+			break
+		}
+		if !historyRecordMatches(record, q) {
+			continue
+		}
+
+		if len(page.Records) == limit {
+			page.NextCursor = page.Records[limit-1].ID
+			break
+		}
+		page.Records = append(page.Records, *record)
+	}
+
+	return page
+}
+
+// historyRecordMatches checks the filters of q not covered by the index lookup
+func historyRecordMatches(record *ExecutionRecord, q ExecutionHistoryQuery) bool {
+	if q.PluginSlug != "" && record.PluginSlug != q.PluginSlug {
+		return false
+	}
+	if q.ActionHook != "" && record.ActionHook != q.ActionHook {
+		return false
+	}
+	if q.CorrelationID != "" && record.CorrelationID != q.CorrelationID {
+		return false
+	}
+	if q.Success != nil && record.Success != *q.Success {
+		return false
+	}
+	if !q.Until.IsZero() && record.Timestamp.After(q.Until) {
+		return false
+	}
+	if q.AllowPlugin != nil && !q.AllowPlugin(record.PluginSlug) {
+		return false
+	}
+	return true
+}
+
+// executionHistoryPath returns the path of the persisted execution history
+func (ps *PluginService) executionHistoryPath() string {
+	return filepath.Join(ps.config.DataDir, "execution_history.json")
+}
+
+// loadExecutionHistory restores the history persisted by a previous run,
+// applying the current retention
+func (ps *PluginService) loadExecutionHistory() {
+	data, err := os.ReadFile(ps.executionHistoryPath())
+	if err != nil {
+		return
+	}
+
+	var persisted persistedExecutionHistory
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"file":  ps.executionHistoryPath(),
+			"error": err,
+		}).Warn("Failed to parse execution history")
+		return
+	}
+
+	// IDs must be consecutive for offset lookups
+	for i := 1; i < len(persisted.Records); i++ {
+		if persisted.Records[i].ID != persisted.Records[i-1].ID+1 {
+			ps.logger.WithFields(logger.Fields{
+				"file": ps.executionHistoryPath(),
+			}).Warn("Execution history has gaps, starting with an empty history")
+			return
+		}
+	}
+
+	h := ps.executionHistory
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for _, record := range persisted.Records {
+		h.records = append(h.records, record)
+		h.indexUnsafe(record)
+	}
+	h.nextID = persisted.NextID
+	if len(h.records) > 0 && h.nextID <= h.records[len(h.records)-1].ID {
+		h.nextID = h.records[len(h.records)-1].ID + 1
+	}
+	if h.nextID == 0 {
+		h.nextID = 1
+	}
+	h.pruneUnsafe(time.Now())
+	h.dirty = false
+}
+
+// FlushExecutionHistory persists the execution history if it changed
+func (ps *PluginService) FlushExecutionHistory() {
+	if ps.executionHistory == nil {
+		return
+	}
+
+	h := ps.executionHistory
+	h.mutex.Lock()
+	h.pruneUnsafe(time.Now())
+	if !h.dirty {
+		h.mutex.Unlock()
+		return
+	}
+	// Records are never modified in place, so a copy can be encoded unlocked
+	persisted := persistedExecutionHistory{NextID: h.nextID, Records: slices.Clone(h.records)}
+	h.dirty = false
+	h.mutex.Unlock()
+
+	data, err := json.Marshal(persisted)
+	if err == nil {
+		err = writeFileDurable(ps.executionHistoryPath(), data, 0644)
+	}
+	if err != nil {
+		h.mutex.Lock()
+		h.dirty = true
+		h.mutex.Unlock()
+
+		ps.logger.WithFields(logger.Fields{
+			"file":  ps.executionHistoryPath(),
+			"error": err,
+		}).Error("Failed to save execution history")
+	}
+}
+
+// writeFileDurable replaces path with data so that a crash or power loss
+// leaves either the old or the new contents: the data is synced to a temporary
+// file, renamed over path, and the rename is synced with the directory
+func writeFileDurable(path string, data []byte, perm os.FileMode) error {
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// executionHistoryFlusher periodically persists the execution history
+func (ps *PluginService) executionHistoryFlusher() {
+	ticker := time.NewTicker(executionHistoryFlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ps.FlushExecutionHistory()
+	}
+}
+
+// recordExecutionHistory adds the per-plugin results of an execution to the
+// history, tagged with the caller's correlation ID
+func (ps *PluginService) recordExecutionHistory(actionHook string, headers http.Header, results []map[string]interface{}) {
+	if ps.executionHistory == nil || len(results) == 0 {
+		return
+	}
+
+	correlationID := headers.Get(CorrelationIDHeader)
+	now := time.Now()
+
+	records := make([]ExecutionRecord, 0, len(results))
+	for _, result := range results {
+		record := ExecutionRecord{
+			CorrelationID: correlationID,
+			ActionHook:    actionHook,
+			Timestamp:     now,
+		}
+		record.PluginSlug, _ = result["plugin_slug"].(string)
+		record.Success, _ = result["success"].(bool)
+		if errType, exists := result["error_type"]; exists {
+			record.ErrorType = fmt.Sprint(errType)
+			if failure, ok := result["result"].(map[string]interface{}); ok {
+				record.Error, _ = failure["error"].(string)
+			}
+		}
+		if durationMs, ok := result["execution_time_ms"].(int); ok {
+			record.DurationMs = int64(durationMs)
+		}
+		records = append(records, record)
+	}
+
+	ps.executionHistory.add(records)
+}
+
+// QueryExecutionHistory returns a page of the execution history
+func (ps *PluginService) QueryExecutionHistory(q ExecutionHistoryQuery) (ExecutionHistoryPage, error) {
+	if ps.executionHistory == nil {
+		return ExecutionHistoryPage{}, ErrExecutionHistoryDisabled
+	}
+	return ps.executionHistory.query(q), nil
+}
@@ -0,0 +1,127 @@
+/*
+ * Firecracker CMS - Execution History Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// addTestExecutions records count executions cycling through plugins
+func addTestExecutions(h *executionHistory, count int, plugins []string, timestamp time.Time) {
+	for i := 0; i < count; i++ {
+		h.add([]ExecutionRecord{{
+			PluginSlug:    plugins[i%len(plugins)],
+			ActionHook:    "order.created",
+			CorrelationID: fmt.Sprintf("corr-%d", i),
+			Success:       true,
+			Timestamp:     timestamp,
+		}})
+	}
+}
+
+// checkIndexes fails unless every index entry points at a kept record and
+// every kept record is indexed once per index
+func checkIndexes(t *testing.T, h *executionHistory) {
+	t.Helper()
+	for name, index := range map[string]map[string][]uint64{
+		"plugin":      h.byPlugin,
+		"hook":        h.byHook,
+		"correlation": h.byCorrelation,
+	} {
+		total := 0
+		for key, ids := range index {
+			if len(ids) == 0 {
+				t.Fatalf("%s index keeps empty key %q", name, key)
+			}
+			for _, id := range ids {
+				if h.recordUnsafe(id) == nil {
+					t.Fatalf("%s index %q points at dropped record %d", name, key, id)
+				}
+			}
+			total += len(ids)
+		}
+		if total != len(h.records) {
+			t.Fatalf("%s index holds %d IDs for %d records", name, total, len(h.records))
+		}
+	}
+}
+
+func TestExecutionHistoryPruneByCount(t *testing.T) {
+	h := newExecutionHistory(10, 0)
+	addTestExecutions(h, 35, []string{"blog", "shop", "mail"}, time.Now())
+
+	if len(h.records) != 10 || h.records[0].ID != 26 {
+		t.Fatalf("kept %d records from ID %d, want 10 from ID 26", len(h.records), h.records[0].ID)
+	}
+	checkIndexes(t, h)
+
+	page := h.query(ExecutionHistoryQuery{PluginSlug: "shop"})
+	for _, record := range page.Records {
+		if record.PluginSlug != "shop" {
+			t.Fatalf("query for shop returned a %s record", record.PluginSlug)
+		}
+	}
+	if len(page.Records) != 4 {
+		t.Fatalf("query for shop returned %d records, want 4", len(page.Records))
+	}
+}
+
+func TestExecutionHistoryQueryAllowPlugin(t *testing.T) {
+	h := newExecutionHistory(100, 0)
+	addTestExecutions(h, 9, []string{"blog", "shop", "mail"}, time.Now())
+
+	query := ExecutionHistoryQuery{Limit: 2, AllowPlugin: func(slug string) bool { return slug == "blog" }}
+	seen := 0
+	for {
+		page := h.query(query)
+		for _, record := range page.Records {
+			if record.PluginSlug != "blog" {
+				t.Fatalf("query allowing blog returned a %s record", record.PluginSlug)
+			}
+			seen++
+		}
+		if page.NextCursor == 0 {
+			break
+		}
+		query.Before = page.NextCursor
+	}
+	if seen != 3 {
+		t.Fatalf("pages held %d blog records, want 3", seen)
+	}
+}
+
+func TestExecutionHistoryPruneByAge(t *testing.T) {
+	h := newExecutionHistory(100, time.Hour)
+	addTestExecutions(h, 5, []string{"blog"}, time.Now().Add(-2*time.Hour))
+	addTestExecutions(h, 3, []string{"shop"}, time.Now())
+
+	if len(h.records) != 3 {
+		t.Fatalf("kept %d records, want the 3 recent ones", len(h.records))
+	}
+	if _, exists := h.byPlugin["blog"]; exists {
+		t.Fatalf("expired plugin still indexed")
+	}
+	checkIndexes(t, h)
+}
+
+func TestFlushExecutionHistoryRoundTrip(t *testing.T) {
+	ps := newTestPluginService(t)
+	ps.executionHistory = newExecutionHistory(10, 0)
+	addTestExecutions(ps.executionHistory, 4, []string{"blog", "shop"}, time.Now())
+	ps.FlushExecutionHistory()
+
+	ps.executionHistory = newExecutionHistory(10, 0)
+	ps.loadExecutionHistory()
+
+	h := ps.executionHistory
+	if len(h.records) != 4 || h.nextID != 5 {
+		t.Fatalf("loaded %d records with next ID %d, want 4 and 5", len(h.records), h.nextID)
+	}
+	checkIndexes(t, h)
+}
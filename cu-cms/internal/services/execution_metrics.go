@@ -0,0 +1,187 @@
+/*
+ * Firecracker CMS - Execution Metrics
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// PluginExecutionStats represents execution concurrency statistics for a plugin
+type PluginExecutionStats struct {
+	InFlight           int     `json:"in_flight"`
+	PeakConcurrency    int     `json:"peak_concurrency"`
+	Acquisitions       int64   `json:"acquisitions"`
+	AcquireWaitTotalMs float64 `json:"acquire_wait_total_ms"`
+	AcquireWaitMaxMs   float64 `json:"acquire_wait_max_ms"`
+
+	// Executions that found a warm instance vs. ones that had to cold start or failed for lack of one
+	WarmHits     int64   `json:"warm_hits"`
+	ColdMisses   int64   `json:"cold_misses"`
+	WarmHitRatio float64 `json:"warm_hit_ratio"`         // 1 until the first execution
+	WarmHitLow   bool    `json:"warm_hit_low,omitempty"` // Below CMS_WARM_HIT_RATIO_WARN_PERCENT
+}
+
+// ExecutionStats represents execution concurrency statistics across all plugins
+type ExecutionStats struct {
+	InFlight        int                             `json:"in_flight"`
+	PeakConcurrency int                             `json:"peak_concurrency"`
+	WarmHits        int64                           `json:"warm_hits"`
+	ColdMisses      int64                           `json:"cold_misses"`
+	WarmHitRatio    float64                         `json:"warm_hit_ratio"`
+	Plugins         map[string]PluginExecutionStats `json:"plugins"`
+}
+
+// warmHitMinSample is how many executions a plugin needs before a low warm-hit
+// ratio is flagged, so a few cold starts after activation do not
+const warmHitMinSample = 20
+
+// executionMetrics tracks in-flight executions and warm instance acquisition time
+type executionMetrics struct {
+	mutex    sync.Mutex
+	inFlight int
+	peak     int
+	plugins  map[string]*PluginExecutionStats
+}
+
+// newExecutionMetrics creates an empty execution metrics tracker
+func newExecutionMetrics() *executionMetrics {
+	return &executionMetrics{
+		plugins: make(map[string]*PluginExecutionStats),
+	}
+}
+
+// pluginStatsUnsafe returns the stats entry for a plugin, creating it if needed
+// Note: Caller must hold m.mutex
+func (m *executionMetrics) pluginStatsUnsafe(pluginSlug string) *PluginExecutionStats {
+	stats, exists := m.plugins[pluginSlug]
+	if !exists {
+		stats = &PluginExecutionStats{}
+		m.plugins[pluginSlug] = stats
+	}
+	return stats
+}
+
+// executionStarted records the start of a plugin execution
+func (m *executionMetrics) executionStarted(pluginSlug string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.inFlight++
+	if m.inFlight > m.peak {
+		m.peak = m.inFlight
+	}
+
+	stats := m.pluginStatsUnsafe(pluginSlug)
+	stats.InFlight++
+	if stats.InFlight > stats.PeakConcurrency {
+		stats.PeakConcurrency = stats.InFlight
+	}
+}
+
+// executionFinished records the end of a plugin execution
+func (m *executionMetrics) executionFinished(pluginSlug string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.inFlight > 0 {
+		m.inFlight--
+	}
+
+	stats := m.pluginStatsUnsafe(pluginSlug)
+	if stats.InFlight > 0 {
+		stats.InFlight--
+	}
+}
+
+// instanceAcquired records how long an execution waited for a warm instance
+func (m *executionMetrics) instanceAcquired(pluginSlug string, wait time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	waitMs := float64(wait) / float64(time.Millisecond)
+
+	stats := m.pluginStatsUnsafe(pluginSlug)
+	stats.Acquisitions++
+	stats.AcquireWaitTotalMs += waitMs
+	if waitMs > stats.AcquireWaitMaxMs {
+		stats.AcquireWaitMaxMs = waitMs
+	}
+}
+
+// instanceLookup records whether an execution found a warm instance in the pool
+func (m *executionMetrics) instanceLookup(pluginSlug string, warm bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats := m.pluginStatsUnsafe(pluginSlug)
+	if warm {
+		stats.WarmHits++
+	} else {
+		stats.ColdMisses++
+	}
+}
+
+// warmHitRatio returns the share of lookups that found a warm instance, 1 without lookups
+func warmHitRatio(hits, misses int64) float64 {
+	if hits+misses == 0 {
+		return 1
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// pluginInFlight returns the executions of a plugin currently in flight
+func (m *executionMetrics) pluginInFlight(pluginSlug string) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if stats, exists := m.plugins[pluginSlug]; exists {
+		return stats.InFlight
+	}
+	return 0
+}
+
+// snapshot returns a copy of the current statistics
+func (m *executionMetrics) snapshot() ExecutionStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats := ExecutionStats{
+		InFlight:        m.inFlight,
+		PeakConcurrency: m.peak,
+		Plugins:         make(map[string]PluginExecutionStats, len(m.plugins)),
+	}
+	for slug, pluginStats := range m.plugins {
+		pluginCopy := *pluginStats
+		pluginCopy.WarmHitRatio = warmHitRatio(pluginCopy.WarmHits, pluginCopy.ColdMisses)
+		stats.Plugins[slug] = pluginCopy
+
+		stats.WarmHits += pluginCopy.WarmHits
+		stats.ColdMisses += pluginCopy.ColdMisses
+	}
+	stats.WarmHitRatio = warmHitRatio(stats.WarmHits, stats.ColdMisses)
+
+	return stats
+}
+
+// GetExecutionStats returns execution concurrency, warm instance wait and
+// warm-hit statistics. Plugins with enough executions whose warm-hit ratio is
+// below the configured threshold are flagged, a sign the pool is too small or
+// evicts too eagerly.
+func (ps *PluginService) GetExecutionStats() ExecutionStats {
+	stats := ps.executionMetrics.snapshot()
+
+	threshold := float64(ps.config.WarmHitRatioWarnPercent) / 100
+	for slug, pluginStats := range stats.Plugins {
+		if pluginStats.WarmHits+pluginStats.ColdMisses >= warmHitMinSample && pluginStats.WarmHitRatio < threshold {
+			pluginStats.WarmHitLow = true
+			stats.Plugins[slug] = pluginStats
+		}
+	}
+
+	return stats
+}
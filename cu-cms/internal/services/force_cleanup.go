@@ -0,0 +1,285 @@
+/*
+ * Firecracker CMS - Forced Plugin Cleanup
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// How long a killed Firecracker process gets to be reaped
+const forceCleanupWaitTimeout = 5 * time.Second
+
+// ForceCleanupReport lists what a forced cleanup freed and what it failed to free
+type ForceCleanupReport struct {
+	PluginSlug     string    `json:"plugin_slug"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	Actions        []string  `json:"actions"`
+	Errors         []string  `json:"errors"`
+	CompletedAt    time.Time `json:"completed_at"`
+}
+
+// action records and logs a resource that was freed
+func (r *ForceCleanupReport) action(log *logger.Logger, message string, fields logger.Fields) {
+	r.Actions = append(r.Actions, message)
+	fields["plugin_slug"] = r.PluginSlug
+	log.WithFields(fields).Info("Force cleanup: " + message)
+}
+
+// failure records and logs a resource that could not be freed
+func (r *ForceCleanupReport) failure(log *logger.Logger, message string, err error, fields logger.Fields) {
+	r.Errors = append(r.Errors, fmt.Sprintf("%s: %v", message, err))
+	fields["plugin_slug"] = r.PluginSlug
+	fields["error"] = err
+	log.WithFields(fields).Warn("Force cleanup: " + message)
+}
+
+// forceCleanupTarget is a VM of the plugin, from the pool, the instance
+// registry or both
+type forceCleanupTarget struct {
+	instanceID string
+	instance   *PrewarmInstance // Nil if the VM is only in the instance registry
+	record     *InstanceRecord  // Nil if the VM was never recorded
+}
+
+// ForceCleanupPlugin kills every VM of a plugin and frees its TAP devices, IPs,
+// jails, sockets and snapshot without waiting for in-flight work or graceful
+// shutdown. The plugin's persisted network assignments are released, so the
+// caller must clear them from the registry.
+func (vm *VMService) ForceCleanupPlugin(plugin *cms_models.Plugin, report *ForceCleanupReport) {
+	targets := make(map[string]*forceCleanupTarget)
+
+	// Take the plugin's VMs out of the pool so nothing routes to them
+	vm.poolMutex.Lock()
+	for instanceID, instance := range vm.prewarmPool {
+		if instance.PluginSlug != plugin.Slug {
+			continue
+		}
+		instance.stopping = true
+		vm.unclaimWarmInstanceUnsafe(instance)
+		delete(vm.prewarmPool, instanceID)
+		targets[instanceID] = &forceCleanupTarget{instanceID: instanceID, instance: instance}
+	}
+	delete(vm.evictedPlugins, plugin.Slug)
+	vm.poolMutex.Unlock()
+
+	// The registry also knows VMs that leaked out of the pool
+	vm.instanceRegistryMutex.Lock()
+	for instanceID, record := range vm.readInstanceRegistryUnsafe() {
+		if record.PluginSlug != plugin.Slug {
+			continue
+		}
+		recordCopy := record
+		if target, exists := targets[instanceID]; exists {
+			target.record = &recordCopy
+		} else {
+			targets[instanceID] = &forceCleanupTarget{instanceID: instanceID, record: &recordCopy}
+		}
+	}
+	vm.instanceRegistryMutex.Unlock()
+
+	taps := make(map[string]bool)
+	ips := make(map[string]bool)
+
+	for _, target := range targets {
+		vm.forceCleanupInstance(target, report)
+
+		if target.record != nil {
+			if target.record.TapName != "" {
+				taps[target.record.TapName] = true
+			}
+			if target.record.IP != "" {
+				ips[target.record.IP] = true
+			}
+		}
+		if target.instance != nil {
+			if target.instance.IP != "" {
+				ips[target.instance.IP] = true
+			}
+			for _, iface := range target.instance.Interfaces {
+				ips[iface.AssignedIP] = true
+			}
+		}
+	}
+
+	// Persistent network resources of the plugin itself
+	if plugin.TapDevice != "" {
+		taps[plugin.TapDevice] = true
+	}
+	if plugin.AssignedIP != "" {
+		ips[plugin.AssignedIP] = true
+	}
+	for _, iface := range plugin.NetworkInterfaces {
+		if iface.TapDevice != "" {
+			taps[iface.TapDevice] = true
+		}
+		if iface.AssignedIP != "" {
+			ips[iface.AssignedIP] = true
+		}
+	}
+
+	for tapName := range taps {
+		if !vm.tapExists(tapName) {
+			continue
+		}
+		if err := vm.deleteTapInterface(tapName); err != nil {
+			report.failure(vm.logger, "failed to delete TAP interface "+tapName, err, logger.Fields{"tap_name": tapName})
+			continue
+		}
+		report.action(vm.logger, "deleted TAP interface "+tapName, logger.Fields{"tap_name": tapName})
+	}
+
+	for ip := range ips {
+		vm.deallocateIP(ip)
+		report.action(vm.logger, "released IP "+ip, logger.Fields{"ip": ip})
+	}
+
+	if vm.HasSnapshot(plugin.Slug) {
+		if err := vm.DeleteSnapshot(plugin.Slug); err != nil {
+			report.failure(vm.logger, "failed to delete snapshot", err, logger.Fields{})
+		} else {
+			report.action(vm.logger, "deleted snapshot", logger.Fields{})
+		}
+	}
+}
+
+// forceCleanupInstance kills a VM's Firecracker process and removes its jail,
+// socket, egress rules and records
+func (vm *VMService) forceCleanupInstance(target *forceCleanupTarget, report *ForceCleanupReport) {
+	fields := func() logger.Fields {
+		return logger.Fields{"instance_id": target.instanceID}
+	}
+
+	pid := 0
+	if target.instance != nil {
+		pid, _ = target.instance.Machine.PID()
+	}
+	if pid == 0 && target.record != nil {
+		pid = target.record.PID
+	}
+
+	if pid > 0 && isFirecrackerProcess(pid) {
+		if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+			report.failure(vm.logger, fmt.Sprintf("failed to kill Firecracker process %d of %s", pid, target.instanceID), err, fields())
+		} else {
+			report.action(vm.logger, fmt.Sprintf("killed Firecracker process %d of %s", pid, target.instanceID), fields())
+		}
+	}
+
+	// Reap the process so the TAP device is released
+	if target.instance != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), forceCleanupWaitTimeout)
+		target.instance.Machine.Wait(ctx)
+		cancel()
+	}
+
+	jailed := (target.instance != nil && target.instance.Jailed) || (target.record != nil && target.record.Jailed)
+	if jailed {
+		if err := vm.removeJail(target.instanceID); err != nil {
+			report.failure(vm.logger, "failed to remove jail of "+target.instanceID, err, fields())
+		} else {
+			report.action(vm.logger, "removed jail of "+target.instanceID, fields())
+		}
+	} else {
+		socketPath := filepath.Join("/tmp/firecracker", fmt.Sprintf("%s.sock", target.instanceID))
+		if target.record != nil && target.record.SocketPath != "" {
+			socketPath = target.record.SocketPath
+		}
+		if err := os.Remove(socketPath); err == nil {
+			report.action(vm.logger, "removed socket "+socketPath, fields())
+		} else if !os.IsNotExist(err) {
+			report.failure(vm.logger, "failed to remove socket "+socketPath, err, fields())
+		}
+	}
+
+	if target.instance != nil && target.instance.EgressBlocked {
+		vm.unblockVMEgress(target.instance.IP, target.instance.Interfaces)
+		report.action(vm.logger, "removed egress rules of "+target.instanceID, fields())
+	} else if target.record != nil && target.record.EgressBlocked {
+		vm.unblockVMEgress(target.record.IP, target.record.Interfaces)
+		report.action(vm.logger, "removed egress rules of "+target.instanceID, fields())
+	}
+
+	vm.forgetInstance(target.instanceID)
+	vm.usage.forgetInstance(target.instanceID)
+	report.action(vm.logger, "removed instance "+target.instanceID, fields())
+}
+
+// ForceCleanupPlugin frees every resource of a wedged plugin and resets it to
+// installed. In-flight executions are not waited for; they fail as their VM is
+// killed. Every freed resource is logged and listed in the report.
+func (ps *PluginService) ForceCleanupPlugin(slug string) (*ForceCleanupReport, error) {
+	// The VM cleanup runs without the registry lock, which a wedged
+	// operation on the plugin may be holding
+	ps.mutex.RLock()
+	plugin, exists := ps.plugins[slug]
+	if !exists {
+		ps.mutex.RUnlock()
+		return nil, fmt.Errorf("plugin not found")
+	}
+	target := *plugin
+	target.NetworkInterfaces = append([]cms_models.PluginNetworkInterface(nil), plugin.NetworkInterfaces...)
+	ps.mutex.RUnlock()
+
+	report := &ForceCleanupReport{
+		PluginSlug:     slug,
+		PreviousStatus: target.Status,
+		Actions:        []string{},
+		Errors:         []string{},
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": slug,
+		"status":      target.Status,
+	}).Warn("Force cleanup of plugin started")
+
+	ps.vmService.ForceCleanupPlugin(&target, report)
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	plugin, exists = ps.plugins[slug]
+	if !exists {
+		return nil, fmt.Errorf("plugin deleted during force cleanup")
+	}
+
+	plugin.Status = "installed"
+	plugin.AssignedIP = ""
+	plugin.TapDevice = ""
+	for i := range plugin.NetworkInterfaces {
+		plugin.NetworkInterfaces[i].TapDevice = ""
+		plugin.NetworkInterfaces[i].AssignedIP = ""
+		plugin.NetworkInterfaces[i].MacAddress = ""
+	}
+	plugin.NeedsResnapshot = false
+	plugin.SnapshotDiffCount = 0
+	plugin.UpdatedAt = time.Now()
+	ps.circuitBreakers.reset(slug)
+
+	if err := ps.savePluginsUnsafe(); err != nil {
+		return nil, fmt.Errorf("failed to save plugin state: %v", err)
+	}
+	report.Status = plugin.Status
+	report.CompletedAt = time.Now()
+	ps.emitEvent(EventPluginForceCleaned, slug, fmt.Sprintf("%d resources freed, %d errors", len(report.Actions), len(report.Errors)))
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": slug,
+		"actions":     len(report.Actions),
+		"errors":      len(report.Errors),
+	}).Warn("Force cleanup of plugin completed, plugin reset to installed")
+
+	return report, nil
+}
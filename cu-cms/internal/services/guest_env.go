@@ -0,0 +1,77 @@
+/*
+ * Firecracker CMS - Guest Environment
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxPluginEnvVars keeps the plugin's variables within the 32 the kernel hands
+// to init, next to its own and the CMS_ ones
+const maxPluginEnvVars = 24
+
+// guestEnvReservedPrefix marks the variables the CMS sets itself
+const guestEnvReservedPrefix = "CMS_"
+
+// validatePluginEnv checks that env can be passed to the guest init on the
+// kernel command line. Names are uppercase so they cannot collide with kernel
+// parameters; values may not contain quotes or control characters.
+func validatePluginEnv(env map[string]string) error {
+	if len(env) > maxPluginEnvVars {
+		return fmt.Errorf("env has %d variables, at most %d are allowed", len(env), maxPluginEnvVars)
+	}
+
+	for name, value := range env {
+		if !validEnvName(name) {
+			return fmt.Errorf("env name %q is invalid (use uppercase letters, digits and underscores, not starting with a digit)", name)
+		}
+		if strings.HasPrefix(name, guestEnvReservedPrefix) {
+			return fmt.Errorf("env name %q is reserved (the %s prefix is set by the CMS)", name, guestEnvReservedPrefix)
+		}
+		for _, char := range value {
+			if char < ' ' || char == '"' || char == 0x7f {
+				return fmt.Errorf("env value of %s contains a quote or control character", name)
+			}
+		}
+	}
+	return nil
+}
+
+// validEnvName reports whether name matches [A-Z_][A-Z0-9_]*
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, char := range name {
+		if !((char >= 'A' && char <= 'Z') || char == '_' || (i > 0 && char >= '0' && char <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// guestEnvArgs renders env as kernel parameters, which the kernel passes on to
+// init as environment variables. Values with spaces are quoted.
+func guestEnvArgs(env map[string]string) string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var args strings.Builder
+	for _, name := range names {
+		value := env[name]
+		if value == "" || strings.ContainsRune(value, ' ') {
+			value = `"` + value + `"`
+		}
+		fmt.Fprintf(&args, " %s=%s", name, value)
+	}
+	return args.String()
+}
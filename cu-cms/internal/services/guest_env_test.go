@@ -0,0 +1,67 @@
+/*
+ * Firecracker CMS - Guest Environment Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/config"
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+func TestValidatePluginEnv(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := 0; i <= maxPluginEnvVars; i++ {
+		tooMany[fmt.Sprintf("VAR_%d", i)] = "x"
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{"empty", nil, false},
+		{"valid", map[string]string{"API_URL": "https://api.example.com", "GREETING": "hello world"}, false},
+		{"lowercase name", map[string]string{"console": "ttyS1"}, true},
+		{"dotted name", map[string]string{"NET.IF": "x"}, true},
+		{"leading digit", map[string]string{"1VAR": "x"}, true},
+		{"reserved prefix", map[string]string{"CMS_DNS": "1.1.1.1"}, true},
+		{"quote in value", map[string]string{"NAME": `a"b`}, true},
+		{"newline in value", map[string]string{"NAME": "a\nb"}, true},
+		{"too many", tooMany, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePluginEnv(tt.env); (err != nil) != tt.wantErr {
+				t.Fatalf("validatePluginEnv = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGuestKernelArgsPassEnv(t *testing.T) {
+	vm := &VMService{config: config.NewConfig()}
+	plugin := &models.Plugin{Slug: "blog", Env: map[string]string{"MODE": "prod", "GREETING": "hello world", "EMPTY": ""}}
+
+	args := vm.guestKernelArgs("192.168.127.2", nil, plugin, false)
+	if !strings.HasSuffix(args, ` EMPTY="" GREETING="hello world" MODE=prod`) {
+		t.Fatalf("kernel args %q do not end with the sorted plugin env", args)
+	}
+}
+
+func TestValidatePluginResourcesAcceptsDefaults(t *testing.T) {
+	if err := validatePluginResources(models.PluginResources{}); err != nil {
+		t.Fatalf("zero resources (defaults) rejected: %v", err)
+	}
+	err := validatePluginResources(models.PluginResources{VcpuCount: -1})
+	if err == nil || !strings.Contains(err.Error(), "or 0 for the default") {
+		t.Fatalf("error %v does not mention the default", err)
+	}
+}
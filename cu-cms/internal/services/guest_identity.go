@@ -0,0 +1,103 @@
+/*
+ * Firecracker CMS - Guest Identity
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	firecracker "github.com/firecracker-microvm/firecracker-go-sdk"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// GuestIdentity is published to the guest through MMDS under the "cms" key
+type GuestIdentity struct {
+	PluginSlug    string    `json:"plugin_slug"`
+	PluginVersion string    `json:"plugin_version"`
+	InstanceID    string    `json:"instance_id"`
+	Hostname      string    `json:"hostname"`
+	IP            string    `json:"ip"`
+	StartedAt     time.Time `json:"started_at"`
+}
+
+// guestHostname returns the hostname of a plugin's VMs: the configured prefix
+// and the slug, without the leading or trailing hyphens hostnames forbid
+func (vm *VMService) guestHostname(slug string) string {
+	hostname := strings.Trim(vm.config.GuestHostnamePrefix+slug, "-")
+	if len(hostname) > 63 {
+		hostname = strings.TrimRight(hostname[:63], "-")
+	}
+	if hostname == "" {
+		return "plugin"
+	}
+	return hostname
+}
+
+// guestMMDSEnabled reports whether a plugin's VMs get an MMDS endpoint, for
+// their identity or the certificate of a plugin using mutual TLS
+func (vm *VMService) guestMMDSEnabled(plugin *cms_models.Plugin) bool {
+	return vm.capabilities.MMDS && (vm.config.GuestMMDS || plugin.MTLS)
+}
+
+// guestMMDSVersion prefers the session-token protected MMDS where available
+func (vm *VMService) guestMMDSVersion() firecracker.MMDSVersion {
+	if vm.capabilities.MMDSv2 {
+		return firecracker.MMDSv2
+	}
+	return firecracker.MMDSv1
+}
+
+// guestMetadata returns the MMDS contents of a VM: its identity under "cms"
+// and the certificate of a plugin using mutual TLS under "cms_tls". It is nil
+// when there is nothing to publish.
+func (vm *VMService) guestMetadata(plugin *cms_models.Plugin, instanceID, ip string, guestTLS *GuestTLS) map[string]interface{} {
+	if !vm.guestMMDSEnabled(plugin) {
+		return nil
+	}
+
+	metadata := make(map[string]interface{})
+	if vm.config.GuestMMDS {
+		metadata["cms"] = GuestIdentity{
+			PluginSlug:    plugin.Slug,
+			PluginVersion: plugin.Version,
+			InstanceID:    instanceID,
+			Hostname:      vm.guestHostname(plugin.Slug),
+			IP:            ip,
+			StartedAt:     time.Now(),
+		}
+	}
+	if guestTLS != nil {
+		metadata["cms_tls"] = guestTLS
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
+// publishGuestMetadata stores the metadata of a VM restored from a snapshot in
+// MMDS, replacing the snapshotted instance ID. Fresh VMs get theirs before
+// boot. Failures are logged; the plugin runs without metadata.
+func (vm *VMService) publishGuestMetadata(machine *firecracker.Machine, plugin *cms_models.Plugin, instanceID string, metadata map[string]interface{}) {
+	if metadata == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := machine.SetMetadata(ctx, metadata); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"instance_id": instanceID,
+			"error":       err,
+		}).Warn("Failed to publish guest metadata through MMDS")
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Firecracker CMS - Install Burst Validation
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// installBurstContexts are the validations that install a new rootfs, as
+// opposed to activation of an already installed one
+var installBurstContexts = map[string]bool{
+	"plugin_upload": true,
+	"plugin_clone":  true,
+	"plugin_update": true,
+	"plugin_import": true,
+}
+
+// installBurstEnabled reports whether a validation runs the install burst
+func (ps *PluginService) installBurstEnabled(context string) bool {
+	return ps.config.InstallBurstCount > 0 && installBurstContexts[context]
+}
+
+// runInstallBurst calls /health, and the manifest selftest if declared,
+// InstallBurstCount times spread over InstallBurstDurationMs. Every round must
+// answer healthy and match the selftest expectation, so a rootfs that only
+// passes a single check is not installed.
+func (ps *PluginService) runInstallBurst(plugin *models.Plugin, vmIP string) error {
+	rounds := ps.config.InstallBurstCount
+	interval := time.Duration(ps.config.InstallBurstDurationMs) * time.Millisecond / time.Duration(rounds)
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"rounds":      rounds,
+		"interval_ms": interval.Milliseconds(),
+	}).Info("Running install burst validation")
+
+	healthURL := pluginURL(plugin, vmIP, "/health")
+	startTime := time.Now()
+
+	var failures []string
+	for round := 1; round <= rounds; round++ {
+		if round > 1 {
+			time.Sleep(interval)
+		}
+
+		response, err := ps.makeHTTPRequest("GET", healthURL, nil)
+		if err == nil {
+			if status, _ := response["status"].(string); status != "healthy" {
+				err = fmt.Errorf("unhealthy status response: %v", response)
+			}
+		}
+		if err == nil && plugin.SelfTest != nil {
+			err = ps.burstSelfTest(plugin, vmIP)
+		}
+
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("round %d: %v", round, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d rounds failed: %s", len(failures), rounds, strings.Join(failures, "; "))
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"rounds":      rounds,
+		"duration_ms": time.Since(startTime).Milliseconds(),
+	}).Info("Install burst validation passed")
+
+	return nil
+}
+
+// burstSelfTest makes one selftest call of the burst, without the logging of runSelfTest
+func (ps *PluginService) burstSelfTest(plugin *models.Plugin, vmIP string) error {
+	method := plugin.SelfTest.Method
+	if method == "" {
+		method = "POST"
+	}
+
+	var body interface{}
+	if plugin.SelfTest.Payload != nil {
+		body = plugin.SelfTest.Payload
+	}
+
+	response, err := ps.makeHTTPRequest(method, pluginURL(plugin, vmIP, plugin.SelfTest.Endpoint), body)
+	if err != nil {
+		return fmt.Errorf("selftest: %v", err)
+	}
+	if !containsExpected(response, plugin.SelfTest.Expect) {
+		return fmt.Errorf("selftest response %v does not match expected %v", response, plugin.SelfTest.Expect)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+ * Firecracker CMS - Instance Pause/Resume Control
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/firecracker-microvm/firecracker-go-sdk/client/models"
+)
+
+// Instance states reported by the control API
+const (
+	InstanceStateRunning = "running"
+	InstanceStatePaused  = "paused"
+)
+
+// ErrInstanceNotFound is returned for instance IDs not in the pool
+var ErrInstanceNotFound = errors.New("instance not found")
+
+// ErrInstanceBusy is returned when pausing, snapshotting or probing an instance
+// that is serving executions
+var ErrInstanceBusy = errors.New("instance has in-flight executions")
+
+// InstanceState describes a VM instance after a pause or resume
+type InstanceState struct {
+	InstanceID string `json:"instance_id"`
+	PluginSlug string `json:"plugin_slug"`
+	State      string `json:"state"` // running or paused
+}
+
+// lookupInstance returns the pooled instance with the given ID, or nil
+func (vm *VMService) lookupInstance(instanceID string) *PrewarmInstance {
+	vm.poolMutex.RLock()
+	defer vm.poolMutex.RUnlock()
+
+	return vm.prewarmPool[instanceID]
+}
+
+// instanceState asks Firecracker whether an instance is paused or running
+func (vm *VMService) instanceState(instance *PrewarmInstance) (string, error) {
+	info, err := instance.Machine.DescribeInstanceInfo(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("failed to describe instance: %v", err)
+	}
+	if info.State != nil && *info.State == models.InstanceInfoStatePaused {
+		return InstanceStatePaused, nil
+	}
+	return InstanceStateRunning, nil
+}
+
+// SetInstancePaused pauses or resumes a single VM instance for debugging or
+// manual scaling and returns its new state. Instances claimed by an
+// execution are not paused; the next execution resumes a paused
+// warm instance as usual.
+func (ps *PluginService) SetInstancePaused(instanceID string, pause bool) (*InstanceState, error) {
+	instance := ps.vmService.lookupInstance(instanceID)
+	if instance == nil {
+		return nil, ErrInstanceNotFound
+	}
+
+	// Without snapshot support VMs cannot be paused at all
+	if !ps.vmService.SnapshotsSupported() {
+		return nil, ErrSnapshotsUnsupported
+	}
+
+	// Wait for snapshot or probe operations on the instance
+	instance.opMutex.Lock()
+	defer instance.opMutex.Unlock()
+
+	state, err := ps.vmService.instanceState(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &InstanceState{InstanceID: instanceID, PluginSlug: instance.PluginSlug}
+
+	switch {
+	case pause && state != InstanceStatePaused:
+		// A claim arriving after this check waits on opMutex in
+		// GetPrewarmInstance and resumes the instance once the pause is done
+		if users := instance.users.Load(); users > 0 {
+			return nil, fmt.Errorf("%w: %d running on instance %s", ErrInstanceBusy, users, instanceID)
+		}
+		if err := ps.vmService.PauseVM(instanceID); err != nil {
+			return nil, err
+		}
+		result.State = InstanceStatePaused
+	case !pause && state == InstanceStatePaused:
+		if err := ps.vmService.ResumeVM(instanceID); err != nil {
+			return nil, err
+		}
+		result.State = InstanceStateRunning
+	default:
+		result.State = state
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"instance_id": instanceID,
+		"plugin_slug": instance.PluginSlug,
+		"state":       result.State,
+	}).Info("Operator pause/resume request handled")
+
+	return result, nil
+}
@@ -0,0 +1,204 @@
+/*
+ * Firecracker CMS - VM Instance Registry
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// InstanceRecord is the persisted record of a running VM instance, used to
+// reconcile leftover processes and network resources after a hard crash
+type InstanceRecord struct {
+	InstanceID string    `json:"instance_id"`
+	PluginSlug string    `json:"plugin_slug"`
+	PID        int       `json:"pid"`
+	SocketPath string    `json:"socket_path"`
+	TapName    string    `json:"tap_name"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	Interfaces []cms_models.PluginNetworkInterface `json:"interfaces,omitempty"` // Additional NICs
+
+	EgressBlocked bool `json:"egress_blocked,omitempty"`
+	Jailed        bool `json:"jailed,omitempty"`
+}
+
+// instanceRegistryPath returns the path of the on-disk instance registry
+func (vm *VMService) instanceRegistryPath() string {
+	return filepath.Join(vm.config.DataDir, "instances.json")
+}
+
+// recordInstance persists an instance record to the registry
+func (vm *VMService) recordInstance(record InstanceRecord) {
+	vm.instanceRegistryMutex.Lock()
+	defer vm.instanceRegistryMutex.Unlock()
+
+	records := vm.readInstanceRegistryUnsafe()
+	records[record.InstanceID] = record
+
+	if err := vm.writeInstanceRegistryUnsafe(records); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"instance_id": record.InstanceID,
+			"error":       err,
+		}).Warn("Failed to persist instance record")
+	}
+}
+
+// forgetInstance removes an instance record from the registry
+func (vm *VMService) forgetInstance(instanceID string) {
+	vm.instanceRegistryMutex.Lock()
+	defer vm.instanceRegistryMutex.Unlock()
+
+	records := vm.readInstanceRegistryUnsafe()
+	if _, exists := records[instanceID]; !exists {
+		return
+	}
+	delete(records, instanceID)
+
+	if err := vm.writeInstanceRegistryUnsafe(records); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"instance_id": instanceID,
+			"error":       err,
+		}).Warn("Failed to remove instance record")
+	}
+}
+
+// readInstanceRegistryUnsafe loads the registry from disk
+// Note: Caller must hold vm.instanceRegistryMutex
+func (vm *VMService) readInstanceRegistryUnsafe() map[string]InstanceRecord {
+	records := make(map[string]InstanceRecord)
+
+	data, err := os.ReadFile(vm.instanceRegistryPath())
+	if err != nil {
+		return records
+	}
+
+	if err := json.Unmarshal(data, &records); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"file":  vm.instanceRegistryPath(),
+			"error": err,
+		}).Warn("Failed to parse instance registry, starting fresh")
+		return make(map[string]InstanceRecord)
+	}
+
+	return records
+}
+
+// writeInstanceRegistryUnsafe saves the registry to disk
+// Note: Caller must hold vm.instanceRegistryMutex
+func (vm *VMService) writeInstanceRegistryUnsafe(records map[string]InstanceRecord) error {
+	if err := os.MkdirAll(filepath.Dir(vm.instanceRegistryPath()), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(vm.instanceRegistryPath(), data, 0644)
+}
+
+// reconcileInstanceRegistry cleans up instances left behind by a previous run:
+// surviving Firecracker processes are killed, sockets and TAP devices removed
+// and IPs released
+func (vm *VMService) reconcileInstanceRegistry() error {
+	vm.instanceRegistryMutex.Lock()
+	defer vm.instanceRegistryMutex.Unlock()
+
+	records := vm.readInstanceRegistryUnsafe()
+	if len(records) == 0 {
+		vm.logger.Debug("No leftover VM instances to reconcile")
+		return nil
+	}
+
+	vm.logger.WithFields(logger.Fields{
+		"count": len(records),
+	}).Info("Reconciling leftover VM instances from previous run")
+
+	for instanceID, record := range records {
+		if record.PID > 0 && isFirecrackerProcess(record.PID) {
+			if err := syscall.Kill(record.PID, syscall.SIGKILL); err != nil {
+				vm.logger.WithFields(logger.Fields{
+					"instance_id": instanceID,
+					"pid":         record.PID,
+					"error":       err,
+				}).Warn("Failed to kill leftover Firecracker process")
+			} else {
+				vm.logger.WithFields(logger.Fields{
+					"instance_id": instanceID,
+					"plugin_slug": record.PluginSlug,
+					"pid":         record.PID,
+				}).Info("Killed leftover Firecracker process")
+			}
+		}
+
+		if record.SocketPath != "" {
+			if err := os.Remove(record.SocketPath); err != nil && !os.IsNotExist(err) {
+				vm.logger.WithFields(logger.Fields{
+					"instance_id": instanceID,
+					"socket_path": record.SocketPath,
+					"error":       err,
+				}).Warn("Failed to remove leftover socket")
+			}
+		}
+
+		if record.Jailed {
+			if err := vm.removeJail(instanceID); err != nil {
+				vm.logger.WithFields(logger.Fields{
+					"instance_id": instanceID,
+					"error":       err,
+				}).Warn("Failed to remove leftover jail directory")
+			}
+		}
+
+		if record.TapName != "" {
+			if err := vm.deleteTapInterface(record.TapName); err != nil {
+				vm.logger.WithFields(logger.Fields{
+					"instance_id": instanceID,
+					"tap_name":    record.TapName,
+					"error":       err,
+				}).Warn("Failed to remove leftover TAP interface")
+			}
+		}
+
+		if record.IP != "" {
+			vm.deallocateIP(record.IP)
+		}
+
+		vm.TeardownExtraInterfaces(record.PluginSlug, record.Interfaces)
+
+		if record.EgressBlocked {
+			vm.unblockVMEgress(record.IP, record.Interfaces)
+		}
+	}
+
+	if err := vm.writeInstanceRegistryUnsafe(make(map[string]InstanceRecord)); err != nil {
+		return fmt.Errorf("failed to reset instance registry: %v", err)
+	}
+
+	return nil
+}
+
+// isFirecrackerProcess checks that a PID is still alive and belongs to Firecracker,
+// so a recycled PID is never killed by mistake
+func isFirecrackerProcess(pid int) bool {
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(cmdline), "firecracker")
+}
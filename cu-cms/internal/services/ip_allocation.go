@@ -0,0 +1,118 @@
+/*
+ * Firecracker CMS - IP Allocation Strategies
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"math/rand"
+	"net"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/config"
+)
+
+// Host numbers handed to plugin VMs in a /24; .1 is the bridge and .255 the broadcast address
+const (
+	firstPluginHost = 2
+	lastPluginHost  = 254
+)
+
+// ipAllocationStrategy decides the order free addresses of a subnet are tried
+// in. Calls are serialized by vm.ipPoolMutex.
+type ipAllocationStrategy interface {
+	// candidates returns every address of the subnet in the order to try. hint
+	// is the address the owner last used, if any.
+	candidates(hint string) []string
+
+	// allocated records the address that was handed out
+	allocated(ip string)
+}
+
+// newIPAllocationStrategy returns the strategy configured by name for the /24 subnet
+func newIPAllocationStrategy(name, subnet string) ipAllocationStrategy {
+	switch name {
+	case config.IPAllocationRandom:
+		return randomIPStrategy{subnet: subnet}
+	case config.IPAllocationSticky:
+		return &stickyIPStrategy{sequentialIPStrategy{subnet: subnet, next: firstPluginHost}}
+	default:
+		return &sequentialIPStrategy{subnet: subnet, next: firstPluginHost}
+	}
+}
+
+// subnetHostIP returns the address of a /24 subnet with the given host number
+func subnetHostIP(cidr string, host int) string {
+	_, subnet, _ := net.ParseCIDR(cidr)
+	ip := subnet.IP.To4()
+	return net.IPv4(ip[0], ip[1], ip[2], byte(host)).String()
+}
+
+// subnetHostNumber returns the host number of an address in a /24 subnet, or 0
+func subnetHostNumber(cidr, ip string) int {
+	_, subnet, _ := net.ParseCIDR(cidr)
+	parsed := net.ParseIP(ip).To4()
+	if parsed == nil || !subnet.Contains(parsed) {
+		return 0
+	}
+	if host := int(parsed[3]); host >= firstPluginHost && host <= lastPluginHost {
+		return host
+	}
+	return 0
+}
+
+// sequentialIPStrategy walks the subnet round-robin from the last address handed out
+type sequentialIPStrategy struct {
+	subnet string
+	next   int
+}
+
+func (s *sequentialIPStrategy) candidates(hint string) []string {
+	count := lastPluginHost - firstPluginHost + 1
+	ips := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		ips = append(ips, subnetHostIP(s.subnet, firstPluginHost+(s.next-firstPluginHost+i)%count))
+	}
+	return ips
+}
+
+func (s *sequentialIPStrategy) allocated(ip string) {
+	if host := subnetHostNumber(s.subnet, ip); host != 0 {
+		s.next = host + 1
+		if s.next > lastPluginHost {
+			s.next = firstPluginHost
+		}
+	}
+}
+
+// randomIPStrategy tries free addresses in random order, so an address freed by
+// a stopped VM is unlikely to be reused while stale ARP entries linger
+type randomIPStrategy struct {
+	subnet string
+}
+
+func (s randomIPStrategy) candidates(hint string) []string {
+	count := lastPluginHost - firstPluginHost + 1
+	ips := make([]string, 0, count)
+	for _, offset := range rand.Perm(count) {
+		ips = append(ips, subnetHostIP(s.subnet, firstPluginHost+offset))
+	}
+	return ips
+}
+
+func (randomIPStrategy) allocated(ip string) {}
+
+// stickyIPStrategy hands an owner its last address again while it is free,
+// falling back to the sequential order
+type stickyIPStrategy struct {
+	sequentialIPStrategy
+}
+
+func (s *stickyIPStrategy) candidates(hint string) []string {
+	ips := s.sequentialIPStrategy.candidates(hint)
+	if subnetHostNumber(s.subnet, hint) == 0 {
+		return ips
+	}
+	return append([]string{hint}, ips...)
+}
@@ -0,0 +1,77 @@
+/*
+ * Firecracker CMS - Plugin Isolation Tiers
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// Resource ceilings of the untrusted tier
+const (
+	untrustedMaxVcpuCount  = 1
+	untrustedMaxMemSizeMib = 256
+)
+
+// isolationSettings are the concrete VM settings an isolation tier resolves to
+type isolationSettings struct {
+	Tier           string
+	Jailed         bool
+	AllowEgress    bool
+	VcpuCount      int64
+	MemSizeMib     int64
+	ReadOnlyRootfs bool
+}
+
+// effectiveIsolationTier returns the tier a plugin runs in: its declared tier
+// or the configured default, raised to the configured minimum
+func (vm *VMService) effectiveIsolationTier(plugin *cms_models.Plugin) string {
+	return vm.resolveIsolationTier(plugin.IsolationTier)
+}
+
+// resolveIsolationTier returns the tier a plugin declaring tier runs in
+func (vm *VMService) resolveIsolationTier(tier string) string {
+	if tier == "" {
+		tier = vm.config.DefaultIsolationTier
+	}
+	if cms_models.IsolationTierRank(tier) < cms_models.IsolationTierRank(vm.config.MinIsolationTier) {
+		tier = vm.config.MinIsolationTier
+	}
+	return tier
+}
+
+// resolveIsolation resolves a plugin's isolation tier against its own settings
+// and the configuration. The standard tier changes nothing.
+func (vm *VMService) resolveIsolation(plugin *cms_models.Plugin) isolationSettings {
+	settings := isolationSettings{
+		Tier:           vm.effectiveIsolationTier(plugin),
+		Jailed:         vm.config.JailerEnabled,
+		AllowEgress:    plugin.AllowEgress,
+		VcpuCount:      defaultVcpuCount,
+		MemSizeMib:     defaultMemSizeMib,
+		ReadOnlyRootfs: plugin.RootfsReadOnly(),
+	}
+	if plugin.Resources.VcpuCount > 0 {
+		settings.VcpuCount = plugin.Resources.VcpuCount
+	}
+	if plugin.Resources.MemSizeMib > 0 {
+		settings.MemSizeMib = plugin.Resources.MemSizeMib
+	}
+
+	switch settings.Tier {
+	case cms_models.IsolationTierTrusted:
+		settings.Jailed = false
+		settings.AllowEgress = true
+	case cms_models.IsolationTierUntrusted:
+		settings.Jailed = true
+		settings.AllowEgress = false
+		settings.VcpuCount = min(settings.VcpuCount, untrustedMaxVcpuCount)
+		settings.MemSizeMib = min(settings.MemSizeMib, untrustedMaxMemSizeMib)
+		settings.ReadOnlyRootfs = true
+	}
+
+	return settings
+}
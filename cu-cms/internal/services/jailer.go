@@ -0,0 +1,114 @@
+/*
+ * Firecracker CMS - Jailer Integration
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/firecracker-microvm/firecracker-go-sdk"
+)
+
+// jailedSocketPath is the API socket path inside the chroot
+const jailedSocketPath = "/firecracker.sock"
+
+// linkSnapshotFilesHandlerName names the handler linking snapshot files into the chroot
+const linkSnapshotFilesHandlerName = "cms.LinkSnapshotFiles"
+
+// jailDir returns the per-instance jail directory created by the jailer
+func (vm *VMService) jailDir(instanceID string) string {
+	return filepath.Join(vm.config.JailerChrootBaseDir, filepath.Base(vm.firecrackerPath), instanceID)
+}
+
+// jailRoot returns the chroot of an instance; paths passed to a jailed
+// Firecracker are resolved relative to it
+func (vm *VMService) jailRoot(instanceID string) string {
+	return filepath.Join(vm.jailDir(instanceID), "root")
+}
+
+// jailerConfig builds the SDK jailer configuration for an instance
+func (vm *VMService) jailerConfig(instanceID string) *firecracker.JailerConfig {
+	return &firecracker.JailerConfig{
+		ID:             instanceID,
+		UID:            firecracker.Int(vm.config.JailerUID),
+		GID:            firecracker.Int(vm.config.JailerGID),
+		NumaNode:       firecracker.Int(0),
+		ExecFile:       vm.firecrackerPath,
+		JailerBinary:   vm.config.JailerPath,
+		ChrootBaseDir:  vm.config.JailerChrootBaseDir,
+		ChrootStrategy: firecracker.NewNaiveChrootStrategy(vm.kernelPath),
+	}
+}
+
+// prepareJail removes a stale jail left by a previous instance and hands the
+// rootfs to the jailer user, which Firecracker runs as after dropping privileges
+func (vm *VMService) prepareJail(instanceID, rootfsPath string) error {
+	if err := os.RemoveAll(vm.jailDir(instanceID)); err != nil {
+		return fmt.Errorf("failed to remove stale jail: %v", err)
+	}
+
+	if err := os.MkdirAll(vm.config.JailerChrootBaseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create chroot base directory: %v", err)
+	}
+
+	if err := os.Chown(rootfsPath, vm.config.JailerUID, vm.config.JailerGID); err != nil {
+		return fmt.Errorf("failed to chown rootfs for jailer: %v", err)
+	}
+
+	return nil
+}
+
+// removeJail deletes the jail directory of a stopped instance
+func (vm *VMService) removeJail(instanceID string) error {
+	return os.RemoveAll(vm.jailDir(instanceID))
+}
+
+// linkSnapshotFilesHandler links the snapshot and rootfs into the chroot once the
+// jailer has created it, and points the snapshot load at the chroot-relative names.
+// Snapshots record drive paths as Firecracker saw them, so a snapshot taken in
+// direct mode cannot be loaded in jailer mode and vice versa.
+func (vm *VMService) linkSnapshotFilesHandler(instanceID, rootfsPath string) firecracker.Handler {
+	return firecracker.Handler{
+		Name: linkSnapshotFilesHandlerName,
+		Fn: func(ctx context.Context, m *firecracker.Machine) error {
+			root := vm.jailRoot(instanceID)
+
+			for _, hostPath := range []*string{&m.Cfg.Snapshot.MemFilePath, &m.Cfg.Snapshot.SnapshotPath} {
+				name := filepath.Base(*hostPath)
+				if err := os.Link(*hostPath, filepath.Join(root, name)); err != nil {
+					return fmt.Errorf("failed to link %s into jail: %v", name, err)
+				}
+				*hostPath = name
+			}
+
+			if err := os.Link(rootfsPath, filepath.Join(root, filepath.Base(rootfsPath))); err != nil {
+				return fmt.Errorf("failed to link rootfs into jail: %v", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// exportJailedSnapshot moves snapshot files written inside an instance's chroot
+// to their destination paths on the host
+func (vm *VMService) exportJailedSnapshot(instanceID string, hostPaths ...string) error {
+	for _, hostPath := range hostPaths {
+		if err := os.MkdirAll(filepath.Dir(hostPath), 0755); err != nil {
+			return err
+		}
+
+		jailedPath := filepath.Join(vm.jailRoot(instanceID), filepath.Base(hostPath))
+		if err := os.Rename(jailedPath, hostPath); err != nil {
+			return fmt.Errorf("failed to move %s out of jail: %v", filepath.Base(hostPath), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+ * Firecracker CMS - Kernel Command Line Limit
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// How many of the largest parameters an overlong command line error lists
+const cmdlineReportedParams = 5
+
+// Parameters Firecracker appends to the command line: the root device, and on
+// x86 one virtio_mmio.device entry per virtio device (longest IRQ shown)
+const (
+	firecrackerRootParams = " root=/dev/vda ro"
+	firecrackerMMIODevice = " virtio_mmio.device=4K@0xd0000000:10"
+)
+
+// firecrackerCmdlineBytes returns how many bytes Firecracker appends to the
+// command line of a VM with devices virtio devices
+func firecrackerCmdlineBytes(devices int) int {
+	return len(firecrackerRootParams) + devices*len(firecrackerMMIODevice)
+}
+
+// checkKernelCmdline fails when a kernel command line, plus what Firecracker
+// appends for devices virtio devices, does not fit maxBytes, which counts the
+// terminating NUL. The kernel would silently truncate it, so the error names
+// the parameters taking the most space instead.
+func checkKernelCmdline(args string, devices, maxBytes int) error {
+	length := len(args) + firecrackerCmdlineBytes(devices)
+	if length < maxBytes {
+		return nil
+	}
+
+	params := strings.Fields(args)
+	sort.SliceStable(params, func(i, j int) bool {
+		return len(params[i]) > len(params[j])
+	})
+
+	largest := make([]string, 0, cmdlineReportedParams)
+	for _, param := range params {
+		if len(largest) == cmdlineReportedParams {
+			break
+		}
+		name, _, _ := strings.Cut(param, "=")
+		largest = append(largest, fmt.Sprintf("%s (%d bytes)", name, len(param)))
+	}
+
+	return fmt.Errorf("kernel command line is %d bytes with the %d bytes Firecracker appends for %d devices, it must stay below the %d byte limit (CMS_KERNEL_CMDLINE_MAX_BYTES); largest parameters: %s. "+
+		"Fewer extra interfaces or DNS servers shorten it; pass large data to the guest through MMDS instead of the command line",
+		length, firecrackerCmdlineBytes(devices), devices, maxBytes, strings.Join(largest, ", "))
+}
@@ -0,0 +1,57 @@
+/*
+ * Firecracker CMS - Kernel Command Line Limit Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckKernelCmdline(t *testing.T) {
+	const maxBytes = 512
+	appended := firecrackerCmdlineBytes(3)
+
+	tests := []struct {
+		name    string
+		length  int
+		devices int
+		wantErr bool
+	}{
+		{"short", 100, 3, false},
+		{"fits with appended parameters", maxBytes - appended - 1, 3, false},
+		{"reaches limit with appended parameters", maxBytes - appended, 3, true},
+		{"fits without devices", maxBytes - appended, 0, false},
+		{"over limit", maxBytes, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := "ip=" + strings.Repeat("a", tt.length-len("ip="))
+			err := checkKernelCmdline(args, tt.devices, maxBytes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkKernelCmdline(%d bytes, %d devices) = %v, want error %v", tt.length, tt.devices, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFirecrackerCmdlineBytes(t *testing.T) {
+	// The longest entries Firecracker appends on x86
+	root := len(" root=/dev/vda rw")
+	device := len(" virtio_mmio.device=4K@0xd0001000:11")
+	if got := firecrackerCmdlineBytes(2); got < root+2*device {
+		t.Fatalf("firecrackerCmdlineBytes(2) = %d, want at least %d", got, root+2*device)
+	}
+}
+
+func TestCheckKernelCmdlineNamesLargestParameters(t *testing.T) {
+	args := "console=ttyS0 nameserver=" + strings.Repeat("1", 600)
+	err := checkKernelCmdline(args, 2, 512)
+	if err == nil || !strings.Contains(err.Error(), "nameserver (") {
+		t.Fatalf("error %v does not name the largest parameter", err)
+	}
+}
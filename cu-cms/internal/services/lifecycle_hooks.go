@@ -0,0 +1,163 @@
+/*
+ * Firecracker CMS - Plugin Lifecycle Hooks
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// callLifecycleHook sends a lifecycle hook request to a plugin VM
+func (ps *PluginService) callLifecycleHook(plugin *models.Plugin, hook *models.PluginLifecycleHook, name, vmIP string, payload map[string]interface{}) error {
+	method := hook.Method
+	if method == "" {
+		method = "POST"
+	}
+
+	timeout := pluginRequestTimeout
+	if hook.TimeoutMs > 0 {
+		timeout = time.Duration(hook.TimeoutMs) * time.Millisecond
+	}
+
+	hookURL := pluginURL(plugin, vmIP, hook.Endpoint)
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"hook":        name,
+		"url":         hookURL,
+		"method":      method,
+	}).Info("Calling plugin lifecycle hook")
+
+	payload["hook"] = name
+	_, err := ps.makeHTTPRequestUntil(method, hookURL, payload, time.Now().Add(timeout))
+	return err
+}
+
+// runInstallHook calls the install hook of a validated plugin on its validation
+// VM. A failure fails the upload like a failed health validation.
+// Note: Caller must hold ps.mutex.Lock()
+func (ps *PluginService) runInstallHook(plugin *models.Plugin, instanceID, vmIP, previousVersion string) error {
+	if plugin.Install == nil {
+		return nil
+	}
+
+	payload := map[string]interface{}{"version": plugin.Version}
+	if previousVersion != "" {
+		payload["previous_version"] = previousVersion
+	}
+
+	if err := ps.callLifecycleHook(plugin, plugin.Install, "install", vmIP, payload); err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"error":       err,
+		}).Error("Plugin install hook failed")
+
+		ps.cleanupPluginVM(plugin.Slug, instanceID, "plugin_install_hook_failure")
+
+		plugin.Status = "failed"
+		plugin.UpdateHealth(models.HealthStatusUnhealthy, fmt.Sprintf("install hook failed: %v", err), 0)
+		ps.emitEvent(EventPluginFailed, plugin.Slug, plugin.Health.Message)
+		if saveErr := ps.savePluginsUnsafe(); saveErr != nil {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"error":       saveErr,
+			}).Error("Failed to save plugin failed state")
+		}
+
+		return fmt.Errorf("plugin install hook failed: %v", err)
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+	}).Info("Plugin install hook completed")
+
+	return nil
+}
+
+// cancelHookTimeout bounds a cancel hook without its own timeout_ms, the
+// caller of the timed-out execution is still waiting
+const cancelHookTimeout = 2 * time.Second
+
+// runCancelHook asks a plugin to abort an action the CMS gave up waiting for.
+// A failure means the guest may still be busy with it.
+func (ps *PluginService) runCancelHook(plugin *models.Plugin, vmIP, actionName, actionHook string) error {
+	hook := *plugin.Cancel
+	if hook.TimeoutMs == 0 {
+		hook.TimeoutMs = int(cancelHookTimeout.Milliseconds())
+	}
+
+	payload := map[string]interface{}{
+		"action":      actionName,
+		"action_hook": actionHook,
+	}
+
+	if err := ps.callLifecycleHook(plugin, &hook, "cancel", vmIP, payload); err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"action":      actionName,
+			"error":       err,
+		}).Warn("Plugin cancel hook failed, the action may still be running")
+		return err
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"action":      actionName,
+	}).Info("Plugin cancelled timed-out action")
+
+	return nil
+}
+
+// runUninstallHook boots a short-lived VM of a plugin being deleted and calls
+// its uninstall hook. Failures are logged and never block the delete.
+// Note: Caller must hold ps.mutex.Lock()
+func (ps *PluginService) runUninstallHook(plugin *models.Plugin) {
+	unlockStart := ps.startLocks.lock(plugin.Slug)
+	defer unlockStart()
+
+	fail := func(err error) {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"error":       err,
+		}).Warn("Plugin uninstall hook failed, deleting plugin anyway")
+	}
+
+	// The uninstall VM takes over the plugin's address, so stop the warm instance
+	if instance := ps.vmService.PeekPrewarmInstance(plugin.Slug); instance != nil {
+		ps.cleanupPluginVM(plugin.Slug, instance.InstanceID, "plugin_uninstall")
+	}
+
+	instanceID := ps.vmService.NewInstanceID(plugin.Slug)
+	if err := ps.vmService.StartVM(instanceID, plugin); err != nil {
+		fail(fmt.Errorf("failed to start VM: %v", err))
+		return
+	}
+	defer ps.cleanupPluginVM(plugin.Slug, instanceID, "plugin_uninstall")
+
+	vmIP, exists := ps.vmService.GetVMIP(instanceID)
+	if !exists {
+		fail(fmt.Errorf("failed to get VM IP"))
+		return
+	}
+
+	if err := ps.healthCheckWithRetries(plugin, vmIP, 500*time.Millisecond); err != nil {
+		fail(err)
+		return
+	}
+
+	if err := ps.callLifecycleHook(plugin, plugin.Uninstall, "uninstall", vmIP, map[string]interface{}{"version": plugin.Version}); err != nil {
+		fail(err)
+		return
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+	}).Info("Plugin uninstall hook completed")
+}
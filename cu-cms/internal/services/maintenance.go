@@ -0,0 +1,68 @@
+/*
+ * Firecracker CMS - Maintenance Mode
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// ErrMaintenanceMode is returned for operations refused during maintenance
+var ErrMaintenanceMode = errors.New("CMS is in maintenance mode")
+
+// MaintenanceStatus represents the maintenance mode state
+type MaintenanceStatus struct {
+	Enabled bool       `json:"enabled"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// SetMaintenanceMode enables or disables maintenance mode. While enabled, warm
+// instances keep serving but are neither evicted, recovered nor created.
+func (vm *VMService) SetMaintenanceMode(enabled bool) MaintenanceStatus {
+	vm.maintenanceMutex.Lock()
+	defer vm.maintenanceMutex.Unlock()
+
+	if enabled != vm.maintenanceEnabled {
+		vm.maintenanceEnabled = enabled
+		vm.maintenanceSince = time.Now()
+
+		vm.logger.WithFields(logger.Fields{
+			"enabled": enabled,
+		}).Warn("Maintenance mode changed")
+	}
+
+	return vm.maintenanceStatusUnsafe()
+}
+
+// MaintenanceStatus returns the current maintenance mode state
+func (vm *VMService) MaintenanceStatus() MaintenanceStatus {
+	vm.maintenanceMutex.RLock()
+	defer vm.maintenanceMutex.RUnlock()
+
+	return vm.maintenanceStatusUnsafe()
+}
+
+// InMaintenanceMode reports whether maintenance mode is enabled
+func (vm *VMService) InMaintenanceMode() bool {
+	vm.maintenanceMutex.RLock()
+	defer vm.maintenanceMutex.RUnlock()
+
+	return vm.maintenanceEnabled
+}
+
+// maintenanceStatusUnsafe builds the maintenance status
+// Note: Caller must hold vm.maintenanceMutex
+func (vm *VMService) maintenanceStatusUnsafe() MaintenanceStatus {
+	status := MaintenanceStatus{Enabled: vm.maintenanceEnabled}
+	if vm.maintenanceEnabled {
+		since := vm.maintenanceSince
+		status.Since = &since
+	}
+	return status
+}
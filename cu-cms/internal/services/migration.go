@@ -0,0 +1,442 @@
+/*
+ * Firecracker CMS - State Export and Import
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// Export archive layout
+const (
+	exportRegistryName = "plugins.json"
+	exportRootfsDir    = "rootfs"
+	exportSnapshotsDir = "snapshots"
+)
+
+// Import result statuses
+const (
+	ImportStatusImported = "imported"
+	ImportStatusSkipped  = "skipped"
+	ImportStatusFailed   = "failed"
+)
+
+// ExportState streams a gzipped tarball of the plugin registry, rootfs images and
+// snapshots to w. Rootfs images of running plugins are copied live, so deactivate
+// plugins first for a consistent export.
+func (ps *PluginService) ExportState(w io.Writer) error {
+	ps.mutex.RLock()
+	registry, err := json.MarshalIndent(ps.plugins, "", "  ")
+	rootfsPaths := make(map[string]string, len(ps.plugins))
+	rootfsTypes := make(map[string]string, len(ps.plugins))
+	for slug, plugin := range ps.plugins {
+		rootfsPaths[slug] = plugin.RootfsPath
+		rootfsTypes[slug] = plugin.EffectiveRootfsType()
+	}
+	ps.mutex.RUnlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to serialize plugin registry: %v", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	header := &tar.Header{
+		Name:    exportRegistryName,
+		Mode:    0644,
+		Size:    int64(len(registry)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := tw.Write(registry); err != nil {
+		return err
+	}
+
+	slugs := make([]string, 0, len(rootfsPaths))
+	for slug := range rootfsPaths {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	for _, slug := range slugs {
+		if err := addFileToTar(tw, rootfsPaths[slug], filepath.Join(exportRootfsDir, slug+"."+rootfsTypes[slug])); err != nil {
+			return fmt.Errorf("failed to export rootfs of %s: %v", slug, err)
+		}
+
+		snapshotDir := filepath.Join(ps.vmService.snapshotDir, slug)
+		entries, err := os.ReadDir(snapshotDir)
+		if err != nil {
+			continue // No snapshot for this plugin
+		}
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			name := filepath.Join(exportSnapshotsDir, slug, entry.Name())
+			if err := addFileToTar(tw, filepath.Join(snapshotDir, entry.Name()), name); err != nil {
+				return fmt.Errorf("failed to export snapshot of %s: %v", slug, err)
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_count": len(slugs),
+	}).Info("Exported plugin state")
+
+	return nil
+}
+
+// ImportState ingests a tarball produced by ExportState. Plugins that already
+// exist are skipped; every other plugin is re-validated in a fresh VM, gets a
+// new IP if its old one is taken on this host, and is re-activated if it was
+// active on the source host.
+func (ps *PluginService) ImportState(r io.Reader) ([]models.ImportResult, error) {
+	// Stage under the data directory so files can be renamed into place
+	stagingDir, err := os.MkdirTemp(ps.config.DataDir, "import-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %v", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	if err := extractImportArchive(r, stagingDir); err != nil {
+		return nil, fmt.Errorf("failed to extract archive: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(stagingDir, exportRegistryName))
+	if err != nil {
+		return nil, fmt.Errorf("archive does not contain %s", exportRegistryName)
+	}
+
+	var imported map[string]*models.Plugin
+	if err := json.Unmarshal(data, &imported); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", exportRegistryName, err)
+	}
+
+	slugs := make([]string, 0, len(imported))
+	for slug := range imported {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	// Plugins are validated in VMs one by one without holding the registry lock
+	results := make([]models.ImportResult, 0, len(slugs))
+	for _, slug := range slugs {
+		result := ps.importPlugin(stagingDir, slug, imported[slug])
+
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug":   slug,
+			"status":        result.Status,
+			"ip_reassigned": result.IPReassigned,
+			"activated":     result.Activated,
+			"error":         result.Error,
+		}).Info("Processed imported plugin")
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// importPlugin installs a single plugin from the staging directory. It is
+// registered only once it passed validation; a failed import removes its
+// rootfs and snapshot and releases the IPs it reserved.
+func (ps *PluginService) importPlugin(stagingDir, slug string, plugin *models.Plugin) models.ImportResult {
+	result := models.ImportResult{PluginSlug: slug}
+	fail := func(message string) models.ImportResult {
+		result.Status = ImportStatusFailed
+		result.Error = message
+		return result
+	}
+
+	if plugin == nil || plugin.Slug != slug || !isSafeSlug(slug) || !models.IsValidRootfsType(plugin.RootfsType) {
+		return fail("invalid plugin entry")
+	}
+
+	// Uploads and clones of the same slug write the same rootfs path
+	unlockUpload, err := ps.uploadLocks.lock(slug, time.Duration(ps.config.UploadLockTimeoutSec)*time.Second)
+	if err != nil {
+		return fail(err.Error())
+	}
+	defer unlockUpload()
+
+	ps.mutex.RLock()
+	_, exists := ps.plugins[slug]
+	ps.mutex.RUnlock()
+	if exists {
+		result.Status = ImportStatusSkipped
+		result.Error = "plugin already exists"
+		return result
+	}
+
+	// Refuse plugins that can never run on this host, like uploads do
+	if unsupported := ps.hostSupportErrors(plugin.Requires, plugin.MTLS, plugin.IsolationTier); len(unsupported) > 0 {
+		return fail(unsupported.Error())
+	}
+	if err := validatePluginEnv(plugin.Env); err != nil {
+		return fail(err.Error())
+	}
+
+	rootfsType := plugin.EffectiveRootfsType()
+	stagedRootfs := filepath.Join(stagingDir, exportRootfsDir, slug+"."+rootfsType)
+	if err := ps.validateRootfsSize(stagedRootfs, rootfsType); err != nil {
+		return fail(err.Error())
+	}
+
+	rootfsPath := filepath.Join(ps.config.DataDir, "plugins", slug+"."+rootfsType)
+	if err := os.MkdirAll(filepath.Dir(rootfsPath), 0755); err != nil {
+		return fail(err.Error())
+	}
+	if err := os.Rename(stagedRootfs, rootfsPath); err != nil {
+		return fail(fmt.Sprintf("failed to install rootfs: %v", err))
+	}
+
+	// Keep the source IP unless another plugin on this host already uses it.
+	// Snapshots have the guest IP baked in, so they are only kept with the IP.
+	var reservedIPs []string
+	ps.mutex.RLock()
+	if plugin.AssignedIP != "" {
+		if ps.ipAssignedUnsafe(plugin.AssignedIP) || !ps.vmService.ReserveIP(plugin.AssignedIP) {
+			plugin.AssignedIP = ""
+			result.IPReassigned = true
+		} else {
+			reservedIPs = append(reservedIPs, plugin.AssignedIP)
+		}
+	}
+	for i, iface := range plugin.NetworkInterfaces {
+		if iface.AssignedIP == "" {
+			continue
+		}
+		if ps.ipAssignedUnsafe(iface.AssignedIP) || !ps.vmService.ReserveExtraIP(iface.AssignedIP) {
+			plugin.NetworkInterfaces[i].AssignedIP = ""
+			result.IPReassigned = true
+		} else {
+			reservedIPs = append(reservedIPs, iface.AssignedIP)
+		}
+	}
+	ps.mutex.RUnlock()
+
+	snapshotDir := filepath.Join(ps.vmService.snapshotDir, slug)
+	stagedSnapshot := filepath.Join(stagingDir, exportSnapshotsDir, slug)
+	if _, err := os.Stat(stagedSnapshot); err == nil && !result.IPReassigned {
+		os.RemoveAll(snapshotDir)
+		if err := os.Rename(stagedSnapshot, snapshotDir); err != nil {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": slug,
+				"error":       err,
+			}).Warn("Failed to import snapshot, it will be recreated on activation")
+		}
+	}
+
+	// rollback undoes the installation; IPs of a VM that was started are
+	// already released by stopping it
+	rollback := func(vmStarted bool) {
+		os.Remove(rootfsPath)
+		os.RemoveAll(snapshotDir)
+		if !vmStarted {
+			for _, ip := range reservedIPs {
+				ps.vmService.deallocateIP(ip)
+			}
+		}
+	}
+
+	wasActive := plugin.IsActive()
+
+	plugin.RootfsPath = rootfsPath
+	plugin.RootfsHash = "" // Imported images are plain copies, not shared blobs
+	plugin.Status = models.PluginStatusInstalled
+	plugin.Health = models.PluginHealth{Status: models.HealthStatusUnknown}
+	plugin.UpdatedAt = time.Now()
+
+	if vmStarted, err := ps.validateImportedPlugin(plugin); err != nil {
+		rollback(vmStarted)
+		return fail(err.Error())
+	}
+
+	// The upload lock kept uploads and clones of the slug out, so it is still free
+	ps.mutex.Lock()
+	ps.plugins[slug] = plugin
+	if wasActive {
+		plugin.Status = models.PluginStatusActive
+	}
+	if err := ps.savePluginsUnsafe(); err != nil {
+		delete(ps.plugins, slug)
+		ps.mutex.Unlock()
+		rollback(true)
+		return fail(fmt.Sprintf("failed to save plugin: %v", err))
+	}
+
+	// Restore under the registry lock, like health-driven recovery
+	if wasActive {
+		if ps.needsWarmInstanceUnsafe(plugin) {
+			ps.restoreWarmInstance(plugin)
+		}
+		result.Activated = true
+	}
+	ps.mutex.Unlock()
+
+	result.Status = ImportStatusImported
+	return result
+}
+
+// validateImportedPlugin boots a plugin in a fresh VM, validates its health
+// and records its network assignment. It reports whether a VM was started.
+func (ps *PluginService) validateImportedPlugin(plugin *models.Plugin) (bool, error) {
+	unlockStart := ps.startLocks.lock(plugin.Slug)
+	defer unlockStart()
+
+	instanceID := ps.vmService.NewInstanceID(plugin.Slug)
+
+	if err := ps.vmService.StartVM(instanceID, plugin); err != nil {
+		return false, fmt.Errorf("failed to start VM for validation: %v", err)
+	}
+
+	vmIP, exists := ps.vmService.GetVMIP(instanceID)
+	if !exists {
+		ps.cleanupPluginVM(plugin.Slug, instanceID, "plugin_import_ip_failure")
+		return true, fmt.Errorf("failed to get VM IP for validation")
+	}
+
+	if err := ps.checkPluginHealth(plugin, instanceID, vmIP, "plugin_import"); err != nil {
+		return true, err
+	}
+
+	plugin.AssignedIP = vmIP
+	plugin.TapDevice = ps.vmService.GetTapNameForPlugin(plugin.Slug)
+	plugin.NetworkInterfaces = ps.vmService.GetVMInterfaces(instanceID)
+	plugin.UpdatedAt = time.Now()
+
+	ps.cleanupPluginVM(plugin.Slug, instanceID, "plugin_import_success")
+
+	return true, nil
+}
+
+// ipAssignedUnsafe reports whether a registered plugin already holds the IP
+// Note: Caller must hold ps.mutex
+func (ps *PluginService) ipAssignedUnsafe(ip string) bool {
+	for _, plugin := range ps.plugins {
+		if plugin.AssignedIP == ip {
+			return true
+		}
+		for _, iface := range plugin.NetworkInterfaces {
+			if iface.AssignedIP == ip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isSafeSlug rejects slugs that could escape the data directories
+func isSafeSlug(slug string) bool {
+	return slug != "" && slug != "." && slug != ".." && !strings.ContainsAny(slug, `/\`)
+}
+
+// addFileToTar streams a file into the archive under the given name
+func addFileToTar(tw *tar.Writer, path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(name)
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// extractImportArchive streams a gzipped tarball into destDir, accepting only
+// regular files within the export layout
+func extractImportArchive(r io.Reader, destDir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			return fmt.Errorf("unsupported entry %s", header.Name)
+		}
+
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid entry path %s", header.Name)
+		}
+
+		parts := strings.Split(name, string(filepath.Separator))
+		validLayout := name == exportRegistryName ||
+			(len(parts) == 2 && parts[0] == exportRootfsDir) ||
+			(len(parts) == 3 && parts[0] == exportSnapshotsDir)
+		if !validLayout {
+			return fmt.Errorf("unexpected entry %s", header.Name)
+		}
+
+		target := filepath.Join(destDir, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, tr); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+}
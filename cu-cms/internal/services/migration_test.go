@@ -0,0 +1,114 @@
+/*
+ * Firecracker CMS - Migration Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// newTestPluginService returns a PluginService with an empty registry on top
+// of newTestVMService, storing data under a temporary directory
+func newTestPluginService(t *testing.T) *PluginService {
+	vm := newTestVMService()
+	vm.config.DataDir = t.TempDir()
+	vm.snapshotDir = filepath.Join(vm.config.DataDir, "snapshots")
+	vm.ipPool = make(map[string]bool)
+	return &PluginService{
+		config:      vm.config,
+		logger:      logger.GetDefault(),
+		vmService:   vm,
+		plugins:     make(map[string]*models.Plugin),
+		startLocks:  newPluginStartLocks(),
+		uploadLocks: newPluginUploadLocks(),
+	}
+}
+
+// stageTestRootfs writes a rootfs of size bytes into an import staging directory
+func stageTestRootfs(t *testing.T, stagingDir, slug string, size int64) string {
+	path := filepath.Join(stagingDir, exportRootfsDir, slug+"."+models.RootfsTypeExt4)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, size); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportPluginSkipsExisting(t *testing.T) {
+	ps := newTestPluginService(t)
+	ps.plugins["blog"] = &models.Plugin{Slug: "blog"}
+
+	result := ps.importPlugin(t.TempDir(), "blog", &models.Plugin{Slug: "blog", RootfsType: models.RootfsTypeExt4})
+	if result.Status != ImportStatusSkipped {
+		t.Fatalf("status = %q, want %q", result.Status, ImportStatusSkipped)
+	}
+}
+
+func TestImportPluginRejectsInvalidRootfs(t *testing.T) {
+	ps := newTestPluginService(t)
+	stagingDir := t.TempDir()
+	staged := stageTestRootfs(t, stagingDir, "blog", 1024)
+
+	plugin := &models.Plugin{Slug: "blog", RootfsType: models.RootfsTypeExt4, AssignedIP: "192.168.127.10"}
+	result := ps.importPlugin(stagingDir, "blog", plugin)
+	if result.Status != ImportStatusFailed {
+		t.Fatalf("status = %q, want %q", result.Status, ImportStatusFailed)
+	}
+	if _, registered := ps.plugins["blog"]; registered {
+		t.Fatalf("plugin registered despite the failed import")
+	}
+	if _, err := os.Stat(staged); err != nil {
+		t.Fatalf("staged rootfs was moved: %v", err)
+	}
+	if ps.vmService.ipPool["192.168.127.10"] {
+		t.Fatalf("IP reserved for a failed import")
+	}
+}
+
+func TestImportPluginRejectsMismatchedEntry(t *testing.T) {
+	ps := newTestPluginService(t)
+
+	result := ps.importPlugin(t.TempDir(), "blog", &models.Plugin{Slug: "shop", RootfsType: models.RootfsTypeExt4})
+	if result.Status != ImportStatusFailed {
+		t.Fatalf("status = %q, want %q", result.Status, ImportStatusFailed)
+	}
+}
+
+func TestImportPluginRejectsUnsupportedPlugin(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin *models.Plugin
+	}{
+		{"missing capability", &models.Plugin{Slug: "blog", RootfsType: models.RootfsTypeExt4, Requires: []string{"snapshots"}}},
+		{"mutual TLS disabled", &models.Plugin{Slug: "blog", RootfsType: models.RootfsTypeExt4, MTLS: true}},
+		{"untrusted without jailer", &models.Plugin{Slug: "blog", RootfsType: models.RootfsTypeExt4, IsolationTier: models.IsolationTierUntrusted}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := newTestPluginService(t)
+			stagingDir := t.TempDir()
+			staged := stageTestRootfs(t, stagingDir, "blog", int64(ps.config.MinRootfsSizeMB)*1024*1024)
+
+			result := ps.importPlugin(stagingDir, "blog", tt.plugin)
+			if result.Status != ImportStatusFailed {
+				t.Fatalf("status = %q, want %q", result.Status, ImportStatusFailed)
+			}
+			if _, err := os.Stat(staged); err != nil {
+				t.Fatalf("staged rootfs was moved: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * Firecracker CMS - Outbound NAT
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// pluginSubnet is the bridge subnet plugin VMs are addressed from
+const pluginSubnet = "192.168.127.0/24"
+
+// masqueradeRule returns the iptables rule spec masquerading the plugin subnet
+func (vm *VMService) masqueradeRule() []string {
+	return []string{"POSTROUTING", "-s", pluginSubnet, "-o", vm.config.NATInterface, "-j", "MASQUERADE"}
+}
+
+// egressBlockRule returns the iptables rule spec dropping forwarded traffic from a VM
+func (vm *VMService) egressBlockRule(ip string) []string {
+	return []string{"FORWARD", "-s", ip, "-o", vm.config.NATInterface, "-j", "DROP"}
+}
+
+// setupNAT enables IP forwarding and programs the subnet MASQUERADE rule
+func (vm *VMService) setupNAT() error {
+	if !vm.config.NATEnabled {
+		return nil
+	}
+
+	if err := os.WriteFile("/proc/sys/net/ipv4/ip_forward", []byte("1"), 0644); err != nil {
+		return fmt.Errorf("failed to enable IP forwarding: %v", err)
+	}
+
+	if err := ensureIptablesRule("nat", vm.masqueradeRule()); err != nil {
+		return fmt.Errorf("failed to add MASQUERADE rule: %v", err)
+	}
+
+	vm.logger.WithFields(logger.Fields{
+		"subnet":    pluginSubnet,
+		"interface": vm.config.NATInterface,
+	}).Info("Outbound NAT enabled for plugin subnet")
+
+	return nil
+}
+
+// teardownNAT removes the subnet MASQUERADE rule
+func (vm *VMService) teardownNAT() {
+	if !vm.config.NATEnabled {
+		return
+	}
+
+	if err := deleteIptablesRule("nat", vm.masqueradeRule()); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"error": err,
+		}).Warn("Failed to remove MASQUERADE rule")
+		return
+	}
+
+	vm.logger.Info("Outbound NAT disabled for plugin subnet")
+}
+
+// blockEgress prevents a VM without egress permission from using the NAT
+func (vm *VMService) blockEgress(ip string) error {
+	if !vm.config.NATEnabled || ip == "" {
+		return nil
+	}
+	return ensureIptablesRule("filter", vm.egressBlockRule(ip))
+}
+
+// blockVMEgress blocks egress of a VM's eth0 address and its additional interfaces
+func (vm *VMService) blockVMEgress(ip string, extra []cms_models.PluginNetworkInterface) error {
+	if err := vm.blockEgress(ip); err != nil {
+		return err
+	}
+	for _, iface := range extra {
+		if err := vm.blockEgress(iface.AssignedIP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unblockVMEgress removes the egress blocks set by blockVMEgress
+func (vm *VMService) unblockVMEgress(ip string, extra []cms_models.PluginNetworkInterface) {
+	vm.unblockEgress(ip)
+	for _, iface := range extra {
+		vm.unblockEgress(iface.AssignedIP)
+	}
+}
+
+// unblockEgress removes the egress block for a VM
+func (vm *VMService) unblockEgress(ip string) {
+	if !vm.config.NATEnabled || ip == "" {
+		return
+	}
+
+	if err := deleteIptablesRule("filter", vm.egressBlockRule(ip)); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"ip":    ip,
+			"error": err,
+		}).Warn("Failed to remove egress block rule")
+	}
+}
+
+// ensureIptablesRule inserts a rule unless an identical one already exists
+func ensureIptablesRule(table string, rule []string) error {
+	check := append([]string{"-t", table, "-C"}, rule...)
+	if exec.Command("iptables", check...).Run() == nil {
+		return nil
+	}
+
+	insert := append([]string{"-t", table, "-I"}, rule...)
+	if output, err := exec.Command("iptables", insert...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, output)
+	}
+	return nil
+}
+
+// deleteIptablesRule removes a rule if it exists
+func deleteIptablesRule(table string, rule []string) error {
+	check := append([]string{"-t", table, "-C"}, rule...)
+	if exec.Command("iptables", check...).Run() != nil {
+		return nil // Rule not present
+	}
+
+	del := append([]string{"-t", table, "-D"}, rule...)
+	if output, err := exec.Command("iptables", del...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, output)
+	}
+	return nil
+}
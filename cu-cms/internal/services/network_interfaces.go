@@ -0,0 +1,218 @@
+/*
+ * Firecracker CMS - Additional Plugin Network Interfaces
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"crypto/md5"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+	"github.com/firecracker-microvm/firecracker-go-sdk"
+)
+
+// extraInterfaceTapName returns the TAP name of an additional plugin interface.
+// It only depends on the plugin and interface name, so snapshots keep matching.
+func extraInterfaceTapName(pluginSlug, name string) string {
+	hash := md5.Sum([]byte(extraInterfaceOwner(pluginSlug, name)))
+	return fmt.Sprintf("tap-%x", hash[:4])
+}
+
+// extraInterfaceOwner identifies an additional interface for IP allocation
+func extraInterfaceOwner(pluginSlug, name string) string {
+	return pluginSlug + "/" + name
+}
+
+// extraInterfaceMAC derives a locally administered MAC from the interface IP
+func extraInterfaceMAC(ip string) string {
+	parsed := net.ParseIP(ip).To4()
+	if parsed == nil {
+		return ""
+	}
+	return fmt.Sprintf("02:FC:%02X:%02X:%02X:%02X", parsed[0], parsed[1], parsed[2], parsed[3])
+}
+
+// extraSubnetContains reports whether ip is in the additional interface subnet
+func (vm *VMService) extraSubnetContains(ip string) bool {
+	_, subnet, err := net.ParseCIDR(vm.config.ExtraInterfaceSubnet)
+	parsed := net.ParseIP(ip)
+	return err == nil && parsed != nil && subnet.Contains(parsed)
+}
+
+// dropLegacyInterfaceAddresses clears additional interface addresses from the
+// plugin subnet, where they were allocated before interfaces got their own
+// subnet, and reports whether any were cleared. New ones are allocated on the
+// next start.
+func (vm *VMService) dropLegacyInterfaceAddresses(plugin *cms_models.Plugin) bool {
+	dropped := false
+	for i, iface := range plugin.NetworkInterfaces {
+		if iface.AssignedIP != "" && !vm.extraSubnetContains(iface.AssignedIP) {
+			plugin.NetworkInterfaces[i].AssignedIP = ""
+			plugin.NetworkInterfaces[i].MacAddress = ""
+			dropped = true
+		}
+	}
+	return dropped
+}
+
+// setupExtraInterfaces prepares a TAP device on the additional interface
+// bridge, IP and MAC for every additional interface of a plugin, reusing the
+// assignments persisted on the plugin
+func (vm *VMService) setupExtraInterfaces(plugin *cms_models.Plugin) ([]cms_models.PluginNetworkInterface, error) {
+	bridge := vm.config.ExtraInterfaceBridge
+	assigned := make([]cms_models.PluginNetworkInterface, 0, len(plugin.NetworkInterfaces))
+	for _, declared := range plugin.NetworkInterfaces {
+		iface := declared
+		iface.TapDevice = extraInterfaceTapName(plugin.Slug, iface.Name)
+
+		if vm.tapExists(iface.TapDevice) {
+			// TAPs created before interfaces got their own bridge are moved
+			if err := runIP("link", "set", iface.TapDevice, "master", bridge); err != nil {
+				vm.releaseExtraInterfaces(plugin, assigned)
+				return nil, fmt.Errorf("failed to add TAP %s to bridge %s: %v", iface.TapDevice, bridge, err)
+			}
+			if err := vm.ensureTapUp(iface.TapDevice); err != nil {
+				vm.releaseExtraInterfaces(plugin, assigned)
+				return nil, err
+			}
+		} else if err := vm.recreateTapInterface(iface.TapDevice, bridge); err != nil {
+			vm.releaseExtraInterfaces(plugin, assigned)
+			return nil, err
+		}
+
+		if !vm.extraSubnetContains(iface.AssignedIP) {
+			iface.AssignedIP = vm.allocateExtraIP(extraInterfaceOwner(plugin.Slug, iface.Name))
+			if iface.AssignedIP == "" {
+				vm.releaseExtraInterfaces(plugin, append(assigned, iface))
+				return nil, fmt.Errorf("failed to allocate IP for interface %s", iface.Name)
+			}
+		}
+		iface.MacAddress = extraInterfaceMAC(iface.AssignedIP)
+
+		assigned = append(assigned, iface)
+	}
+
+	return assigned, nil
+}
+
+// releaseExtraInterfaces frees the TAP devices and IPs of a failed start,
+// keeping those the plugin already owns in the registry for the next start
+func (vm *VMService) releaseExtraInterfaces(plugin *cms_models.Plugin, assigned []cms_models.PluginNetworkInterface) {
+	owned := make(map[string]cms_models.PluginNetworkInterface, len(plugin.NetworkInterfaces))
+	for _, iface := range plugin.NetworkInterfaces {
+		owned[iface.Name] = iface
+	}
+
+	for _, iface := range assigned {
+		persisted := owned[iface.Name]
+		if persisted.TapDevice == "" {
+			if err := vm.deleteTapInterface(iface.TapDevice); err != nil {
+				vm.logger.WithFields(logger.Fields{
+					"plugin_slug": plugin.Slug,
+					"tap_name":    iface.TapDevice,
+					"error":       err,
+				}).Warn("Failed to delete TAP interface after failed VM start")
+			}
+		}
+		if persisted.AssignedIP != iface.AssignedIP && iface.AssignedIP != "" {
+			vm.deallocateIP(iface.AssignedIP)
+		}
+	}
+}
+
+// TeardownExtraInterfaces deletes the TAP devices and releases the IPs of
+// additional interfaces, e.g. when their plugin is deleted
+func (vm *VMService) TeardownExtraInterfaces(pluginSlug string, ifaces []cms_models.PluginNetworkInterface) {
+	for _, iface := range ifaces {
+		if iface.TapDevice != "" {
+			if err := vm.deleteTapInterface(iface.TapDevice); err != nil {
+				vm.logger.WithFields(logger.Fields{
+					"plugin_slug": pluginSlug,
+					"interface":   iface.Name,
+					"tap_name":    iface.TapDevice,
+					"error":       err,
+				}).Warn("Failed to delete TAP interface of additional network interface")
+			}
+		}
+		// A legacy plugin subnet address was never reserved for the interface
+		if vm.extraSubnetContains(iface.AssignedIP) {
+			vm.deallocateIP(iface.AssignedIP)
+		}
+	}
+}
+
+// GetVMInterfaces returns the additional interfaces of a running VM instance
+func (vm *VMService) GetVMInterfaces(instanceID string) []cms_models.PluginNetworkInterface {
+	vm.poolMutex.RLock()
+	defer vm.poolMutex.RUnlock()
+
+	instance, exists := vm.prewarmPool[instanceID]
+	if !exists {
+		return nil
+	}
+	return instance.Interfaces
+}
+
+// mergeInterfaceAssignments applies the interfaces declared by an updated
+// manifest, keeping the assignments of interfaces that are still declared
+// and tearing down those that were dropped
+func (ps *PluginService) mergeInterfaceAssignments(plugin *cms_models.Plugin, declared []cms_models.PluginNetworkInterface) []cms_models.PluginNetworkInterface {
+	existing := make(map[string]cms_models.PluginNetworkInterface, len(plugin.NetworkInterfaces))
+	for _, iface := range plugin.NetworkInterfaces {
+		existing[iface.Name] = iface
+	}
+
+	merged := make([]cms_models.PluginNetworkInterface, 0, len(declared))
+	for _, iface := range declared {
+		if assigned, ok := existing[iface.Name]; ok {
+			iface = assigned
+			delete(existing, iface.Name)
+		}
+		merged = append(merged, iface)
+	}
+
+	dropped := make([]cms_models.PluginNetworkInterface, 0, len(existing))
+	for _, iface := range existing {
+		dropped = append(dropped, iface)
+	}
+	ps.vmService.TeardownExtraInterfaces(plugin.Slug, dropped)
+
+	return merged
+}
+
+// firecrackerInterfaces returns the Firecracker NICs of a VM: eth0, which serves
+// MMDS if allowed, followed by the additional interfaces in declaration order
+func firecrackerInterfaces(tapName string, extra []cms_models.PluginNetworkInterface, allowMMDS bool) []firecracker.NetworkInterface {
+	ifaces := []firecracker.NetworkInterface{{
+		StaticConfiguration: &firecracker.StaticNetworkConfiguration{
+			HostDevName: tapName,
+			MacAddress:  "02:FC:00:00:00:01",
+		},
+		AllowMMDS: allowMMDS,
+	}}
+	for _, iface := range extra {
+		ifaces = append(ifaces, firecracker.NetworkInterface{
+			StaticConfiguration: &firecracker.StaticNetworkConfiguration{
+				HostDevName: iface.TapDevice,
+				MacAddress:  iface.MacAddress,
+			},
+		})
+	}
+	return ifaces
+}
+
+// guestInterfacesArg describes the additional interfaces to the guest init as
+// name:device:address entries, e.g. "mgmt:eth1:192.168.128.5/24"
+func guestInterfacesArg(extra []cms_models.PluginNetworkInterface) string {
+	entries := make([]string, 0, len(extra))
+	for i, iface := range extra {
+		entries = append(entries, fmt.Sprintf("%s:eth%d:%s/24", iface.Name, i+1, iface.AssignedIP))
+	}
+	return strings.Join(entries, ",")
+}
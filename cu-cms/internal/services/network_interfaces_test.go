@@ -0,0 +1,79 @@
+/*
+ * Firecracker CMS - Additional Plugin Network Interface Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"testing"
+
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// newTestAllocatingVMService returns a VMService able to allocate addresses
+// without probing the bridges
+func newTestAllocatingVMService() *VMService {
+	vm := newTestVMService()
+	vm.config.IPLivenessCheck = false
+	vm.ipPool = make(map[string]bool)
+	vm.lastIPs = make(map[string]string)
+	vm.ipStrategy = newIPAllocationStrategy(vm.config.IPAllocationStrategy, pluginSubnet)
+	vm.extraIPStrategy = newIPAllocationStrategy(vm.config.IPAllocationStrategy, vm.config.ExtraInterfaceSubnet)
+	return vm
+}
+
+func TestExtraInterfacesUseTheirOwnSubnet(t *testing.T) {
+	vm := newTestAllocatingVMService()
+
+	eth0 := vm.allocateIP("blog")
+	mgmt := vm.allocateExtraIP(extraInterfaceOwner("blog", "mgmt"))
+	if eth0 != "192.168.127.2" || mgmt != "192.168.128.2" {
+		t.Fatalf("allocated eth0 %s and mgmt %s, want 192.168.127.2 and 192.168.128.2", eth0, mgmt)
+	}
+
+	if vm.ReserveExtraIP("192.168.127.9") {
+		t.Fatalf("reserved a plugin subnet address for an additional interface")
+	}
+	if vm.ReserveIP("192.168.128.9") {
+		t.Fatalf("reserved an additional interface address for eth0")
+	}
+	if !vm.ReserveExtraIP("192.168.128.9") {
+		t.Fatalf("failed to reserve a free additional interface address")
+	}
+}
+
+func TestDropLegacyInterfaceAddresses(t *testing.T) {
+	vm := newTestAllocatingVMService()
+	plugin := &cms_models.Plugin{Slug: "blog", NetworkInterfaces: []cms_models.PluginNetworkInterface{
+		{Name: "mgmt", AssignedIP: "192.168.127.5", MacAddress: "02:FC:C0:A8:7F:05"},
+		{Name: "data", AssignedIP: "192.168.128.5", MacAddress: "02:FC:C0:A8:80:05"},
+	}}
+
+	if !vm.dropLegacyInterfaceAddresses(plugin) {
+		t.Fatalf("legacy address not reported")
+	}
+	if mgmt := plugin.NetworkInterfaces[0]; mgmt.AssignedIP != "" || mgmt.MacAddress != "" {
+		t.Fatalf("legacy assignment kept: %+v", mgmt)
+	}
+	if data := plugin.NetworkInterfaces[1]; data.AssignedIP != "192.168.128.5" {
+		t.Fatalf("current assignment dropped: %+v", data)
+	}
+	if vm.dropLegacyInterfaceAddresses(plugin) {
+		t.Fatalf("nothing left to drop, but reported a change")
+	}
+}
+
+func TestTeardownSkipsLegacyInterfaceAddress(t *testing.T) {
+	vm := newTestAllocatingVMService()
+	if !vm.ReserveIP("192.168.127.5") {
+		t.Fatal("failed to reserve eth0 address")
+	}
+
+	// The legacy address now belongs to another plugin's eth0
+	vm.TeardownExtraInterfaces("blog", []cms_models.PluginNetworkInterface{{Name: "mgmt", AssignedIP: "192.168.127.5"}})
+	if !vm.ipPool["192.168.127.5"] {
+		t.Fatalf("teardown released an address the interface never held")
+	}
+}
@@ -0,0 +1,48 @@
+/*
+ * Firecracker CMS - Execution Payload Transforms
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"net/http"
+)
+
+// payloadTransforms adds operator-configured fields to execution payloads
+type payloadTransforms struct {
+	injectFields map[string]string // field -> static value
+	headerFields map[string]string // canonical header -> field
+}
+
+// empty reports whether no transforms are configured
+func (pt *payloadTransforms) empty() bool {
+	return len(pt.injectFields) == 0 && len(pt.headerFields) == 0
+}
+
+// apply returns a copy of payload with the static fields and the values of
+// mapped request headers set, overriding fields the caller sent. Headers
+// missing from the request leave their field untouched.
+func (pt *payloadTransforms) apply(payload map[string]interface{}, headers http.Header) map[string]interface{} {
+	if pt.empty() {
+		return payload
+	}
+
+	transformed := make(map[string]interface{}, len(payload)+len(pt.injectFields)+len(pt.headerFields))
+	for field, value := range payload {
+		transformed[field] = value
+	}
+
+	for field, value := range pt.injectFields {
+		transformed[field] = value
+	}
+
+	for header, field := range pt.headerFields {
+		if value := headers.Get(header); value != "" {
+			transformed[field] = value
+		}
+	}
+
+	return transformed
+}
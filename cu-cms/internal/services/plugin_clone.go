@@ -0,0 +1,196 @@
+/*
+ * Firecracker CMS - Plugin Cloning
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// ErrPluginExists is returned when a clone targets a slug already registered
+var ErrPluginExists = errors.New("plugin already exists")
+
+// ErrCloneSourceRunning is returned when cloning a writable rootfs whose plugin
+// has VMs, which may be writing to it
+var ErrCloneSourceRunning = errors.New("source plugin has running VMs, deactivate it before cloning its writable rootfs")
+
+// maxPluginSlugLength keeps slugs within TAP device and hostname limits
+const maxPluginSlugLength = 50
+
+// validatePluginSlug checks a slug uses only lowercase letters, digits and hyphens
+func validatePluginSlug(slug string) error {
+	if slug == "" {
+		return fmt.Errorf("slug is required")
+	}
+	if len(slug) > maxPluginSlugLength {
+		return fmt.Errorf("slug too long (max %d characters)", maxPluginSlugLength)
+	}
+	for _, char := range slug {
+		if !((char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') || char == '-') {
+			return fmt.Errorf("slug %q contains invalid characters (use only lowercase letters, numbers, hyphens)", slug)
+		}
+	}
+	return nil
+}
+
+// ClonePlugin registers a copy of a plugin under a new slug. The manifest and
+// operational settings are copied; the clone gets its own rootfs (a link to
+// the shared blob for read-only images), network identity and certificate,
+// and is validated like an upload. It starts installed.
+func (ps *PluginService) ClonePlugin(slug string, request *models.PluginCloneRequest) (*models.Plugin, error) {
+	target := request.TargetSlug
+	if err := validatePluginSlug(target); err != nil {
+		return nil, fmt.Errorf("invalid target slug: %v", err)
+	}
+	if target == slug {
+		return nil, fmt.Errorf("target slug must differ from the source")
+	}
+
+	ps.mutex.RLock()
+	source, exists := ps.plugins[slug]
+	if !exists {
+		ps.mutex.RUnlock()
+		return nil, fmt.Errorf("plugin not found")
+	}
+	if _, taken := ps.plugins[target]; taken {
+		ps.mutex.RUnlock()
+		return nil, fmt.Errorf("%w: %s", ErrPluginExists, target)
+	}
+	// A JSON round trip deep-copies actions, tags, env and hooks
+	data, err := json.Marshal(source)
+	ps.mutex.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy plugin: %v", err)
+	}
+
+	clone := &models.Plugin{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, fmt.Errorf("failed to copy plugin: %v", err)
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": slug,
+		"target_slug": target,
+	}).Info("Cloning plugin")
+
+	// The clone's rootfs path is also written by uploads of the target slug
+	unlockUpload, err := ps.uploadLocks.lock(target, time.Duration(ps.config.UploadLockTimeoutSec)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, target)
+	}
+	defer unlockUpload()
+
+	// Copy the image before taking the registry lock, it may be large
+	rootfsType := clone.EffectiveRootfsType()
+	rootfsPath := filepath.Join(ps.config.DataDir, "plugins", target+"."+rootfsType)
+	if err := ps.cloneRootfs(clone, rootfsPath); err != nil {
+		return nil, err
+	}
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	// Validation boots a VM, so wait out any other boot of this plugin
+	unlockStart := ps.startLocks.lock(target)
+	defer unlockStart()
+
+	// Another upload or clone may have taken the slug meanwhile
+	if _, taken := ps.plugins[target]; taken {
+		os.Remove(rootfsPath)
+		ps.releaseRootfsBlob(clone.RootfsHash, rootfsType)
+		return nil, fmt.Errorf("%w: %s", ErrPluginExists, target)
+	}
+
+	clone.Slug = target
+	if request.Name != "" {
+		clone.Name = request.Name
+	}
+	if request.Tags != nil {
+		clone.Tags = request.Tags
+	}
+	clone.RootfsPath = rootfsPath
+	clone.CreatedAt = time.Now()
+	clone.UpdatedAt = time.Now()
+	clone.Status = "installed"
+	clone.Health = models.PluginHealth{Status: "unknown"}
+
+	// The clone gets its own network identity and snapshot when validated
+	clone.AssignedIP = ""
+	clone.TapDevice = ""
+	for i := range clone.NetworkInterfaces {
+		clone.NetworkInterfaces[i].TapDevice = ""
+		clone.NetworkInterfaces[i].AssignedIP = ""
+		clone.NetworkInterfaces[i].MacAddress = ""
+	}
+	clone.NeedsResnapshot = false
+	clone.SnapshotDiffCount = 0
+	clone.SnapshotRefreshedAt = nil
+	clone.ActionUsage = nil
+
+	if err := ps.installPluginUnsafe(clone, "plugin_clone"); err != nil {
+		return nil, err
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": target,
+		"source_slug": slug,
+		"version":     clone.Version,
+		"assigned_ip": clone.AssignedIP,
+		"tap_device":  clone.TapDevice,
+		"status":      clone.Status,
+	}).Info("Plugin cloned and installed successfully")
+	ps.emitEvent(EventPluginInstalled, target, "cloned from "+slug)
+
+	return clone, nil
+}
+
+// cloneRootfs gives a cloned plugin its rootfs at rootfsPath: another link to
+// the blob of a shared read-only image, else a full copy. Writable images are
+// only copied while the source plugin has no VMs.
+func (ps *PluginService) cloneRootfs(clone *models.Plugin, rootfsPath string) error {
+	if clone.RootfsHash != "" {
+		ps.blobMutex.Lock()
+		defer ps.blobMutex.Unlock()
+
+		os.Remove(rootfsPath)
+		if err := os.Link(ps.rootfsBlobPath(clone.RootfsHash, clone.EffectiveRootfsType()), rootfsPath); err != nil {
+			return fmt.Errorf("failed to link rootfs blob: %v", err)
+		}
+		return nil
+	}
+
+	// A guest may be writing to a writable image, and even a paused one can hold
+	// unflushed writes. The start lock keeps a VM from booting during the copy.
+	if !clone.RootfsReadOnly() {
+		unlockStart := ps.startLocks.lock(clone.Slug)
+		defer unlockStart()
+		if ps.vmService.pluginInstanceCount(clone.Slug) > 0 {
+			return fmt.Errorf("%w: %s", ErrCloneSourceRunning, clone.Slug)
+		}
+	}
+
+	info, err := os.Stat(clone.RootfsPath)
+	if err != nil {
+		return fmt.Errorf("source rootfs not found: %v", err)
+	}
+	if err := checkMinFreeDisk(filepath.Dir(rootfsPath), info.Size(), 0, ps.config.MinFreeDiskMB, "plugin_clone"); err != nil {
+		return err
+	}
+
+	if err := ps.copyFile(clone.RootfsPath, rootfsPath); err != nil {
+		os.Remove(rootfsPath)
+		return fmt.Errorf("failed to copy rootfs: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+ * Firecracker CMS - Plugin Cloning Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// newTestCloneSource writes a small rootfs for a plugin of the given type
+func newTestCloneSource(t *testing.T, ps *PluginService, rootfsType string) *models.Plugin {
+	rootfsPath := filepath.Join(ps.config.DataDir, "plugins", "blog."+rootfsType)
+	if err := os.MkdirAll(filepath.Dir(rootfsPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rootfsPath, []byte("rootfs"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &models.Plugin{Slug: "blog", RootfsType: rootfsType, RootfsPath: rootfsPath}
+}
+
+func TestCloneRootfsRefusesRunningWritableSource(t *testing.T) {
+	ps := newTestPluginService(t)
+	ps.config.MinFreeDiskMB = 0
+	source := newTestCloneSource(t, ps, models.RootfsTypeExt4)
+	addTestInstance(ps.vmService, "blog", "blog-1")
+
+	target := filepath.Join(ps.config.DataDir, "plugins", "blog-copy.ext4")
+	if err := ps.cloneRootfs(source, target); !errors.Is(err, ErrCloneSourceRunning) {
+		t.Fatalf("cloneRootfs of a running ext4 plugin = %v, want ErrCloneSourceRunning", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("target rootfs written for a refused clone")
+	}
+
+	delete(ps.vmService.prewarmPool, "blog-1")
+	if err := ps.cloneRootfs(source, target); err != nil {
+		t.Fatalf("cloneRootfs of a stopped ext4 plugin = %v", err)
+	}
+	if data, err := os.ReadFile(target); err != nil || string(data) != "rootfs" {
+		t.Fatalf("target rootfs = %q (%v), want a copy of the source", data, err)
+	}
+}
+
+func TestCloneRootfsCopiesRunningReadOnlySource(t *testing.T) {
+	ps := newTestPluginService(t)
+	ps.config.MinFreeDiskMB = 0
+	source := newTestCloneSource(t, ps, models.RootfsTypeSquashfs)
+	addTestInstance(ps.vmService, "blog", "blog-1")
+
+	target := filepath.Join(ps.config.DataDir, "plugins", "blog-copy.squashfs")
+	if err := ps.cloneRootfs(source, target); err != nil {
+		t.Fatalf("cloneRootfs of a running squashfs plugin = %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ * Firecracker CMS - Plugin Drain
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// ErrPluginDraining is returned for lifecycle changes to a plugin that is
+// being deactivated
+var ErrPluginDraining = errors.New("plugin is being deactivated")
+
+// drainPollInterval is how often in-flight executions are checked while draining
+const drainPollInterval = 100 * time.Millisecond
+
+// drainPlugin waits for the in-flight executions of a plugin that no longer
+// receives new ones, up to the drain timeout. It reports whether the plugin
+// drained; executions still running afterwards fail when the VM stops.
+func (ps *PluginService) drainPlugin(slug string) bool {
+	start := time.Now()
+	deadline := start.Add(time.Duration(ps.config.PluginDrainTimeoutSec) * time.Second)
+
+	for {
+		inFlight := ps.executionMetrics.pluginInFlight(slug)
+		if inFlight == 0 {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": slug,
+				"waited_ms":   time.Since(start).Milliseconds(),
+			}).Info("Plugin drained")
+			return true
+		}
+
+		if time.Now().After(deadline) {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": slug,
+				"in_flight":   inFlight,
+				"timeout_sec": ps.config.PluginDrainTimeoutSec,
+			}).Warn("Plugin drain timed out, stopping with executions in flight")
+			return false
+		}
+
+		time.Sleep(drainPollInterval)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ * Firecracker CMS - Plugin-Exposed Metrics
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	cms_errors "github.com/centraunit/cu-firecracker-cms/internal/errors"
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// pluginMetricsCacheTTL is how long scraped metrics are served before the
+// guest is asked again, so frequent scrapes don't keep resuming it
+const pluginMetricsCacheTTL = 10 * time.Second
+
+// pluginMetricsScrapeTimeout bounds a scrape of the guest metrics endpoint
+const pluginMetricsScrapeTimeout = 5 * time.Second
+
+// pluginMetricsMaxBytes caps the metrics body read from a guest
+const pluginMetricsMaxBytes = 4 << 20
+
+// PluginMetrics represents metrics scraped from a plugin's guest
+type PluginMetrics struct {
+	ContentType string
+	Body        []byte
+	ScrapedAt   time.Time
+}
+
+// pluginMetricsCache keeps the latest scrape of each plugin
+type pluginMetricsCache struct {
+	mutex   sync.Mutex
+	entries map[string]*PluginMetrics
+}
+
+// newPluginMetricsCache creates an empty plugin metrics cache
+func newPluginMetricsCache() *pluginMetricsCache {
+	return &pluginMetricsCache{
+		entries: make(map[string]*PluginMetrics),
+	}
+}
+
+// get returns a scrape of the plugin younger than the cache TTL, or nil
+func (c *pluginMetricsCache) get(pluginSlug string) *PluginMetrics {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	metrics, exists := c.entries[pluginSlug]
+	if !exists || time.Since(metrics.ScrapedAt) > pluginMetricsCacheTTL {
+		return nil
+	}
+	return metrics
+}
+
+// put stores the latest scrape of the plugin
+func (c *pluginMetricsCache) put(pluginSlug string, metrics *PluginMetrics) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries[pluginSlug] = metrics
+}
+
+// ScrapePluginMetrics returns the metrics a plugin serves from its warm
+// instance, resuming it briefly if it is parked. Recent scrapes are served
+// from cache.
+func (ps *PluginService) ScrapePluginMetrics(slug string) (*PluginMetrics, error) {
+	ps.mutex.RLock()
+	plugin, exists := ps.plugins[slug]
+	ps.mutex.RUnlock()
+
+	if !exists {
+		return nil, cms_errors.NewValidationError("scrape_plugin_metrics", "plugin not found")
+	}
+	if plugin.Metrics == nil {
+		return nil, cms_errors.NewValidationError("scrape_plugin_metrics", "plugin does not declare a metrics endpoint")
+	}
+	if !plugin.IsActive() {
+		return nil, cms_errors.NewValidationError("scrape_plugin_metrics", "plugin is not active")
+	}
+
+	if metrics := ps.metricsCache.get(slug); metrics != nil {
+		return metrics, nil
+	}
+
+	instance := ps.vmService.PeekPrewarmInstance(slug)
+	if instance == nil {
+		return nil, cms_errors.NewVMError("scrape_plugin_metrics", "plugin not ready - no pre-warmed instance available")
+	}
+
+	// Plugins using mutual TLS serve metrics over HTTPS like everything else
+	url := guestPortURL(instance.IP, plugin.Metrics.EffectivePort(), instance.MTLS, plugin.Metrics.Path)
+
+	var metrics *PluginMetrics
+	err := ps.vmService.ProbeInstance(instance, func(*PrewarmInstance) error {
+		var scrapeErr error
+		metrics, scrapeErr = ps.scrapeMetrics(url)
+		return scrapeErr
+	})
+	if errors.Is(err, ErrInstanceBusy) {
+		return nil, cms_errors.WrapVMError(err, "scrape_plugin_metrics", "plugin instance is serving executions, retry later")
+	}
+	if err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": slug,
+			"url":         url,
+			"error":       err,
+		}).Warn("Failed to scrape plugin metrics")
+		return nil, cms_errors.WrapNetworkError(err, "scrape_plugin_metrics", "failed to scrape plugin metrics")
+	}
+
+	ps.metricsCache.put(slug, metrics)
+	return metrics, nil
+}
+
+// scrapeMetrics fetches a guest metrics endpoint
+func (ps *PluginService) scrapeMetrics(url string) (*PluginMetrics, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginMetricsScrapeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ps.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, pluginMetricsMaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > pluginMetricsMaxBytes {
+		return nil, fmt.Errorf("metrics exceed %d bytes", pluginMetricsMaxBytes)
+	}
+
+	return &PluginMetrics{
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+		ScrapedAt:   time.Now(),
+	}, nil
+}
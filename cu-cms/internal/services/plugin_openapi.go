@@ -0,0 +1,151 @@
+/*
+ * Firecracker CMS - Plugin OpenAPI Documents
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cms_errors "github.com/centraunit/cu-firecracker-cms/internal/errors"
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// openAPIFetchTimeout bounds the fetch of a plugin's OpenAPI document
+const openAPIFetchTimeout = 5 * time.Second
+
+// openAPIMaxBytes caps the OpenAPI document read from a guest
+const openAPIMaxBytes = 2 << 20
+
+// openAPISpecPath returns where the OpenAPI document of a plugin is stored
+func (ps *PluginService) openAPISpecPath(slug string) string {
+	return filepath.Join(ps.config.DataDir, "plugins", slug+".openapi.json")
+}
+
+// storeOpenAPISpec fetches the OpenAPI document a plugin declares, checks it
+// and stores it. A plugin that no longer declares one loses the stored copy.
+func (ps *PluginService) storeOpenAPISpec(plugin *models.Plugin, vmIP string) error {
+	if plugin.OpenAPI == "" {
+		ps.removeOpenAPISpec(plugin.Slug)
+		return nil
+	}
+
+	spec, err := ps.fetchOpenAPISpec(pluginURL(plugin, vmIP, plugin.OpenAPI))
+	if err != nil {
+		return fmt.Errorf("%s: %v", plugin.OpenAPI, err)
+	}
+	if err := validateOpenAPISpec(spec); err != nil {
+		return fmt.Errorf("%s: %v", plugin.OpenAPI, err)
+	}
+
+	if err := os.WriteFile(ps.openAPISpecPath(plugin.Slug), spec, 0644); err != nil {
+		return fmt.Errorf("failed to store document: %v", err)
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"path":        plugin.OpenAPI,
+		"bytes":       len(spec),
+	}).Info("Stored plugin OpenAPI document")
+
+	return nil
+}
+
+// fetchOpenAPISpec reads an OpenAPI document from the guest
+func (ps *PluginService) fetchOpenAPISpec(url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), openAPIFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ps.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, openAPIMaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > openAPIMaxBytes {
+		return nil, fmt.Errorf("document exceeds %d bytes", openAPIMaxBytes)
+	}
+	return body, nil
+}
+
+// validateOpenAPISpec checks that a document is JSON carrying an OpenAPI 3 or
+// Swagger 2 version and a paths object
+func validateOpenAPISpec(spec []byte) error {
+	var document struct {
+		OpenAPI string                     `json:"openapi"`
+		Swagger string                     `json:"swagger"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &document); err != nil {
+		return fmt.Errorf("not a JSON OpenAPI document: %v", err)
+	}
+
+	switch {
+	case strings.HasPrefix(document.OpenAPI, "3."):
+	case document.Swagger == "2.0":
+	default:
+		return fmt.Errorf("missing openapi 3.x or swagger 2.0 version field")
+	}
+	if document.Paths == nil {
+		return fmt.Errorf("missing paths object")
+	}
+	return nil
+}
+
+// removeOpenAPISpec deletes the stored OpenAPI document of a plugin, if any
+func (ps *PluginService) removeOpenAPISpec(slug string) {
+	if err := os.Remove(ps.openAPISpecPath(slug)); err != nil && !os.IsNotExist(err) {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": slug,
+			"error":       err,
+		}).Warn("Failed to remove plugin OpenAPI document")
+	}
+}
+
+// GetPluginOpenAPI returns the OpenAPI document stored when the plugin was
+// last validated
+func (ps *PluginService) GetPluginOpenAPI(slug string) ([]byte, error) {
+	ps.mutex.RLock()
+	plugin, exists := ps.plugins[slug]
+	ps.mutex.RUnlock()
+
+	if !exists {
+		return nil, cms_errors.NewValidationError("get_plugin_openapi", "plugin not found")
+	}
+	if plugin.OpenAPI == "" {
+		return nil, cms_errors.NewValidationError("get_plugin_openapi", "plugin does not declare an OpenAPI document")
+	}
+
+	spec, err := os.ReadFile(ps.openAPISpecPath(slug))
+	if os.IsNotExist(err) {
+		return nil, cms_errors.NewValidationError("get_plugin_openapi", "OpenAPI document not fetched yet, the plugin has not passed validation")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI document: %v", err)
+	}
+	return spec, nil
+}
@@ -9,23 +9,38 @@ package services
 import (
 	"archive/zip"
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/centraunit/cu-firecracker-cms/internal/config"
+	cms_errors "github.com/centraunit/cu-firecracker-cms/internal/errors"
 	"github.com/centraunit/cu-firecracker-cms/internal/logger"
 	"github.com/centraunit/cu-firecracker-cms/internal/models"
 )
 
+// postExecutionProbeTimeout bounds the health probe run before returning an instance to the pool
+const postExecutionProbeTimeout = 2 * time.Second
+
+// pluginRequestTimeout bounds a single HTTP request to a plugin VM
+const pluginRequestTimeout = 10 * time.Second
+
 // PluginService handles plugin management operations
 type PluginService struct {
 	config    *config.Config
@@ -33,6 +48,48 @@ type PluginService struct {
 	plugins   map[string]*models.Plugin
 	mutex     sync.RWMutex
 	vmService *VMService
+
+	executionMetrics *executionMetrics
+	rateLimiter      *pluginRateLimiter
+	circuitBreakers  *pluginCircuitBreakers
+	startLocks       *pluginStartLocks
+	uploadLocks      *pluginUploadLocks
+	blobMutex        sync.Mutex // Guards rootfs blob storage and release
+	metricsCache     *pluginMetricsCache
+	actionUsage      *actionUsageTracker
+
+	// Result fields masked in logs
+	logRedactFields map[string]bool
+
+	// Plugins whose executions are logged in full regardless of their debug setting
+	debugPlugins map[string]bool
+
+	// Execution modes of hooks not run in broadcast mode
+	hookModes map[string]string
+
+	// Fields added to every execution payload
+	payloadTransforms payloadTransforms
+
+	// Shared client so connections to warm plugin VMs are reused across requests
+	httpTransport *http.Transport
+	httpClient    *http.Client
+
+	// Hosts asynchronous executions may deliver results to
+	callbackHosts  []string
+	callbackClient *http.Client
+
+	// Plugins whose evicted warm instance is being restored
+	coldStarts sync.Map
+
+	// Slots bounding asynchronous executions, and the ones still running
+	asyncSlots      chan struct{}
+	asyncExecutions sync.WaitGroup
+
+	// Queryable record of recent executions, nil when disabled
+	executionHistory *executionHistory
+
+	// Plugin lifecycle events for SSE subscribers and the event webhook
+	events *eventBus
 }
 
 // NewPluginService creates a new plugin service
@@ -42,18 +99,160 @@ func NewPluginService(cfg *config.Config, log *logger.Logger, vmService *VMServi
 		logger:    log,
 		plugins:   make(map[string]*models.Plugin),
 		vmService: vmService,
+
+		executionMetrics: newExecutionMetrics(),
+		rateLimiter:      newPluginRateLimiter(),
+		circuitBreakers:  newPluginCircuitBreakers(),
+		startLocks:       newPluginStartLocks(),
+		uploadLocks:      newPluginUploadLocks(),
+		metricsCache:     newPluginMetricsCache(),
+		actionUsage:      newActionUsageTracker(),
+		events:           newEventBus(),
+
+		logRedactFields: parseRedactFields(cfg.LogRedactFields),
+		debugPlugins:    parseDebugPlugins(cfg.DebugPlugins),
+	}
+
+	// Validated with the rest of the config
+	service.hookModes, _ = config.ParseHookExecutionModes(cfg.HookExecutionModes)
+	service.payloadTransforms.injectFields, _ = config.ParsePayloadInjectFields(cfg.PayloadInjectFields)
+	service.payloadTransforms.headerFields, _ = config.ParsePayloadHeaderFields(cfg.PayloadHeaderFields)
+	service.callbackHosts, _ = config.ParseCallbackAllowlist(cfg.CallbackAllowlist)
+	service.callbackClient = newCallbackClient()
+	service.asyncSlots = make(chan struct{}, cfg.MaxAsyncExecutions)
+
+	service.httpTransport = newPluginTransport(vmService.PluginTLSConfig())
+	service.httpClient = &http.Client{Transport: service.httpTransport}
+
+	vmService.SetInstanceExitHandler(service.handleInstanceExit)
+
+	if cfg.EventWebhookURL != "" {
+		go service.forwardEvents(cfg.EventWebhookURL)
 	}
 
 	// Load existing plugins from disk
 	service.loadPlugins()
+	service.updatePoolFootprintsUnsafe()
 
 	// Restore active plugins after startup
 	service.restoreActivePlugins()
 
+	// Start periodic health checking of active plugins
+	go service.healthMonitor()
+
+	// Persist action invocation counters
+	go service.actionUsageFlusher()
+
+	// Keep a queryable execution history within the configured retention
+	if cfg.ExecutionHistoryMaxRecords > 0 {
+		maxAge := time.Duration(cfg.ExecutionHistoryMaxAgeHours) * time.Hour
+		service.executionHistory = newExecutionHistory(cfg.ExecutionHistoryMaxRecords, maxAge)
+		service.loadExecutionHistory()
+		go service.executionHistoryFlusher()
+	}
+
+	// Keep recovery snapshots of long-lived instances fresh
+	if cfg.SnapshotRefreshIntervalSec > 0 && vmService.SnapshotsSupported() {
+		go service.snapshotRefresher()
+	}
+
+	if cfg.DiagnosticsEnabled {
+		log.WithFields(logger.Fields{
+			"hook": DiagnosticEchoHook,
+		}).Warn("Diagnostics enabled: built-in echo plugin registered")
+	}
+
 	return service
 }
 
-// ListPlugins returns all registered plugins
+// HookOverlaps returns the active plugins that handle the same hooks as the given plugin
+func (ps *PluginService) HookOverlaps(slug string) []models.HookOverlap {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	plugin, exists := ps.plugins[slug]
+	if !exists {
+		return nil
+	}
+
+	return ps.hookOverlapsUnsafe(slug, plugin.Actions)
+}
+
+// hookOverlapsUnsafe finds active plugins other than slug handling any of the given hooks
+// Note: Caller must hold ps.mutex
+func (ps *PluginService) hookOverlapsUnsafe(slug string, actions map[string]models.PluginAction) []models.HookOverlap {
+	hooks := make(map[string]bool)
+	for _, action := range actions {
+		for _, hook := range action.Hooks {
+			hooks[hook] = true
+		}
+	}
+
+	var overlaps []models.HookOverlap
+	for _, other := range ps.plugins {
+		if other.Slug == slug || !other.IsActive() {
+			continue
+		}
+		for hook := range hooks {
+			if len(other.GetActionsForHook(hook)) > 0 {
+				overlaps = append(overlaps, models.HookOverlap{
+					Hook:       hook,
+					PluginSlug: other.Slug,
+					Priority:   other.Priority,
+				})
+			}
+		}
+	}
+
+	sort.Slice(overlaps, func(i, j int) bool {
+		if overlaps[i].Hook != overlaps[j].Hook {
+			return overlaps[i].Hook < overlaps[j].Hook
+		}
+		return overlaps[i].PluginSlug < overlaps[j].PluginSlug
+	})
+
+	return overlaps
+}
+
+// describeHookOverlaps renders overlaps for messages, e.g. "content.create: seo (priority 50)"
+func describeHookOverlaps(overlaps []models.HookOverlap) string {
+	parts := make([]string, 0, len(overlaps))
+	for _, overlap := range overlaps {
+		parts = append(parts, fmt.Sprintf("%s: %s (priority %d)", overlap.Hook, overlap.PluginSlug, overlap.Priority))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Supported sort keys for plugin listings
+const (
+	PluginSortSlug      = "slug"
+	PluginSortName      = "name"
+	PluginSortPriority  = "priority"
+	PluginSortCreatedAt = "created_at"
+)
+
+// newPluginTransport creates the transport used for plugin VM requests. Plugin IPs
+// are private and short-lived, so dials fail fast and idle connections expire quickly.
+func newPluginTransport(tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		TLSClientConfig: tlsConfig,
+		DialContext: (&net.Dialer{
+			Timeout:   2 * time.Second,
+			KeepAlive: 15 * time.Second,
+		}).DialContext,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     30 * time.Second,
+	}
+}
+
+// closePluginConnections drops idle keep-alive connections so a stopped or
+// replaced VM reusing the same IP is never reached through a stale socket
+func (ps *PluginService) closePluginConnections() {
+	ps.httpTransport.CloseIdleConnections()
+}
+
+// ListPlugins returns all registered plugins ordered by slug
 func (ps *PluginService) ListPlugins() ([]*models.Plugin, error) {
 	ps.mutex.RLock()
 	defer ps.mutex.RUnlock()
@@ -63,9 +262,41 @@ func (ps *PluginService) ListPlugins() ([]*models.Plugin, error) {
 		plugins = append(plugins, plugin)
 	}
 
+	SortPlugins(plugins, PluginSortSlug)
+
 	return plugins, nil
 }
 
+// SortPlugins orders plugins by the given key, falling back to slug for ties.
+// Priority sorts highest first, matching execution order.
+func SortPlugins(plugins []*models.Plugin, key string) error {
+	var less func(a, b *models.Plugin) bool
+	switch key {
+	case "", PluginSortSlug:
+		less = func(a, b *models.Plugin) bool { return false }
+	case PluginSortName:
+		less = func(a, b *models.Plugin) bool { return a.Name < b.Name }
+	case PluginSortPriority:
+		less = func(a, b *models.Plugin) bool { return a.Priority > b.Priority }
+	case PluginSortCreatedAt:
+		less = func(a, b *models.Plugin) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	default:
+		return fmt.Errorf("unsupported sort key: %s", key)
+	}
+
+	sort.SliceStable(plugins, func(i, j int) bool {
+		if less(plugins[i], plugins[j]) {
+			return true
+		}
+		if less(plugins[j], plugins[i]) {
+			return false
+		}
+		return plugins[i].Slug < plugins[j].Slug
+	})
+
+	return nil
+}
+
 // GetPlugin returns a specific plugin by slug
 func (ps *PluginService) GetPlugin(slug string) (*models.Plugin, error) {
 	ps.mutex.RLock()
@@ -91,6 +322,16 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 		return nil, fmt.Errorf("failed to create plugins directory: %v", err)
 	}
 
+	// Refuse uploads that would fill the disk before buffering them
+	if size, err := file.Seek(0, io.SeekEnd); err == nil {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind upload: %v", err)
+		}
+		if err := checkMinFreeDisk(os.TempDir(), size, 0, ps.config.MinFreeDiskMB, "plugin_upload"); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create temporary directory for extraction
 	tempDir, err := os.MkdirTemp("", "cms-plugin-upload-")
 	if err != nil {
@@ -111,47 +352,119 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 	}
 	dst.Close()
 
-	// Extract ZIP file
-	if err := ps.extractPluginZip(zipPath, tempDir); err != nil {
+	// Compressed images may be far larger once extracted
+	extractSize, err := pluginZipExtractSize(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ZIP: %v", err)
+	}
+	if err := checkMinFreeDisk(tempDir, extractSize, 0, ps.config.MinFreeDiskMB, "plugin_upload"); err != nil {
+		return nil, err
+	}
+
+	// Extract ZIP file (the rootfs is optional for manifest-only updates)
+	rootfsName, err := ps.extractPluginZip(zipPath, tempDir)
+	if err != nil {
 		return nil, fmt.Errorf("failed to extract ZIP: %v", err)
 	}
+	hasRootfs := rootfsName != ""
 
 	// Parse plugin.json to get metadata
 	pluginJsonPath := filepath.Join(tempDir, "plugin.json")
-	metadata, err := ps.parsePluginJson(pluginJsonPath)
+	metadata, validationErrors, err := ps.parsePluginJson(pluginJsonPath)
 	if err != nil {
 		return nil, fmt.Errorf("invalid plugin.json: %v", err)
 	}
 
-	// Validate required fields
-	if metadata.Slug == "" {
-		return nil, fmt.Errorf("plugin must provide a unique slug in plugin.json")
+	// Uploads of the same plugin queue from here on, they share its rootfs path
+	if metadata.Slug != "" {
+		unlockUpload, err := ps.uploadLocks.lock(metadata.Slug, time.Duration(ps.config.UploadLockTimeoutSec)*time.Second)
+		if err != nil {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": metadata.Slug,
+				"timeout_sec": ps.config.UploadLockTimeoutSec,
+			}).Warn("Gave up waiting for another upload of the plugin")
+			return nil, fmt.Errorf("%w: %s", err, metadata.Slug)
+		}
+		defer unlockUpload()
+	}
+
+	// Check the package structure too, so every problem is reported at once
+	rootfsType := metadata.EffectiveRootfsType()
+	rootfsTempPath := filepath.Join(tempDir, rootfsName)
+	var installedRootfs string
+	if hasRootfs {
+		if expected := models.RootfsFileName(rootfsType); rootfsName != expected {
+			validationErrors.Add(rootfsName, "plugin.json declares rootfs_type %q, so the ZIP must contain %s", rootfsType, expected)
+		} else if err := ps.validateRootfsSize(rootfsTempPath, rootfsType); err != nil {
+			// Validate rootfs size against configured bounds
+			validationErrors.Add(rootfsName, "%v", err)
+		}
+	} else if metadata.Slug != "" {
+		// Manifest-only update - reuse the rootfs of the installed plugin
+		if installedRootfs, err = ps.installedRootfsPath(metadata.Slug, rootfsType); err != nil {
+			validationErrors.Add(models.RootfsFileName(rootfsType), "%v", err)
+		}
 	}
 
-	// Validate plugin metadata
-	if metadata.Name == "" {
-		return nil, fmt.Errorf("plugin must provide a name in plugin.json")
+	// Refuse plugins that can never run on this host
+	if len(validationErrors) == 0 {
+		validationErrors = ps.hostSupportErrors(metadata.Requires, metadata.MTLS, metadata.IsolationTier)
 	}
 
-	if metadata.Version == "" {
-		return nil, fmt.Errorf("plugin must provide a version in plugin.json")
+	if len(validationErrors) > 0 {
+		return nil, validationErrors
 	}
 
-	// Move rootfs to final location using slug-based naming
-	rootfsTempPath := filepath.Join(tempDir, "rootfs.ext4")
-	rootfsPath := filepath.Join(pluginsDir, metadata.Slug+".ext4")
+	rootfsPath := filepath.Join(pluginsDir, metadata.Slug+"."+rootfsType)
+	rootfsHash := ""
+
+	if hasRootfs {
+		// The installed image is replaced, so its space counts as free
+		existingRootfs := []string{
+			filepath.Join(pluginsDir, metadata.Slug+"."+models.RootfsTypeExt4),
+			filepath.Join(pluginsDir, metadata.Slug+"."+models.RootfsTypeSquashfs),
+		}
+		if info, err := os.Stat(rootfsTempPath); err == nil {
+			if err := checkMinFreeDisk(pluginsDir, info.Size(), snapshotFilesSize(existingRootfs...), ps.config.MinFreeDiskMB, "plugin_upload"); err != nil {
+				return nil, err
+			}
+		}
+
+		// Remove existing plugin files, including an image of another type
+		os.Remove(filepath.Join(pluginsDir, metadata.Slug+"."+models.RootfsTypeExt4))
+		os.Remove(filepath.Join(pluginsDir, metadata.Slug+"."+models.RootfsTypeSquashfs))
 
-	// Remove existing plugin file if it exists
-	os.Remove(rootfsPath)
+		// Read-only images are shared between plugins; writable ones need their own copy
+		if metadata.RootfsReadOnly() {
+			if rootfsHash, err = ps.storeRootfsBlob(rootfsTempPath, rootfsPath, rootfsType); err != nil {
+				return nil, fmt.Errorf("failed to install plugin rootfs: %v", err)
+			}
+		} else if err := ps.copyFile(rootfsTempPath, rootfsPath); err != nil {
+			return nil, fmt.Errorf("failed to install plugin rootfs: %v", err)
+		}
+	} else {
+		rootfsPath = installedRootfs
 
-	// Copy rootfs file
-	if err := ps.copyFile(rootfsTempPath, rootfsPath); err != nil {
-		return nil, fmt.Errorf("failed to install plugin rootfs: %v", err)
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": metadata.Slug,
+			"rootfs_path": rootfsPath,
+		}).Info("ZIP contains only plugin.json, reusing installed rootfs")
 	}
 
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 
+	// Validation boots a VM, so wait out any other boot of this plugin
+	unlockStart := ps.startLocks.lock(metadata.Slug)
+	defer unlockStart()
+
+	// Overlapping hooks are ordered by priority alone, so optionally insist on one
+	if ps.config.RequireHookPriority && metadata.Priority == 0 {
+		if overlaps := ps.hookOverlapsUnsafe(metadata.Slug, metadata.Actions); len(overlaps) > 0 {
+			return nil, fmt.Errorf("plugin hooks overlap with active plugins (%s); declare an explicit priority in plugin.json", describeHookOverlaps(overlaps))
+		}
+	}
+
 	// Check if plugin already exists (update scenario)
 	if existingPlugin, exists := ps.plugins[metadata.Slug]; exists {
 		ps.logger.WithFields(logger.Fields{
@@ -165,11 +478,15 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 		reason := ""
 
 		if existingPlugin.Version == metadata.Version {
-			// Same version - require force=true
-			if !force {
+			if !hasRootfs {
+				// Manifest-only update of the same version - the image is unchanged
+				reason = "manifest-only update"
+			} else if !force {
+				// Same version - require force=true
 				return nil, fmt.Errorf("plugin '%s' version '%s' already exists. Use force=true to overwrite", metadata.Slug, metadata.Version)
+			} else {
+				reason = "force overwrite of same version"
 			}
-			reason = "force overwrite of same version"
 		} else {
 			// Different versions - compare
 			if ps.isVersionHigher(metadata.Version, existingPlugin.Version) {
@@ -202,13 +519,15 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 			}).Info("Cleaning up existing plugin resources before update")
 
 			// Stop any running VM instance first (this also removes from prewarm pool and deallocates IP)
-			instanceID := metadata.Slug
-			if err := ps.vmService.StopVM(instanceID); err != nil {
-				ps.logger.WithFields(logger.Fields{
-					"plugin_slug": metadata.Slug,
-					"error":       err,
-				}).Warn("Failed to stop existing VM during update")
-				// Continue with update even if cleanup fails
+			if instance := ps.vmService.PeekPrewarmInstance(metadata.Slug); instance != nil {
+				if err := ps.vmService.StopVM(instance.InstanceID); err != nil {
+					ps.logger.WithFields(logger.Fields{
+						"plugin_slug": metadata.Slug,
+						"instance_id": instance.InstanceID,
+						"error":       err,
+					}).Warn("Failed to stop existing VM during update")
+					// Continue with update even if cleanup fails
+				}
 			}
 
 			// Delete existing snapshot to force fresh snapshot creation
@@ -226,12 +545,21 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 		}
 
 		// Update existing plugin metadata
+		previousVersion := existingPlugin.Version
 		existingPlugin.Name = metadata.Name
 		existingPlugin.Description = metadata.Description
 		existingPlugin.Version = metadata.Version
 		existingPlugin.Author = metadata.Author
 		existingPlugin.Runtime = metadata.Runtime
+		if hasRootfs {
+			// The old image's link is already gone, drop its blob if nothing else shares it
+			if existingPlugin.RootfsHash != rootfsHash {
+				ps.releaseRootfsBlob(existingPlugin.RootfsHash, existingPlugin.EffectiveRootfsType())
+			}
+			existingPlugin.RootfsHash = rootfsHash
+		}
 		existingPlugin.RootfsPath = rootfsPath
+		existingPlugin.RootfsType = metadata.RootfsType
 		existingPlugin.UpdatedAt = time.Now()
 		// Preserve the existing status - if it was active, keep it active after update
 		// Only change to "installed" if it was previously failed
@@ -240,6 +568,25 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 		}
 		// Note: If status was "active", we keep it "active" - it will remain active after successful update
 		existingPlugin.Actions = metadata.Actions
+		existingPlugin.AllowEgress = metadata.AllowEgress
+		existingPlugin.SelfTest = metadata.SelfTest
+		existingPlugin.RestartPolicy = metadata.RestartPolicy
+		existingPlugin.MaxExecutionsPerSecond = metadata.MaxExecutionsPerSecond
+		existingPlugin.IsolationTier = metadata.IsolationTier
+		existingPlugin.Entrypoint = metadata.Entrypoint
+		existingPlugin.Requires = metadata.Requires
+		existingPlugin.Tags = metadata.Tags
+		existingPlugin.NetworkInterfaces = ps.mergeInterfaceAssignments(existingPlugin, metadata.NetworkInterfaces)
+		existingPlugin.Metrics = metadata.Metrics
+		existingPlugin.DeepValidation = metadata.DeepValidation
+		existingPlugin.OpenAPI = metadata.OpenAPI
+		existingPlugin.MTLS = metadata.MTLS
+		existingPlugin.Install = metadata.Install
+		existingPlugin.Uninstall = metadata.Uninstall
+		existingPlugin.Cancel = metadata.Cancel
+		if metadata.Priority != 0 {
+			existingPlugin.Priority = metadata.Priority
+		}
 		existingPlugin.Health = models.PluginHealth{Status: "unknown"}
 		// Preserve existing network configuration for now, will be updated during validation
 		// Note: We'll validate and potentially update network config during the health check phase
@@ -254,8 +601,7 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 			"plugin_slug": existingPlugin.Slug,
 		}).Info("Starting VM for plugin update validation")
 
-		// Use plugin slug as instance ID for consistency
-		instanceID := existingPlugin.Slug
+		instanceID := ps.vmService.NewInstanceID(existingPlugin.Slug)
 
 		// Start VM for health check
 		if err := ps.vmService.StartVM(instanceID, existingPlugin); err != nil {
@@ -287,14 +633,15 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 			"vm_ip":       vmIP,
 		}).Info("VM started successfully for update validation")
 
-		// Allow extra time for VM boot and application initialization
-		time.Sleep(3 * time.Second)
-
 		// Perform health validation using centralized method
 		if err := ps.validatePluginHealth(existingPlugin, instanceID, vmIP, "plugin_update"); err != nil {
 			return nil, err
 		}
 
+		if err := ps.runInstallHook(existingPlugin, instanceID, vmIP, previousVersion); err != nil {
+			return nil, err
+		}
+
 		// Update plugin with assigned IP and TAP device
 		// For updates, try to preserve existing network configuration if available
 		if existingPlugin.AssignedIP == "" || existingPlugin.TapDevice == "" {
@@ -314,6 +661,7 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 				"tap_device":  existingPlugin.TapDevice,
 			}).Info("Preserved existing network configuration for plugin update")
 		}
+		existingPlugin.NetworkInterfaces = ps.vmService.GetVMInterfaces(instanceID)
 
 		// Determine final status based on previous status
 		wasActive := existingPlugin.Status == "active"
@@ -346,13 +694,15 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 			}).Info("Plugin was active - keeping validation VM in prewarm pool")
 
 			// Create snapshot for the validation VM
-			snapshotPath := ps.vmService.GetSnapshotPath(existingPlugin.Slug)
-			if err := ps.vmService.CreateSnapshot(instanceID, snapshotPath, false); err != nil {
+			if err := ps.snapshotNewWarmInstance(instanceID, existingPlugin.Slug); err != nil {
 				ps.logger.WithFields(logger.Fields{
 					"plugin_slug": existingPlugin.Slug,
 					"error":       err,
 				}).Error("Failed to create snapshot for active plugin update")
 			} else {
+				existingPlugin.NeedsResnapshot = false
+				existingPlugin.SnapshotDiffCount = 0
+
 				// Pause VM to add to prewarm pool
 				if err := ps.vmService.PauseVM(instanceID); err != nil {
 					ps.logger.WithFields(logger.Fields{
@@ -380,6 +730,7 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 			"tap_device":  existingPlugin.TapDevice,
 			"status":      existingPlugin.Status,
 		}).Info("Plugin updated successfully")
+		ps.emitEvent(EventPluginUpdated, existingPlugin.Slug, "version "+existingPlugin.Version)
 
 		return existingPlugin, nil
 	}
@@ -393,19 +744,59 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 		Author:      metadata.Author,
 		Runtime:     metadata.Runtime,
 		RootfsPath:  rootfsPath,
+		RootfsType:  metadata.RootfsType,
+		RootfsHash:  rootfsHash,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		Status:      "installed", // New plugins start as installed, not ready
 		Health:      models.PluginHealth{Status: "unknown"},
 		Actions:     metadata.Actions,
-		Priority:    0,
+		AllowEgress: metadata.AllowEgress,
+		SelfTest:    metadata.SelfTest,
+		Priority:    metadata.Priority,
+
+		RestartPolicy:          metadata.RestartPolicy,
+		MaxExecutionsPerSecond: metadata.MaxExecutionsPerSecond,
+		IsolationTier:          metadata.IsolationTier,
+		Entrypoint:             metadata.Entrypoint,
+		Requires:               metadata.Requires,
+		Tags:                   metadata.Tags,
+		NetworkInterfaces:      metadata.NetworkInterfaces,
+		Metrics:                metadata.Metrics,
+		DeepValidation:         metadata.DeepValidation,
+		OpenAPI:                metadata.OpenAPI,
+		MTLS:                   metadata.MTLS,
+		Install:                metadata.Install,
+		Uninstall:              metadata.Uninstall,
+		Cancel:                 metadata.Cancel,
+	}
+
+	if err := ps.installPluginUnsafe(plugin, "plugin_upload"); err != nil {
+		return nil, err
 	}
 
-	ps.plugins[metadata.Slug] = plugin
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"name":        metadata.Name,
+		"version":     metadata.Version,
+		"assigned_ip": plugin.AssignedIP,
+		"tap_device":  plugin.TapDevice,
+		"status":      plugin.Status,
+	}).Info("Plugin uploaded and installed successfully")
+	ps.emitEvent(EventPluginInstalled, plugin.Slug, "version "+plugin.Version)
+
+	return plugin, nil
+}
+
+// installPluginUnsafe registers a new plugin and validates it in a VM, which
+// assigns its network identity. The validation VM is stopped afterwards.
+// Note: Caller must hold ps.mutex and the plugin's start lock
+func (ps *PluginService) installPluginUnsafe(plugin *models.Plugin, operation string) error {
+	ps.plugins[plugin.Slug] = plugin
 
 	// Save plugins registry
 	if err := ps.savePluginsUnsafe(); err != nil {
-		return nil, fmt.Errorf("failed to save plugins: %v", err)
+		return fmt.Errorf("failed to save plugins: %v", err)
 	}
 
 	// Start VM for health check and installation validation
@@ -413,8 +804,7 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 		"plugin_slug": plugin.Slug,
 	}).Info("Starting VM for plugin installation validation")
 
-	// Use plugin slug as instance ID for consistency
-	instanceID := plugin.Slug
+	instanceID := ps.vmService.NewInstanceID(plugin.Slug)
 
 	// Start VM for health check
 	if err := ps.vmService.StartVM(instanceID, plugin); err != nil {
@@ -422,7 +812,7 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 			"plugin_slug": plugin.Slug,
 			"error":       err,
 		}).Error("Failed to start VM for plugin installation validation")
-		return nil, fmt.Errorf("failed to start VM for installation validation: %v", err)
+		return fmt.Errorf("failed to start VM for installation validation: %v", err)
 	}
 
 	// Get VM IP from static networking
@@ -438,7 +828,7 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 				"error":       stopErr,
 			}).Error("Failed to stop VM after IP retrieval failure")
 		}
-		return nil, fmt.Errorf("failed to get VM IP for installation validation")
+		return fmt.Errorf("failed to get VM IP for installation validation")
 	}
 
 	ps.logger.WithFields(logger.Fields{
@@ -446,17 +836,19 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 		"vm_ip":       vmIP,
 	}).Info("VM started successfully for installation validation")
 
-	// Allow extra time for VM boot and application initialization
-	time.Sleep(3 * time.Second)
-
 	// Perform health validation using centralized method
-	if err := ps.validatePluginHealth(plugin, instanceID, vmIP, "plugin_upload"); err != nil {
-		return nil, err
+	if err := ps.validatePluginHealth(plugin, instanceID, vmIP, operation); err != nil {
+		return err
+	}
+
+	if err := ps.runInstallHook(plugin, instanceID, vmIP, ""); err != nil {
+		return err
 	}
 
 	// Update plugin with assigned IP and TAP device
 	plugin.AssignedIP = vmIP
 	plugin.TapDevice = ps.vmService.GetTapNameForPlugin(plugin.Slug)
+	plugin.NetworkInterfaces = ps.vmService.GetVMInterfaces(instanceID)
 	plugin.Status = "installed"
 	plugin.UpdatedAt = time.Now()
 
@@ -467,25 +859,106 @@ func (ps *PluginService) UploadPlugin(file multipart.File, filename string, forc
 			"error":       err,
 		}).Error("Failed to save plugin state after successful installation")
 		// Clean up VM on save failure
-		ps.cleanupPluginVM(plugin.Slug, instanceID, "plugin_upload_save_failure")
-		return nil, fmt.Errorf("failed to save plugin state: %v", err)
+		ps.cleanupPluginVM(plugin.Slug, instanceID, operation+"_save_failure")
+		return fmt.Errorf("failed to save plugin state: %v", err)
+	}
+
+	// Clean up VM and network - no prewarm on install, clean for next step
+	ps.cleanupPluginVM(plugin.Slug, instanceID, operation+"_success")
+
+	return nil
+}
+
+// UpdatePluginMetadata applies a partial update of mutable plugin metadata without touching the rootfs
+func (ps *PluginService) UpdatePluginMetadata(slug string, update *models.PluginMetadataUpdate) (*models.Plugin, error) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	plugin, exists := ps.plugins[slug]
+	if !exists {
+		return nil, fmt.Errorf("plugin not found")
+	}
+
+	if update.Resources != nil {
+		if err := validatePluginResources(*update.Resources); err != nil {
+			return nil, err
+		}
+	}
+
+	if update.Env != nil {
+		if err := validatePluginEnv(update.Env); err != nil {
+			return nil, err
+		}
+	}
+
+	if update.Priority != nil && !ps.validPriority(*update.Priority) {
+		return nil, fmt.Errorf("priority must be between %d and %d", ps.config.PluginPriorityMin, ps.config.PluginPriorityMax)
+	}
+
+	if update.Description != nil {
+		plugin.Description = *update.Description
+	}
+
+	if update.Priority != nil {
+		plugin.Priority = *update.Priority
+	}
+
+	// The environment reaches the guest on the kernel command line, so
+	// snapshotted VMs keep the old one
+	if update.Env != nil && !maps.Equal(update.Env, plugin.Env) {
+		plugin.Env = update.Env
+		plugin.NeedsResnapshot = true
+	}
+
+	if update.Enabled != nil {
+		plugin.Disabled = !*update.Enabled
+	}
+
+	if update.Debug != nil {
+		plugin.Debug = *update.Debug
+	}
+
+	// Resource changes only take effect in a freshly booted VM
+	if update.Resources != nil && *update.Resources != plugin.Resources {
+		plugin.Resources = *update.Resources
+		plugin.NeedsResnapshot = true
 	}
 
-	// Clean up VM and network - no prewarm during upload, clean for next step
-	ps.cleanupPluginVM(plugin.Slug, instanceID, "plugin_upload_success")
+	plugin.UpdatedAt = time.Now()
+
+	if err := ps.savePluginsUnsafe(); err != nil {
+		return nil, fmt.Errorf("failed to save plugin state: %v", err)
+	}
 
 	ps.logger.WithFields(logger.Fields{
-		"plugin_slug": plugin.Slug,
-		"name":        metadata.Name,
-		"version":     metadata.Version,
-		"assigned_ip": plugin.AssignedIP,
-		"tap_device":  plugin.TapDevice,
-		"status":      plugin.Status,
-	}).Info("Plugin uploaded and installed successfully")
+		"plugin_slug":      slug,
+		"priority":         plugin.Priority,
+		"disabled":         plugin.Disabled,
+		"debug":            plugin.Debug,
+		"needs_resnapshot": plugin.NeedsResnapshot,
+	}).Info("Plugin metadata updated")
 
 	return plugin, nil
 }
 
+// validPriority reports whether a priority lies within the configured range
+func (ps *PluginService) validPriority(priority int) bool {
+	return priority >= ps.config.PluginPriorityMin && priority <= ps.config.PluginPriorityMax
+}
+
+// validatePluginResources validates VM resource limits (zero values select the defaults)
+func validatePluginResources(resources models.PluginResources) error {
+	if resources.VcpuCount < 0 || resources.VcpuCount > maxVcpuCount {
+		return fmt.Errorf("vcpu_count must be between 1 and %d, or 0 for the default", maxVcpuCount)
+	}
+
+	if resources.MemSizeMib != 0 && (resources.MemSizeMib < minMemSizeMib || resources.MemSizeMib > maxMemSizeMib) {
+		return fmt.Errorf("mem_size_mib must be between %d and %d, or 0 for the default", minMemSizeMib, maxMemSizeMib)
+	}
+
+	return nil
+}
+
 // DeletePlugin deletes a plugin by slug
 func (ps *PluginService) DeletePlugin(slug string) error {
 	ps.mutex.Lock()
@@ -496,6 +969,11 @@ func (ps *PluginService) DeletePlugin(slug string) error {
 		return fmt.Errorf("plugin not found")
 	}
 
+	// Teardown failures are logged by the hook and never block the delete
+	if plugin.Uninstall != nil {
+		ps.runUninstallHook(plugin)
+	}
+
 	// Remove rootfs file
 	if err := os.Remove(plugin.RootfsPath); err != nil {
 		ps.logger.WithFields(logger.Fields{
@@ -503,8 +981,13 @@ func (ps *PluginService) DeletePlugin(slug string) error {
 			"error":       err,
 		}).Error("Failed to remove rootfs file")
 	}
+	ps.releaseRootfsBlob(plugin.RootfsHash, plugin.EffectiveRootfsType())
+	ps.removeOpenAPISpec(slug)
+	ps.vmService.TeardownExtraInterfaces(slug, plugin.NetworkInterfaces)
+	ps.vmService.RemovePluginCert(slug)
 
 	delete(ps.plugins, slug)
+	ps.circuitBreakers.reset(slug)
 
 	// Save plugins registry
 	if err := ps.savePluginsUnsafe(); err != nil {
@@ -516,6 +999,7 @@ func (ps *PluginService) DeletePlugin(slug string) error {
 		"name":        plugin.Name,
 		"version":     plugin.Version,
 	}).Info("Plugin deleted successfully")
+	ps.emitEvent(EventPluginDeleted, slug, "")
 
 	return nil
 }
@@ -537,6 +1021,24 @@ func (ps *PluginService) ActivatePlugin(slug string) (*models.Plugin, error) {
 		return plugin, nil
 	}
 
+	if plugin.Status == models.PluginStatusDraining {
+		return nil, ErrPluginDraining
+	}
+
+	if ps.vmService.InMaintenanceMode() {
+		return nil, ErrMaintenanceMode
+	}
+
+	// Discard a snapshot made stale by metadata changes
+	if plugin.NeedsResnapshot {
+		if err := ps.vmService.DeleteSnapshot(slug); err != nil {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": slug,
+				"error":       err,
+			}).Warn("Failed to delete stale snapshot")
+		}
+	}
+
 	// If snapshot already exists, just mark as active and ensure network config
 	if ps.vmService.HasSnapshot(slug) {
 		ps.logger.WithFields(logger.Fields{
@@ -559,11 +1061,15 @@ func (ps *PluginService) ActivatePlugin(slug string) (*models.Plugin, error) {
 		ps.logger.WithFields(logger.Fields{
 			"plugin_slug": slug,
 		}).Info("Plugin activated with existing snapshot")
+		ps.emitEvent(EventPluginActivated, slug, "")
 		return plugin, nil
 	}
 
+	unlockStart := ps.startLocks.lock(slug)
+	defer unlockStart()
+
 	// Create temporary VM to warm up and take snapshot
-	instanceID := slug // Use plugin slug as instance ID for consistency
+	instanceID := ps.vmService.NewInstanceID(slug)
 	ps.logger.WithFields(logger.Fields{
 		"plugin_slug": slug,
 		"instance_id": instanceID,
@@ -588,9 +1094,6 @@ func (ps *PluginService) ActivatePlugin(slug string) (*models.Plugin, error) {
 		"vm_ip":       vmIP,
 	}).Info("VM started successfully with static networking")
 
-	// Allow extra time for VM boot and Python app initialization
-	time.Sleep(3 * time.Second)
-
 	// Perform health validation using centralized method
 	if err := ps.validatePluginHealth(plugin, instanceID, vmIP, "plugin_activation"); err != nil {
 		return nil, err
@@ -598,7 +1101,7 @@ func (ps *PluginService) ActivatePlugin(slug string) (*models.Plugin, error) {
 
 	// Create snapshot for fast future execution (use full snapshot for first time)
 	snapshotPath := ps.vmService.GetSnapshotPath(slug)
-	if err := ps.vmService.CreateSnapshot(instanceID, snapshotPath, false); err != nil {
+	if err := ps.snapshotNewWarmInstance(instanceID, slug); err != nil {
 		ps.logger.WithFields(logger.Fields{
 			"plugin_slug": slug,
 			"error":       err,
@@ -640,7 +1143,10 @@ func (ps *PluginService) ActivatePlugin(slug string) (*models.Plugin, error) {
 	// Persist the assigned IP and TAP device for this plugin
 	plugin.AssignedIP = vmIP
 	plugin.TapDevice = ps.vmService.GetTapNameForPlugin(plugin.Slug)
+	plugin.NetworkInterfaces = ps.vmService.GetVMInterfaces(instanceID)
 
+	plugin.NeedsResnapshot = false
+	plugin.SnapshotDiffCount = 0
 	plugin.Status = "active"
 	plugin.UpdatedAt = time.Now()
 
@@ -655,28 +1161,78 @@ func (ps *PluginService) ActivatePlugin(slug string) (*models.Plugin, error) {
 		"tap_device":    plugin.TapDevice,
 	}).Info("Plugin activated successfully with snapshot and persistent networking")
 
+	// A fresh VM starts with a closed circuit
+	ps.circuitBreakers.reset(slug)
+	ps.emitEvent(EventPluginActivated, slug, "")
+
 	return plugin, nil
 }
 
+// snapshotNewWarmInstance takes the first full snapshot of a freshly booted
+// instance. Without snapshot support the booted VM is simply kept warm.
+func (ps *PluginService) snapshotNewWarmInstance(instanceID, slug string) error {
+	if !ps.vmService.SnapshotsSupported() {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": slug,
+			"instance_id": instanceID,
+		}).Warn("Snapshots unsupported, keeping booted VM warm without a snapshot")
+		return nil
+	}
+
+	return ps.vmService.CreateSnapshot(instanceID, ps.vmService.GetSnapshotPath(slug), false)
+}
+
 // DeactivatePlugin deactivates a plugin and cleans up network resources
 func (ps *PluginService) DeactivatePlugin(slug string) (*models.Plugin, error) {
 	ps.mutex.Lock()
-	defer ps.mutex.Unlock()
 
 	plugin, exists := ps.plugins[slug]
 	if !exists {
+		ps.mutex.Unlock()
 		return nil, fmt.Errorf("plugin not found")
 	}
 
 	if plugin.Status == "installed" {
+		ps.mutex.Unlock()
 		ps.logger.WithFields(logger.Fields{
 			"plugin_slug": slug,
 		}).Info("Plugin already installed (not active)")
 		return plugin, nil
 	}
 
-	// Remove from prewarm pool
-	ps.vmService.RemoveFromPrewarmPool(slug)
+	if plugin.Status == models.PluginStatusDraining {
+		ps.mutex.Unlock()
+		return nil, ErrPluginDraining
+	}
+
+	// Stop routing new executions, then let in-flight ones finish
+	wasActive := plugin.IsActive()
+	plugin.Status = models.PluginStatusDraining
+	ps.mutex.Unlock()
+
+	if wasActive {
+		ps.drainPlugin(slug)
+	}
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	// Deleted or re-uploaded while draining
+	if current, exists := ps.plugins[slug]; !exists || current != plugin || plugin.Status != models.PluginStatusDraining {
+		return nil, fmt.Errorf("plugin changed while draining, deactivation aborted")
+	}
+
+	// Remove from prewarm pool and stop the warm instance
+	if instance := ps.vmService.PeekPrewarmInstance(slug); instance != nil {
+		ps.vmService.RemoveFromPrewarmPool(slug)
+		if err := ps.vmService.StopVM(instance.InstanceID); err != nil {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": slug,
+				"instance_id": instance.InstanceID,
+				"error":       err,
+			}).Warn("Failed to stop warm instance during deactivation")
+		}
+	}
 
 	// Delete snapshot files
 	if err := ps.vmService.DeleteSnapshot(slug); err != nil {
@@ -702,60 +1258,265 @@ func (ps *PluginService) DeactivatePlugin(slug string) (*models.Plugin, error) {
 	ps.logger.WithFields(logger.Fields{
 		"plugin_slug": slug,
 	}).Info("Plugin deactivated successfully (set to installed)")
+	ps.emitEvent(EventPluginDeactivated, slug, "")
 
 	return plugin, nil
 }
 
-// ExecuteAction executes an action on a plugin using external VM service
-func (ps *PluginService) ExecuteAction(actionHook string, payload map[string]interface{}, vmService *VMService) (map[string]interface{}, error) {
-	ps.logger.WithFields(logger.Fields{
-		"action_hook": actionHook,
-	}).Info("Executing action")
-
-	// Find plugins that handle this action
-	var targetPlugins []*models.Plugin
-	for _, plugin := range ps.plugins {
+// SnapshotAllActive creates a fresh snapshot of every active plugin's warm instance
+// with bounded concurrency and returns the per-plugin outcome
+func (ps *PluginService) SnapshotAllActive() []models.SnapshotResult {
+	ps.mutex.RLock()
+	slugs := make([]string, 0)
+	for slug, plugin := range ps.plugins {
 		if plugin.Status == "active" {
-			for actionSlug, action := range plugin.Actions {
-				for _, hook := range action.Hooks {
-					if hook == actionHook {
-						targetPlugins = append(targetPlugins, plugin)
-						ps.logger.WithFields(logger.Fields{
-							"plugin_slug": plugin.Slug,
-							"action_slug": actionSlug,
-						}).Debug("Found plugin for action")
-						break
-					}
-				}
-			}
+			slugs = append(slugs, slug)
 		}
 	}
+	ps.mutex.RUnlock()
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_count": len(slugs),
+		"concurrency":  ps.config.SnapshotConcurrency,
+	}).Info("Creating snapshots for all active plugins")
+
+	results := make([]models.SnapshotResult, len(slugs))
+	semaphore := make(chan struct{}, ps.config.SnapshotConcurrency)
+	var wg sync.WaitGroup
+
+	for i, slug := range slugs {
+		wg.Add(1)
+		go func(i int, slug string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			startTime := time.Now()
+			result := models.SnapshotResult{PluginSlug: slug, Success: true}
+
+			var err error
+			if instance := ps.vmService.PeekPrewarmInstance(slug); instance == nil {
+				err = fmt.Errorf("no warm instance available")
+			} else {
+				err = ps.vmService.SnapshotInstance(instance.InstanceID, ps.vmService.GetSnapshotPath(slug))
+			}
+			if err != nil {
+				result.Success = false
+				result.Error = err.Error()
+				ps.logger.WithFields(logger.Fields{
+					"plugin_slug": slug,
+					"error":       err,
+				}).Error("Failed to snapshot active plugin")
+			} else {
+				// The full snapshot replaced any merged differentials
+				ps.mutex.Lock()
+				if plugin, exists := ps.plugins[slug]; exists {
+					plugin.SnapshotDiffCount = 0
+				}
+				ps.mutex.Unlock()
+			}
+
+			result.DurationMs = time.Since(startTime).Milliseconds()
+			results[i] = result
+		}(i, slug)
+	}
+
+	wg.Wait()
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_count": len(slugs),
+	}).Info("Snapshot of all active plugins completed")
+
+	return results
+}
+
+// ErrNoPermittedPlugins is returned when plugins handle an action but the
+// filter excludes all of them
+var ErrNoPermittedPlugins = errors.New("no permitted plugins for action")
+
+// PluginFilter reports whether a plugin may be executed
+type PluginFilter func(plugin *models.Plugin) bool
+
+// ExecutionOptions narrows and shapes an execution; the zero value runs every
+// handling plugin in the hook's configured mode
+type ExecutionOptions struct {
+	Headers http.Header  // Caller request headers: deadline, budget and payload transform fields
+	Filter  PluginFilter // Plugins the caller may execute, nil for all
+	Mode    string       // Execution mode, empty for the hook's configured mode
+	Tag     string       // Only plugins with this tag, empty for all
+}
+
+// ExecuteAction executes an action on a plugin using external VM service
+func (ps *PluginService) ExecuteAction(actionHook string, payload map[string]interface{}, vmService *VMService) (map[string]interface{}, error) {
+	return ps.ExecuteActionFiltered(actionHook, payload, vmService, ExecutionOptions{})
+}
+
+// ExecuteActionFiltered executes an action on the plugins accepted by the
+// options' filter, or on all handling plugins if it is nil. A non-empty tag
+// limits the targets to plugins with that tag. Plugins run in priority order
+// until the mode is satisfied; an empty mode uses the hook's configured mode.
+// The configured payload transforms are applied first, reading mapped fields
+// from the headers. A deadline the caller set in the headers bounds every
+// plugin request. Once the execution budget is spent no further plugins are
+// called and the partial results are returned with budget_exceeded set.
+func (ps *PluginService) ExecuteActionFiltered(actionHook string, payload map[string]interface{}, vmService *VMService, opts ExecutionOptions) (map[string]interface{}, error) {
+	headers, filter, mode, tag := opts.Headers, opts.Filter, opts.Mode, opts.Tag
+	deadline := callerDeadline(headers, time.Now())
+
+	// The budget is shared by every plugin of this execution
+	budgetCtx := context.Background()
+	var budgetDeadline time.Time
+	budget := executionBudget(ps.config.ExecutionBudgetMs, headers)
+	if budget > 0 {
+		var cancel context.CancelFunc
+		budgetCtx, cancel = context.WithTimeout(budgetCtx, budget)
+		defer cancel()
+
+		budgetDeadline, _ = budgetCtx.Deadline()
+		if deadline.IsZero() || budgetDeadline.Before(deadline) {
+			deadline = budgetDeadline
+		}
+	}
+	budgetExceeded := false
+
+	payload = ps.payloadTransforms.apply(payload, headers)
+
+	if mode == "" {
+		mode = ps.hookExecutionMode(actionHook)
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"action_hook": actionHook,
+		"mode":        mode,
+		"tag":         tag,
+	}).Info("Executing action")
+
+	// Find plugins that handle this action, within the tag if one is given
+	var targetPlugins []*models.Plugin
+	for _, plugin := range ps.plugins {
+		if plugin.Status == "active" && plugin.IsEnabled() && (tag == "" || plugin.HasTag(tag)) {
+			for actionSlug, action := range plugin.Actions {
+				for _, hook := range action.Hooks {
+					if hook == actionHook {
+						targetPlugins = append(targetPlugins, plugin)
+						ps.logger.WithFields(logger.Fields{
+							"plugin_slug": plugin.Slug,
+							"action_slug": actionSlug,
+						}).Debug("Found plugin for action")
+						break
+					}
+				}
+			}
+		}
+	}
+
+	// The echo plugin is added before the caller filter, which applies to it too
+	targetPlugins = ps.diagnosticTargets(actionHook, targetPlugins)
+
+	if filter != nil && len(targetPlugins) > 0 {
+		var permitted []*models.Plugin
+		for _, plugin := range targetPlugins {
+			if filter(plugin) {
+				permitted = append(permitted, plugin)
+			}
+		}
+		if len(permitted) == 0 {
+			return nil, ErrNoPermittedPlugins
+		}
+		targetPlugins = permitted
+	}
 
 	if len(targetPlugins) == 0 {
 		return map[string]interface{}{
 			"action_hook":      actionHook,
+			"mode":             mode,
 			"executed_plugins": 0,
+			"skipped_plugins":  0,
 			"results":          []interface{}{},
 			"timestamp":        time.Now(),
 		}, nil
 	}
 
-	// Sort plugins by priority (highest first)
-	for i := 0; i < len(targetPlugins)-1; i++ {
-		for j := i + 1; j < len(targetPlugins); j++ {
-			if targetPlugins[i].Priority < targetPlugins[j].Priority {
-				targetPlugins[i], targetPlugins[j] = targetPlugins[j], targetPlugins[i]
-			}
-		}
-	}
+	// Sort plugins by priority (highest first), by slug among equal priorities
+	SortPlugins(targetPlugins, PluginSortPriority)
 
 	var results []map[string]interface{}
 
 	for _, plugin := range targetPlugins {
 		startTime := time.Now()
 
+		// Plugins left when the budget is spent are skipped, not failed
+		if budgetCtx.Err() != nil || (budget > 0 && !startTime.Before(budgetDeadline)) {
+			budgetExceeded = true
+			break
+		}
+
+		// The built-in echo plugin runs in-process; it passed the caller filter above
+		if plugin == diagnosticEchoPlugin {
+			results = append(results, ps.executeEcho(actionHook, payload, startTime))
+			if mode != config.ExecutionModeBroadcast {
+				break
+			}
+			continue
+		}
+
+		// Plugins left when the caller's deadline passes are not called at all
+		if !deadline.IsZero() && !startTime.Before(deadline) {
+			results = append(results, executionFailure(plugin.Slug, cms_errors.ErrTypeTimeout,
+				"Caller deadline exceeded before the plugin was called", startTime))
+			continue
+		}
+
+		// Over-limit calls never reach the VM
+		if !ps.rateLimiter.allow(plugin.Slug, plugin.MaxExecutionsPerSecond) {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug":               plugin.Slug,
+				"action_hook":               actionHook,
+				"max_executions_per_second": plugin.MaxExecutionsPerSecond,
+			}).Warn("Plugin execution throttled")
+
+			results = append(results, executionFailure(plugin.Slug, cms_errors.ErrTypeRateLimit,
+				"Plugin rate limit exceeded", startTime))
+			continue
+		}
+
+		// A plugin that keeps failing is not called until its circuit closes again
+		if !ps.allowExecution(plugin.Slug) {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"action_hook": actionHook,
+			}).Debug("Plugin circuit open, execution rejected")
+
+			results = append(results, executionFailure(plugin.Slug, cms_errors.ErrTypeCircuitOpen,
+				"Plugin circuit breaker is open after repeated failures", startTime))
+			continue
+		}
+
+		// Track in-flight executions (instances are held until the action completes)
+		ps.executionMetrics.executionStarted(plugin.Slug)
+		defer ps.executionMetrics.executionFinished(plugin.Slug)
+
 		// Try to get a pre-warmed instance from the pool
 		prewarmInstance := ps.vmService.GetPrewarmInstance(plugin.Slug)
+		ps.executionMetrics.instanceLookup(plugin.Slug, prewarmInstance != nil)
+
+		// Set when the instance may still be busy with abandoned work
+		var retireCause error
+
+		// Instances evicted by the pool caps are restored with a cold start in the
+		// background; the boot would not fit the execution budget
+		if prewarmInstance == nil && ps.vmService.WasEvicted(plugin.Slug) && !ps.vmService.InMaintenanceMode() {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"action_hook": actionHook,
+			}).Info("Warm instance was evicted, cold starting plugin")
+
+			ps.coldStartEvicted(plugin)
+			results = append(results, executionFailure(plugin.Slug, cms_errors.ErrTypeVM,
+				"Plugin not ready - warm instance was evicted and is being restored, retry shortly", startTime))
+			ps.recordExecutionOutcome(plugin.Slug, breakerNeutral)
+			continue
+		}
 
 		var instanceID string
 		var vmIP string
@@ -777,28 +1538,27 @@ func (ps *PluginService) ExecuteAction(actionHook string, payload map[string]int
 					"plugin_slug": plugin.Slug,
 					"error":       err,
 				}).Error("Failed to resume pre-warmed VM")
+				ps.vmService.AbandonPrewarmInstance(prewarmInstance)
 
-				results = append(results, map[string]interface{}{
-					"plugin_slug":       plugin.Slug,
-					"success":           false,
-					"result":            map[string]interface{}{"error": fmt.Sprintf("Failed to resume VM: %v", err)},
-					"execution_time_ms": int(time.Since(startTime).Milliseconds()),
-				})
+				results = append(results, executionFailure(plugin.Slug, cms_errors.ErrTypeVM,
+					fmt.Sprintf("Failed to resume VM: %v", err), startTime))
+				ps.recordExecutionOutcome(plugin.Slug, breakerFailure)
 				continue
 			}
 
-			// Return VM to pool after execution
-			defer func(pluginSlug string, instance *PrewarmInstance) {
-				// Pause VM and return to pool
-				if pauseErr := ps.vmService.PauseVM(instance.InstanceID); pauseErr != nil {
-					ps.logger.WithFields(logger.Fields{
-						"instance_id": instance.InstanceID,
-						"error":       pauseErr,
-					}).Error("Failed to pause VM for pool return")
-				} else {
-					ps.vmService.ReturnPrewarmInstance(pluginSlug, instance)
+			// Record time spent acquiring and resuming the warm instance
+			ps.executionMetrics.instanceAcquired(plugin.Slug, time.Since(startTime))
+
+			// Return VM to pool after execution, unless it is no longer healthy
+			// or could not be stopped from finishing a timed-out action
+			defer func() {
+				if retireCause != nil {
+					ps.vmService.AbandonPrewarmInstance(prewarmInstance)
+					ps.retireWarmInstance(plugin, prewarmInstance, retireCause)
+					return
 				}
-			}(plugin.Slug, prewarmInstance)
+				ps.releaseWarmInstance(plugin, prewarmInstance)
+			}()
 
 		} else {
 			// No pre-warmed instance available - this should not happen for active plugins
@@ -808,25 +1568,35 @@ func (ps *PluginService) ExecuteAction(actionHook string, payload map[string]int
 				"action_hook": actionHook,
 			}).Error("No pre-warmed instance available for active plugin - plugin may not be properly activated")
 
-			results = append(results, map[string]interface{}{
-				"plugin_slug":       plugin.Slug,
-				"success":           false,
-				"result":            map[string]interface{}{"error": "Plugin not ready - no pre-warmed instance available"},
-				"execution_time_ms": int(time.Since(startTime).Milliseconds()),
-			})
+			results = append(results, executionFailure(plugin.Slug, cms_errors.ErrTypeVM,
+				"Plugin not ready - no pre-warmed instance available", startTime))
+			ps.recordExecutionOutcome(plugin.Slug, breakerFailure)
 			continue
 		}
 
-		// Brief wait for VM to be ready
-		time.Sleep(10 * time.Millisecond)
+		// Dispatch as soon as the resumed guest accepts connections
+		if err := ps.waitGuestReady(vmIP, deadline); err != nil {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"instance_id": instanceID,
+				"error":       err,
+			}).Error("Resumed VM did not accept connections")
+
+			results = append(results, executionFailure(plugin.Slug, cms_errors.ErrTypeNetwork,
+				fmt.Sprintf("Plugin not ready after resume: %v", err), startTime))
+			ps.recordExecutionOutcome(plugin.Slug, breakerFailure)
+			continue
+		}
 
 		// Find the appropriate action endpoint
 		var targetAction *models.PluginAction
-		for _, action := range plugin.Actions {
+		var targetActionName string
+		for actionName, action := range plugin.Actions {
 			for _, hook := range action.Hooks {
 				if hook == actionHook {
 					actionCopy := action
 					targetAction = &actionCopy
+					targetActionName = actionName
 					break
 				}
 			}
@@ -836,17 +1606,14 @@ func (ps *PluginService) ExecuteAction(actionHook string, payload map[string]int
 		}
 
 		if targetAction == nil {
-			results = append(results, map[string]interface{}{
-				"plugin_slug":       plugin.Slug,
-				"success":           false,
-				"result":            map[string]interface{}{"error": "Action not found in plugin"},
-				"execution_time_ms": int(time.Since(startTime).Milliseconds()),
-			})
+			results = append(results, executionFailure(plugin.Slug, cms_errors.ErrTypeValidation,
+				"Action not found in plugin", startTime))
+			ps.recordExecutionOutcome(plugin.Slug, breakerNeutral)
 			continue
 		}
 
 		// HTTP REQUEST to the running plugin VM
-		actionURL := fmt.Sprintf("http://%s:80%s", vmIP, targetAction.Endpoint)
+		actionURL := pluginURL(plugin, vmIP, targetAction.Endpoint)
 
 		requestPayload := map[string]interface{}{
 			"hook":    actionHook,
@@ -859,24 +1626,73 @@ func (ps *PluginService) ExecuteAction(actionHook string, payload map[string]int
 			"method":      targetAction.Method,
 		}).Info("Making HTTP request to running plugin VM")
 
-		response, err := ps.makeHTTPRequest(targetAction.Method, actionURL, requestPayload)
+		ps.actionUsage.invoked(plugin.Slug, targetActionName, startTime)
+
+		debugLogging := ps.debugLoggingEnabled(plugin)
+		if debugLogging {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"action_hook": actionHook,
+				"action_url":  actionURL,
+				"request":     ps.debugBody(requestPayload),
+			}).Info("Plugin debug: execution request")
+		}
+
+		response, err := ps.makeHTTPRequestUntil(targetAction.Method, actionURL, requestPayload,
+			actionDeadline(targetAction, deadline, time.Now()))
+		if debugLogging {
+			debugFields := logger.Fields{
+				"plugin_slug":    plugin.Slug,
+				"action_hook":    actionHook,
+				"execution_time": time.Since(startTime).Milliseconds(),
+			}
+			if err != nil {
+				debugFields["error"] = err.Error()
+			} else {
+				debugFields["response"] = ps.debugBody(response)
+			}
+			ps.logger.WithFields(debugFields).Info("Plugin debug: execution response")
+		}
 		if err != nil {
+			errType := categorizeRequestError(err)
+
 			ps.logger.WithFields(logger.Fields{
 				"plugin_slug": plugin.Slug,
 				"action_url":  actionURL,
+				"error_type":  errType,
 				"error":       err,
 			}).Error("HTTP request to plugin failed")
 
-			results = append(results, map[string]interface{}{
-				"plugin_slug":       plugin.Slug,
-				"success":           false,
-				"result":            map[string]interface{}{"error": fmt.Sprintf("HTTP request failed: %v", err)},
-				"execution_time_ms": int(time.Since(startTime).Milliseconds()),
-			})
+			failure := executionFailure(plugin.Slug, errType,
+				fmt.Sprintf("HTTP request failed: %v", err), startTime)
+
+			// Ask the plugin to abort the work the CMS stopped waiting for
+			if errType == cms_errors.ErrTypeTimeout && plugin.Cancel != nil {
+				cancelErr := ps.runCancelHook(plugin, vmIP, targetActionName, actionHook)
+				failure["cancelled"] = cancelErr == nil
+				if cancelErr != nil && !ps.vmService.InMaintenanceMode() {
+					retireCause = fmt.Errorf("cancel hook failed after timeout: %v", cancelErr)
+				}
+			}
+			results = append(results, failure)
+
+			// A request cut short by the budget says nothing about the plugin
+			if budgetCtx.Err() != nil {
+				ps.recordExecutionOutcome(plugin.Slug, breakerNeutral)
+			} else {
+				ps.recordExecutionOutcome(plugin.Slug, breakerOutcomeOf(errType))
+			}
+
+			// An error response still answers the call
+			var statusErr *httpStatusError
+			if mode == config.ExecutionModeAny && errors.As(err, &statusErr) {
+				break
+			}
 			continue
 		}
 
 		// SUCCESS: Actual response from plugin
+		ps.recordExecutionOutcome(plugin.Slug, breakerSuccess)
 		results = append(results, map[string]interface{}{
 			"plugin_slug":       plugin.Slug,
 			"success":           true,
@@ -884,53 +1700,117 @@ func (ps *PluginService) ExecuteAction(actionHook string, payload map[string]int
 			"execution_time_ms": int(time.Since(startTime).Milliseconds()),
 		})
 
-		ps.logger.WithFields(logger.Fields{
+		successFields := logger.Fields{
 			"plugin_slug":    plugin.Slug,
 			"execution_time": time.Since(startTime).Milliseconds(),
 			"action_hook":    actionHook,
-		}).Info("Action executed successfully")
+		}
+		if ps.config.LogResultMaxBytes > 0 {
+			successFields["result"] = ps.loggableResult(response)
+		}
+		ps.logger.WithFields(successFields).Info("Action executed successfully")
+
+		if mode != config.ExecutionModeBroadcast {
+			break
+		}
+	}
+
+	ps.recordExecutionHistory(actionHook, headers, results)
+
+	if budgetExceeded {
+		ps.logger.WithFields(logger.Fields{
+			"action_hook":     actionHook,
+			"budget_ms":       budget.Milliseconds(),
+			"skipped_plugins": len(targetPlugins) - len(results),
+		}).Warn("Execution budget exceeded, remaining plugins not called")
+	} else if skipped := len(targetPlugins) - len(results); skipped > 0 {
+		ps.logger.WithFields(logger.Fields{
+			"action_hook":     actionHook,
+			"mode":            mode,
+			"skipped_plugins": skipped,
+		}).Debug("Remaining plugins skipped after action was answered")
 	}
 
 	return map[string]interface{}{
 		"action_hook":      actionHook,
+		"mode":             mode,
 		"executed_plugins": len(results),
+		"skipped_plugins":  len(targetPlugins) - len(results),
+		"budget_exceeded":  budgetExceeded,
 		"results":          results,
 		"timestamp":        time.Now(),
 	}, nil
 }
 
-func (ps *PluginService) extractPluginZip(zipPath, destDir string) error {
+// hookExecutionMode returns the configured execution mode of a hook
+func (ps *PluginService) hookExecutionMode(actionHook string) string {
+	if mode, exists := ps.hookModes[actionHook]; exists {
+		return mode
+	}
+	return config.ExecutionModeBroadcast
+}
+
+// pluginZipExtractSize returns the uncompressed size of the files
+// extractPluginZip extracts
+func pluginZipExtractSize(zipPath string) (int64, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	var size int64
+	for _, file := range reader.File {
+		if file.Name == "plugin.json" || file.Name == models.RootfsFileName(models.RootfsTypeExt4) ||
+			file.Name == models.RootfsFileName(models.RootfsTypeSquashfs) {
+			size += int64(file.UncompressedSize64)
+		}
+	}
+	return size, nil
+}
+
+// extractPluginZip extracts plugin.json and, if present, the rootfs image
+// (rootfs.ext4 or rootfs.squashfs) from the plugin ZIP. It returns the name of
+// the rootfs image, or "" if the ZIP contained none.
+func (ps *PluginService) extractPluginZip(zipPath, destDir string) (string, error) {
 	reader, err := zip.OpenReader(zipPath)
 	if err != nil {
-		return fmt.Errorf("failed to open ZIP file: %v", err)
+		return "", fmt.Errorf("failed to open ZIP file: %v", err)
 	}
 	defer reader.Close()
 
-	hasRootfs := false
+	rootfsName := ""
 	hasPluginJson := false
 
 	for _, file := range reader.File {
 		// Security check: prevent path traversal
 		if strings.Contains(file.Name, "..") {
-			return fmt.Errorf("invalid file path in ZIP: %s", file.Name)
+			return "", fmt.Errorf("invalid file path in ZIP: %s", file.Name)
 		}
 
+		isRootfs := file.Name == models.RootfsFileName(models.RootfsTypeExt4) ||
+			file.Name == models.RootfsFileName(models.RootfsTypeSquashfs)
+
 		// Only extract required files
-		if file.Name != "rootfs.ext4" && file.Name != "plugin.json" {
+		if !isRootfs && file.Name != "plugin.json" {
 			continue
 		}
 
+		if isRootfs && rootfsName != "" {
+			return "", fmt.Errorf("plugin ZIP contains both %s and %s", rootfsName, file.Name)
+		}
+
 		destPath := filepath.Join(destDir, file.Name)
 
 		fileReader, err := file.Open()
 		if err != nil {
-			return fmt.Errorf("failed to open file %s in ZIP: %v", file.Name, err)
+			return "", fmt.Errorf("failed to open file %s in ZIP: %v", file.Name, err)
 		}
 
 		destFile, err := os.Create(destPath)
 		if err != nil {
 			fileReader.Close()
-			return fmt.Errorf("failed to create file %s: %v", destPath, err)
+			return "", fmt.Errorf("failed to create file %s: %v", destPath, err)
 		}
 
 		_, err = io.Copy(destFile, fileReader)
@@ -938,30 +1818,96 @@ func (ps *PluginService) extractPluginZip(zipPath, destDir string) error {
 		destFile.Close()
 
 		if err != nil {
-			return fmt.Errorf("failed to extract file %s: %v", file.Name, err)
+			return "", fmt.Errorf("failed to extract file %s: %v", file.Name, err)
 		}
 
-		if file.Name == "rootfs.ext4" {
-			hasRootfs = true
-		} else if file.Name == "plugin.json" {
+		if isRootfs {
+			rootfsName = file.Name
+		} else {
 			hasPluginJson = true
 		}
 	}
 
-	if !hasRootfs {
-		return fmt.Errorf("rootfs.ext4 not found in plugin ZIP")
-	}
 	if !hasPluginJson {
-		return fmt.Errorf("plugin.json not found in plugin ZIP")
+		return "", fmt.Errorf("plugin.json not found in plugin ZIP")
+	}
+
+	return rootfsName, nil
+}
+
+// installedRootfsPath returns the rootfs of an installed plugin for manifest-only
+// updates, which cannot change the rootfs type
+func (ps *PluginService) installedRootfsPath(slug, rootfsType string) (string, error) {
+	ps.mutex.RLock()
+	existingPlugin, exists := ps.plugins[slug]
+	ps.mutex.RUnlock()
+
+	rootfsName := models.RootfsFileName(rootfsType)
+
+	if !exists || existingPlugin.RootfsPath == "" {
+		return "", fmt.Errorf("%s not found in plugin ZIP and plugin '%s' has no installed rootfs to reuse", rootfsName, slug)
+	}
+
+	if installedType := existingPlugin.EffectiveRootfsType(); installedType != rootfsType {
+		return "", fmt.Errorf("%s not found in plugin ZIP and the installed rootfs of '%s' is %s", rootfsName, slug, installedType)
+	}
+
+	if _, err := os.Stat(existingPlugin.RootfsPath); err != nil {
+		return "", fmt.Errorf("%s not found in plugin ZIP and installed rootfs for '%s' is missing: %v", rootfsName, slug, err)
+	}
+
+	return existingPlugin.RootfsPath, nil
+}
+
+// validateRootfsSize rejects rootfs images outside the configured size bounds.
+// Compressed squashfs images only have to stay below the maximum.
+func (ps *PluginService) validateRootfsSize(rootfsPath, rootfsType string) error {
+	rootfsName := models.RootfsFileName(rootfsType)
+
+	info, err := os.Stat(rootfsPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %v", rootfsName, err)
+	}
+
+	const mb = 1024 * 1024
+	minBytes := int64(ps.config.MinRootfsSizeMB) * mb
+	maxBytes := int64(ps.config.MaxRootfsSizeMB) * mb
+	if rootfsType == models.RootfsTypeSquashfs {
+		minBytes = 0
+	}
+
+	if info.Size() < minBytes || info.Size() > maxBytes {
+		return fmt.Errorf("%s size %.1fMB is outside the allowed range %dMB-%dMB",
+			rootfsName, float64(info.Size())/mb, minBytes/mb, ps.config.MaxRootfsSizeMB)
 	}
 
 	return nil
 }
 
-func (ps *PluginService) parsePluginJson(jsonPath string) (*models.Plugin, error) {
+// hostSupportErrors reports the required capabilities, mutual TLS setup and
+// jailer for the untrusted isolation tier this host cannot provide to a plugin
+func (ps *PluginService) hostSupportErrors(requires []string, mtls bool, isolationTier string) models.ValidationErrors {
+	var validationErrors models.ValidationErrors
+	if missing := ps.vmService.Capabilities().Missing(requires); len(missing) > 0 {
+		validationErrors.Add("requires", "host does not provide required capabilities: %s (see /api/system/info)", strings.Join(missing, ", "))
+	}
+	if mtls && !ps.vmService.PluginTLSEnabled() {
+		validationErrors.Add("mtls", "plugin requires mutual TLS, which is disabled (set CMS_PLUGIN_MTLS=true)")
+	} else if mtls && !ps.vmService.Capabilities().MMDS {
+		validationErrors.Add("mtls", "plugin requires mutual TLS, whose certificate is delivered through MMDS, which this Firecracker lacks")
+	}
+	if ps.vmService.resolveIsolationTier(isolationTier) == models.IsolationTierUntrusted && !ps.config.JailerEnabled {
+		validationErrors.Add("isolation_tier", "plugin runs in the untrusted tier, which requires the jailer (set CMS_JAILER_ENABLED=true)")
+	}
+	return validationErrors
+}
+
+// parsePluginJson reads a plugin manifest. Manifest problems are collected into
+// the returned validation errors; the error is only set if the file is unreadable.
+func (ps *PluginService) parsePluginJson(jsonPath string) (*models.Plugin, models.ValidationErrors, error) {
 	data, err := os.ReadFile(jsonPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read plugin.json: %v", err)
+		return nil, nil, fmt.Errorf("failed to read plugin.json: %v", err)
 	}
 
 	var metadata struct {
@@ -972,21 +1918,51 @@ func (ps *PluginService) parsePluginJson(jsonPath string) (*models.Plugin, error
 		Author      string                         `json:"author"`
 		Runtime     string                         `json:"runtime"`
 		Actions     map[string]models.PluginAction `json:"actions"`
+		Defaults    *models.PluginActionDefaults   `json:"action_defaults"`
+		Priority    int                            `json:"priority"`
+		AllowEgress bool                           `json:"allow_egress"`
+		SelfTest    *models.PluginSelfTest         `json:"selftest"`
+
+		RestartPolicy          string  `json:"restart_policy"`
+		MaxExecutionsPerSecond float64 `json:"max_executions_per_second"`
+		IsolationTier          string  `json:"isolation_tier"`
+
+		Entrypoint string `json:"entrypoint"`
+		Port       int    `json:"port"`
+		RootfsType string `json:"rootfs_type"`
+
+		Requires []string                      `json:"requires"`
+		Metrics  *models.PluginMetricsEndpoint `json:"metrics"`
+		Tags     []string                      `json:"tags"`
+
+		NetworkInterfaces []struct {
+			Name string `json:"name"`
+		} `json:"network_interfaces"`
+
+		DeepValidation bool   `json:"deep_validation"`
+		MTLS           bool   `json:"mtls"`
+		OpenAPI        string `json:"openapi"`
+
+		Install   *models.PluginLifecycleHook `json:"install"`
+		Uninstall *models.PluginLifecycleHook `json:"uninstall"`
+		Cancel    *models.PluginLifecycleHook `json:"cancel"`
 	}
 
 	if err := json.Unmarshal(data, &metadata); err != nil {
-		return nil, fmt.Errorf("failed to parse plugin.json: %v", err)
+		return nil, nil, fmt.Errorf("failed to parse plugin.json: %v", err)
 	}
 
+	var validationErrors models.ValidationErrors
+
 	// Validate required fields
 	if metadata.Slug == "" {
-		return nil, fmt.Errorf("plugin slug is required")
+		validationErrors.Add("slug", "plugin slug is required")
 	}
 	if metadata.Name == "" {
-		return nil, fmt.Errorf("plugin name is required")
+		validationErrors.Add("name", "plugin name is required")
 	}
 	if metadata.Version == "" {
-		return nil, fmt.Errorf("plugin version is required")
+		validationErrors.Add("version", "plugin version is required")
 	}
 
 	plugin := &models.Plugin{
@@ -997,80 +1973,348 @@ func (ps *PluginService) parsePluginJson(jsonPath string) (*models.Plugin, error
 		Author:      metadata.Author,
 		Runtime:     metadata.Runtime,
 		Actions:     metadata.Actions,
+		Priority:    metadata.Priority,
+		AllowEgress: metadata.AllowEgress,
+		SelfTest:    metadata.SelfTest,
+
+		RestartPolicy:          metadata.RestartPolicy,
+		MaxExecutionsPerSecond: metadata.MaxExecutionsPerSecond,
+		IsolationTier:          metadata.IsolationTier,
+		Entrypoint:             metadata.Entrypoint,
+		RootfsType:             metadata.RootfsType,
+		Requires:               metadata.Requires,
+		Tags:                   metadata.Tags,
+		Metrics:                metadata.Metrics,
+		DeepValidation:         metadata.DeepValidation,
+		OpenAPI:                metadata.OpenAPI,
+		MTLS:                   metadata.MTLS,
+		Install:                metadata.Install,
+		Uninstall:              metadata.Uninstall,
+		Cancel:                 metadata.Cancel,
 	}
 
-	return plugin, nil
-}
+	// Unmarshal keeps only the last of duplicate actions, hiding the others
+	for _, name := range duplicateActionKeys(data) {
+		validationErrors.Add("actions."+name, "action %q is declared more than once", name)
+	}
 
-func (ps *PluginService) copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
-	if err != nil {
-		return err
+	if metadata.Port != 0 && metadata.Port != 80 {
+		validationErrors.Add("port", "port %d is not supported: plugins must serve HTTP on port 80", metadata.Port)
 	}
-	defer sourceFile.Close()
 
-	destFile, err := os.Create(dst)
-	if err != nil {
-		return err
+	if plugin.Entrypoint != "" && !strings.HasPrefix(plugin.Entrypoint, "/") {
+		validationErrors.Add("entrypoint", "entrypoint must be an absolute path, got %q", plugin.Entrypoint)
 	}
-	defer destFile.Close()
 
-	_, err = destFile.ReadFrom(sourceFile)
-	return err
-}
+	if !models.IsValidRootfsType(plugin.RootfsType) {
+		validationErrors.Add("rootfs_type", "unsupported rootfs_type %q: must be %s or %s",
+			plugin.RootfsType, models.RootfsTypeExt4, models.RootfsTypeSquashfs)
+	}
 
-func (ps *PluginService) savePluginsUnsafe() error {
-	// Note: Caller must hold ps.mutex.Lock()
-	pluginsDir := filepath.Join(ps.config.DataDir, "plugins")
-	if err := os.MkdirAll(pluginsDir, 0755); err != nil {
-		return err
+	if plugin.Metrics != nil {
+		if !strings.HasPrefix(plugin.Metrics.Path, "/") {
+			validationErrors.Add("metrics.path", "metrics path must start with /, got %q", plugin.Metrics.Path)
+		}
+		if plugin.Metrics.Port < 0 || plugin.Metrics.Port > 65535 {
+			validationErrors.Add("metrics.port", "metrics port %d is out of range", plugin.Metrics.Port)
+		}
 	}
 
-	pluginsFile := filepath.Join(pluginsDir, "plugins.json")
-	data, err := json.MarshalIndent(ps.plugins, "", "  ")
-	if err != nil {
-		return err
+	if plugin.OpenAPI != "" && !strings.HasPrefix(plugin.OpenAPI, "/") {
+		validationErrors.Add("openapi", "openapi path must start with /, got %q", plugin.OpenAPI)
 	}
 
-	if err := os.WriteFile(pluginsFile, data, 0644); err != nil {
-		return err
+	// Only interface names come from the manifest; the CMS assigns the host side
+	if len(metadata.NetworkInterfaces) > ps.config.MaxPluginInterfaces {
+		validationErrors.Add("network_interfaces", "%d additional network interfaces declared, at most %d allowed",
+			len(metadata.NetworkInterfaces), ps.config.MaxPluginInterfaces)
+	}
+	seenInterfaces := make(map[string]bool, len(metadata.NetworkInterfaces))
+	for _, iface := range metadata.NetworkInterfaces {
+		if !models.IsValidInterfaceName(iface.Name) {
+			validationErrors.Add("network_interfaces", "invalid interface name %q: use 1-15 lowercase letters, digits or hyphens", iface.Name)
+		} else if seenInterfaces[iface.Name] {
+			validationErrors.Add("network_interfaces", "interface %q is declared more than once", iface.Name)
+		}
+		seenInterfaces[iface.Name] = true
+		plugin.NetworkInterfaces = append(plugin.NetworkInterfaces, models.PluginNetworkInterface{Name: iface.Name})
 	}
 
-	ps.logger.WithFields(logger.Fields{
-		"file":         pluginsFile,
-		"plugin_count": len(ps.plugins),
-	}).Info("Plugins saved to registry")
+	seenTags := make(map[string]bool, len(plugin.Tags))
+	for _, tag := range plugin.Tags {
+		if !models.IsValidTag(tag) {
+			validationErrors.Add("tags", "invalid tag %q: use 1-32 lowercase letters, digits, hyphens or underscores", tag)
+		} else if seenTags[tag] {
+			validationErrors.Add("tags", "tag %q is listed more than once", tag)
+		}
+		seenTags[tag] = true
+	}
 
-	return nil
-}
+	// Checked before defaults apply: actions inheriting a shared endpoint are
+	// meant to be told apart by the hook in the request
+	defaultMethod := ""
+	if metadata.Defaults != nil {
+		defaultMethod = metadata.Defaults.Method
+	}
+	for _, collision := range endpointCollisions(plugin.Actions, defaultMethod) {
+		validationErrors.Add("actions."+collision.action+".endpoint", "action %q declares %s %s, already declared by action %q",
+			collision.action, collision.method, collision.endpoint, collision.first)
+	}
 
-func (ps *PluginService) loadPlugins() {
-	pluginsFile := filepath.Join(ps.config.DataDir, "plugins", "plugins.json")
+	if defaults := metadata.Defaults; defaults != nil {
+		defaults.Method = strings.ToUpper(defaults.Method)
+		switch defaults.Method {
+		case "", http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			validationErrors.Add("action_defaults.method", "unsupported method %q", defaults.Method)
+		}
+		if defaults.Endpoint != "" && !strings.HasPrefix(defaults.Endpoint, "/") {
+			validationErrors.Add("action_defaults.endpoint", "endpoint must start with /, got %q", defaults.Endpoint)
+		}
+		if defaults.TimeoutMs < 0 || time.Duration(defaults.TimeoutMs)*time.Millisecond > maxActionTimeout {
+			validationErrors.Add("action_defaults.timeout_ms", "timeout_ms must be between 0 and %d", maxActionTimeout.Milliseconds())
+		}
 
-	ps.logger.WithFields(logger.Fields{
-		"file": pluginsFile,
-	}).Debug("Loading plugins from registry")
+		// Actions are stored resolved, so nothing downstream sees the defaults
+		for name, action := range plugin.Actions {
+			plugin.Actions[name] = defaults.Apply(action)
+		}
+	}
 
-	data, err := os.ReadFile(pluginsFile)
-	if err != nil {
-		ps.logger.WithFields(logger.Fields{
-			"file": pluginsFile,
-		}).Info("No existing plugins registry found")
-		return
+	for name, action := range plugin.Actions {
+		if action.TimeoutMs < 0 || time.Duration(action.TimeoutMs)*time.Millisecond > maxActionTimeout {
+			validationErrors.Add("actions."+name+".timeout_ms", "timeout_ms must be between 0 and %d", maxActionTimeout.Milliseconds())
+		}
 	}
 
-	var plugins map[string]*models.Plugin
-	if err := json.Unmarshal(data, &plugins); err != nil {
-		ps.logger.WithFields(logger.Fields{
-			"file":  pluginsFile,
-			"error": err,
-		}).Error("Failed to parse plugins registry")
-		return
+	if plugin.SelfTest != nil && plugin.SelfTest.Endpoint == "" {
+		validationErrors.Add("selftest.endpoint", "selftest endpoint is required")
 	}
 
-	ps.mutex.Lock()
-	defer ps.mutex.Unlock()
-	ps.plugins = plugins
+	for name, hook := range map[string]*models.PluginLifecycleHook{"install": plugin.Install, "uninstall": plugin.Uninstall, "cancel": plugin.Cancel} {
+		if hook == nil {
+			continue
+		}
+		if !strings.HasPrefix(hook.Endpoint, "/") {
+			validationErrors.Add(name+".endpoint", "%s endpoint must start with /, got %q", name, hook.Endpoint)
+		}
+		if hook.TimeoutMs < 0 || time.Duration(hook.TimeoutMs)*time.Millisecond > maxActionTimeout {
+			validationErrors.Add(name+".timeout_ms", "timeout_ms must be between 0 and %d", maxActionTimeout.Milliseconds())
+		}
+	}
+
+	if plugin.IsolationTier != "" && models.IsolationTierRank(plugin.IsolationTier) < 0 {
+		validationErrors.Add("isolation_tier", "invalid isolation_tier %q (must be trusted, standard or untrusted)", plugin.IsolationTier)
+	}
+
+	if !models.IsValidRestartPolicy(plugin.RestartPolicy) {
+		validationErrors.Add("restart_policy", "invalid restart_policy %q (must be always, on-failure or never)", plugin.RestartPolicy)
+	}
+
+	if !ps.validPriority(plugin.Priority) {
+		validationErrors.Add("priority", "priority %d is out of range (%d to %d)",
+			plugin.Priority, ps.config.PluginPriorityMin, ps.config.PluginPriorityMax)
+	}
+
+	if plugin.MaxExecutionsPerSecond < 0 {
+		validationErrors.Add("max_executions_per_second", "max_executions_per_second cannot be negative")
+	}
+
+	for _, capability := range plugin.Requires {
+		if !isKnownCapability(capability) {
+			validationErrors.Add("requires", "unknown capability %q (known: %s, kernel_module:<name>)",
+				capability, strings.Join(knownCapabilityNames(), ", "))
+		}
+	}
+
+	return plugin, validationErrors, nil
+}
+
+// endpointCollision is an action declaring the method and endpoint of another
+type endpointCollision struct {
+	action, first    string
+	method, endpoint string
+}
+
+// endpointCollisions finds actions that explicitly declare the same method and
+// endpoint as another action of the plugin. An empty method is the default
+// method or, without one, GET. Actions are compared in name order.
+func endpointCollisions(actions map[string]models.PluginAction, defaultMethod string) []endpointCollision {
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var collisions []endpointCollision
+	declared := make(map[string]string)
+	for _, name := range names {
+		action := actions[name]
+		if action.Endpoint == "" {
+			continue
+		}
+
+		method := action.Method
+		if method == "" {
+			method = defaultMethod
+		}
+		method = strings.ToUpper(method)
+		if method == "" {
+			method = http.MethodGet
+		}
+
+		key := method + " " + action.Endpoint
+		if first, exists := declared[key]; exists {
+			collisions = append(collisions, endpointCollision{action: name, first: first, method: method, endpoint: action.Endpoint})
+			continue
+		}
+		declared[key] = name
+	}
+
+	return collisions
+}
+
+// duplicateActionKeys returns the action names declared more than once in the
+// actions object of a plugin.json
+func duplicateActionKeys(data []byte) []string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+
+		if key != "actions" {
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return nil
+			}
+			continue
+		}
+
+		if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+			return nil
+		}
+
+		counts := make(map[string]int)
+		var duplicates []string
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return duplicates
+			}
+			name, _ := token.(string)
+			if counts[name]++; counts[name] == 2 {
+				duplicates = append(duplicates, name)
+			}
+
+			var action json.RawMessage
+			if err := decoder.Decode(&action); err != nil {
+				return duplicates
+			}
+		}
+		return duplicates
+	}
+
+	return nil
+}
+
+func (ps *PluginService) copyFile(src, dst string) error {
+	sourceFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	destFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer destFile.Close()
+
+	_, err = destFile.ReadFrom(sourceFile)
+	return err
+}
+
+func (ps *PluginService) savePluginsUnsafe() error {
+	// Note: Caller must hold ps.mutex.Lock()
+	pluginsDir := filepath.Join(ps.config.DataDir, "plugins")
+	if err := os.MkdirAll(pluginsDir, 0755); err != nil {
+		return err
+	}
+
+	pluginsFile := filepath.Join(pluginsDir, "plugins.json")
+	data, err := json.MarshalIndent(ps.plugins, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(pluginsFile, data, 0644); err != nil {
+		return err
+	}
+
+	// Every registry change goes through here, so pool sizing follows it
+	ps.updatePoolFootprintsUnsafe()
+
+	ps.logger.WithFields(logger.Fields{
+		"file":         pluginsFile,
+		"plugin_count": len(ps.plugins),
+	}).Info("Plugins saved to registry")
+
+	return nil
+}
+
+func (ps *PluginService) loadPlugins() {
+	pluginsFile := filepath.Join(ps.config.DataDir, "plugins", "plugins.json")
+
+	ps.logger.WithFields(logger.Fields{
+		"file": pluginsFile,
+	}).Debug("Loading plugins from registry")
+
+	data, err := os.ReadFile(pluginsFile)
+	if err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"file": pluginsFile,
+		}).Info("No existing plugins registry found")
+		return
+	}
+
+	var plugins map[string]*models.Plugin
+	if err := json.Unmarshal(data, &plugins); err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"file":  pluginsFile,
+			"error": err,
+		}).Error("Failed to parse plugins registry")
+		return
+	}
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	ps.plugins = plugins
+
+	for slug, plugin := range plugins {
+		// A deactivation interrupted while draining is completed
+		if plugin.Status == models.PluginStatusDraining {
+			plugin.Status = models.PluginStatusInstalled
+		}
+
+		// Snapshots have the guest addresses baked in, so they go with them
+		if ps.vmService.dropLegacyInterfaceAddresses(plugin) {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": slug,
+			}).Info("Moving additional interfaces to their own subnet, discarding the snapshot")
+			if err := ps.vmService.DeleteSnapshot(slug); err != nil {
+				ps.logger.WithFields(logger.Fields{
+					"plugin_slug": slug,
+					"error":       err,
+				}).Warn("Failed to delete snapshot with old interface addresses")
+			}
+		}
+	}
 
 	ps.logger.WithFields(logger.Fields{
 		"file":  pluginsFile,
@@ -1078,56 +2322,81 @@ func (ps *PluginService) loadPlugins() {
 	}).Info("Loaded plugins from registry")
 }
 
-// healthCheckWithRetries performs health check with retry logic
-func (ps *PluginService) healthCheckWithRetries(vmIP, pluginSlug string, maxRetries int, retryDelay time.Duration) error {
-	healthURL := fmt.Sprintf("http://%s:80/health", vmIP)
+// ErrBootTimeout is returned when a plugin VM never answers /health within the boot timeout
+var ErrBootTimeout = errors.New("boot timeout")
+
+// ErrUnhealthyResponse is returned when a plugin VM answers /health but never reports healthy
+var ErrUnhealthyResponse = errors.New("unhealthy response")
+
+// healthCheckWithRetries polls /health every retryDelay until the plugin reports
+// healthy or the boot timeout elapses. A plugin that never answered fails with
+// ErrBootTimeout, one that answered but was not healthy with ErrUnhealthyResponse.
+func (ps *PluginService) healthCheckWithRetries(plugin *models.Plugin, vmIP string, retryDelay time.Duration) error {
+	healthURL := pluginURL(plugin, vmIP, "/health")
+	bootTimeout := time.Duration(ps.config.PluginBootTimeoutSec) * time.Second
+	deadline := time.Now().Add(bootTimeout)
 
 	var lastErr error
-	for attempt := 1; attempt <= maxRetries; attempt++ {
+	answered := false
+	attempt := 1
+	for ; ; attempt++ {
 		response, err := ps.makeHTTPRequest("GET", healthURL, nil)
-		if err != nil {
-			lastErr = err
-			ps.logger.WithFields(logger.Fields{
-				"plugin_slug": pluginSlug,
-				"attempt":     attempt,
-				"max_retries": maxRetries,
-				"error":       err,
-			}).Debug("Health check failed, retrying")
-
-			if attempt < maxRetries {
-				time.Sleep(retryDelay)
-				continue
-			}
-		} else {
+		if err == nil {
 			// Validate health response
 			if status, ok := response["status"].(string); ok && status == "healthy" {
 				ps.logger.WithFields(logger.Fields{
-					"plugin_slug": pluginSlug,
+					"plugin_slug": plugin.Slug,
 					"attempt":     attempt,
 				}).Info("Health check successful")
 				return nil
-			} else {
-				lastErr = fmt.Errorf("unhealthy status response: %v", response)
-				ps.logger.WithFields(logger.Fields{
-					"plugin_slug": pluginSlug,
-					"attempt":     attempt,
-					"response":    response,
-				}).Debug("Health check returned unhealthy status, retrying")
-
-				if attempt < maxRetries {
-					time.Sleep(retryDelay)
-					continue
-				}
 			}
+			err = fmt.Errorf("unhealthy status response: %v", response)
+			answered = true
+		} else if errType := categorizeRequestError(err); errType != cms_errors.ErrTypeNetwork && errType != cms_errors.ErrTypeTimeout {
+			// Any HTTP answer, even an error status, shows the plugin server is up
+			answered = true
 		}
+		lastErr = err
+
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"attempt":     attempt,
+			"error":       err,
+		}).Debug("Health check failed, retrying")
+
+		if time.Now().Add(retryDelay).After(deadline) {
+			break
+		}
+		time.Sleep(retryDelay)
 	}
 
-	return fmt.Errorf("health check failed after %d attempts: %v", maxRetries, lastErr)
+	if !answered {
+		return fmt.Errorf("%w: no answer on /health within %s (%d attempts): %v", ErrBootTimeout, bootTimeout, attempt, lastErr)
+	}
+	return fmt.Errorf("%w: /health not healthy within %s (%d attempts): %v", ErrUnhealthyResponse, bootTimeout, attempt, lastErr)
 }
 
-// validatePluginHealth performs comprehensive plugin health validation
-// This centralizes the health check logic used across different operations
+// validatePluginHealth performs comprehensive plugin health validation and
+// persists the failed state of a registered plugin
+// Note: Caller must hold ps.mutex
 func (ps *PluginService) validatePluginHealth(plugin *models.Plugin, instanceID, vmIP string, context string) error {
+	err := ps.checkPluginHealth(plugin, instanceID, vmIP, context)
+	if err != nil {
+		if saveErr := ps.savePluginsUnsafe(); saveErr != nil {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"error":       saveErr,
+			}).Error("Failed to save plugin failed state")
+		}
+	}
+	return err
+}
+
+// checkPluginHealth validates a plugin running as instanceID. On failure the
+// VM is stopped and the plugin marked failed, but nothing is saved, so it is
+// safe for plugins not yet in the registry and without ps.mutex.
+// This centralizes the health check logic used across different operations
+func (ps *PluginService) checkPluginHealth(plugin *models.Plugin, instanceID, vmIP string, context string) error {
 	ps.logger.WithFields(logger.Fields{
 		"plugin_slug": plugin.Slug,
 		"context":     context,
@@ -1137,8 +2406,40 @@ func (ps *PluginService) validatePluginHealth(plugin *models.Plugin, instanceID,
 	// VM is already in prewarm pool from StartVM
 	// No need to manually add it
 
-	// Perform health check
-	if err := ps.healthCheckWithRetries(vmIP, plugin.Slug, 30, 500*time.Millisecond); err != nil {
+	// Perform health check, followed by the optional manifest selftest
+	err := ps.healthCheckWithRetries(plugin, vmIP, 500*time.Millisecond)
+	if err != nil {
+		// Nothing ever answered: the guest most likely never started its server
+		if errors.Is(err, ErrBootTimeout) {
+			entrypoint := plugin.Entrypoint
+			if entrypoint == "" {
+				entrypoint = defaultGuestEntrypoint
+			}
+			err = fmt.Errorf("%v (no HTTP server answered on port 80: make sure %s starts the plugin server listening on 0.0.0.0:80)", err, entrypoint)
+		}
+	}
+	if err == nil && plugin.SelfTest != nil {
+		if testErr := ps.runSelfTest(plugin, vmIP); testErr != nil {
+			err = fmt.Errorf("selftest failed: %v", testErr)
+		}
+	}
+	if err == nil && ps.deepValidationEnabled(plugin) {
+		if probeErr := ps.probeActionEndpoints(plugin, vmIP); probeErr != nil {
+			err = fmt.Errorf("deep validation failed: %v", probeErr)
+		}
+	}
+	if err == nil && ps.installBurstEnabled(context) {
+		if burstErr := ps.runInstallBurst(plugin, vmIP); burstErr != nil {
+			err = fmt.Errorf("install burst failed: %v", burstErr)
+		}
+	}
+	if err == nil {
+		if specErr := ps.storeOpenAPISpec(plugin, vmIP); specErr != nil {
+			err = fmt.Errorf("openapi document invalid: %v", specErr)
+		}
+	}
+
+	if err != nil {
 		ps.logger.WithFields(logger.Fields{
 			"plugin_slug": plugin.Slug,
 			"context":     context,
@@ -1157,19 +2458,14 @@ func (ps *PluginService) validatePluginHealth(plugin *models.Plugin, instanceID,
 
 		// Mark plugin as failed
 		plugin.Status = "failed"
-		plugin.Health = models.PluginHealth{Status: "unhealthy", Message: err.Error()}
-		if saveErr := ps.savePluginsUnsafe(); saveErr != nil {
-			ps.logger.WithFields(logger.Fields{
-				"plugin_slug": plugin.Slug,
-				"error":       saveErr,
-			}).Error("Failed to save plugin failed state")
-		}
+		plugin.UpdateHealth(models.HealthStatusUnhealthy, err.Error(), 0)
+		ps.emitEvent(EventPluginFailed, plugin.Slug, err.Error())
 
 		return fmt.Errorf("plugin failed health validation: %v", err)
 	}
 
 	// Health check passed - mark plugin as healthy
-	plugin.Health = models.PluginHealth{Status: "healthy", Message: "Plugin validated successfully"}
+	plugin.UpdateHealth(models.HealthStatusHealthy, "Plugin validated successfully", 0)
 
 	ps.logger.WithFields(logger.Fields{
 		"plugin_slug": plugin.Slug,
@@ -1180,6 +2476,392 @@ func (ps *PluginService) validatePluginHealth(plugin *models.Plugin, instanceID,
 	return nil
 }
 
+// probeInstanceHealth performs a single quick health check against a warm instance
+func (ps *PluginService) probeInstanceHealth(instance *PrewarmInstance) error {
+	ctx, cancel := context.WithTimeout(context.Background(), postExecutionProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", guestURL(instance.IP, instance.MTLS, "/health"), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := ps.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	if status, ok := result["status"].(string); !ok || status != "healthy" {
+		return fmt.Errorf("unhealthy status response: %v", result)
+	}
+
+	return nil
+}
+
+// releaseWarmInstance ends an execution's claim on a warm instance, pausing it
+// and returning it to the pool once unused, unless it failed its
+// post-execution health probe and must be replaced
+func (ps *PluginService) releaseWarmInstance(plugin *models.Plugin, instance *PrewarmInstance) {
+	if probeErr := ps.probeInstanceHealth(instance); probeErr != nil {
+		// A live instance is only replaced eagerly under the always policy; otherwise
+		// the health monitor decides its fate. Nothing is replaced during maintenance.
+		replace := plugin.EffectiveRestartPolicy() == models.RestartPolicyAlways || ps.vmService.InstanceExited(instance)
+		if replace && !ps.vmService.InMaintenanceMode() {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"instance_id": instance.InstanceID,
+				"error":       probeErr,
+			}).Warn("Warm instance failed post-execution health probe, retiring it")
+			ps.vmService.AbandonPrewarmInstance(instance)
+			ps.retireWarmInstance(plugin, instance, probeErr)
+			return
+		}
+
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug":    plugin.Slug,
+			"instance_id":    instance.InstanceID,
+			"restart_policy": plugin.EffectiveRestartPolicy(),
+			"error":          probeErr,
+		}).Warn("Warm instance failed post-execution health probe, returning it to the pool")
+	}
+
+	// Pause VM and return to pool once the last execution using it is done
+	paused, pauseErr := ps.vmService.ReleasePrewarmInstance(instance)
+	if pauseErr != nil {
+		ps.logger.WithFields(logger.Fields{
+			"instance_id": instance.InstanceID,
+			"error":       pauseErr,
+		}).Error("Failed to pause VM for pool return")
+	} else if paused {
+		ps.vmService.ReturnPrewarmInstance(plugin.Slug, instance)
+	}
+}
+
+// retireWarmInstance stops an unhealthy warm instance and, if the plugin's restart
+// policy allows it, recovers the plugin in the background
+func (ps *PluginService) retireWarmInstance(plugin *models.Plugin, instance *PrewarmInstance, cause error) {
+	crashed := ps.vmService.InstanceExited(instance)
+
+	if err := ps.vmService.StopVM(instance.InstanceID); err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"instance_id": instance.InstanceID,
+			"error":       err,
+		}).Error("Failed to stop retired warm instance")
+	}
+	ps.closePluginConnections()
+
+	if shouldRestart(plugin.EffectiveRestartPolicy(), crashed) {
+		go ps.recoverWarmInstance(plugin, cause)
+	} else {
+		go ps.markInstanceLost(plugin, cause)
+	}
+}
+
+// handleInstanceExit retires a VM whose Firecracker process crashed. A warm
+// instance of an active plugin is recovered according to its restart policy;
+// any other VM, e.g. one booted for validation, is only cleaned up.
+func (ps *PluginService) handleInstanceExit(instance *PrewarmInstance, cause error) {
+	ps.mutex.RLock()
+	plugin, exists := ps.plugins[instance.PluginSlug]
+	ps.mutex.RUnlock()
+
+	warm := ps.vmService.PeekPrewarmInstance(instance.PluginSlug) == instance
+	if exists && warm && plugin.IsActive() && !ps.vmService.InMaintenanceMode() {
+		ps.emitEvent(EventPluginCrashed, plugin.Slug, cause.Error())
+		ps.retireWarmInstance(plugin, instance, cause)
+		return
+	}
+
+	if err := ps.vmService.StopVM(instance.InstanceID); err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": instance.PluginSlug,
+			"instance_id": instance.InstanceID,
+			"error":       err,
+		}).Error("Failed to clean up crashed instance")
+	}
+	ps.closePluginConnections()
+}
+
+// shouldRestart reports whether a retired warm instance is replaced under a
+// restart policy; crashed is true when its Firecracker process had exited
+func shouldRestart(policy string, crashed bool) bool {
+	switch policy {
+	case models.RestartPolicyAlways:
+		return true
+	case models.RestartPolicyOnFailure:
+		return crashed
+	default:
+		return false
+	}
+}
+
+// markInstanceLost records a retired warm instance that is not replaced
+func (ps *PluginService) markInstanceLost(plugin *models.Plugin, cause error) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	if current, exists := ps.plugins[plugin.Slug]; !exists || current != plugin || !plugin.IsActive() {
+		return
+	}
+
+	plugin.UpdateHealth(models.HealthStatusUnhealthy, cause.Error(), 0)
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug":    plugin.Slug,
+		"restart_policy": plugin.EffectiveRestartPolicy(),
+		"error":          cause,
+	}).Warn("Warm instance retired and not restarted due to restart policy")
+
+	if err := ps.savePluginsUnsafe(); err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"error":       err,
+		}).Error("Failed to save plugin health state")
+	}
+}
+
+// recoverWarmInstance replaces a retired warm instance with a freshly booted one
+func (ps *PluginService) recoverWarmInstance(plugin *models.Plugin, cause error) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	if !ps.needsWarmInstanceUnsafe(plugin) {
+		return
+	}
+
+	plugin.UpdateHealth(models.HealthStatusUnhealthy, cause.Error(), 0)
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+	}).Info("Recovering warm instance for plugin")
+
+	ps.restoreWarmInstance(plugin)
+	if ps.vmService.PeekPrewarmInstance(plugin.Slug) != nil {
+		ps.emitEvent(EventPluginRestarted, plugin.Slug, cause.Error())
+	}
+}
+
+// needsWarmInstanceUnsafe reports whether a plugin still needs its warm
+// instance restored once the registry lock is held
+// Note: Caller must hold ps.mutex
+func (ps *PluginService) needsWarmInstanceUnsafe(plugin *models.Plugin) bool {
+	// Plugin may have been deactivated or removed meanwhile
+	if current, exists := ps.plugins[plugin.Slug]; !exists || current != plugin || plugin.Status != "active" {
+		return false
+	}
+
+	// Another restore may have replaced the instance while waiting for the lock
+	if ps.vmService.PeekPrewarmInstance(plugin.Slug) != nil {
+		return false
+	}
+
+	// Maintenance began while waiting for the lock
+	return !ps.vmService.InMaintenanceMode()
+}
+
+// coldStartEvicted restores the warm instance of a plugin evicted by the pool
+// caps in the background, once per plugin at a time
+func (ps *PluginService) coldStartEvicted(plugin *models.Plugin) {
+	if _, running := ps.coldStarts.LoadOrStore(plugin.Slug, true); running {
+		return
+	}
+
+	go func() {
+		defer ps.coldStarts.Delete(plugin.Slug)
+
+		ps.mutex.Lock()
+		defer ps.mutex.Unlock()
+
+		if ps.needsWarmInstanceUnsafe(plugin) {
+			ps.restoreWarmInstance(plugin)
+		}
+	}()
+}
+
+// healthMonitor periodically health checks the warm instances of active plugins
+func (ps *PluginService) healthMonitor() {
+	ticker := time.NewTicker(time.Duration(ps.config.HealthCheckIntervalSec) * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ps.checkActivePluginHealth()
+	}
+}
+
+// checkActivePluginHealth probes every idle warm instance once and applies the
+// failure/recovery thresholds, so a single transient failure does not flap the
+// plugin health or trigger a recovery
+func (ps *PluginService) checkActivePluginHealth() {
+	ps.mutex.RLock()
+	var plugins []*models.Plugin
+	for _, plugin := range ps.plugins {
+		if plugin.IsActive() && plugin.IsEnabled() {
+			plugins = append(plugins, plugin)
+		}
+	}
+	ps.mutex.RUnlock()
+
+	execStats := ps.executionMetrics.snapshot()
+
+	for _, plugin := range plugins {
+		// Instances busy with an action are probed after execution instead
+		if stats, exists := execStats.Plugins[plugin.Slug]; exists && stats.InFlight > 0 {
+			continue
+		}
+
+		// Health is still tracked during maintenance, but instances are not
+		// retired or replaced
+		maintenance := ps.vmService.InMaintenanceMode()
+
+		instance := ps.vmService.PeekPrewarmInstance(plugin.Slug)
+		if instance == nil {
+			// The always policy keeps a warm instance around, retrying failed restores.
+			// Evicted instances wait for their next execution instead.
+			if plugin.EffectiveRestartPolicy() == models.RestartPolicyAlways && !maintenance && !ps.vmService.WasEvicted(plugin.Slug) {
+				go ps.recoverWarmInstance(plugin, fmt.Errorf("no warm instance available"))
+			}
+			continue
+		}
+
+		if ps.vmService.InstanceExited(instance) && !maintenance {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"instance_id": instance.InstanceID,
+			}).Warn("Warm instance process exited")
+			ps.retireWarmInstance(plugin, instance, fmt.Errorf("VM process exited"))
+			continue
+		}
+
+		start := time.Now()
+		probeErr := ps.vmService.ProbeInstance(instance, ps.probeInstanceHealth)
+		responseTime := time.Since(start).Milliseconds()
+
+		// Executions probe the instance themselves when they release it
+		if errors.Is(probeErr, ErrInstanceBusy) {
+			continue
+		}
+
+		message := "Periodic health check passed"
+		if probeErr != nil {
+			message = probeErr.Error()
+		}
+
+		ps.mutex.Lock()
+		if current, exists := ps.plugins[plugin.Slug]; !exists || current != plugin || !plugin.IsActive() {
+			ps.mutex.Unlock()
+			continue
+		}
+
+		if !plugin.RecordHealthCheck(probeErr == nil, message, responseTime, ps.config.HealthFailureThreshold, ps.config.HealthRecoveryThreshold) {
+			if probeErr != nil {
+				ps.logger.WithFields(logger.Fields{
+					"plugin_slug":          plugin.Slug,
+					"consecutive_failures": plugin.Health.ConsecutiveFailures,
+					"error":                probeErr,
+				}).Debug("Periodic health check failed")
+			}
+			ps.mutex.Unlock()
+			continue
+		}
+
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"status":      plugin.Health.Status,
+			"message":     message,
+		}).Warn("Plugin health status changed")
+		if probeErr != nil {
+			ps.emitEvent(EventPluginUnhealthy, plugin.Slug, message)
+		} else {
+			ps.emitEvent(EventPluginRecovered, plugin.Slug, message)
+		}
+
+		if err := ps.savePluginsUnsafe(); err != nil {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"error":       err,
+			}).Error("Failed to save plugin health state")
+		}
+		ps.mutex.Unlock()
+
+		// Live but unhealthy instances are only replaced under the always policy
+		if probeErr != nil && plugin.EffectiveRestartPolicy() == models.RestartPolicyAlways && !maintenance {
+			ps.retireWarmInstance(plugin, instance, probeErr)
+		}
+	}
+}
+
+// runSelfTest invokes the plugin's selftest endpoint and compares the response with the expected output
+func (ps *PluginService) runSelfTest(plugin *models.Plugin, vmIP string) error {
+	method := plugin.SelfTest.Method
+	if method == "" {
+		method = "POST"
+	}
+
+	testURL := pluginURL(plugin, vmIP, plugin.SelfTest.Endpoint)
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"url":         testURL,
+		"method":      method,
+	}).Info("Running plugin selftest")
+
+	var body interface{}
+	if plugin.SelfTest.Payload != nil {
+		body = plugin.SelfTest.Payload
+	}
+
+	response, err := ps.makeHTTPRequest(method, testURL, body)
+	if err != nil {
+		return err
+	}
+
+	if !containsExpected(response, plugin.SelfTest.Expect) {
+		return fmt.Errorf("response %v does not match expected %v", response, plugin.SelfTest.Expect)
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+	}).Info("Plugin selftest passed")
+
+	return nil
+}
+
+// containsExpected reports whether actual contains every field of expected,
+// comparing nested objects recursively
+func containsExpected(actual, expected map[string]interface{}) bool {
+	for key, expectedValue := range expected {
+		actualValue, exists := actual[key]
+		if !exists {
+			return false
+		}
+
+		expectedMap, expectedIsMap := expectedValue.(map[string]interface{})
+		actualMap, actualIsMap := actualValue.(map[string]interface{})
+		if expectedIsMap && actualIsMap {
+			if !containsExpected(actualMap, expectedMap) {
+				return false
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(actualValue, expectedValue) {
+			return false
+		}
+	}
+	return true
+}
+
 // cleanupPluginVM cleans up VM and network resources after plugin operations
 func (ps *PluginService) cleanupPluginVM(pluginSlug, instanceID string, context string) {
 	ps.logger.WithFields(logger.Fields{
@@ -1187,8 +2869,9 @@ func (ps *PluginService) cleanupPluginVM(pluginSlug, instanceID string, context
 		"context":     context,
 	}).Info("Cleaning up VM and network resources")
 
-	// Remove from prewarm pool
+	// Remove from prewarm pool and drop connections to the VM
 	ps.vmService.RemoveFromPrewarmPool(pluginSlug)
+	ps.closePluginConnections()
 
 	// Stop VM and clean up network resources
 	if err := ps.vmService.StopVM(instanceID); err != nil {
@@ -1207,7 +2890,14 @@ func (ps *PluginService) cleanupPluginVM(pluginSlug, instanceID string, context
 
 // makeHTTPRequest makes an HTTP request and returns the response as a map
 func (ps *PluginService) makeHTTPRequest(method, url string, body interface{}) (map[string]interface{}, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
+	return ps.makeHTTPRequestUntil(method, url, body, time.Now().Add(pluginRequestTimeout))
+}
+
+// makeHTTPRequestUntil makes an HTTP request that is abandoned at deadline,
+// forwarding the deadline to the plugin so it can stop early
+func (ps *PluginService) makeHTTPRequestUntil(method, url string, body interface{}, deadline time.Time) (map[string]interface{}, error) {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
 
 	var reqBody io.Reader
 	if body != nil {
@@ -1218,7 +2908,7 @@ func (ps *PluginService) makeHTTPRequest(method, url string, body interface{}) (
 		reqBody = bytes.NewBuffer(bodyBytes)
 	}
 
-	req, err := http.NewRequest(method, url, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -1226,23 +2916,75 @@ func (ps *PluginService) makeHTTPRequest(method, url string, body interface{}) (
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	setDeadlineHeaders(req, deadline)
 
-	resp, err := client.Do(req)
+	resp, err := ps.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+	return ps.decodePluginResponse(resp)
+}
+
+// httpStatusError reports a non-200 response from a plugin VM
+type httpStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Status)
+}
+
+// categorizeRequestError maps a plugin request failure onto the CMS error taxonomy,
+// separating infrastructure problems from plugin bugs
+func categorizeRequestError(err error) cms_errors.ErrorType {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode >= 500 {
+			return cms_errors.ErrTypePlugin
+		}
+		return cms_errors.ErrTypeHTTP
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return cms_errors.ErrTypeTimeout
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return cms_errors.ErrTypeNetwork
+	}
+
+	// Response body that is not a JSON object violates the plugin response schema
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errNonJSONResponse) || errors.Is(err, errResponseTooLarge) {
+		return cms_errors.ErrTypeValidation
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return cms_errors.ErrTypeNetwork
 	}
 
-	return result, nil
+	return cms_errors.ErrTypeInternal
+}
+
+// executionFailure builds a failed per-plugin execution result with its error category
+func executionFailure(pluginSlug string, errType cms_errors.ErrorType, message string, startTime time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"plugin_slug":       pluginSlug,
+		"success":           false,
+		"error_type":        errType,
+		"result":            map[string]interface{}{"error": message},
+		"execution_time_ms": int(time.Since(startTime).Milliseconds()),
+	}
 }
 
 // restoreActivePlugins restores active plugins after CMS startup
@@ -1269,105 +3011,127 @@ func (ps *PluginService) restoreActivePlugins() {
 
 	// Restore each plugin
 	for _, plugin := range pluginsToRestore {
+		ps.restoreWarmInstance(plugin)
+	}
+
+	ps.logger.Info("Active plugin restoration completed")
+}
+
+// restoreWarmInstance boots a fresh VM for an active plugin, validates its health,
+// refreshes its snapshot and pauses it into the pre-warm pool
+// Note: Caller must hold ps.mutex, except during the startup restore
+func (ps *PluginService) restoreWarmInstance(plugin *models.Plugin) {
+	unlockStart := ps.startLocks.lock(plugin.Slug)
+	defer unlockStart()
+
+	// A concurrent restore may have finished while we waited
+	if instance := ps.vmService.PeekPrewarmInstance(plugin.Slug); instance != nil {
 		ps.logger.WithFields(logger.Fields{
 			"plugin_slug": plugin.Slug,
-			"assigned_ip": plugin.AssignedIP,
-			"tap_device":  plugin.TapDevice,
-		}).Info("Restoring active plugin")
+			"instance_id": instance.InstanceID,
+		}).Info("Plugin already has a warm instance, skipping restore")
+		return
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"assigned_ip": plugin.AssignedIP,
+		"tap_device":  plugin.TapDevice,
+	}).Info("Restoring active plugin")
+
+	instanceID := ps.vmService.NewInstanceID(plugin.Slug)
+
+	// The fresh VM reuses the plugin's IP, so forget connections to the old one
+	ps.closePluginConnections()
 
-		// Always use plugin slug as instance ID for consistency
-		instanceID := plugin.Slug
+	// Always start fresh VMs for active plugin restoration
+	// This ensures clean state and proper network initialization
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+	}).Info("Starting fresh VM for active plugin restoration")
 
-		// Always start fresh VMs for active plugin restoration
-		// This ensures clean state and proper network initialization
+	if err := ps.vmService.StartVM(instanceID, plugin); err != nil {
 		ps.logger.WithFields(logger.Fields{
 			"plugin_slug": plugin.Slug,
-		}).Info("Starting fresh VM for active plugin restoration")
+			"error":       err,
+		}).Error("Failed to start VM for active plugin restoration")
+		return
+	}
 
-		if err := ps.vmService.StartVM(instanceID, plugin); err != nil {
-			ps.logger.WithFields(logger.Fields{
-				"plugin_slug": plugin.Slug,
-				"error":       err,
-			}).Error("Failed to start VM for active plugin restoration")
-			continue
-		}
+	// Get VM IP
+	vmIP, exists := ps.vmService.GetVMIP(instanceID)
+	if !exists {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"instance_id": instanceID,
+		}).Error("Failed to get VM IP for active plugin restoration")
+		return
+	}
 
-		// Get VM IP
-		vmIP, exists := ps.vmService.GetVMIP(instanceID)
-		if !exists {
-			ps.logger.WithFields(logger.Fields{
-				"plugin_slug": plugin.Slug,
-				"instance_id": instanceID,
-			}).Error("Failed to get VM IP for active plugin restoration")
-			continue
-		}
+	// Perform health check to ensure VM is working properly
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"vm_ip":       vmIP,
+	}).Info("Performing health check for active plugin restoration")
 
-		// Perform health check to ensure VM is working properly
+	if err := ps.healthCheckWithRetries(plugin, vmIP, 1*time.Second); err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"vm_ip":       vmIP,
+			"error":       err,
+		}).Error("Health check failed for active plugin restoration")
+		// Mark plugin as unhealthy but continue with restoration
+		plugin.UpdateHealth(models.HealthStatusUnhealthy, err.Error(), 0)
+	} else {
 		ps.logger.WithFields(logger.Fields{
 			"plugin_slug": plugin.Slug,
 			"vm_ip":       vmIP,
-		}).Info("Performing health check for active plugin restoration")
+		}).Info("Health check passed for active plugin restoration")
+		// Mark plugin as healthy
+		plugin.UpdateHealth(models.HealthStatusHealthy, "Plugin restored successfully", 0)
 
-		if err := ps.healthCheckWithRetries(vmIP, plugin.Slug, 15, 1*time.Second); err != nil {
+		// Create fresh snapshot for this plugin
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+		}).Info("Creating fresh snapshot for active plugin")
+
+		if err := ps.snapshotNewWarmInstance(instanceID, plugin.Slug); err != nil {
 			ps.logger.WithFields(logger.Fields{
 				"plugin_slug": plugin.Slug,
-				"vm_ip":       vmIP,
 				"error":       err,
-			}).Error("Health check failed for active plugin restoration")
-			// Mark plugin as unhealthy but continue with restoration
-			plugin.Health = models.PluginHealth{Status: "unhealthy", Message: err.Error()}
+			}).Error("Failed to create snapshot for active plugin restoration")
+			// Continue even if snapshot creation fails
 		} else {
+			plugin.NeedsResnapshot = false
+			plugin.SnapshotDiffCount = 0
 			ps.logger.WithFields(logger.Fields{
 				"plugin_slug": plugin.Slug,
-				"vm_ip":       vmIP,
-			}).Info("Health check passed for active plugin restoration")
-			// Mark plugin as healthy
-			plugin.Health = models.PluginHealth{Status: "healthy", Message: "Plugin restored successfully"}
-
-			// Create fresh snapshot for this plugin
-			ps.logger.WithFields(logger.Fields{
-				"plugin_slug": plugin.Slug,
-			}).Info("Creating fresh snapshot for active plugin")
-
-			snapshotPath := ps.vmService.GetSnapshotPath(plugin.Slug)
-			if err := ps.vmService.CreateSnapshot(instanceID, snapshotPath, false); err != nil {
-				ps.logger.WithFields(logger.Fields{
-					"plugin_slug": plugin.Slug,
-					"error":       err,
-				}).Error("Failed to create snapshot for active plugin restoration")
-				// Continue even if snapshot creation fails
-			} else {
-				ps.logger.WithFields(logger.Fields{
-					"plugin_slug": plugin.Slug,
-				}).Info("Successfully created fresh snapshot for active plugin")
-			}
-		}
-
-		// Pause the VM for pre-warming
-		if err := ps.vmService.PauseVM(instanceID); err != nil {
-			ps.logger.WithFields(logger.Fields{
-				"plugin_slug": plugin.Slug,
-				"error":       err,
-			}).Error("Failed to pause VM for active plugin restoration")
-			continue
+			}).Info("Successfully created fresh snapshot for active plugin")
 		}
+	}
 
-		// Save plugin health status and network configuration
-		if saveErr := ps.savePluginsUnsafe(); saveErr != nil {
-			ps.logger.WithFields(logger.Fields{
-				"plugin_slug": plugin.Slug,
-				"error":       saveErr,
-			}).Error("Failed to save plugin health status during startup")
-		}
+	// Pause the VM for pre-warming
+	if err := ps.vmService.PauseVM(instanceID); err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"error":       err,
+		}).Error("Failed to pause VM for active plugin restoration")
+		return
+	}
 
+	// Save plugin health status and network configuration
+	if saveErr := ps.savePluginsUnsafe(); saveErr != nil {
 		ps.logger.WithFields(logger.Fields{
 			"plugin_slug": plugin.Slug,
-			"instance_id": instanceID,
-			"vm_ip":       vmIP,
-		}).Info("Successfully restored active plugin")
+			"error":       saveErr,
+		}).Error("Failed to save plugin health status during startup")
 	}
 
-	ps.logger.Info("Active plugin restoration completed")
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": plugin.Slug,
+		"instance_id": instanceID,
+		"vm_ip":       vmIP,
+	}).Info("Successfully restored active plugin")
 }
 
 // isVersionHigher compares two version strings and returns true if version1 > version2
@@ -0,0 +1,152 @@
+/*
+ * Firecracker CMS - Plugin Runtime Status
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"time"
+
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// Warm instance state reported when its Firecracker process is gone
+const InstanceStateExited = "exited"
+
+// PluginRuntimeStatus combines a plugin's registry entry with its live state
+type PluginRuntimeStatus struct {
+	Slug          string                  `json:"slug"`
+	Version       string                  `json:"version"`
+	Status        string                  `json:"status"`
+	Enabled       bool                    `json:"enabled"`
+	Health        cms_models.PluginHealth `json:"health"`
+	IsolationTier string                  `json:"isolation_tier"` // After defaults and the configured minimum
+	AssignedIP    string                  `json:"assigned_ip,omitempty"`
+	TapDevice     string                  `json:"tap_device,omitempty"`
+	Hostname      string                  `json:"hostname"` // Guest hostname of the plugin's VMs
+
+	WarmInstance *WarmInstanceStatus `json:"warm_instance"`       // Nil if the plugin has none
+	Evicted      bool                `json:"evicted"`             // Warm instance evicted by the pool caps
+	Instances    int                 `json:"instances"`           // Running VMs, including ones booted for validation
+	InFlight     int                 `json:"in_flight"`           // Executions currently running
+	HasSnapshot  bool                `json:"has_snapshot"`        // A full snapshot exists on disk
+	Resnapshot   bool                `json:"needs_resnapshot"`    // The snapshot is stale
+	DiffCount    int                 `json:"snapshot_diff_count"` // Differentials merged into the snapshot
+
+	CircuitBreaker *PluginCircuitStats `json:"circuit_breaker,omitempty"` // Nil until the plugin executes
+
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// WarmInstanceStatus describes the live state of a plugin's warm instance
+type WarmInstanceStatus struct {
+	InstanceID    string    `json:"instance_id"`
+	State         string    `json:"state"` // running, paused or exited
+	IP            string    `json:"ip"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastUsed      time.Time `json:"last_used"`
+	Jailed        bool      `json:"jailed"`
+	EgressBlocked bool      `json:"egress_blocked"`
+
+	// Outcome of a /health probe, only made while the instance is running;
+	// a paused instance cannot answer
+	Reachable      *bool  `json:"reachable,omitempty"`
+	ProbeError     string `json:"probe_error,omitempty"`
+	ProbeLatencyMs int64  `json:"probe_latency_ms,omitempty"`
+}
+
+// GetPluginStatus returns the registry and live runtime state of a plugin
+func (ps *PluginService) GetPluginStatus(slug string) (*PluginRuntimeStatus, error) {
+	ps.mutex.RLock()
+	plugin, exists := ps.plugins[slug]
+	if !exists {
+		ps.mutex.RUnlock()
+		return nil, fmt.Errorf("plugin not found")
+	}
+	status := &PluginRuntimeStatus{
+		Slug:          plugin.Slug,
+		Version:       plugin.Version,
+		Status:        plugin.Status,
+		Enabled:       plugin.IsEnabled(),
+		Health:        plugin.Health,
+		IsolationTier: ps.vmService.effectiveIsolationTier(plugin),
+		AssignedIP:    plugin.AssignedIP,
+		TapDevice:     plugin.TapDevice,
+		Hostname:      ps.vmService.guestHostname(plugin.Slug),
+		Resnapshot:    plugin.NeedsResnapshot,
+		DiffCount:     plugin.SnapshotDiffCount,
+	}
+	ps.mutex.RUnlock()
+
+	status.Evicted = ps.vmService.WasEvicted(slug)
+	status.Instances = ps.vmService.pluginInstanceCount(slug)
+	status.InFlight = ps.executionMetrics.pluginInFlight(slug)
+	status.HasSnapshot = ps.vmService.HasSnapshot(slug)
+	status.CircuitBreaker = ps.circuitBreakers.pluginStats(slug, ps.circuitBreakerCooldown())
+
+	if instance := ps.vmService.PeekPrewarmInstance(slug); instance != nil {
+		status.WarmInstance = ps.warmInstanceStatus(instance)
+	}
+
+	status.CheckedAt = time.Now()
+	return status, nil
+}
+
+// warmInstanceStatus queries Firecracker for an instance's state and probes it if running
+func (ps *PluginService) warmInstanceStatus(instance *PrewarmInstance) *WarmInstanceStatus {
+	warm := &WarmInstanceStatus{
+		InstanceID:    instance.InstanceID,
+		IP:            instance.IP,
+		CreatedAt:     instance.CreatedAt,
+		LastUsed:      instance.LastUsed,
+		Jailed:        instance.Jailed,
+		EgressBlocked: instance.EgressBlocked,
+	}
+
+	if ps.vmService.InstanceExited(instance) {
+		warm.State = InstanceStateExited
+		return warm
+	}
+
+	// Wait out snapshot or probe operations on the instance
+	instance.opMutex.Lock()
+	defer instance.opMutex.Unlock()
+
+	state, err := ps.vmService.instanceState(instance)
+	if err != nil {
+		warm.State = InstanceStateExited
+		warm.ProbeError = err.Error()
+		return warm
+	}
+	warm.State = state
+
+	if state == InstanceStateRunning {
+		start := time.Now()
+		probeErr := ps.probeInstanceHealth(instance)
+		reachable := probeErr == nil
+		warm.Reachable = &reachable
+		warm.ProbeLatencyMs = time.Since(start).Milliseconds()
+		if probeErr != nil {
+			warm.ProbeError = probeErr.Error()
+		}
+	}
+
+	return warm
+}
+
+// pluginInstanceCount returns how many VMs of a plugin are in the pool
+func (vm *VMService) pluginInstanceCount(pluginSlug string) int {
+	vm.poolMutex.RLock()
+	defer vm.poolMutex.RUnlock()
+
+	count := 0
+	for _, instance := range vm.prewarmPool {
+		if instance.PluginSlug == pluginSlug {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,498 @@
+/*
+ * Firecracker CMS - Mutual TLS with Plugin Guests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+const (
+	// caValidity is how long the generated plugin CA is valid
+	caValidity = 10 * 365 * 24 * time.Hour
+
+	// certRenewalWindow is how long before expiry a certificate is reissued
+	certRenewalWindow = 7 * 24 * time.Hour
+)
+
+// ErrPluginTLSDisabled is returned for mutual TLS operations while
+// CMS_PLUGIN_MTLS is off
+var ErrPluginTLSDisabled = errors.New("mutual TLS with plugins is disabled")
+
+// ErrPluginTLSNoMMDS is returned when booting a plugin using mutual TLS on a
+// Firecracker without MMDS, which delivers its certificate
+var ErrPluginTLSNoMMDS = errors.New("mutual TLS needs MMDS to deliver the plugin certificate, which this Firecracker lacks")
+
+// GuestTLS is published through MMDS under the "cms_tls" key to guests of
+// plugins using mutual TLS, as base64 DER
+type GuestTLS struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+	CA   string `json:"ca"`
+}
+
+// PluginCertInfo describes an issued certificate
+type PluginCertInfo struct {
+	Subject     string    `json:"subject"`
+	IP          string    `json:"ip,omitempty"`
+	Fingerprint string    `json:"fingerprint"` // SHA-256 of the DER certificate
+	NotAfter    time.Time `json:"not_after"`
+}
+
+// PluginTLSInfo describes the plugin CA and the certificates it issued
+type PluginTLSInfo struct {
+	CACert  string                    `json:"ca_cert"` // PEM, for verifying plugins outside the CMS
+	CA      PluginCertInfo            `json:"ca"`
+	Client  PluginCertInfo            `json:"client"`
+	Plugins map[string]PluginCertInfo `json:"plugins"`
+}
+
+// pluginTLS is the CA that issues plugin server certificates and the CMS
+// client certificate. Everything is kept under DataDir/tls, plugin
+// certificates as plugins/<slug>.crt and plugins/<slug>.key.
+type pluginTLS struct {
+	dir      string
+	validity time.Duration
+
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	client tls.Certificate
+	pool   *x509.CertPool
+
+	mutex sync.Mutex // Serializes plugin certificate issuance
+}
+
+// newPluginTLS loads the plugin CA and client certificate, creating them on
+// first use
+func newPluginTLS(dir string, validity time.Duration) (*pluginTLS, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "plugins"), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create TLS directory: %v", err)
+	}
+
+	pt := &pluginTLS{dir: dir, validity: validity}
+
+	caCert, caKey, err := loadCertPair(filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem"))
+	if os.IsNotExist(err) {
+		caCert, caKey, err = pt.createCA()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plugin CA: %v", err)
+	}
+	pt.caCert, pt.caKey = caCert, caKey
+
+	pt.pool = x509.NewCertPool()
+	pt.pool.AddCert(caCert)
+
+	clientPath, clientKeyPath := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	clientCert, clientKey, err := loadCertPair(clientPath, clientKeyPath)
+	if err != nil || pt.needsRenewal(clientCert, "") {
+		template := &x509.Certificate{
+			Subject:     pkix.Name{CommonName: "cu-cms"},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		if clientCert, clientKey, err = pt.issue(template, clientPath, clientKeyPath); err != nil {
+			return nil, fmt.Errorf("failed to issue CMS client certificate: %v", err)
+		}
+	}
+	pt.client = tls.Certificate{
+		Certificate: [][]byte{clientCert.Raw},
+		PrivateKey:  clientKey,
+		Leaf:        clientCert,
+	}
+
+	return pt, nil
+}
+
+// createCA generates a self-signed plugin CA
+func (pt *pluginTLS) createCA() (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Firecracker CMS Plugin CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := writeCertPair(filepath.Join(pt.dir, "ca.pem"), filepath.Join(pt.dir, "ca-key.pem"), cert, key); err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
+
+// issue signs a certificate for template with a fresh key and stores both.
+// P-256 keys are fast to generate on every reissue and supported by every TLS stack.
+func (pt *pluginTLS) issue(template *x509.Certificate, certPath, keyPath string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if template.SerialNumber, err = newSerialNumber(); err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template.NotBefore = now.Add(-time.Hour)
+	template.NotAfter = now.Add(pt.validity)
+	if template.NotAfter.After(pt.caCert.NotAfter) {
+		template.NotAfter = pt.caCert.NotAfter
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, pt.caCert, &key.PublicKey, pt.caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := writeCertPair(certPath, keyPath, cert, key); err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
+
+// needsRenewal reports whether a certificate is missing, close to expiry or,
+// for plugin certificates, issued for another IP
+func (pt *pluginTLS) needsRenewal(cert *x509.Certificate, ip string) bool {
+	if cert == nil || time.Until(cert.NotAfter) < certRenewalWindow {
+		return true
+	}
+	if ip == "" {
+		return false
+	}
+	return len(cert.IPAddresses) != 1 || !cert.IPAddresses[0].Equal(net.ParseIP(ip))
+}
+
+// pluginCertPaths returns where the server certificate of a plugin is stored
+func (pt *pluginTLS) pluginCertPaths(pluginSlug string) (string, string) {
+	return filepath.Join(pt.dir, "plugins", pluginSlug+".crt"), filepath.Join(pt.dir, "plugins", pluginSlug+".key")
+}
+
+// pluginCert returns the server certificate and key of a plugin as DER,
+// issuing a new pair when none is stored or the stored one needs renewal
+func (pt *pluginTLS) pluginCert(pluginSlug, ip string) ([]byte, []byte, error) {
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+
+	certPath, keyPath := pt.pluginCertPaths(pluginSlug)
+	cert, key, err := loadCertPair(certPath, keyPath)
+	if err != nil || pt.needsRenewal(cert, ip) {
+		template := &x509.Certificate{
+			Subject:     pkix.Name{CommonName: pluginSlug},
+			IPAddresses: []net.IP{net.ParseIP(ip)},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		if cert, key, err = pt.issue(template, certPath, keyPath); err != nil {
+			return nil, nil, fmt.Errorf("failed to issue certificate for plugin %s: %v", pluginSlug, err)
+		}
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert.Raw, keyDER, nil
+}
+
+// removePluginCert deletes the stored certificate of a plugin, so its next
+// fresh boot is issued a new one
+func (pt *pluginTLS) removePluginCert(pluginSlug string) error {
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+
+	certPath, keyPath := pt.pluginCertPaths(pluginSlug)
+	if err := os.Remove(certPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(keyPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// clientConfig returns the TLS config for requests to plugins: the CMS client
+// certificate is presented and plugin certificates must chain to the CA and
+// match the IP being dialed
+func (pt *pluginTLS) clientConfig() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{pt.client},
+		RootCAs:      pt.pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// guestTLS encodes a plugin's certificate, key and the CA for the guest
+func (pt *pluginTLS) guestTLS(certDER, keyDER []byte) *GuestTLS {
+	return &GuestTLS{
+		Cert: base64.StdEncoding.EncodeToString(certDER),
+		Key:  base64.StdEncoding.EncodeToString(keyDER),
+		CA:   base64.StdEncoding.EncodeToString(pt.caCert.Raw),
+	}
+}
+
+// info describes the CA, the client certificate and every stored plugin certificate
+func (pt *pluginTLS) info() PluginTLSInfo {
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+
+	info := PluginTLSInfo{
+		CACert:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pt.caCert.Raw})),
+		CA:      certInfo(pt.caCert),
+		Client:  certInfo(pt.client.Leaf),
+		Plugins: make(map[string]PluginCertInfo),
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(pt.dir, "plugins", "*.crt"))
+	for _, path := range paths {
+		if cert, err := loadCert(path); err == nil {
+			info.Plugins[strings.TrimSuffix(filepath.Base(path), ".crt")] = certInfo(cert)
+		}
+	}
+
+	return info
+}
+
+// certInfo summarizes a certificate
+func certInfo(cert *x509.Certificate) PluginCertInfo {
+	fingerprint := sha256.Sum256(cert.Raw)
+	info := PluginCertInfo{
+		Subject:     cert.Subject.CommonName,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		NotAfter:    cert.NotAfter,
+	}
+	if len(cert.IPAddresses) > 0 {
+		info.IP = cert.IPAddresses[0].String()
+	}
+	return info
+}
+
+// newSerialNumber returns a random 128-bit certificate serial number
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// loadCert reads a PEM certificate
+func loadCert(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s does not contain a PEM certificate", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// loadCertPair reads a PEM certificate and its PKCS#8 ECDSA key
+func loadCertPair(certPath, keyPath string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	cert, err := loadCert(certPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, nil, fmt.Errorf("%s does not contain a PEM private key", keyPath)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not an ECDSA key", keyPath)
+	}
+
+	return cert, key, nil
+}
+
+// writeCertPair stores a certificate and its key as PEM, the key readable by
+// the CMS only
+func writeCertPair(certPath, keyPath string, cert *x509.Certificate, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0644)
+}
+
+// PluginTLSEnabled reports whether mutual TLS with plugins is configured
+func (vm *VMService) PluginTLSEnabled() bool {
+	return vm.pluginTLS != nil
+}
+
+// PluginTLSConfig returns the client TLS config for plugin requests, or nil
+// when mutual TLS is disabled
+func (vm *VMService) PluginTLSConfig() *tls.Config {
+	if vm.pluginTLS == nil {
+		return nil
+	}
+	return vm.pluginTLS.clientConfig()
+}
+
+// PluginTLSInfo describes the plugin CA and issued certificates
+func (vm *VMService) PluginTLSInfo() (PluginTLSInfo, error) {
+	if vm.pluginTLS == nil {
+		return PluginTLSInfo{}, ErrPluginTLSDisabled
+	}
+	return vm.pluginTLS.info(), nil
+}
+
+// RemovePluginCert deletes the certificate of a deleted plugin
+func (vm *VMService) RemovePluginCert(pluginSlug string) {
+	if vm.pluginTLS == nil {
+		return
+	}
+	if err := vm.pluginTLS.removePluginCert(pluginSlug); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"plugin_slug": pluginSlug,
+			"error":       err,
+		}).Warn("Failed to remove plugin certificate")
+	}
+}
+
+// guestTLS returns the certificate material of a plugin that uses mutual TLS,
+// or nil for other plugins. It reaches the guest through MMDS, so the key
+// never appears on the kernel command line.
+func (vm *VMService) guestTLS(plugin *cms_models.Plugin, ip string) (*GuestTLS, error) {
+	if !plugin.MTLS {
+		return nil, nil
+	}
+	if vm.pluginTLS == nil {
+		return nil, ErrPluginTLSDisabled
+	}
+	if !vm.capabilities.MMDS {
+		return nil, ErrPluginTLSNoMMDS
+	}
+
+	certDER, keyDER, err := vm.pluginTLS.pluginCert(plugin.Slug, ip)
+	if err != nil {
+		return nil, err
+	}
+	return vm.pluginTLS.guestTLS(certDER, keyDER), nil
+}
+
+// timeSyncClient returns the client for time pushes to an instance
+func (vm *VMService) timeSyncClient(instance *PrewarmInstance) *http.Client {
+	if instance.MTLS && vm.pluginTLS != nil {
+		return vm.guestTLSClient
+	}
+	return guestTimeSyncClient
+}
+
+// guestURL returns the URL of path on a plugin VM, over HTTPS for plugins
+// using mutual TLS. Plugins serve on port 80 either way.
+func guestURL(ip string, mtls bool, path string) string {
+	return guestPortURL(ip, 80, mtls, path)
+}
+
+// guestPortURL returns the URL of path on a port of a plugin VM, over HTTPS
+// for plugins using mutual TLS
+func guestPortURL(ip string, port int, mtls bool, path string) string {
+	scheme := "http"
+	if mtls {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d%s", scheme, ip, port, path)
+}
+
+// pluginURL returns the URL of path on a VM of plugin
+func pluginURL(plugin *cms_models.Plugin, ip, path string) string {
+	return guestURL(ip, plugin.MTLS, path)
+}
+
+// RotatePluginCert discards the certificate of a plugin using mutual TLS. The
+// running instance keeps serving its old certificate, which stays valid; the
+// next fresh boot is issued a new one, so the snapshot is marked stale.
+func (ps *PluginService) RotatePluginCert(slug string) (*cms_models.Plugin, error) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	plugin, exists := ps.plugins[slug]
+	if !exists {
+		return nil, fmt.Errorf("plugin not found")
+	}
+	if ps.vmService.pluginTLS == nil {
+		return nil, ErrPluginTLSDisabled
+	}
+	if !plugin.MTLS {
+		return nil, fmt.Errorf("plugin %s does not use mutual TLS", slug)
+	}
+
+	if err := ps.vmService.pluginTLS.removePluginCert(slug); err != nil {
+		return nil, fmt.Errorf("failed to remove plugin certificate: %v", err)
+	}
+
+	plugin.NeedsResnapshot = true
+	plugin.UpdatedAt = time.Now()
+	if err := ps.savePluginsUnsafe(); err != nil {
+		return nil, fmt.Errorf("failed to save plugin state: %v", err)
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug": slug,
+	}).Info("Plugin certificate rotated, new certificate is issued on the next fresh boot")
+
+	return plugin, nil
+}
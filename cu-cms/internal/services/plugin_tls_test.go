@@ -0,0 +1,124 @@
+/*
+ * Firecracker CMS - Mutual TLS With Plugins Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+func TestPluginCertIssuedAndReused(t *testing.T) {
+	pt, err := newPluginTLS(t.TempDir(), 365*24*time.Hour)
+	if err != nil {
+		t.Fatalf("newPluginTLS: %v", err)
+	}
+
+	certDER, _, err := pt.pluginCert("blog", "192.168.127.10")
+	if err != nil {
+		t.Fatalf("pluginCert: %v", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: pt.pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}); err != nil {
+		t.Fatalf("plugin certificate does not chain to the CA: %v", err)
+	}
+	if len(cert.IPAddresses) != 1 || !cert.IPAddresses[0].Equal(net.ParseIP("192.168.127.10")) {
+		t.Fatalf("certificate IPs = %v, want the plugin IP", cert.IPAddresses)
+	}
+
+	again, _, err := pt.pluginCert("blog", "192.168.127.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, certDER) {
+		t.Fatalf("stored certificate was not reused")
+	}
+
+	moved, _, err := pt.pluginCert("blog", "192.168.127.11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(moved, certDER) {
+		t.Fatalf("certificate not reissued for a new IP")
+	}
+}
+
+func TestPluginCertRotation(t *testing.T) {
+	dir := t.TempDir()
+	pt, err := newPluginTLS(dir, 365*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, _, err := pt.pluginCert("blog", "192.168.127.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pt.removePluginCert("blog"); err != nil {
+		t.Fatalf("removePluginCert: %v", err)
+	}
+	if err := pt.removePluginCert("blog"); err != nil {
+		t.Fatalf("removing a missing certificate: %v", err)
+	}
+	after, _, err := pt.pluginCert("blog", "192.168.127.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(before, after) {
+		t.Fatalf("rotation kept the old certificate")
+	}
+
+	// The CA survives a restart, so rotated certificates stay trusted
+	reloaded, err := newPluginTLS(dir, 365*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reloaded.caCert.Raw, pt.caCert.Raw) {
+		t.Fatalf("CA was recreated on reload")
+	}
+}
+
+func TestGuestTLSPublishedThroughMMDS(t *testing.T) {
+	pt, err := newPluginTLS(t.TempDir(), 365*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm := newTestVMService()
+	vm.pluginTLS = pt
+	plugin := &cms_models.Plugin{Slug: "blog", MTLS: true}
+
+	if _, err := vm.guestTLS(plugin, "192.168.127.10"); !errors.Is(err, ErrPluginTLSNoMMDS) {
+		t.Fatalf("guestTLS without MMDS = %v, want ErrPluginTLSNoMMDS", err)
+	}
+
+	vm.capabilities.MMDS = true
+	vm.config.GuestMMDS = false
+	guestTLS, err := vm.guestTLS(plugin, "192.168.127.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caDER, _ := base64.StdEncoding.DecodeString(guestTLS.CA); !bytes.Equal(caDER, pt.caCert.Raw) {
+		t.Fatalf("published CA does not match the plugin CA")
+	}
+
+	metadata := vm.guestMetadata(plugin, "blog-1", "192.168.127.10", guestTLS)
+	if metadata["cms_tls"] != guestTLS {
+		t.Fatalf("certificate missing from MMDS metadata: %v", metadata)
+	}
+	if _, exists := metadata["cms"]; exists {
+		t.Fatalf("identity published although CMS_GUEST_MMDS is off")
+	}
+}
@@ -0,0 +1,191 @@
+/*
+ * Firecracker CMS - Memory-Based Pool Sizing
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// Host memory used by a Firecracker process on top of its guest memory
+const vmmOverheadMib = 8
+
+// PoolCapacity describes how the warm pool fits the memory budget
+type PoolCapacity struct {
+	MemoryBudgetMib int  `json:"memory_budget_mib"` // 0 when the pool is not sized by memory
+	MemoryUsedMib   int  `json:"memory_used_mib"`   // Running VMs, parked ones included
+	ReservedMib     int  `json:"reserved_mib"`      // One warm instance of every active plugin
+	Overcommitted   bool `json:"overcommitted"`     // The active plugins alone exceed the budget
+
+	Plugins map[string]PluginPoolCapacity `json:"plugins"`
+}
+
+// PluginPoolCapacity is the computed pool size of a registered plugin
+type PluginPoolCapacity struct {
+	FootprintMib int  `json:"footprint_mib"` // Guest memory plus VMM overhead per VM
+	MaxInstances int  `json:"max_instances"` // Per-plugin cap after the memory budget
+	Instances    int  `json:"instances"`     // Running VMs
+	Active       bool `json:"active"`
+}
+
+// resolveMemoryBudget returns the configured budget in MiB: an explicit size,
+// else a share of host memory, else 0
+func (vm *VMService) resolveMemoryBudget() int {
+	if vm.config.WarmMemoryBudgetMB > 0 {
+		return vm.config.WarmMemoryBudgetMB
+	}
+	if vm.config.WarmMemoryBudgetPercent == 0 {
+		return 0
+	}
+
+	totalMib, err := hostMemTotalMib()
+	if err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"error": err,
+		}).Warn("Failed to read host memory, warm pool is not sized by memory")
+		return 0
+	}
+
+	budget := totalMib * vm.config.WarmMemoryBudgetPercent / 100
+	vm.logger.WithFields(logger.Fields{
+		"host_memory_mib":   totalMib,
+		"budget_percent":    vm.config.WarmMemoryBudgetPercent,
+		"memory_budget_mib": budget,
+	}).Info("Warm pool sized to host memory")
+	return budget
+}
+
+// hostMemTotalMib reads the total host memory from /proc/meminfo
+func hostMemTotalMib() (int, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kib, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return 0, fmt.Errorf("invalid MemTotal %q: %v", fields[1], err)
+			}
+			return kib / 1024, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("MemTotal not found in /proc/meminfo")
+}
+
+// SetPluginFootprints recomputes the memory footprint of every registered plugin,
+// called whenever plugins are added, removed or change state
+func (vm *VMService) SetPluginFootprints(plugins []*cms_models.Plugin) {
+	footprints := make(map[string]int, len(plugins))
+	active := make(map[string]bool, len(plugins))
+	reserved := 0
+	for _, plugin := range plugins {
+		footprints[plugin.Slug] = int(vm.resolveIsolation(plugin).MemSizeMib) + vmmOverheadMib
+		if plugin.IsActive() {
+			active[plugin.Slug] = true
+			reserved += footprints[plugin.Slug]
+		}
+	}
+
+	vm.poolMutex.Lock()
+	vm.pluginFootprints = footprints
+	vm.activeFootprints = active
+	vm.poolMutex.Unlock()
+
+	if vm.memoryBudgetMib > 0 && reserved > vm.memoryBudgetMib {
+		vm.logger.WithFields(logger.Fields{
+			"reserved_mib":      reserved,
+			"memory_budget_mib": vm.memoryBudgetMib,
+			"active_plugins":    len(active),
+		}).Warn("Active plugins need more memory than the warm budget, instances will cold start")
+	}
+}
+
+// footprintUnsafe returns the MiB a VM of a plugin takes, assuming the default
+// size for plugins not (or no longer) registered
+// Note: Caller must hold vm.poolMutex
+func (vm *VMService) footprintUnsafe(pluginSlug string) int {
+	if footprint, exists := vm.pluginFootprints[pluginSlug]; exists {
+		return footprint
+	}
+	return defaultMemSizeMib + vmmOverheadMib
+}
+
+// poolMemoryUnsafe returns the MiB taken by all running VMs
+// Note: Caller must hold vm.poolMutex
+func (vm *VMService) poolMemoryUnsafe() int {
+	used := 0
+	for _, instance := range vm.prewarmPool {
+		used += vm.footprintUnsafe(instance.PluginSlug)
+	}
+	return used
+}
+
+// pluginPoolCapUnsafe returns how many VMs of a plugin may run: the configured
+// pool size, lowered to what fits the memory budget but never below one
+// Note: Caller must hold vm.poolMutex
+func (vm *VMService) pluginPoolCapUnsafe(pluginSlug string) int {
+	if vm.memoryBudgetMib == 0 {
+		return vm.maxPoolSize
+	}
+	return max(1, min(vm.maxPoolSize, vm.memoryBudgetMib/vm.footprintUnsafe(pluginSlug)))
+}
+
+// GetPoolCapacity returns the memory budget and the computed per-plugin caps
+func (vm *VMService) GetPoolCapacity() PoolCapacity {
+	vm.poolMutex.RLock()
+	defer vm.poolMutex.RUnlock()
+
+	capacity := PoolCapacity{
+		MemoryBudgetMib: vm.memoryBudgetMib,
+		MemoryUsedMib:   vm.poolMemoryUnsafe(),
+		Plugins:         make(map[string]PluginPoolCapacity, len(vm.pluginFootprints)),
+	}
+
+	instances := make(map[string]int)
+	for _, instance := range vm.prewarmPool {
+		instances[instance.PluginSlug]++
+	}
+
+	for slug, footprint := range vm.pluginFootprints {
+		if vm.activeFootprints[slug] {
+			capacity.ReservedMib += footprint
+		}
+		capacity.Plugins[slug] = PluginPoolCapacity{
+			FootprintMib: footprint,
+			MaxInstances: vm.pluginPoolCapUnsafe(slug),
+			Instances:    instances[slug],
+			Active:       vm.activeFootprints[slug],
+		}
+	}
+	capacity.Overcommitted = vm.memoryBudgetMib > 0 && capacity.ReservedMib > vm.memoryBudgetMib
+
+	return capacity
+}
+
+// updatePoolFootprintsUnsafe hands the registered plugins to the pool sizing
+// Note: Caller must hold ps.mutex
+func (ps *PluginService) updatePoolFootprintsUnsafe() {
+	plugins := make([]*cms_models.Plugin, 0, len(ps.plugins))
+	for _, plugin := range ps.plugins {
+		plugins = append(plugins, plugin)
+	}
+	ps.vmService.SetPluginFootprints(plugins)
+}
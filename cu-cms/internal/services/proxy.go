@@ -0,0 +1,106 @@
+/*
+ * Firecracker CMS - Streaming Plugin Proxy
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	cms_errors "github.com/centraunit/cu-firecracker-cms/internal/errors"
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// ProxyRequest streams a raw request to path on a plugin's warm instance and
+// streams the response back, without decoding either body. Errors returned
+// before anything is written are CMSErrors; failures while proxying are
+// answered directly with a 502, 504 or 413.
+func (ps *PluginService) ProxyRequest(slug, path string, w http.ResponseWriter, r *http.Request) error {
+	ps.mutex.RLock()
+	plugin, exists := ps.plugins[slug]
+	ps.mutex.RUnlock()
+
+	if !exists {
+		return cms_errors.NewValidationError("proxy_request", "plugin not found")
+	}
+	if !plugin.IsActive() || !plugin.IsEnabled() {
+		return cms_errors.NewValidationError("proxy_request", "plugin is not active")
+	}
+
+	if !ps.rateLimiter.allow(plugin.Slug, plugin.MaxExecutionsPerSecond) {
+		return cms_errors.NewRateLimitError("proxy_request", "plugin rate limit exceeded")
+	}
+
+	startTime := time.Now()
+
+	ps.executionMetrics.executionStarted(plugin.Slug)
+	defer ps.executionMetrics.executionFinished(plugin.Slug)
+
+	instance := ps.vmService.GetPrewarmInstance(plugin.Slug)
+	ps.executionMetrics.instanceLookup(plugin.Slug, instance != nil)
+	if instance == nil {
+		return cms_errors.NewVMError("proxy_request", "plugin not ready - no pre-warmed instance available")
+	}
+
+	if err := ps.vmService.ResumeVM(instance.InstanceID); err != nil {
+		ps.vmService.AbandonPrewarmInstance(instance)
+		return cms_errors.WrapVMError(err, "proxy_request", "failed to resume VM")
+	}
+	ps.executionMetrics.instanceAcquired(plugin.Slug, time.Since(startTime))
+	defer ps.releaseWarmInstance(plugin, instance)
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(ps.config.ProxyTimeoutSec)*time.Second)
+	defer cancel()
+
+	r = r.WithContext(ctx)
+	r.Body = http.MaxBytesReader(w, r.Body, int64(ps.config.ProxyMaxBodyMB)<<20)
+
+	target, _ := url.Parse(guestURL(instance.IP, instance.MTLS, ""))
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.URL.Path = path
+			pr.Out.URL.RawPath = ""
+			pr.SetXForwarded()
+		},
+		Transport:     ps.httpTransport,
+		FlushInterval: -1, // Stream responses as they arrive
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			status := http.StatusBadGateway
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			} else if categorizeRequestError(err) == cms_errors.ErrTypeTimeout {
+				status = http.StatusGatewayTimeout
+			}
+
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"path":        path,
+				"status":      status,
+				"error":       err,
+			}).Error("Proxied request to plugin failed")
+
+			http.Error(w, fmt.Sprintf("proxy error: %v", err), status)
+		},
+	}
+
+	proxy.ServeHTTP(w, r)
+
+	ps.logger.WithFields(logger.Fields{
+		"plugin_slug":    plugin.Slug,
+		"path":           path,
+		"method":         r.Method,
+		"execution_time": time.Since(startTime).Milliseconds(),
+	}).Info("Proxied request to plugin")
+
+	return nil
+}
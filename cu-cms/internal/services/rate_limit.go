@@ -0,0 +1,117 @@
+/*
+ * Firecracker CMS - Plugin Rate Limiting
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// PluginThrottleStats represents the rate limiting state of a plugin
+type PluginThrottleStats struct {
+	MaxExecutionsPerSecond float64 `json:"max_executions_per_second"`
+	TokensAvailable        float64 `json:"tokens_available"`
+	Throttling             bool    `json:"throttling"` // The next execution would be rejected
+	ThrottledTotal         int64   `json:"throttled_total"`
+}
+
+// tokenBucket allows rate executions per second with bursts of up to burst
+type tokenBucket struct {
+	rate      float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+	throttled int64
+}
+
+// newTokenBucket creates a full bucket. The burst is one second worth of
+// executions, but at least one.
+func newTokenBucket(rate float64) *tokenBucket {
+	burst := math.Max(1, math.Ceil(rate))
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// refill adds the tokens accrued since the last refill
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// take consumes a token if one is available
+func (b *tokenBucket) take(now time.Time) bool {
+	b.refill(now)
+	if b.tokens < 1 {
+		b.throttled++
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// pluginRateLimiter keeps a token bucket per rate limited plugin
+type pluginRateLimiter struct {
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// newPluginRateLimiter creates a rate limiter without any buckets
+func newPluginRateLimiter() *pluginRateLimiter {
+	return &pluginRateLimiter{
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow reports whether a plugin may execute now under the given rate. A rate of
+// zero disables limiting; a changed rate starts a fresh bucket.
+func (l *pluginRateLimiter) allow(pluginSlug string, rate float64) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if rate <= 0 {
+		delete(l.buckets, pluginSlug)
+		return true
+	}
+
+	bucket, exists := l.buckets[pluginSlug]
+	if !exists || bucket.rate != rate {
+		bucket = newTokenBucket(rate)
+		l.buckets[pluginSlug] = bucket
+	}
+
+	return bucket.take(time.Now())
+}
+
+// snapshot returns the current state of every bucket
+func (l *pluginRateLimiter) snapshot() map[string]PluginThrottleStats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	stats := make(map[string]PluginThrottleStats, len(l.buckets))
+	for slug, bucket := range l.buckets {
+		bucket.refill(now)
+		stats[slug] = PluginThrottleStats{
+			MaxExecutionsPerSecond: bucket.rate,
+			TokensAvailable:        bucket.tokens,
+			Throttling:             bucket.tokens < 1,
+			ThrottledTotal:         bucket.throttled,
+		}
+	}
+
+	return stats
+}
+
+// GetThrottleStats returns the rate limiting state of rate limited plugins
+func (ps *PluginService) GetThrottleStats() map[string]PluginThrottleStats {
+	return ps.rateLimiter.snapshot()
+}
@@ -0,0 +1,274 @@
+/*
+ * Firecracker CMS - Resource Usage History
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// clockTicksPerSecond is the USER_HZ used by /proc/<pid>/stat CPU times
+const clockTicksPerSecond = 100
+
+// usageRing is a fixed-size ring buffer of resource samples
+type usageRing struct {
+	samples []cms_models.ResourceSample
+	next    int
+	full    bool
+}
+
+// add appends a sample, overwriting the oldest one when full
+func (r *usageRing) add(sample cms_models.ResourceSample) {
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// since returns samples newer than the cutoff in chronological order
+func (r *usageRing) since(cutoff time.Time) []cms_models.ResourceSample {
+	ordered := make([]cms_models.ResourceSample, 0, len(r.samples))
+	if r.full {
+		ordered = append(ordered, r.samples[r.next:]...)
+	}
+	ordered = append(ordered, r.samples[:r.next]...)
+
+	result := make([]cms_models.ResourceSample, 0, len(ordered))
+	for _, sample := range ordered {
+		if !sample.Timestamp.Before(cutoff) {
+			result = append(result, sample)
+		}
+	}
+	return result
+}
+
+// cpuReading is the last observed CPU time of an instance process
+type cpuReading struct {
+	ticks uint64
+	at    time.Time
+}
+
+// resourceUsageTracker keeps bounded per-plugin usage history and persisted summaries
+type resourceUsageTracker struct {
+	mutex     sync.Mutex
+	size      int
+	history   map[string]*usageRing
+	summaries map[string]*cms_models.ResourceUsageSummary
+	lastCPU   map[string]cpuReading // instanceID -> last reading
+}
+
+// newResourceUsageTracker creates a tracker keeping size samples per plugin
+func newResourceUsageTracker(size int) *resourceUsageTracker {
+	return &resourceUsageTracker{
+		size:      size,
+		history:   make(map[string]*usageRing),
+		summaries: make(map[string]*cms_models.ResourceUsageSummary),
+		lastCPU:   make(map[string]cpuReading),
+	}
+}
+
+// record stores a sample and folds it into the plugin summary
+func (t *resourceUsageTracker) record(pluginSlug string, sample cms_models.ResourceSample) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	ring, exists := t.history[pluginSlug]
+	if !exists {
+		ring = &usageRing{samples: make([]cms_models.ResourceSample, t.size)}
+		t.history[pluginSlug] = ring
+	}
+	ring.add(sample)
+
+	summary, exists := t.summaries[pluginSlug]
+	if !exists {
+		summary = &cms_models.ResourceUsageSummary{}
+		t.summaries[pluginSlug] = summary
+	}
+	n := float64(summary.Samples)
+	summary.AvgCPUPercent = (summary.AvgCPUPercent*n + sample.CPUPercent) / (n + 1)
+	summary.AvgMemoryMB = (summary.AvgMemoryMB*n + sample.MemoryMB) / (n + 1)
+	if sample.CPUPercent > summary.MaxCPUPercent {
+		summary.MaxCPUPercent = sample.CPUPercent
+	}
+	if sample.MemoryMB > summary.MaxMemoryMB {
+		summary.MaxMemoryMB = sample.MemoryMB
+	}
+	summary.Samples++
+	summary.UpdatedAt = sample.Timestamp
+}
+
+// forgetInstance drops the CPU reading of a stopped instance
+func (t *resourceUsageTracker) forgetInstance(instanceID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.lastCPU, instanceID)
+}
+
+// resourceUsagePath returns the path of the persisted usage summaries
+func (vm *VMService) resourceUsagePath() string {
+	return filepath.Join(vm.config.DataDir, "resource_usage.json")
+}
+
+// loadResourceUsageSummaries restores usage summaries persisted by a previous run
+func (vm *VMService) loadResourceUsageSummaries() {
+	data, err := os.ReadFile(vm.resourceUsagePath())
+	if err != nil {
+		return
+	}
+
+	summaries := make(map[string]*cms_models.ResourceUsageSummary)
+	if err := json.Unmarshal(data, &summaries); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"file":  vm.resourceUsagePath(),
+			"error": err,
+		}).Warn("Failed to parse resource usage summaries")
+		return
+	}
+
+	vm.usage.mutex.Lock()
+	vm.usage.summaries = summaries
+	vm.usage.mutex.Unlock()
+}
+
+// saveResourceUsageSummaries persists the rolled-up usage summaries
+func (vm *VMService) saveResourceUsageSummaries() error {
+	vm.usage.mutex.Lock()
+	data, err := json.MarshalIndent(vm.usage.summaries, "", "  ")
+	vm.usage.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(vm.resourceUsagePath(), data, 0644)
+}
+
+// usageSampler periodically samples CPU and memory of every running instance
+func (vm *VMService) usageSampler() {
+	ticker := time.NewTicker(time.Duration(vm.config.UsageSampleIntervalSec) * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		vm.sampleResourceUsage()
+
+		if err := vm.saveResourceUsageSummaries(); err != nil {
+			vm.logger.WithFields(logger.Fields{
+				"error": err,
+			}).Warn("Failed to persist resource usage summaries")
+		}
+	}
+}
+
+// sampleResourceUsage records one sample per running instance
+func (vm *VMService) sampleResourceUsage() {
+	vm.poolMutex.RLock()
+	instances := make([]*PrewarmInstance, 0, len(vm.prewarmPool))
+	for _, instance := range vm.prewarmPool {
+		instances = append(instances, instance)
+	}
+	vm.poolMutex.RUnlock()
+
+	for _, instance := range instances {
+		pid, err := instance.Machine.PID()
+		if err != nil {
+			continue
+		}
+
+		ticks, memoryMB, err := readProcessUsage(pid)
+		if err != nil {
+			vm.logger.WithFields(logger.Fields{
+				"instance_id": instance.InstanceID,
+				"pid":         pid,
+				"error":       err,
+			}).Debug("Failed to read instance resource usage")
+			continue
+		}
+
+		now := time.Now()
+		sample := cms_models.ResourceSample{Timestamp: now, MemoryMB: memoryMB}
+
+		vm.usage.mutex.Lock()
+		if last, exists := vm.usage.lastCPU[instance.InstanceID]; exists && ticks >= last.ticks {
+			elapsed := now.Sub(last.at).Seconds()
+			if elapsed > 0 {
+				sample.CPUPercent = float64(ticks-last.ticks) / clockTicksPerSecond / elapsed * 100
+			}
+		}
+		vm.usage.lastCPU[instance.InstanceID] = cpuReading{ticks: ticks, at: now}
+		vm.usage.mutex.Unlock()
+
+		vm.usage.record(instance.PluginSlug, sample)
+	}
+}
+
+// GetResourceUsage returns samples within the window and the rolled-up summary for a plugin.
+// A zero window returns the full buffered history.
+func (vm *VMService) GetResourceUsage(pluginSlug string, window time.Duration) ([]cms_models.ResourceSample, cms_models.ResourceUsageSummary) {
+	vm.usage.mutex.Lock()
+	defer vm.usage.mutex.Unlock()
+
+	samples := []cms_models.ResourceSample{}
+	if ring, exists := vm.usage.history[pluginSlug]; exists {
+		cutoff := time.Time{}
+		if window > 0 {
+			cutoff = time.Now().Add(-window)
+		}
+		samples = ring.since(cutoff)
+	}
+
+	var summary cms_models.ResourceUsageSummary
+	if existing, exists := vm.usage.summaries[pluginSlug]; exists {
+		summary = *existing
+	}
+
+	return samples, summary
+}
+
+// readProcessUsage returns total CPU ticks and resident memory (MB) of a process
+func readProcessUsage(pid int) (uint64, float64, error) {
+	statData, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Fields after the command name, which is wrapped in parentheses
+	stat := string(statData)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 13 {
+		return 0, 0, fmt.Errorf("unexpected /proc/%d/stat format", pid)
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+
+	statusData, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var memoryMB float64
+	for _, line := range strings.Split(string(statusData), "\n") {
+		if strings.HasPrefix(line, "VmRSS:") {
+			parts := strings.Fields(line)
+			if len(parts) >= 2 {
+				kb, _ := strconv.ParseFloat(parts[1], 64)
+				memoryMB = kb / 1024
+			}
+			break
+		}
+	}
+
+	return utime + stime, memoryMB, nil
+}
@@ -0,0 +1,147 @@
+/*
+ * Firecracker CMS - Plugin Response Decoding
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// errNonJSONResponse is returned for non-JSON plugin responses when raw responses are disabled
+var errNonJSONResponse = errors.New("plugin response is not JSON")
+
+// errResponseTooLarge is returned for non-JSON plugin responses larger than PluginStreamMaxBytes
+var errResponseTooLarge = errors.New("plugin response too large")
+
+// Encodings of a wrapped non-JSON response body
+const (
+	rawEncodingText   = "text"
+	rawEncodingBase64 = "base64"
+)
+
+// decodePluginResponse decodes a plugin response by its Content-Type. JSON, and
+// responses without a Content-Type, must be a JSON object; streamed JSON lines
+// are aggregated; anything else is wrapped as {"content_type", "encoding",
+// "body"} with binary bodies in base64, failing beyond PluginStreamMaxBytes.
+func (ps *PluginService) decodePluginResponse(resp *http.Response) (map[string]interface{}, error) {
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	if isJSONLinesMediaType(mediaType) {
+		return decodeJSONLines(resp.Body, ps.config.PluginStreamMaxBytes)
+	}
+
+	if contentType == "" || isJSONMediaType(mediaType) {
+		var result map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	if !ps.config.PluginRawResponses {
+		return nil, fmt.Errorf("%w: Content-Type %s", errNonJSONResponse, contentType)
+	}
+
+	// Raw bodies are held in memory and base64 grows them, so cap them like streams
+	maxBytes := ps.config.PluginStreamMaxBytes
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", errResponseTooLarge, maxBytes)
+	}
+
+	result := map[string]interface{}{
+		"content_type": contentType,
+		"encoding":     rawEncodingBase64,
+		"body":         base64.StdEncoding.EncodeToString(body),
+	}
+	if isTextMediaType(mediaType) && utf8.Valid(body) {
+		result["encoding"] = rawEncodingText
+		result["body"] = string(body)
+	}
+	return result, nil
+}
+
+// decodeJSONLines aggregates a stream of JSON values, one per line, into
+// {"items", "count", "truncated"}. Reading stops once maxBytes were read: the
+// items complete by then are kept and the result is marked truncated, so a
+// large stream never holds more than maxBytes in memory.
+func decodeJSONLines(body io.Reader, maxBytes int64) (map[string]interface{}, error) {
+	reader := bufio.NewReader(io.LimitReader(body, maxBytes+1))
+	items := []interface{}{}
+	truncated := false
+
+	var read int64
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		read += int64(len(data))
+		if read > maxBytes {
+			truncated = true
+			break
+		}
+
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			var item interface{}
+			if jsonErr := json.Unmarshal(trimmed, &item); jsonErr != nil {
+				return nil, fmt.Errorf("invalid JSON on line %d of streamed response: %w", line, jsonErr)
+			}
+			items = append(items, item)
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]interface{}{
+		"items":     items,
+		"count":     len(items),
+		"truncated": truncated,
+	}, nil
+}
+
+// isJSONLinesMediaType reports whether a media type is a stream of JSON lines
+func isJSONLinesMediaType(mediaType string) bool {
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return true
+	}
+	return false
+}
+
+// isJSONMediaType reports whether a media type is JSON, e.g. application/problem+json
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isTextMediaType reports whether a media type carries text that is returned as is
+func isTextMediaType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/xml", strings.HasSuffix(mediaType, "+xml"):
+		return true
+	case mediaType == "application/javascript", mediaType == "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+/*
+ * Firecracker CMS - Plugin Response Decoding Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/config"
+	cms_errors "github.com/centraunit/cu-firecracker-cms/internal/errors"
+)
+
+// newTestResponse returns a plugin response with body and Content-Type
+func newTestResponse(contentType, body string) *http.Response {
+	resp := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(body))}
+	if contentType != "" {
+		resp.Header.Set("Content-Type", contentType)
+	}
+	return resp
+}
+
+func newDecodingTestService(maxBytes int64) *PluginService {
+	cfg := config.NewConfig()
+	cfg.PluginRawResponses = true
+	cfg.PluginStreamMaxBytes = maxBytes
+	return &PluginService{config: cfg}
+}
+
+func TestDecodeRawResponseWithinLimit(t *testing.T) {
+	ps := newDecodingTestService(16)
+
+	result, err := ps.decodePluginResponse(newTestResponse("text/csv", "a,b\n1,2\n"))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if result["encoding"] != rawEncodingText || result["body"] != "a,b\n1,2\n" {
+		t.Fatalf("unexpected result %v", result)
+	}
+
+	result, err = ps.decodePluginResponse(newTestResponse("application/octet-stream", "\xff\x00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["encoding"] != rawEncodingBase64 || result["body"] != "/wA=" {
+		t.Fatalf("unexpected binary result %v", result)
+	}
+}
+
+func TestDecodeRawResponseOverLimit(t *testing.T) {
+	ps := newDecodingTestService(16)
+
+	// Exactly the limit is accepted
+	if _, err := ps.decodePluginResponse(newTestResponse("text/plain", strings.Repeat("x", 16))); err != nil {
+		t.Fatalf("body at the limit rejected: %v", err)
+	}
+
+	_, err := ps.decodePluginResponse(newTestResponse("text/plain", strings.Repeat("x", 17)))
+	if !errors.Is(err, errResponseTooLarge) {
+		t.Fatalf("oversized body = %v, want errResponseTooLarge", err)
+	}
+	if got := categorizeRequestError(err); got != cms_errors.ErrTypeValidation {
+		t.Fatalf("oversized body categorized as %v, want validation", got)
+	}
+}
+
+func TestDecodeJSONLinesTruncates(t *testing.T) {
+	result, err := decodeJSONLines(strings.NewReader("{\"a\":1}\n{\"b\":2}\n{\"c\":3}\n"), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["count"] != 2 || result["truncated"] != true {
+		t.Fatalf("result = %v, want 2 items and truncated", result)
+	}
+}
+
+func TestDecodeNonJSONRejectedWhenRawDisabled(t *testing.T) {
+	ps := newDecodingTestService(16)
+	ps.config.PluginRawResponses = false
+
+	if _, err := ps.decodePluginResponse(newTestResponse("text/html", "<p>")); !errors.Is(err, errNonJSONResponse) {
+		t.Fatalf("decode = %v, want errNonJSONResponse", err)
+	}
+}
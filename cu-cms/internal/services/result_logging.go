@@ -0,0 +1,99 @@
+/*
+ * Firecracker CMS - Execution Result Logging
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// redactedValue replaces the values of redacted fields in logged results
+const redactedValue = "[REDACTED]"
+
+// parseRedactFields turns a comma-separated field list into a lowercase set
+func parseRedactFields(fields string) map[string]bool {
+	redact := make(map[string]bool)
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			redact[strings.ToLower(field)] = true
+		}
+	}
+	return redact
+}
+
+// parseDebugPlugins turns a comma-separated slug list into a set
+func parseDebugPlugins(slugs string) map[string]bool {
+	debug := make(map[string]bool)
+	for _, slug := range strings.Split(slugs, ",") {
+		if slug = strings.TrimSpace(slug); slug != "" {
+			debug[slug] = true
+		}
+	}
+	return debug
+}
+
+// debugLoggingEnabled reports whether a plugin's execution payloads and
+// responses are logged in full, by its debug setting or CMS_DEBUG_PLUGINS
+func (ps *PluginService) debugLoggingEnabled(plugin *models.Plugin) bool {
+	return plugin.Debug || ps.debugPlugins[plugin.Slug]
+}
+
+// debugBody renders a payload or response for debug logging, with redacted
+// fields masked but without the LogResultMaxBytes cut
+func (ps *PluginService) debugBody(value interface{}) string {
+	data, err := json.Marshal(redactResult(value, ps.logRedactFields))
+	if err != nil {
+		return fmt.Sprintf("<unserializable body: %v>", err)
+	}
+	return string(data)
+}
+
+// loggableResult renders a plugin result for logging, with redacted fields
+// masked at any depth and the output cut to LogResultMaxBytes. The result
+// returned to the caller is left untouched.
+func (ps *PluginService) loggableResult(result map[string]interface{}) string {
+	data, err := json.Marshal(redactResult(result, ps.logRedactFields))
+	if err != nil {
+		return fmt.Sprintf("<unserializable result: %v>", err)
+	}
+
+	if len(data) > ps.config.LogResultMaxBytes {
+		return fmt.Sprintf("%s...(truncated, %d bytes)", data[:ps.config.LogResultMaxBytes], len(data))
+	}
+	return string(data)
+}
+
+// redactResult returns a copy of value with the values of redacted fields masked
+func redactResult(value interface{}, redact map[string]bool) interface{} {
+	if len(redact) == 0 {
+		return value
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(typed))
+		for key, fieldValue := range typed {
+			if redact[strings.ToLower(key)] {
+				copied[key] = redactedValue
+			} else {
+				copied[key] = redactResult(fieldValue, redact)
+			}
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(typed))
+		for i, item := range typed {
+			copied[i] = redactResult(item, redact)
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,54 @@
+/*
+ * Firecracker CMS - Resume Readiness Probe
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// Pause between connection attempts to a resumed guest; a refused connect
+// returns at once, so retries are tight
+const readyProbeInterval = 2 * time.Millisecond
+
+// waitGuestReady blocks until the plugin port of a resumed VM accepts TCP
+// connections, up to the configured timeout or the caller's deadline,
+// whichever comes first. A zero timeout skips the probe.
+func (ps *PluginService) waitGuestReady(ip string, callerDeadline time.Time) error {
+	if ps.config.ResumeReadyTimeoutMs == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	deadline := start.Add(time.Duration(ps.config.ResumeReadyTimeoutMs) * time.Millisecond)
+	if !callerDeadline.IsZero() && callerDeadline.Before(deadline) {
+		deadline = callerDeadline
+	}
+
+	address := net.JoinHostPort(ip, "80")
+	attempts := 0
+	var lastErr error = fmt.Errorf("deadline passed before the first attempt")
+	// A non-positive dial timeout would mean no timeout at all
+	for remaining := time.Until(deadline); remaining > 0; remaining = time.Until(deadline) {
+		attempts++
+		conn, err := net.DialTimeout("tcp", address, remaining)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		lastErr = err
+
+		if time.Until(deadline) <= readyProbeInterval {
+			break
+		}
+		time.Sleep(readyProbeInterval)
+	}
+
+	return fmt.Errorf("%s not accepting connections after %d attempts in %v: %v",
+		address, attempts, time.Since(start).Round(time.Millisecond), lastErr)
+}
@@ -0,0 +1,122 @@
+/*
+ * Firecracker CMS - Content-Addressed Rootfs Storage
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// rootfsBlobsDir returns the directory holding one copy of each rootfs image by hash
+func (ps *PluginService) rootfsBlobsDir() string {
+	return filepath.Join(ps.config.DataDir, "plugins", "blobs")
+}
+
+// rootfsBlobPath returns the blob path of a rootfs image with the given hash
+func (ps *PluginService) rootfsBlobPath(hash, rootfsType string) string {
+	return filepath.Join(ps.rootfsBlobsDir(), hash+"."+rootfsType)
+}
+
+// hashFile returns the hex SHA-256 of a file
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// storeRootfsBlob installs the image at srcPath as rootfsPath, a hardlink to the
+// blob of its hash, storing the blob first if no plugin shares the image yet.
+// Each hardlink counts as a reference, so the blob's link count is its refcount.
+// Only read-only images may be shared this way; VMs write to ext4 images.
+func (ps *PluginService) storeRootfsBlob(srcPath, rootfsPath, rootfsType string) (string, error) {
+	hash, err := hashFile(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash rootfs: %v", err)
+	}
+
+	ps.blobMutex.Lock()
+	defer ps.blobMutex.Unlock()
+
+	if err := os.MkdirAll(ps.rootfsBlobsDir(), 0755); err != nil {
+		return "", fmt.Errorf("failed to create blobs directory: %v", err)
+	}
+
+	blobPath := ps.rootfsBlobPath(hash, rootfsType)
+	shared := true
+	if _, err := os.Stat(blobPath); os.IsNotExist(err) {
+		shared = false
+		// The upload is extracted to a temp dir, possibly on another filesystem
+		if err := os.Rename(srcPath, blobPath); err != nil {
+			if err := ps.copyFile(srcPath, blobPath); err != nil {
+				os.Remove(blobPath)
+				return "", fmt.Errorf("failed to store rootfs blob: %v", err)
+			}
+		}
+	}
+
+	os.Remove(rootfsPath)
+	if err := os.Link(blobPath, rootfsPath); err != nil {
+		return "", fmt.Errorf("failed to link rootfs blob: %v", err)
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"rootfs_path": rootfsPath,
+		"rootfs_hash": hash,
+		"shared":      shared,
+	}).Info("Rootfs image stored by content hash")
+
+	return hash, nil
+}
+
+// releaseRootfsBlob removes the blob of a hash once no plugin links to it.
+// The plugin's own link must already be removed.
+func (ps *PluginService) releaseRootfsBlob(hash, rootfsType string) {
+	if hash == "" {
+		return
+	}
+
+	ps.blobMutex.Lock()
+	defer ps.blobMutex.Unlock()
+
+	blobPath := ps.rootfsBlobPath(hash, rootfsType)
+	info, err := os.Stat(blobPath)
+	if err != nil {
+		return
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Nlink > 1 {
+		return
+	}
+
+	if err := os.Remove(blobPath); err != nil {
+		ps.logger.WithFields(logger.Fields{
+			"rootfs_hash": hash,
+			"error":       err,
+		}).Warn("Failed to remove unreferenced rootfs blob")
+		return
+	}
+
+	ps.logger.WithFields(logger.Fields{
+		"rootfs_hash": hash,
+	}).Info("Removed unreferenced rootfs blob")
+}
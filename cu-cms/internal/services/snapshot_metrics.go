@@ -0,0 +1,200 @@
+/*
+ * Firecracker CMS - Snapshot Metrics
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// guestPageSize is the page size dirty pages are tracked at
+const guestPageSize = 4096
+
+// SnapshotOperationStats represents timing and size statistics of one kind of
+// snapshot operation
+type SnapshotOperationStats struct {
+	Count         int64   `json:"count"`
+	TotalMs       float64 `json:"total_ms"`
+	AvgMs         float64 `json:"avg_ms"`
+	MaxMs         float64 `json:"max_ms"`
+	LastMs        float64 `json:"last_ms"`
+	AvgSizeBytes  int64   `json:"avg_size_bytes"`
+	LastSizeBytes int64   `json:"last_size_bytes"`
+
+	totalSizeBytes int64
+}
+
+// DirtyPageStats represents how much guest memory differential snapshots
+// carried, relative to the full guest memory
+type DirtyPageStats struct {
+	Count             int64   `json:"count"`
+	LastDirtyPages    int64   `json:"last_dirty_pages"`
+	LastDirtyBytes    int64   `json:"last_dirty_bytes"`
+	LastRatio         float64 `json:"last_ratio"`
+	AvgRatio          float64 `json:"avg_ratio"`
+	MaxRatio          float64 `json:"max_ratio"`
+	RebaseRecommended bool    `json:"rebase_recommended"`
+
+	totalRatio float64
+}
+
+// PluginSnapshotStats represents snapshot statistics of a plugin. Resumes load
+// the full snapshot, so they are not split by snapshot type.
+type PluginSnapshotStats struct {
+	Full         SnapshotOperationStats `json:"full"`
+	Differential SnapshotOperationStats `json:"differential"`
+	Resume       SnapshotOperationStats `json:"resume"`
+	DirtyPages   DirtyPageStats         `json:"dirty_pages"`
+}
+
+// snapshotMetrics tracks snapshot creation and resume timings per plugin
+type snapshotMetrics struct {
+	mutex   sync.Mutex
+	plugins map[string]*PluginSnapshotStats
+}
+
+// newSnapshotMetrics creates an empty snapshot metrics tracker
+func newSnapshotMetrics() *snapshotMetrics {
+	return &snapshotMetrics{
+		plugins: make(map[string]*PluginSnapshotStats),
+	}
+}
+
+// record adds a completed operation to the given stats
+func (s *SnapshotOperationStats) record(duration time.Duration, sizeBytes int64) {
+	durationMs := float64(duration) / float64(time.Millisecond)
+
+	s.Count++
+	s.TotalMs += durationMs
+	s.AvgMs = s.TotalMs / float64(s.Count)
+	if durationMs > s.MaxMs {
+		s.MaxMs = durationMs
+	}
+	s.LastMs = durationMs
+
+	s.totalSizeBytes += sizeBytes
+	s.AvgSizeBytes = s.totalSizeBytes / s.Count
+	s.LastSizeBytes = sizeBytes
+}
+
+// snapshotCreated records a completed snapshot creation
+func (m *snapshotMetrics) snapshotCreated(pluginSlug string, differential bool, duration time.Duration, sizeBytes int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats := m.pluginStatsUnsafe(pluginSlug)
+	if differential {
+		stats.Differential.record(duration, sizeBytes)
+	} else {
+		stats.Full.record(duration, sizeBytes)
+		// A fresh full snapshot is the new base
+		stats.DirtyPages.RebaseRecommended = false
+	}
+}
+
+// dirtyPagesRecorded records the dirty memory of a differential snapshot and
+// returns its ratio to the full guest memory. Once the ratio exceeds
+// rebaseRatio (if positive) a new full snapshot is recommended.
+func (m *snapshotMetrics) dirtyPagesRecorded(pluginSlug string, dirtyBytes, fullBytes int64, rebaseRatio float64) float64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	ratio := 0.0
+	if fullBytes > 0 {
+		ratio = float64(dirtyBytes) / float64(fullBytes)
+	}
+
+	stats := &m.pluginStatsUnsafe(pluginSlug).DirtyPages
+	stats.Count++
+	stats.LastDirtyPages = dirtyBytes / guestPageSize
+	stats.LastDirtyBytes = dirtyBytes
+	stats.LastRatio = ratio
+	stats.totalRatio += ratio
+	stats.AvgRatio = stats.totalRatio / float64(stats.Count)
+	if ratio > stats.MaxRatio {
+		stats.MaxRatio = ratio
+	}
+	if rebaseRatio > 0 && ratio > rebaseRatio {
+		stats.RebaseRecommended = true
+	}
+
+	return ratio
+}
+
+// rebaseRecommended reports whether the last differential snapshot of a plugin
+// was large enough that a new full snapshot is cheaper
+func (m *snapshotMetrics) rebaseRecommended(pluginSlug string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats, exists := m.plugins[pluginSlug]
+	return exists && stats.DirtyPages.RebaseRecommended
+}
+
+// snapshotResumed records a completed resume from snapshot
+func (m *snapshotMetrics) snapshotResumed(pluginSlug string, duration time.Duration, sizeBytes int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.pluginStatsUnsafe(pluginSlug).Resume.record(duration, sizeBytes)
+}
+
+// pluginStatsUnsafe returns the stats entry for a plugin, creating it if needed
+// Note: Caller must hold m.mutex
+func (m *snapshotMetrics) pluginStatsUnsafe(pluginSlug string) *PluginSnapshotStats {
+	stats, exists := m.plugins[pluginSlug]
+	if !exists {
+		stats = &PluginSnapshotStats{}
+		m.plugins[pluginSlug] = stats
+	}
+	return stats
+}
+
+// snapshot returns a copy of the current statistics
+func (m *snapshotMetrics) snapshot() map[string]PluginSnapshotStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats := make(map[string]PluginSnapshotStats, len(m.plugins))
+	for slug, pluginStats := range m.plugins {
+		stats[slug] = *pluginStats
+	}
+
+	return stats
+}
+
+// snapshotFilesSize returns the combined size of snapshot files, ignoring missing ones
+func snapshotFilesSize(paths ...string) int64 {
+	var total int64
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// allocatedFileSize returns the bytes actually allocated to a file, which for
+// a sparse differential snapshot are its dirty pages
+func allocatedFileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size(), nil
+	}
+	return min(stat.Blocks*512, info.Size()), nil
+}
+
+// GetSnapshotStats returns snapshot creation and resume statistics per plugin
+func (vm *VMService) GetSnapshotStats() map[string]PluginSnapshotStats {
+	return vm.snapshots.snapshot()
+}
@@ -0,0 +1,304 @@
+/*
+ * Firecracker CMS - Background Snapshot Refresh
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/firecracker-microvm/firecracker-go-sdk/client/models"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// lseek whence values for walking the data regions of sparse files (Linux)
+const (
+	seekData = 3
+	seekHole = 4
+)
+
+// RefreshSnapshot re-snapshots a warm instance so recovery resumes recent state,
+// restoring its paused state afterwards. When the full snapshot on disk belongs
+// to this VM and dirty pages are tracked, only changed pages are written and
+// merged into it; otherwise the full snapshot is replaced. forceFull replaces
+// it regardless, consolidating the differentials merged so far. It reports
+// whether the refresh was differential. Instances claimed by an execution are
+// not refreshed and yield ErrInstanceBusy.
+func (vm *VMService) RefreshSnapshot(instanceID, pluginSlug string, forceFull bool) (bool, error) {
+	vm.poolMutex.RLock()
+	instance, exists := vm.prewarmPool[instanceID]
+	vm.poolMutex.RUnlock()
+
+	if !exists {
+		return false, fmt.Errorf("VM instance %s not found", instanceID)
+	}
+
+	instance.opMutex.Lock()
+	defer instance.opMutex.Unlock()
+
+	// Executions claim the instance before using it, so this check holds
+	// until the snapshot is done
+	if instance.busy() {
+		return false, ErrInstanceBusy
+	}
+
+	// Snapshots always resume the VM, so remember whether it was paused
+	wasPaused := false
+	if info, err := instance.Machine.DescribeInstanceInfo(context.Background()); err == nil && info.State != nil {
+		wasPaused = *info.State == models.InstanceInfoStatePaused
+	}
+
+	differential := vm.capabilities.DifferentialSnapshots && vm.capabilities.DirtyPageTracking &&
+		instance.SnapshotBase && vm.HasSnapshot(pluginSlug)
+
+	// Diffs this large cost about as much as a full snapshot, so rebase instead
+	if differential && vm.snapshots.rebaseRecommended(pluginSlug) {
+		vm.logger.WithFields(logger.Fields{
+			"plugin_slug": pluginSlug,
+		}).Info("Differential snapshots exceed the rebase threshold, taking a full snapshot")
+		differential = false
+	}
+
+	if differential && forceFull {
+		vm.logger.WithFields(logger.Fields{
+			"plugin_slug": pluginSlug,
+		}).Info("Differential snapshot limit reached, consolidating into a full snapshot")
+		differential = false
+	}
+
+	var err error
+	if differential {
+		err = vm.refreshDifferential(instanceID, pluginSlug)
+	} else {
+		err = vm.CreateSnapshot(instanceID, vm.GetSnapshotPath(pluginSlug), false)
+	}
+	if err != nil {
+		return differential, err
+	}
+
+	if wasPaused {
+		if err := vm.PauseVM(instanceID); err != nil {
+			return differential, fmt.Errorf("snapshot refreshed but failed to re-pause VM: %v", err)
+		}
+	}
+
+	return differential, nil
+}
+
+// refreshDifferential takes a differential snapshot and merges it into the full
+// snapshot. A failed merge leaves the full snapshot inconsistent, so it is
+// removed and recovery falls back to a fresh boot.
+func (vm *VMService) refreshDifferential(instanceID, pluginSlug string) error {
+	diffMemPath, diffStatePath, err := vm.CreateDifferentialSnapshot(instanceID, pluginSlug)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(diffMemPath)
+	defer os.Remove(diffStatePath)
+
+	snapshotDir := vm.GetSnapshotPath(pluginSlug)
+	baseMemPath := snapshotMemPath(snapshotDir, false, 0)
+	baseStatePath := snapshotStatePath(snapshotDir, false, 0)
+
+	// Without holes, clean pages can't be told apart from dirty ones
+	if !isSparseFile(diffMemPath) {
+		vm.logger.WithFields(logger.Fields{
+			"plugin_slug": pluginSlug,
+			"mem_path":    diffMemPath,
+		}).Warn("Differential snapshot is not sparse, taking a full snapshot instead")
+		return vm.CreateSnapshot(instanceID, snapshotDir, false)
+	}
+
+	if err := mergeDifferentialSnapshot(diffMemPath, diffStatePath, baseMemPath, baseStatePath); err != nil {
+		os.Remove(baseMemPath)
+		os.Remove(baseStatePath)
+		return fmt.Errorf("failed to merge differential snapshot, full snapshot discarded: %v", err)
+	}
+
+	return nil
+}
+
+// isSparseFile reports whether a file has fewer blocks allocated than its size needs
+func isSparseFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	allocated, err := allocatedFileSize(path)
+	return err == nil && allocated < info.Size()
+}
+
+// mergeDifferentialSnapshot builds a new full snapshot memory file from the
+// current one with the data regions of a sparse differential memory file
+// written over it, then renames it and the differential state into place.
+// VMs restored from the old memory file keep mapping its inode, so they never
+// see pages change under them.
+func mergeDifferentialSnapshot(diffMemPath, diffStatePath, baseMemPath, baseStatePath string) error {
+	diff, err := os.Open(diffMemPath)
+	if err != nil {
+		return err
+	}
+	defer diff.Close()
+
+	base, err := os.Open(baseMemPath)
+	if err != nil {
+		return err
+	}
+	defer base.Close()
+
+	baseInfo, err := base.Stat()
+	if err != nil {
+		return err
+	}
+	diffInfo, err := diff.Stat()
+	if err != nil {
+		return err
+	}
+
+	mergedPath := baseMemPath + ".merge"
+	merged, err := os.OpenFile(mergedPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, baseInfo.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer os.Remove(mergedPath) // No-op once renamed
+	defer merged.Close()
+
+	if err := merged.Truncate(max(baseInfo.Size(), diffInfo.Size())); err != nil {
+		return err
+	}
+	if err := copyDataRegions(merged, base, baseInfo.Size()); err != nil {
+		return fmt.Errorf("failed to copy full snapshot memory: %v", err)
+	}
+	if err := copyDataRegions(merged, diff, diffInfo.Size()); err != nil {
+		return fmt.Errorf("failed to write snapshot pages: %v", err)
+	}
+
+	if err := merged.Sync(); err != nil {
+		return err
+	}
+	if err := os.Rename(mergedPath, baseMemPath); err != nil {
+		return err
+	}
+
+	return os.Rename(diffStatePath, baseStatePath)
+}
+
+// copyDataRegions copies the data regions of a sparse file to the same offsets
+// in dst, leaving dst's holes unallocated
+func copyDataRegions(dst, src *os.File, size int64) error {
+	for offset := int64(0); offset < size; {
+		dataStart, err := src.Seek(offset, seekData)
+		if errors.Is(err, syscall.ENXIO) {
+			return nil // No data after offset
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find data: %v", err)
+		}
+
+		dataEnd, err := src.Seek(dataStart, seekHole)
+		if err != nil {
+			return fmt.Errorf("failed to find hole: %v", err)
+		}
+
+		section := io.NewSectionReader(src, dataStart, dataEnd-dataStart)
+		if _, err := io.Copy(io.NewOffsetWriter(dst, dataStart), section); err != nil {
+			return err
+		}
+
+		offset = dataEnd
+	}
+	return nil
+}
+
+// snapshotRefresher periodically re-snapshots long-lived warm instances
+func (ps *PluginService) snapshotRefresher() {
+	interval := time.Duration(ps.config.SnapshotRefreshIntervalSec) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ps.refreshSnapshots(interval)
+	}
+}
+
+// refreshSnapshots re-snapshots the warm instances of active plugins that have
+// run for at least interval since they started or were last refreshed. Busy
+// instances are left for the next round. Once a plugin has
+// SnapshotMaxDiffs differentials merged, its next refresh is a full snapshot.
+func (ps *PluginService) refreshSnapshots(interval time.Duration) {
+	ps.mutex.RLock()
+	var plugins []*cms_models.Plugin
+	for _, plugin := range ps.plugins {
+		if plugin.IsActive() && plugin.IsEnabled() {
+			plugins = append(plugins, plugin)
+		}
+	}
+	ps.mutex.RUnlock()
+
+	for _, plugin := range plugins {
+		instance := ps.vmService.PeekPrewarmInstance(plugin.Slug)
+		if instance == nil {
+			continue
+		}
+
+		ps.mutex.RLock()
+		lastRefresh := instance.CreatedAt
+		if plugin.SnapshotRefreshedAt != nil && plugin.SnapshotRefreshedAt.After(lastRefresh) {
+			lastRefresh = *plugin.SnapshotRefreshedAt
+		}
+		forceFull := ps.config.SnapshotMaxDiffs > 0 && plugin.SnapshotDiffCount >= ps.config.SnapshotMaxDiffs
+		ps.mutex.RUnlock()
+
+		if time.Since(lastRefresh) < interval {
+			continue
+		}
+
+		start := time.Now()
+		differential, err := ps.vmService.RefreshSnapshot(instance.InstanceID, plugin.Slug, forceFull)
+		if errors.Is(err, ErrInstanceBusy) {
+			continue
+		}
+		if err != nil {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug":  plugin.Slug,
+				"differential": differential,
+				"error":        err,
+			}).Error("Failed to refresh snapshot")
+			continue
+		}
+
+		ps.mutex.Lock()
+		refreshedAt := time.Now()
+		plugin.SnapshotRefreshedAt = &refreshedAt
+		if differential {
+			plugin.SnapshotDiffCount++
+		} else {
+			plugin.SnapshotDiffCount = 0
+		}
+		diffCount := plugin.SnapshotDiffCount
+		if err := ps.savePluginsUnsafe(); err != nil {
+			ps.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"error":       err,
+			}).Error("Failed to save snapshot refresh time")
+		}
+		ps.mutex.Unlock()
+
+		ps.logger.WithFields(logger.Fields{
+			"plugin_slug":  plugin.Slug,
+			"differential": differential,
+			"diff_count":   diffCount,
+			"duration_ms":  time.Since(start).Milliseconds(),
+		}).Info("Snapshot refreshed")
+	}
+}
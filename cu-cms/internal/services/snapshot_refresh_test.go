@@ -0,0 +1,93 @@
+/*
+ * Firecracker CMS - Background Snapshot Refresh Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeDifferentialSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	baseMemPath := filepath.Join(dir, "snapshot.mem")
+	baseStatePath := filepath.Join(dir, "snapshot.state")
+	diffMemPath := filepath.Join(dir, "diff.mem")
+	diffStatePath := filepath.Join(dir, "diff.state")
+
+	const page = 4096
+	if err := os.WriteFile(baseMemPath, bytes.Repeat([]byte{'a'}, 3*page), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(baseStatePath, []byte("old state"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(diffStatePath, []byte("new state"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sparse differential with only the middle page dirty
+	diff, err := os.Create(diffMemPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := diff.Truncate(3 * page); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := diff.WriteAt(bytes.Repeat([]byte{'b'}, page), page); err != nil {
+		t.Fatal(err)
+	}
+	diff.Close()
+
+	// Stands in for a VM mapping the full snapshot memory
+	mapped, err := os.Open(baseMemPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mapped.Close()
+
+	if err := mergeDifferentialSnapshot(diffMemPath, diffStatePath, baseMemPath, baseStatePath); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+
+	merged, err := os.ReadFile(baseMemPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(append(bytes.Repeat([]byte{'a'}, page), bytes.Repeat([]byte{'b'}, page)...), bytes.Repeat([]byte{'a'}, page)...)
+	if !bytes.Equal(merged, want) {
+		t.Fatalf("merged memory does not match base with the dirty page applied")
+	}
+
+	old, err := io.ReadAll(mapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(old, bytes.Repeat([]byte{'a'}, 3*page)) {
+		t.Fatalf("merge modified the memory file in place")
+	}
+
+	if state, _ := os.ReadFile(baseStatePath); string(state) != "new state" {
+		t.Fatalf("state = %q, want the differential state", state)
+	}
+	if _, err := os.Stat(baseMemPath + ".merge"); !os.IsNotExist(err) {
+		t.Fatalf("temporary merge file left behind")
+	}
+}
+
+func TestRefreshSnapshotSkipsClaimedInstance(t *testing.T) {
+	vm := newTestVMService()
+	addTestInstance(vm, "blog", "blog-1")
+	vm.GetPrewarmInstance("blog")
+
+	if _, err := vm.RefreshSnapshot("blog-1", "blog", false); !errors.Is(err, ErrInstanceBusy) {
+		t.Fatalf("RefreshSnapshot on a claimed instance = %v, want ErrInstanceBusy", err)
+	}
+}
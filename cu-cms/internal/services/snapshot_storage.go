@@ -0,0 +1,62 @@
+/*
+ * Firecracker CMS - Snapshot Storage
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// snapshotStateOverheadMB covers the VM state file written next to guest memory
+const snapshotStateOverheadMB = 1
+
+// initSnapshotDir creates the snapshot directory if it doesn't exist and checks
+// that it is writable
+func (vm *VMService) initSnapshotDir() error {
+	return probeWritableDir(vm.snapshotDir)
+}
+
+// snapshotMemPath returns the guest memory file of a snapshot. Differential
+// snapshots get timestamped names so they never overwrite the full snapshot.
+func snapshotMemPath(snapshotDir string, differential bool, timestamp int64) string {
+	if differential {
+		return filepath.Join(snapshotDir, fmt.Sprintf("diff-%d.mem", timestamp))
+	}
+	return filepath.Join(snapshotDir, "snapshot.mem")
+}
+
+// snapshotStatePath returns the VM state file of a snapshot
+func snapshotStatePath(snapshotDir string, differential bool, timestamp int64) string {
+	if differential {
+		return filepath.Join(snapshotDir, fmt.Sprintf("diff-%d.state", timestamp))
+	}
+	return filepath.Join(snapshotDir, "snapshot.state")
+}
+
+// checkSnapshotDiskSpace fails when dir cannot hold a snapshot of a VM with
+// memSizeMib of guest memory while keeping the snapshot reserve or the minimum
+// free disk, whichever is larger, free. Full snapshots replace existing files,
+// whose space is counted as reclaimable.
+func (vm *VMService) checkSnapshotDiskSpace(dir string, memSizeMib int64, differential bool) error {
+	freeMB, _, err := diskSpaceMB(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check free space in %s: %v", dir, err)
+	}
+
+	if !differential {
+		freeMB += snapshotFilesSize(snapshotMemPath(dir, false, 0), snapshotStatePath(dir, false, 0)) >> 20
+	}
+
+	reserveMB := max(vm.config.SnapshotReserveMB, vm.config.MinFreeDiskMB)
+	neededMB := memSizeMib + snapshotStateOverheadMB + int64(reserveMB)
+	if freeMB < neededMB {
+		return fmt.Errorf("insufficient disk space for snapshot in %s: %dMB available, %dMB needed (%dMB guest memory plus %dMB reserve)",
+			dir, freeMB, neededMB, memSizeMib, reserveMB)
+	}
+
+	return nil
+}
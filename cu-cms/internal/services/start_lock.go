@@ -0,0 +1,38 @@
+/*
+ * Firecracker CMS - Plugin VM Start Serialization
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import "sync"
+
+// pluginStartLocks serializes VM boots per plugin. Every VM of a plugin uses
+// its IP and TAP device, so two concurrent boots would fight over them.
+type pluginStartLocks struct {
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// newPluginStartLocks creates an empty set of start locks
+func newPluginStartLocks() *pluginStartLocks {
+	return &pluginStartLocks{
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+// lock blocks until no other VM of the plugin is starting and returns the
+// function releasing the lock. Acquire it after ps.mutex, never before.
+func (l *pluginStartLocks) lock(pluginSlug string) func() {
+	l.mutex.Lock()
+	pluginLock, exists := l.locks[pluginSlug]
+	if !exists {
+		pluginLock = &sync.Mutex{}
+		l.locks[pluginSlug] = pluginLock
+	}
+	l.mutex.Unlock()
+
+	pluginLock.Lock()
+	return pluginLock.Unlock
+}
@@ -0,0 +1,79 @@
+/*
+ * Firecracker CMS - Guest Clock Synchronization
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// guestTimeSyncMinPause is the shortest pause worth correcting the guest clock for
+const guestTimeSyncMinPause = time.Second
+
+// guestTimeSyncTimeout bounds the time push; it delays the resumed execution
+const guestTimeSyncTimeout = 500 * time.Millisecond
+
+// guestTimeSyncClient pushes wall-clock time to resumed guests
+var guestTimeSyncClient = &http.Client{Timeout: guestTimeSyncTimeout}
+
+// guestTimeSync is the body of the time push sent to a resumed guest
+type guestTimeSync struct {
+	UnixMs   int64  `json:"unix_ms"`
+	RFC3339  string `json:"rfc3339"`
+	PausedMs int64  `json:"paused_ms"` // How long the guest clock stood still, 0 if unknown
+}
+
+// syncGuestTime pushes the host wall-clock time to a guest whose clock stood
+// still while it was paused or snapshotted. Plugins opt in by handling
+// POST /time-sync; a guest that answers 404 is not pushed to again.
+func (vm *VMService) syncGuestTime(instance *PrewarmInstance, paused time.Duration) {
+	if !vm.config.GuestTimeSync || instance.noTimeSync.Load() {
+		return
+	}
+
+	now := time.Now()
+	body, err := json.Marshal(guestTimeSync{
+		UnixMs:   now.UnixMilli(),
+		RFC3339:  now.UTC().Format(time.RFC3339Nano),
+		PausedMs: paused.Milliseconds(),
+	})
+	if err != nil {
+		return
+	}
+
+	resp, err := vm.timeSyncClient(instance).Post(guestURL(instance.IP, instance.MTLS, "/time-sync"), "application/json", bytes.NewReader(body))
+	if err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"instance_id": instance.InstanceID,
+			"error":       err,
+		}).Warn("Failed to sync guest time after resume")
+		return
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		instance.noTimeSync.Store(true)
+		vm.logger.WithFields(logger.Fields{
+			"instance_id": instance.InstanceID,
+		}).Debug("Plugin does not handle time sync, guest clock left as is")
+	case resp.StatusCode >= 300:
+		vm.logger.WithFields(logger.Fields{
+			"instance_id": instance.InstanceID,
+			"status":      resp.StatusCode,
+		}).Warn("Guest rejected time sync after resume")
+	default:
+		vm.logger.WithFields(logger.Fields{
+			"instance_id": instance.InstanceID,
+			"paused_ms":   paused.Milliseconds(),
+		}).Debug("Guest time synced after resume")
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Firecracker CMS - Guest Clock Synchronization Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useTestGuest routes time pushes to handler, whatever the guest IP
+func useTestGuest(t *testing.T, handler http.HandlerFunc) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	previous := guestTimeSyncClient
+	guestTimeSyncClient = &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+		},
+	}}
+	t.Cleanup(func() { guestTimeSyncClient = previous })
+}
+
+func TestSyncGuestTimeStopsAfterNotFound(t *testing.T) {
+	var pushes atomic.Int32
+	useTestGuest(t, func(w http.ResponseWriter, r *http.Request) {
+		pushes.Add(1)
+		http.NotFound(w, r)
+	})
+
+	vm := newTestVMService()
+	instance := addTestInstance(vm, "blog", "blog-1")
+	instance.IP = "192.168.127.2"
+
+	vm.syncGuestTime(instance, time.Minute)
+	vm.syncGuestTime(instance, time.Minute)
+	if got := pushes.Load(); got != 1 {
+		t.Fatalf("guest without /time-sync pushed %d times, want 1", got)
+	}
+}
+
+func TestSyncGuestTimeKeepsPushing(t *testing.T) {
+	var pushes atomic.Int32
+	useTestGuest(t, func(w http.ResponseWriter, r *http.Request) {
+		pushes.Add(1)
+	})
+
+	vm := newTestVMService()
+	instance := addTestInstance(vm, "blog", "blog-1")
+	instance.IP = "192.168.127.2"
+
+	vm.syncGuestTime(instance, time.Minute)
+	vm.syncGuestTime(instance, time.Minute)
+	if got := pushes.Load(); got != 2 {
+		t.Fatalf("guest handling /time-sync pushed %d times, want 2", got)
+	}
+}
+
+func TestPausedAt(t *testing.T) {
+	instance := &PrewarmInstance{}
+	if !instance.PausedAt().IsZero() {
+		t.Fatalf("new instance reported paused")
+	}
+
+	pausedAt := time.Now()
+	instance.setPausedAt(pausedAt)
+	if !instance.PausedAt().Equal(pausedAt) {
+		t.Fatalf("PausedAt = %v, want %v", instance.PausedAt(), pausedAt)
+	}
+
+	instance.setPausedAt(time.Time{})
+	if !instance.PausedAt().IsZero() {
+		t.Fatalf("resumed instance still reported paused")
+	}
+}
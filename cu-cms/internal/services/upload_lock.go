@@ -0,0 +1,62 @@
+/*
+ * Firecracker CMS - Plugin Upload Serialization
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrUploadInProgress is returned when an upload gave up waiting for another
+// upload of the same plugin
+var ErrUploadInProgress = errors.New("another upload of this plugin is in progress")
+
+// pluginUploadLocks serializes uploads per slug. Uploads write the plugin's
+// rootfs before taking ps.mutex, so two uploads of one plugin would replace
+// each other's image; uploads of different plugins still run in parallel.
+type pluginUploadLocks struct {
+	mutex sync.Mutex
+	locks map[string]chan struct{}
+}
+
+// newPluginUploadLocks creates an empty set of upload locks
+func newPluginUploadLocks() *pluginUploadLocks {
+	return &pluginUploadLocks{
+		locks: make(map[string]chan struct{}),
+	}
+}
+
+// lock waits up to timeout (forever if zero) until no other upload of the
+// plugin runs and returns the function releasing the lock. Acquire it before
+// ps.mutex, never while holding it.
+func (l *pluginUploadLocks) lock(pluginSlug string, timeout time.Duration) (func(), error) {
+	l.mutex.Lock()
+	pluginLock, exists := l.locks[pluginSlug]
+	if !exists {
+		pluginLock = make(chan struct{}, 1)
+		l.locks[pluginSlug] = pluginLock
+	}
+	l.mutex.Unlock()
+
+	release := func() { <-pluginLock }
+
+	if timeout == 0 {
+		pluginLock <- struct{}{}
+		return release, nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case pluginLock <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, ErrUploadInProgress
+	}
+}
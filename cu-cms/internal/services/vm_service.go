@@ -9,14 +9,20 @@ package services
 import (
 	"context"
 	"crypto/md5"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	firecracker "github.com/firecracker-microvm/firecracker-go-sdk"
@@ -28,6 +34,18 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// Default and allowed VM resource limits
+const (
+	defaultVcpuCount  = 1
+	defaultMemSizeMib = 512
+	maxVcpuCount      = 32
+	minMemSizeMib     = 128
+	maxMemSizeMib     = 32768
+)
+
+// maxInstanceIDPrefix keeps generated instance IDs within the jailer's 64 characters
+const maxInstanceIDPrefix = 55
+
 // VMService handles Firecracker microVM operations
 type VMService struct {
 	config          *config.Config
@@ -39,25 +57,120 @@ type VMService struct {
 	firecrackerLogger *logrus.Entry
 
 	// Pre-warming pool for ultra-fast plugin execution
-	prewarmPool map[string]*PrewarmInstance // instanceID -> prewarm instance
-	poolMutex   sync.RWMutex
-	maxPoolSize int // Maximum instances per plugin in pool
+	prewarmPool   map[string]*PrewarmInstance // instanceID -> prewarm instance
+	warmInstances map[string]string           // plugin slug -> instance ID claimable for execution
+	poolMutex     sync.RWMutex
+	maxPoolSize   int // Maximum instances per plugin in pool
+
+	// Plugins whose warm instance was evicted by the pool caps; they cold start
+	// on their next execution
+	evictedPlugins map[string]bool
+
+	// Memory-based pool sizing, guarded by poolMutex
+	memoryBudgetMib  int             // 0 when the pool is not sized by memory
+	pluginFootprints map[string]int  // plugin slug -> MiB per running VM
+	activeFootprints map[string]bool // plugins owed a warm instance
+
+	// Called when a Firecracker process exits unexpectedly
+	exitHandler InstanceExitHandler
 
 	// IP allocation for static networking
 	ipPool      map[string]bool // IP -> allocated status
 	ipPoolMutex sync.RWMutex
-	nextIP      net.IP // Next IP to allocate
+	ipStrategy  ipAllocationStrategy
+	lastIPs     map[string]string // Owner (plugin slug, or slug/interface) -> last address
+
+	// Additional interfaces are allocated from their own subnet
+	extraIPStrategy ipAllocationStrategy
+
+	// Host features detected at startup
+	capabilities HostCapabilities
+
+	// Report of the startup self-check
+	selfCheck SelfCheckReport
+
+	// DNS servers injected into guests
+	guestDNS []string
+
+	// Whether setupBridge created the plugin bridge and the bridge of
+	// additional interfaces, so shutdown may delete them
+	bridgeCreated      bool
+	extraBridgeCreated bool
+
+	// CA for mutual TLS with plugins, nil when disabled
+	pluginTLS      *pluginTLS
+	guestTLSClient *http.Client
+
+	// On-disk instance registry for crash recovery
+	instanceRegistryMutex sync.Mutex
+
+	// Per-plugin resource usage history
+	usage *resourceUsageTracker
+
+	// Per-plugin snapshot creation and resume timings
+	snapshots *snapshotMetrics
+
+	// Maintenance mode suspends VM churn
+	maintenanceEnabled bool
+	maintenanceSince   time.Time
+	maintenanceMutex   sync.RWMutex
 }
 
 // PrewarmInstance represents a pre-warmed VM instance ready for immediate use
 type PrewarmInstance struct {
 	InstanceID   string
+	PluginSlug   string
 	Machine      *firecracker.Machine // Store the actual machine for operations
 	IP           string
-	TapName      string // Store TAP device name for reuse
+	TapName      string                              // Store TAP device name for reuse
+	Interfaces   []cms_models.PluginNetworkInterface // Additional NICs after eth0
 	CreatedAt    time.Time
 	LastUsed     time.Time
 	SnapshotType string // "full" or "differential"
+
+	EgressBlocked bool // Forwarded traffic from this VM is dropped
+	Jailed        bool // Firecracker runs inside a jailer chroot
+
+	Balloon bool // The VM has a balloon device
+
+	balloonInflated atomic.Bool // Guest memory is reclaimed while parked; PauseVM runs with and without opMutex
+
+	SnapshotBase bool // The full snapshot on disk was loaded into or written by this VM
+
+	MTLS bool // The guest serves HTTPS and requires the CMS client certificate
+
+	pausedAt atomic.Int64 // UnixNano when the VM was last parked, its guest clock stopped then; 0 while running
+
+	noTimeSync atomic.Bool // The guest answered 404 to the time push, so it is not retried
+
+	stopping bool // StopVM is shutting the VM down, so its exit is expected
+
+	users atomic.Int32 // Executions holding the instance, claimed under the pool lock
+
+	opMutex sync.Mutex // Serializes snapshot, probe, pause and release operations on this instance
+}
+
+// PausedAt returns when the VM was last parked, or zero while it runs
+func (instance *PrewarmInstance) PausedAt() time.Time {
+	if nanos := instance.pausedAt.Load(); nanos != 0 {
+		return time.Unix(0, nanos)
+	}
+	return time.Time{}
+}
+
+// setPausedAt records when the VM was parked; the zero time marks it running
+func (instance *PrewarmInstance) setPausedAt(t time.Time) {
+	if t.IsZero() {
+		instance.pausedAt.Store(0)
+		return
+	}
+	instance.pausedAt.Store(t.UnixNano())
+}
+
+// busy reports whether executions hold the instance
+// Note: Caller must hold instance.opMutex
+func (instance *PrewarmInstance) busy() bool {
+	return instance.users.Load() > 0
 }
 
 // NewVMService creates a new VM service
@@ -80,16 +193,95 @@ func NewVMService(cfg *config.Config) (*VMService, error) {
 
 	service := &VMService{
 		config:            cfg,
-		logger:            logger.GetDefault(),
+		logger:            logger.GetDefault().Component("vm"),
 		firecrackerPath:   firecrackerPath,
 		kernelPath:        kernelPath,
 		snapshotDir:       snapshotDir,
 		firecrackerLogger: logger.GetDefault().WithComponent("firecracker"),
 		prewarmPool:       make(map[string]*PrewarmInstance),
+		warmInstances:     make(map[string]string),
+		evictedPlugins:    make(map[string]bool),
 		maxPoolSize:       cfg.PrewarmPoolSize, // Use configurable pool size
 		ipPool:            make(map[string]bool),
 		ipPoolMutex:       sync.RWMutex{},
-		nextIP:            net.ParseIP("192.168.127.2"), // Start from 192.168.127.2
+		ipStrategy:        newIPAllocationStrategy(cfg.IPAllocationStrategy, pluginSubnet),
+		lastIPs:           make(map[string]string),
+		extraIPStrategy:   newIPAllocationStrategy(cfg.IPAllocationStrategy, cfg.ExtraInterfaceSubnet),
+		usage:             newResourceUsageTracker(cfg.UsageHistorySize),
+		snapshots:         newSnapshotMetrics(),
+	}
+
+	// Detect Firecracker version and supported features
+	service.capabilities = service.probeCapabilities()
+	service.logger.WithFields(logger.Fields{
+		"firecracker_version":    service.capabilities.FirecrackerVersion,
+		"sdk_version":            service.capabilities.SDKVersion,
+		"kvm_available":          service.capabilities.KVMAvailable,
+		"snapshots":              service.capabilities.Snapshots,
+		"differential_snapshots": service.capabilities.DifferentialSnapshots,
+		"dirty_page_tracking":    service.capabilities.DirtyPageTracking,
+		"mmds":                   service.capabilities.MMDS,
+		"mmds_v2":                service.capabilities.MMDSv2,
+	}).Info("Detected host capabilities")
+
+	if !service.capabilities.Snapshots {
+		service.logger.WithFields(logger.Fields{
+			"firecracker_version": service.capabilities.FirecrackerVersion,
+		}).Warn("Snapshots unsupported: running in degraded always-warm mode, plugin VMs stay booted and are not paused or snapshotted")
+	}
+
+	// Unwritable data directories fail regardless of CMS_SELFCHECK_STRICT,
+	// nothing can be persisted without them
+	if err := service.checkDataDirs(); err != nil {
+		return nil, err
+	}
+
+	// A fresh host has no plugin bridge yet; create it before the self-check looks for it
+	if err := service.setupBridge(); err != nil {
+		return nil, fmt.Errorf("failed to set up bridge: %v", err)
+	}
+
+	// Verify the host before touching networking or persisted state
+	service.selfCheck = service.runSelfCheck()
+	if err := service.selfCheck.Err(); err != nil {
+		if service.config.SelfCheckStrict {
+			return nil, err
+		}
+		service.logger.WithFields(logger.Fields{
+			"error": err,
+		}).Warn("Continuing despite failed self-check (CMS_SELFCHECK_STRICT=false)")
+	}
+
+	// Resolve the DNS servers handed to guests
+	service.guestDNS = service.resolveGuestDNS()
+	if len(service.guestDNS) == 0 {
+		service.logger.Warn("No guest DNS servers configured or usable from host, plugins will have no resolver")
+	} else {
+		service.logger.WithFields(logger.Fields{
+			"dns_servers": service.guestDNS,
+		}).Info("Guest DNS servers configured")
+	}
+
+	// Load or create the CA for mutual TLS with plugins
+	if cfg.PluginMTLS {
+		validity := time.Duration(cfg.PluginCertValidityDays) * 24 * time.Hour
+		pluginTLS, err := newPluginTLS(filepath.Join(cfg.DataDir, "tls"), validity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up plugin mutual TLS: %v", err)
+		}
+		service.pluginTLS = pluginTLS
+		service.guestTLSClient = &http.Client{
+			Timeout:   guestTimeSyncTimeout,
+			Transport: &http.Transport{TLSClientConfig: pluginTLS.clientConfig()},
+		}
+		service.logger.WithFields(logger.Fields{
+			"ca_expires": pluginTLS.caCert.NotAfter,
+		}).Info("Mutual TLS with plugins enabled")
+	}
+
+	// Set up outbound NAT for the plugin subnet if enabled
+	if err := service.setupNAT(); err != nil {
+		return nil, fmt.Errorf("failed to set up NAT: %v", err)
 	}
 
 	// Initialize snapshot directory
@@ -114,12 +306,21 @@ func NewVMService(cfg *config.Config) (*VMService, error) {
 	// Start pre-warming background process
 	go service.prewarmManager()
 
+	// Size the pool to the memory budget, if one is configured
+	service.memoryBudgetMib = service.resolveMemoryBudget()
+
+	// Start resource usage sampling, continuing the persisted summaries
+	service.loadResourceUsageSummaries()
+	go service.usageSampler()
+
 	service.logger.WithFields(logger.Fields{
-		"firecracker_path": firecrackerPath,
-		"kernel_path":      kernelPath,
-		"snapshot_dir":     snapshotDir,
-		"max_pool_size":    service.maxPoolSize,
-		"mode":             service.config.GetModeString(),
+		"firecracker_path":  firecrackerPath,
+		"kernel_path":       kernelPath,
+		"snapshot_dir":      snapshotDir,
+		"max_pool_size":     service.maxPoolSize,
+		"max_warm":          service.config.MaxWarmInstances,
+		"memory_budget_mib": service.memoryBudgetMib,
+		"mode":              service.config.GetModeString(),
 	}).Info("VM service initialized with pre-warming pool")
 
 	// Mode-specific initialization messages
@@ -154,48 +355,108 @@ func (vm *VMService) prewarmManager() {
 
 // maintainPrewarmPool ensures each active plugin has pre-warmed instances ready
 func (vm *VMService) maintainPrewarmPool() {
-	vm.poolMutex.Lock()
-	defer vm.poolMutex.Unlock()
+	// Leave warm instances alone during maintenance
+	if vm.InMaintenanceMode() {
+		vm.logger.Debug("Skipping pre-warm pool maintenance in maintenance mode")
+		return
+	}
 
 	// Clean up expired instances (older than 10 minutes)
 	cutoffTime := time.Now().Add(-10 * time.Minute)
 
-	for pluginSlug, instance := range vm.prewarmPool {
+	vm.poolMutex.Lock()
+	var expired []*PrewarmInstance
+	for _, instance := range vm.prewarmPool {
 		if instance.CreatedAt.Before(cutoffTime) {
-			// Stop expired instance
-			vm.logger.WithFields(logger.Fields{
-				"plugin_slug": pluginSlug,
-				"instance_id": instance.InstanceID,
-			}).Debug("Removing expired pre-warm instance")
+			expired = append(expired, instance)
+			vm.unclaimWarmInstanceUnsafe(instance)
+		}
+	}
+	vm.poolMutex.Unlock()
 
-			if err := vm.StopVM(instance.InstanceID); err != nil {
-				vm.logger.WithFields(logger.Fields{
-					"instance_id": instance.InstanceID,
-					"error":       err,
-				}).Error("Failed to stop expired pre-warm instance")
-			}
+	// StopVM takes the pool lock itself
+	for _, instance := range expired {
+		vm.logger.WithFields(logger.Fields{
+			"plugin_slug": instance.PluginSlug,
+			"instance_id": instance.InstanceID,
+		}).Debug("Removing expired pre-warm instance")
 
-			// Remove from pool
-			delete(vm.prewarmPool, pluginSlug)
+		if err := vm.StopVM(instance.InstanceID); err != nil {
+			vm.logger.WithFields(logger.Fields{
+				"instance_id": instance.InstanceID,
+				"error":       err,
+			}).Error("Failed to stop expired pre-warm instance")
 		}
 	}
 
+	// Instances busy when they were started may be evictable now
+	vm.enforcePoolCaps("", "")
+
+	vm.poolMutex.RLock()
+	totalInstances := len(vm.prewarmPool)
+	vm.poolMutex.RUnlock()
+
 	vm.logger.WithFields(logger.Fields{
-		"total_pools": len(vm.prewarmPool),
+		"total_pools": totalInstances,
 	}).Debug("Pre-warm pool maintenance completed")
 }
 
-// GetPrewarmInstance retrieves a ready instance from the pre-warm pool
-func (vm *VMService) GetPrewarmInstance(pluginSlug string) *PrewarmInstance {
-	vm.poolMutex.Lock()
-	defer vm.poolMutex.Unlock()
+// NewInstanceID returns the ID for a new VM of a plugin. Under the default
+// "unique" scheme every VM gets its own ID; the "slug" scheme reuses the plugin
+// slug, allowing only one VM per plugin.
+func (vm *VMService) NewInstanceID(pluginSlug string) string {
+	if vm.config.InstanceIDScheme == config.InstanceIDSchemeSlug {
+		return pluginSlug
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+
+	// The jailer limits IDs to 64 characters
+	prefix := pluginSlug
+	if len(prefix) > maxInstanceIDPrefix {
+		prefix = prefix[:maxInstanceIDPrefix]
+	}
+	return fmt.Sprintf("%s-%x", prefix, suffix)
+}
 
-	instance, exists := vm.prewarmPool[pluginSlug]
+// warmInstanceUnsafe returns the instance a plugin's executions use
+// Note: Caller must hold vm.poolMutex
+func (vm *VMService) warmInstanceUnsafe(pluginSlug string) *PrewarmInstance {
+	instanceID, exists := vm.warmInstances[pluginSlug]
 	if !exists {
 		return nil
 	}
+	return vm.prewarmPool[instanceID]
+}
+
+// unclaimWarmInstanceUnsafe stops routing executions to an instance, leaving
+// the VM tracked until it is stopped
+// Note: Caller must hold vm.poolMutex.Lock()
+func (vm *VMService) unclaimWarmInstanceUnsafe(instance *PrewarmInstance) {
+	if vm.warmInstances[instance.PluginSlug] == instance.InstanceID {
+		delete(vm.warmInstances, instance.PluginSlug)
+	}
+}
 
+// GetPrewarmInstance retrieves a ready instance from the pre-warm pool and
+// claims it for an execution. Snapshot, probe and eviction leave claimed
+// instances alone; every claim must end with ReleasePrewarmInstance or
+// AbandonPrewarmInstance.
+func (vm *VMService) GetPrewarmInstance(pluginSlug string) *PrewarmInstance {
+	vm.poolMutex.Lock()
+	instance := vm.warmInstanceUnsafe(pluginSlug)
+	if instance == nil {
+		vm.poolMutex.Unlock()
+		return nil
+	}
+	instance.users.Add(1)
 	instance.LastUsed = time.Now()
+	vm.poolMutex.Unlock()
+
+	// Wait out a snapshot, probe or pause that started before the claim
+	instance.opMutex.Lock()
+	instance.opMutex.Unlock()
 
 	vm.logger.WithFields(logger.Fields{
 		"plugin_slug":   pluginSlug,
@@ -206,13 +467,49 @@ func (vm *VMService) GetPrewarmInstance(pluginSlug string) *PrewarmInstance {
 	return instance
 }
 
+// ReleasePrewarmInstance ends an execution's claim on an instance and pauses
+// it once no other execution holds it. It reports whether the VM was paused.
+func (vm *VMService) ReleasePrewarmInstance(instance *PrewarmInstance) (bool, error) {
+	instance.opMutex.Lock()
+	defer instance.opMutex.Unlock()
+
+	if instance.users.Add(-1) > 0 {
+		return false, nil
+	}
+	if err := vm.PauseVM(instance.InstanceID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AbandonPrewarmInstance ends an execution's claim on an instance that is
+// being retired or could not be resumed, leaving its VM state alone
+func (vm *VMService) AbandonPrewarmInstance(instance *PrewarmInstance) {
+	instance.users.Add(-1)
+}
+
+// PeekPrewarmInstance looks up the pooled instance of a plugin without claiming it
+func (vm *VMService) PeekPrewarmInstance(pluginSlug string) *PrewarmInstance {
+	vm.poolMutex.RLock()
+	defer vm.poolMutex.RUnlock()
+
+	return vm.warmInstanceUnsafe(pluginSlug)
+}
+
+// InstanceExited reports whether the Firecracker process of an instance has exited
+func (vm *VMService) InstanceExited(instance *PrewarmInstance) bool {
+	_, err := instance.Machine.PID()
+	return err != nil
+}
+
 // ReturnPrewarmInstance returns an instance to the pool for reuse
 func (vm *VMService) ReturnPrewarmInstance(pluginSlug string, instance *PrewarmInstance) {
 	vm.poolMutex.Lock()
 	defer vm.poolMutex.Unlock()
 
-	// Simply add back to pool (one instance per plugin)
-	vm.prewarmPool[pluginSlug] = instance
+	// Simply add back to pool (one warm instance per plugin)
+	vm.prewarmPool[instance.InstanceID] = instance
+	vm.warmInstances[pluginSlug] = instance.InstanceID
 
 	vm.logger.WithFields(logger.Fields{
 		"plugin_slug": pluginSlug,
@@ -225,8 +522,10 @@ func (vm *VMService) AddToPrewarmPool(pluginSlug string, instance *PrewarmInstan
 	vm.poolMutex.Lock()
 	defer vm.poolMutex.Unlock()
 
-	// Add to pool (one instance per plugin)
-	vm.prewarmPool[pluginSlug] = instance
+	// Add to pool (one warm instance per plugin)
+	vm.prewarmPool[instance.InstanceID] = instance
+	vm.warmInstances[pluginSlug] = instance.InstanceID
+	delete(vm.evictedPlugins, pluginSlug)
 
 	vm.logger.WithFields(logger.Fields{
 		"plugin_slug": pluginSlug,
@@ -234,17 +533,18 @@ func (vm *VMService) AddToPrewarmPool(pluginSlug string, instance *PrewarmInstan
 	}).Info("Added instance to pre-warm pool")
 }
 
-// RemoveFromPrewarmPool removes an instance from the pre-warm pool
+// RemoveFromPrewarmPool stops routing a plugin's executions to its warm
+// instance. The VM keeps running until StopVM is called with its instance ID.
 func (vm *VMService) RemoveFromPrewarmPool(pluginSlug string) {
 	vm.poolMutex.Lock()
 	defer vm.poolMutex.Unlock()
 
-	if instance, exists := vm.prewarmPool[pluginSlug]; exists {
+	if instance := vm.warmInstanceUnsafe(pluginSlug); instance != nil {
 		vm.logger.WithFields(logger.Fields{
 			"plugin_slug": pluginSlug,
 			"instance_id": instance.InstanceID,
 		}).Info("Removing instance from pre-warm pool")
-		delete(vm.prewarmPool, pluginSlug)
+		vm.unclaimWarmInstanceUnsafe(instance)
 	} else {
 		vm.logger.WithFields(logger.Fields{
 			"plugin_slug": pluginSlug,
@@ -252,58 +552,114 @@ func (vm *VMService) RemoveFromPrewarmPool(pluginSlug string) {
 	}
 }
 
-// CreateDifferentialSnapshot creates a differential snapshot from the base snapshot
-func (vm *VMService) CreateDifferentialSnapshot(instanceID, pluginSlug string) error {
+// CreateDifferentialSnapshot creates a differential snapshot from the base
+// snapshot and returns the paths of its memory and state files
+func (vm *VMService) CreateDifferentialSnapshot(instanceID, pluginSlug string) (string, string, error) {
 	vm.logger.WithFields(logger.Fields{
 		"instance_id": instanceID,
 		"plugin_slug": pluginSlug,
 	}).Info("Creating differential snapshot")
 
-	snapshotDir := vm.GetSnapshotPath(pluginSlug)
-	timestamp := time.Now().Unix()
+	if !vm.capabilities.DifferentialSnapshots {
+		return "", "", fmt.Errorf("differential snapshots are not supported by firecracker %s", vm.capabilities.FirecrackerVersion)
+	}
 
-	// Differential snapshots use timestamped names
-	diffMemPath := filepath.Join(snapshotDir, fmt.Sprintf("diff-%d.mem", timestamp))
-	diffStatePath := filepath.Join(snapshotDir, fmt.Sprintf("diff-%d.state", timestamp))
+	snapshotDir := vm.GetSnapshotPath(pluginSlug)
 
-	// Create differential snapshot (only changed memory pages)
-	err := vm.CreateSnapshot(instanceID, snapshotDir, true) // useDifferential = true
+	// Create differential snapshot (only changed memory pages) with timestamped names
+	memPath, statePath, err := vm.createSnapshot(instanceID, snapshotDir, true) // useDifferential = true
 	if err != nil {
-		return fmt.Errorf("failed to create differential snapshot: %v", err)
+		return "", "", fmt.Errorf("failed to create differential snapshot: %v", err)
 	}
 
 	vm.logger.WithFields(logger.Fields{
-		"instance_id":     instanceID,
-		"plugin_slug":     pluginSlug,
-		"diff_mem_path":   diffMemPath,
-		"diff_state_path": diffStatePath,
+		"instance_id":  instanceID,
+		"plugin_slug":  pluginSlug,
+		"snapshot_dir": snapshotDir,
 	}).Info("Differential snapshot created successfully")
 
-	return nil
+	vm.recordDirtyPages(instanceID, pluginSlug, memPath)
+
+	return memPath, statePath, nil
 }
 
-// initSnapshotDir creates the snapshot directory if it doesn't exist
-func (vm *VMService) initSnapshotDir() error {
-	return os.MkdirAll(vm.snapshotDir, 0755)
+// recordDirtyPages records how much memory a differential snapshot carried and
+// warns once it approaches the size of a full snapshot
+func (vm *VMService) recordDirtyPages(instanceID, pluginSlug, memPath string) {
+	dirtyBytes, err := allocatedFileSize(memPath)
+	if err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"plugin_slug": pluginSlug,
+			"error":       err,
+		}).Warn("Failed to measure differential snapshot")
+		return
+	}
+
+	var fullBytes int64
+	vm.poolMutex.RLock()
+	if instance, exists := vm.prewarmPool[instanceID]; exists {
+		fullBytes = firecracker.Int64Value(instance.Machine.Cfg.MachineCfg.MemSizeMib) * 1024 * 1024
+	}
+	vm.poolMutex.RUnlock()
+
+	rebaseRatio := float64(vm.config.SnapshotRebasePercent) / 100
+	ratio := vm.snapshots.dirtyPagesRecorded(pluginSlug, dirtyBytes, fullBytes, rebaseRatio)
+
+	fields := logger.Fields{
+		"plugin_slug": pluginSlug,
+		"dirty_pages": dirtyBytes / guestPageSize,
+		"dirty_bytes": dirtyBytes,
+		"full_bytes":  fullBytes,
+		"dirty_ratio": ratio,
+	}
+	if rebaseRatio > 0 && ratio > rebaseRatio {
+		vm.logger.WithFields(fields).Warn("Differential snapshot exceeds rebase threshold, next refresh takes a full snapshot")
+		return
+	}
+	vm.logger.WithFields(fields).Info("Differential snapshot dirty pages")
 }
 
 // StartVM starts a new Firecracker microVM for a plugin
 func (vm *VMService) StartVM(instanceID string, plugin *cms_models.Plugin) error {
-	return vm.createVM(instanceID, plugin, false, "", "")
+	return vm.createVMWithRetry(instanceID, plugin, false, "", "")
 }
 
 // ResumeFromSnapshot creates a new VM instance from an existing snapshot
 func (vm *VMService) ResumeFromSnapshot(instanceID string, plugin *cms_models.Plugin) error {
 	snapshotDir := vm.GetSnapshotPath(plugin.Slug)
-	memPath := filepath.Join(snapshotDir, "snapshot.mem")
-	statePath := filepath.Join(snapshotDir, "snapshot.state")
+	memPath := snapshotMemPath(snapshotDir, false, 0)
+	statePath := snapshotStatePath(snapshotDir, false, 0)
 
 	// Check if snapshot files exist
 	if !vm.HasSnapshot(plugin.Slug) {
 		return fmt.Errorf("snapshot not found for plugin %s", plugin.Slug)
 	}
 
-	return vm.createVM(instanceID, plugin, true, memPath, statePath)
+	start := time.Now()
+	if err := vm.createVMWithRetry(instanceID, plugin, true, memPath, statePath); err != nil {
+		return err
+	}
+	duration := time.Since(start)
+
+	// The guest clock resumes at snapshot time
+	vm.poolMutex.RLock()
+	instance, exists := vm.prewarmPool[instanceID]
+	vm.poolMutex.RUnlock()
+	if exists {
+		vm.syncGuestTime(instance, 0)
+	}
+
+	sizeBytes := snapshotFilesSize(memPath, statePath)
+	vm.snapshots.snapshotResumed(plugin.Slug, duration, sizeBytes)
+
+	vm.logger.WithFields(logger.Fields{
+		"instance_id": instanceID,
+		"plugin_slug": plugin.Slug,
+		"duration_ms": duration.Milliseconds(),
+		"size_bytes":  sizeBytes,
+	}).Info("Resumed VM from snapshot")
+
+	return nil
 }
 
 // createVM is the unified method for creating VMs (fresh or from snapshot)
@@ -319,31 +675,93 @@ func (vm *VMService) createVM(instanceID string, plugin *cms_models.Plugin, useS
 		"vm_type":     vmType,
 	}).Info("Creating VM with static networking")
 
+	// A missing kernel or rootfs is a misconfiguration that retries cannot fix
+	for _, path := range []string{vm.kernelPath, plugin.RootfsPath} {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("cannot start VM: %v", err)
+		}
+	}
+
 	// Get or create TAP interface for this plugin
 	tapName, err := vm.getOrCreateTapInterface(plugin, instanceID)
 	if err != nil {
-		return fmt.Errorf("failed to setup TAP interface: %v", err)
+		return &transientStartError{fmt.Errorf("failed to setup TAP interface: %v", err)}
 	}
 
+	// The plugin's isolation tier decides jailing, egress, limits and rootfs mode
+	isolation := vm.resolveIsolation(plugin)
+
+	// Socket path for this VM instance. In jailer mode the path is inside
+	// the chroot and the SDK resolves it to the host path.
+	jailed := isolation.Jailed
+	socketPath := filepath.Join("/tmp/firecracker", fmt.Sprintf("%s.sock", instanceID))
+	if jailed {
+		socketPath = jailedSocketPath
+	}
+
+	// Release this attempt's resources unless the VM comes up, so a retry starts clean
+	var allocatedIP string
+	var extraInterfaces []cms_models.PluginNetworkInterface
+	started := false
+	defer func() {
+		if !started {
+			vm.releaseFailedStart(plugin, tapName, allocatedIP, socketPath, jailed)
+			vm.releaseExtraInterfaces(plugin, extraInterfaces)
+		}
+	}()
+
 	// Get or allocate IP for this plugin
-	allocatedIP, err := vm.getOrAllocateIP(plugin)
+	allocatedIP, err = vm.getOrAllocateIP(plugin)
 	if err != nil {
-		return fmt.Errorf("failed to setup IP: %v", err)
+		return &transientStartError{fmt.Errorf("failed to setup IP: %v", err)}
 	}
 
-	// Create socket path for this VM instance
-	socketPath := filepath.Join("/tmp/firecracker", fmt.Sprintf("%s.sock", instanceID))
+	// Additional NICs declared by the plugin, each with its own TAP, IP and MAC
+	extraInterfaces, err = vm.setupExtraInterfaces(plugin)
+	if err != nil {
+		return &transientStartError{fmt.Errorf("failed to setup additional network interfaces: %v", err)}
+	}
+
+	// Configure kernel arguments with static IP and guest DNS
+	kernelArgs := vm.guestKernelArgs(allocatedIP, extraInterfaces, plugin, isolation.ReadOnlyRootfs)
+
+	// Plugins using mutual TLS get their certificate through MMDS
+	guestTLS, err := vm.guestTLS(plugin, allocatedIP)
+	if err != nil {
+		return fmt.Errorf("failed to prepare plugin certificate: %v", err)
+	}
+	metadata := vm.guestMetadata(plugin, instanceID, allocatedIP, guestTLS)
+
+	// The rootfs drive and every NIC are virtio devices, as is a fresh VM's balloon
+	devices := 2 + len(extraInterfaces)
+	if vm.config.BalloonTargetMB > 0 && !useSnapshot {
+		devices++
+	}
+	if err := checkKernelCmdline(kernelArgs, devices, vm.config.KernelCmdlineMaxBytes); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"plugin_slug": plugin.Slug,
+			"length":      len(kernelArgs) + firecrackerCmdlineBytes(devices),
+			"max_bytes":   vm.config.KernelCmdlineMaxBytes,
+		}).Error("Kernel command line too long")
+		return err
+	}
 
-	// Ensure socket directory exists
-	if err := os.MkdirAll(filepath.Dir(socketPath), 0755); err != nil {
-		if plugin.AssignedIP == "" {
-			vm.deallocateIP(allocatedIP) // Only clean up if we allocated new IP
+	if jailed {
+		if err := vm.prepareJail(instanceID, plugin.RootfsPath); err != nil {
+			return &transientStartError{err}
 		}
-		return fmt.Errorf("failed to create socket directory: %v", err)
+	} else if err := os.MkdirAll(filepath.Dir(socketPath), 0755); err != nil {
+		// Ensure socket directory exists
+		return &transientStartError{fmt.Errorf("failed to create socket directory: %v", err)}
 	}
 
-	// Configure kernel arguments with static IP
-	kernelArgs := fmt.Sprintf("console=ttyS0 reboot=k panic=1 pci=off ip=%s::192.168.127.1:255.255.255.0::eth0:off", allocatedIP)
+	// Plugins without egress permission must not reach the outside through NAT
+	egressBlocked := vm.config.NATEnabled && !isolation.AllowEgress
+	if egressBlocked {
+		if err := vm.blockVMEgress(allocatedIP, extraInterfaces); err != nil {
+			return &transientStartError{fmt.Errorf("failed to block egress: %v", err)}
+		}
+	}
 
 	// Create machine configuration
 	cfg := firecracker.Config{
@@ -353,21 +771,24 @@ func (vm *VMService) createVM(instanceID string, plugin *cms_models.Plugin, useS
 		Drives: []models.Drive{{
 			DriveID:      firecracker.String("rootfs"),
 			IsRootDevice: firecracker.Bool(true),
-			IsReadOnly:   firecracker.Bool(false),
+			IsReadOnly:   firecracker.Bool(isolation.ReadOnlyRootfs),
 			PathOnHost:   firecracker.String(plugin.RootfsPath),
 		}},
 		MachineCfg: models.MachineConfiguration{
-			VcpuCount:       firecracker.Int64(1),
-			MemSizeMib:      firecracker.Int64(512),
-			TrackDirtyPages: true, // Enable dirty page tracking for differential snapshots
+			VcpuCount:       firecracker.Int64(isolation.VcpuCount),
+			MemSizeMib:      firecracker.Int64(isolation.MemSizeMib),
+			TrackDirtyPages: vm.capabilities.DirtyPageTracking, // Enable dirty page tracking for differential snapshots
 		},
-		NetworkInterfaces: []firecracker.NetworkInterface{{
-			StaticConfiguration: &firecracker.StaticNetworkConfiguration{
-				HostDevName: tapName,
-				MacAddress:  "02:FC:00:00:00:01",
-			},
-		}},
-		VMID: plugin.Slug, // Use plugin name as VMID
+		NetworkInterfaces: firecrackerInterfaces(tapName, extraInterfaces, vm.guestMMDSEnabled(plugin)),
+		VMID:              instanceID,
+	}
+
+	if vm.guestMMDSEnabled(plugin) {
+		cfg.MmdsVersion = vm.guestMMDSVersion()
+	}
+
+	if jailed {
+		cfg.JailerCfg = vm.jailerConfig(instanceID)
 	}
 
 	// Add snapshot-specific configuration if needed
@@ -389,12 +810,73 @@ func (vm *VMService) createVM(instanceID string, plugin *cms_models.Plugin, useS
 	}
 
 	if err != nil {
+		if egressBlocked {
+			vm.unblockVMEgress(allocatedIP, extraInterfaces)
+		}
 		return fmt.Errorf("failed to create machine: %v", err)
 	}
 
+	if jailed {
+		// Host-side socket path inside the chroot
+		socketPath = machine.Cfg.SocketPath
+
+		// Snapshot loading replaces the jailer's file linking, so link the
+		// snapshot and rootfs into the chroot ourselves
+		if useSnapshot {
+			machine.Handlers.FcInit = machine.Handlers.FcInit.AppendAfter(
+				firecracker.CreateLogFilesHandlerName,
+				vm.linkSnapshotFilesHandler(instanceID, plugin.RootfsPath),
+			)
+		}
+	}
+
+	// Fresh VMs get a deflated balloon; restored VMs keep the snapshotted devices
+	if vm.config.BalloonTargetMB > 0 && !useSnapshot {
+		machine.Handlers.FcInit = machine.Handlers.FcInit.AppendAfter(
+			firecracker.CreateMachineHandlerName,
+			balloonHandler(),
+		)
+	}
+
+	// Fresh VMs find their metadata in MMDS as soon as they boot
+	if metadata != nil && !useSnapshot {
+		machine.Handlers.FcInit = machine.Handlers.FcInit.AppendAfter(
+			firecracker.ConfigMmdsHandlerName,
+			firecracker.NewSetMetadataHandler(metadata),
+		)
+	}
+
 	// Start the machine
 	if err := machine.Start(context.Background()); err != nil {
-		return fmt.Errorf("failed to start machine: %v", err)
+		if egressBlocked {
+			vm.unblockVMEgress(allocatedIP, extraInterfaces)
+		}
+		// Make sure no half-started Firecracker process holds the tap or socket
+		machine.StopVMM()
+		if jailed {
+			vm.removeJail(instanceID)
+		}
+		return &transientStartError{fmt.Errorf("failed to start machine: %v", err)}
+	}
+
+	// A fresh guest is only started once its network answers; restored VMs
+	// stay paused and are probed when resumed
+	if !useSnapshot {
+		if err := vm.waitGuestReachable(allocatedIP); err != nil {
+			if egressBlocked {
+				vm.unblockVMEgress(allocatedIP, extraInterfaces)
+			}
+			machine.StopVMM()
+			if jailed {
+				vm.removeJail(instanceID)
+			}
+			return fmt.Errorf("VM started but its network never came up: %v", err)
+		}
+	}
+	started = true
+
+	if useSnapshot {
+		vm.publishGuestMetadata(machine, plugin, instanceID, metadata)
 	}
 
 	// Store VM instance in prewarm pool with allocated IP
@@ -403,24 +885,63 @@ func (vm *VMService) createVM(instanceID string, plugin *cms_models.Plugin, useS
 		snapshotType = "full"
 	}
 
+	// A restored balloon may still hold the memory it reclaimed before the
+	// snapshot; the next resume deflates it even if ballooning is now off
+	balloon, balloonInflated := machineBalloon(machine)
+
 	vm.poolMutex.Lock()
-	vm.prewarmPool[instanceID] = &PrewarmInstance{
+	vm.warmInstances[plugin.Slug] = instanceID
+	delete(vm.evictedPlugins, plugin.Slug)
+	instance := &PrewarmInstance{
 		InstanceID:   instanceID,
+		PluginSlug:   plugin.Slug,
 		Machine:      machine,
 		IP:           allocatedIP,
 		TapName:      tapName,
+		Interfaces:   extraInterfaces,
 		CreatedAt:    time.Now(),
 		LastUsed:     time.Now(),
 		SnapshotType: snapshotType,
+
+		EgressBlocked: egressBlocked,
+		Jailed:        jailed,
+
+		Balloon: balloon,
+
+		SnapshotBase: useSnapshot,
+
+		MTLS: plugin.MTLS,
 	}
+	instance.balloonInflated.Store(balloonInflated)
+	vm.prewarmPool[instanceID] = instance
 	vm.poolMutex.Unlock()
 
+	// Detect crashes as they happen rather than on the next health check
+	go vm.watchInstance(instance)
+
+	// Persist the instance so it can be reconciled after a crash
+	pid, _ := machine.PID()
+	vm.recordInstance(InstanceRecord{
+		InstanceID: instanceID,
+		PluginSlug: plugin.Slug,
+		PID:        pid,
+		SocketPath: socketPath,
+		TapName:    tapName,
+		IP:         allocatedIP,
+		Interfaces: extraInterfaces,
+		CreatedAt:  time.Now(),
+
+		EgressBlocked: egressBlocked,
+		Jailed:        jailed,
+	})
+
 	vm.logger.WithFields(logger.Fields{
 		"plugin_slug": plugin.Slug,
 		"instance_id": instanceID,
 		"assigned_ip": allocatedIP,
 		"tap_name":    tapName,
 		"vm_type":     vmType,
+		"isolation":   isolation.Tier,
 	}).Info("VM created successfully with static networking")
 
 	return nil
@@ -428,9 +949,12 @@ func (vm *VMService) createVM(instanceID string, plugin *cms_models.Plugin, useS
 
 // StopVM stops and cleans up a VM instance
 func (vm *VMService) StopVM(instanceID string) error {
-	vm.poolMutex.RLock()
+	vm.poolMutex.Lock()
 	instance, exists := vm.prewarmPool[instanceID]
-	vm.poolMutex.RUnlock()
+	if exists {
+		instance.stopping = true
+	}
+	vm.poolMutex.Unlock()
 
 	if !exists {
 		vm.logger.WithFields(logger.Fields{
@@ -490,16 +1014,36 @@ func (vm *VMService) StopVM(instanceID string) error {
 		// Continue with cleanup even if wait fails
 	}
 
-	// Deallocate IP before removing from tracking
+	if instance.Jailed {
+		if err := vm.removeJail(instanceID); err != nil {
+			vm.logger.WithFields(logger.Fields{
+				"instance_id": instanceID,
+				"error":       err,
+			}).Warn("Failed to remove jail directory")
+		}
+	}
+
+	// Deallocate IPs before removing from tracking
 	if instance.IP != "" {
 		vm.deallocateIP(instance.IP)
 	}
+	for _, iface := range instance.Interfaces {
+		vm.deallocateIP(iface.AssignedIP)
+	}
+
+	if instance.EgressBlocked {
+		vm.unblockVMEgress(instance.IP, instance.Interfaces)
+	}
 
 	// Remove from prewarm pool
 	vm.poolMutex.Lock()
+	vm.unclaimWarmInstanceUnsafe(instance)
 	delete(vm.prewarmPool, instanceID)
 	vm.poolMutex.Unlock()
 
+	vm.forgetInstance(instanceID)
+	vm.usage.forgetInstance(instanceID)
+
 	vm.logger.WithFields(logger.Fields{
 		"instance_id": instanceID,
 	}).Info("VM stopped successfully")
@@ -509,19 +1053,26 @@ func (vm *VMService) StopVM(instanceID string) error {
 
 // PauseVM pauses a VM instance (keeps it in memory for instant resume)
 func (vm *VMService) PauseVM(instanceID string) error {
+	// The pool lock is not held across API calls; a VM stopped meanwhile
+	// just fails them
 	vm.poolMutex.RLock()
 	instance, exists := vm.prewarmPool[instanceID]
+	vm.poolMutex.RUnlock()
 	if !exists {
-		vm.poolMutex.RUnlock()
 		return fmt.Errorf("VM instance %s not found", instanceID)
 	}
-	// Keep the lock while we use the instance to prevent race conditions
-	defer vm.poolMutex.RUnlock()
+
+	// Always-warm mode: the VM keeps running until the next execution
+	if !vm.capabilities.Snapshots {
+		return nil
+	}
 
 	vm.logger.WithFields(logger.Fields{
 		"instance_id": instanceID,
 	}).Info("Pausing VM for pre-warming")
 
+	vm.inflateBalloon(instance)
+
 	// Pause the Firecracker machine
 	if err := instance.Machine.PauseVM(context.Background()); err != nil {
 		vm.logger.WithFields(logger.Fields{
@@ -530,6 +1081,7 @@ func (vm *VMService) PauseVM(instanceID string) error {
 		}).Error("Failed to pause VM")
 		return fmt.Errorf("failed to pause VM: %v", err)
 	}
+	instance.setPausedAt(time.Now())
 
 	vm.logger.WithFields(logger.Fields{
 		"instance_id": instanceID,
@@ -540,14 +1092,18 @@ func (vm *VMService) PauseVM(instanceID string) error {
 
 // ResumeVM resumes a paused VM instance
 func (vm *VMService) ResumeVM(instanceID string) error {
+	// Not held across API calls and the time push, see PauseVM
 	vm.poolMutex.RLock()
 	instance, exists := vm.prewarmPool[instanceID]
+	vm.poolMutex.RUnlock()
 	if !exists {
-		vm.poolMutex.RUnlock()
 		return fmt.Errorf("VM instance %s not found", instanceID)
 	}
-	// Keep the lock while we use the instance to prevent race conditions
-	defer vm.poolMutex.RUnlock()
+
+	// Always-warm mode: the VM was never paused
+	if !vm.capabilities.Snapshots {
+		return nil
+	}
 
 	vm.logger.WithFields(logger.Fields{
 		"instance_id": instanceID,
@@ -562,6 +1118,14 @@ func (vm *VMService) ResumeVM(instanceID string) error {
 		return fmt.Errorf("failed to resume VM: %v", err)
 	}
 
+	vm.deflateBalloon(instance)
+
+	// Correct the guest clock before the instance serves again
+	if pausedAt := instance.PausedAt(); !pausedAt.IsZero() && time.Since(pausedAt) >= guestTimeSyncMinPause {
+		vm.syncGuestTime(instance, time.Since(pausedAt))
+	}
+	instance.setPausedAt(time.Time{})
+
 	vm.logger.WithFields(logger.Fields{
 		"instance_id": instanceID,
 	}).Info("VM resumed successfully")
@@ -569,17 +1133,35 @@ func (vm *VMService) ResumeVM(instanceID string) error {
 	return nil
 }
 
+// ErrSnapshotsUnsupported is returned when snapshotting on a Firecracker binary without snapshot support
+var ErrSnapshotsUnsupported = errors.New("snapshots are not supported by this firecracker binary")
+
 // CreateSnapshot creates a snapshot of the running VM
 func (vm *VMService) CreateSnapshot(instanceID, snapshotDir string, useDifferential bool) error {
+	_, _, err := vm.createSnapshot(instanceID, snapshotDir, useDifferential)
+	return err
+}
+
+// createSnapshot creates a snapshot of the running VM and returns the paths of
+// its memory and state files
+func (vm *VMService) createSnapshot(instanceID, snapshotDir string, useDifferential bool) (string, string, error) {
 	vm.poolMutex.RLock()
 	instance, exists := vm.prewarmPool[instanceID]
 	if !exists {
 		vm.poolMutex.RUnlock()
-		return fmt.Errorf("VM instance %s not found", instanceID)
+		return "", "", fmt.Errorf("VM instance %s not found", instanceID)
 	}
 	// Keep the lock while we use the instance to prevent race conditions
 	defer vm.poolMutex.RUnlock()
 
+	if !vm.capabilities.Snapshots {
+		return "", "", ErrSnapshotsUnsupported
+	}
+
+	if useDifferential && !vm.capabilities.DifferentialSnapshots {
+		return "", "", fmt.Errorf("differential snapshots are not supported by firecracker %s", vm.capabilities.FirecrackerVersion)
+	}
+
 	vm.logger.WithFields(logger.Fields{
 		"instance_id":      instanceID,
 		"snapshot_dir":     snapshotDir,
@@ -587,26 +1169,25 @@ func (vm *VMService) CreateSnapshot(instanceID, snapshotDir string, useDifferent
 	}).Info("Creating VM snapshot")
 
 	// Define snapshot file paths
-	memPath := filepath.Join(snapshotDir, "snapshot.mem")
-	statePath := filepath.Join(snapshotDir, "snapshot.state")
+	timestamp := time.Now().Unix()
+	memPath := snapshotMemPath(snapshotDir, useDifferential, timestamp)
+	statePath := snapshotStatePath(snapshotDir, useDifferential, timestamp)
 
-	// For differential snapshots, use different memory file name
-	if useDifferential {
-		timestamp := time.Now().Unix()
-		memPath = filepath.Join(snapshotDir, fmt.Sprintf("snapshot-diff-%d.mem", timestamp))
-		vm.logger.WithFields(logger.Fields{
-			"instance_id": instanceID,
-			"mem_path":    memPath,
-		}).Info("Creating differential snapshot")
+	// Fail before pausing rather than leave half-written files on a full disk
+	if err := vm.checkSnapshotDiskSpace(snapshotDir, firecracker.Int64Value(instance.Machine.Cfg.MachineCfg.MemSizeMib), useDifferential); err != nil {
+		return "", "", err
 	}
 
+	// Timed from pause until the files are in place, excluding the resume
+	start := time.Now()
+
 	// Pause VM before creating snapshot
 	vm.logger.WithFields(logger.Fields{
 		"instance_id": instanceID,
 	}).Debug("Pausing VM for snapshot creation")
 
 	if err := instance.Machine.PauseVM(context.Background()); err != nil {
-		return fmt.Errorf("failed to pause VM: %v", err)
+		return "", "", fmt.Errorf("failed to pause VM: %v", err)
 	}
 
 	// Ensure VM is resumed after snapshot creation
@@ -619,26 +1200,119 @@ func (vm *VMService) CreateSnapshot(instanceID, snapshotDir string, useDifferent
 		}
 	}()
 
+	// A jailed Firecracker writes snapshot files relative to its chroot
+	apiMemPath, apiStatePath := memPath, statePath
+	if instance.Jailed {
+		apiMemPath, apiStatePath = filepath.Base(memPath), filepath.Base(statePath)
+	}
+
 	// Create snapshot using the correct Firecracker SDK API
-	err := instance.Machine.CreateSnapshot(context.Background(), memPath, statePath)
+	err := instance.Machine.CreateSnapshot(context.Background(), apiMemPath, apiStatePath)
 	if err != nil {
 		vm.logger.WithFields(logger.Fields{
 			"instance_id": instanceID,
 			"error":       err,
 		}).Error("Failed to create snapshot")
-		return fmt.Errorf("failed to create snapshot: %v", err)
+		return "", "", fmt.Errorf("failed to create snapshot: %v", err)
+	}
+
+	if instance.Jailed {
+		if err := vm.exportJailedSnapshot(instanceID, memPath, statePath); err != nil {
+			return "", "", fmt.Errorf("failed to export snapshot: %v", err)
+		}
+	}
+
+	if !useDifferential {
+		instance.SnapshotBase = true
 	}
 
+	duration := time.Since(start)
+	sizeBytes := snapshotFilesSize(memPath, statePath)
+	vm.snapshots.snapshotCreated(instance.PluginSlug, useDifferential, duration, sizeBytes)
+
 	vm.logger.WithFields(logger.Fields{
 		"instance_id":      instanceID,
 		"mem_path":         memPath,
 		"state_path":       statePath,
 		"use_differential": useDifferential,
+		"duration_ms":      duration.Milliseconds(),
+		"size_bytes":       sizeBytes,
 	}).Info("VM snapshot created successfully")
 
+	return memPath, statePath, nil
+}
+
+// SnapshotInstance creates a full snapshot of a warm instance and restores its
+// paused state afterwards so it stays ready in the pre-warm pool
+func (vm *VMService) SnapshotInstance(instanceID, snapshotDir string) error {
+	vm.poolMutex.RLock()
+	instance, exists := vm.prewarmPool[instanceID]
+	vm.poolMutex.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("VM instance %s not found", instanceID)
+	}
+
+	instance.opMutex.Lock()
+	defer instance.opMutex.Unlock()
+
+	// Snapshotting pauses the VM, which would stall a running execution
+	if instance.busy() {
+		return ErrInstanceBusy
+	}
+
+	// CreateSnapshot always resumes the VM, so remember whether it was paused
+	wasPaused := false
+	if info, err := instance.Machine.DescribeInstanceInfo(context.Background()); err == nil && info.State != nil {
+		wasPaused = *info.State == models.InstanceInfoStatePaused
+	}
+
+	if err := vm.CreateSnapshot(instanceID, snapshotDir, false); err != nil {
+		return err
+	}
+
+	if wasPaused {
+		if err := vm.PauseVM(instanceID); err != nil {
+			return fmt.Errorf("snapshot created but failed to re-pause VM: %v", err)
+		}
+	}
+
 	return nil
 }
 
+// ProbeInstance runs a probe against a warm instance. A paused instance is
+// resumed for the probe and paused again afterwards. Instances claimed by an
+// execution are left alone and yield ErrInstanceBusy.
+func (vm *VMService) ProbeInstance(instance *PrewarmInstance, probe func(instance *PrewarmInstance) error) error {
+	instance.opMutex.Lock()
+	defer instance.opMutex.Unlock()
+
+	if instance.busy() {
+		return ErrInstanceBusy
+	}
+
+	wasPaused := false
+	if info, err := instance.Machine.DescribeInstanceInfo(context.Background()); err == nil && info.State != nil {
+		wasPaused = *info.State == models.InstanceInfoStatePaused
+	}
+
+	if wasPaused {
+		if err := vm.ResumeVM(instance.InstanceID); err != nil {
+			return err
+		}
+	}
+
+	probeErr := probe(instance)
+
+	if wasPaused {
+		if err := vm.PauseVM(instance.InstanceID); err != nil {
+			return fmt.Errorf("failed to re-pause VM after probe: %v", err)
+		}
+	}
+
+	return probeErr
+}
+
 // GetSnapshotPath returns the snapshot directory path for a plugin
 func (vm *VMService) GetSnapshotPath(pluginSlug string) string {
 	pluginSnapshotDir := filepath.Join(vm.snapshotDir, pluginSlug)
@@ -656,8 +1330,8 @@ func (vm *VMService) GetSnapshotPath(pluginSlug string) string {
 // HasSnapshot checks if a snapshot exists for the given plugin
 func (vm *VMService) HasSnapshot(pluginSlug string) bool {
 	snapshotDir := vm.GetSnapshotPath(pluginSlug)
-	memPath := filepath.Join(snapshotDir, "snapshot.mem")
-	statePath := filepath.Join(snapshotDir, "snapshot.state")
+	memPath := snapshotMemPath(snapshotDir, false, 0)
+	statePath := snapshotStatePath(snapshotDir, false, 0)
 
 	_, memErr := os.Stat(memPath)
 	_, stateErr := os.Stat(statePath)
@@ -668,8 +1342,8 @@ func (vm *VMService) HasSnapshot(pluginSlug string) bool {
 // DeleteSnapshot deletes snapshot files for a plugin
 func (vm *VMService) DeleteSnapshot(pluginSlug string) error {
 	snapshotDir := vm.GetSnapshotPath(pluginSlug)
-	memPath := filepath.Join(snapshotDir, "snapshot.mem")
-	statePath := filepath.Join(snapshotDir, "snapshot.state")
+	memPath := snapshotMemPath(snapshotDir, false, 0)
+	statePath := snapshotStatePath(snapshotDir, false, 0)
 
 	var errors []string
 
@@ -745,6 +1419,7 @@ func (vm *VMService) ListVMs() []string {
 	for _, instance := range vm.prewarmPool {
 		instanceIDs = append(instanceIDs, instance.InstanceID)
 	}
+	sort.Strings(instanceIDs)
 
 	vm.logger.WithFields(logger.Fields{
 		"count":     len(instanceIDs),
@@ -754,24 +1429,38 @@ func (vm *VMService) ListVMs() []string {
 	return instanceIDs
 }
 
-// Shutdown gracefully shuts down the VM service
-func (vm *VMService) Shutdown(ctx context.Context) {
+// ShutdownSummary reports how the VMs in the prewarm pool were stopped
+type ShutdownSummary struct {
+	Total        int   `json:"total"`
+	StoppedClean int   `json:"stopped_clean"`
+	ForceKilled  int   `json:"force_killed"`
+	Errored      int   `json:"errored"`
+	ElapsedMs    int64 `json:"elapsed_ms"`
+}
+
+// Shutdown gracefully shuts down the VM service and reports how each VM stopped
+func (vm *VMService) Shutdown(ctx context.Context) ShutdownSummary {
+	startTime := time.Now()
+
 	vm.poolMutex.Lock()
 	defer vm.poolMutex.Unlock()
 
-	totalInstances := len(vm.prewarmPool)
+	summary := ShutdownSummary{Total: len(vm.prewarmPool)}
 
 	vm.logger.WithFields(logger.Fields{
-		"count": totalInstances,
+		"count": summary.Total,
 	}).Info("Stopping all VMs in prewarm pool")
 
 	// Stop all VMs in the prewarm pool
-	for pluginSlug, instance := range vm.prewarmPool {
+	for _, instance := range vm.prewarmPool {
 		vm.logger.WithFields(logger.Fields{
 			"instance_id": instance.InstanceID,
-			"plugin_slug": pluginSlug,
+			"plugin_slug": instance.PluginSlug,
 		}).Debug("Stopping VM from prewarm pool")
 
+		forced := false
+		failed := false
+
 		// Get the machine from prewarm pool
 		if instance.Machine != nil {
 			// Attempt graceful shutdown first
@@ -781,7 +1470,14 @@ func (vm *VMService) Shutdown(ctx context.Context) {
 					"error":       err,
 				}).Warn("Graceful shutdown failed, forcing stop")
 				// Force stop if graceful shutdown fails
-				instance.Machine.StopVMM()
+				forced = true
+				if killErr := instance.Machine.StopVMM(); killErr != nil {
+					vm.logger.WithFields(logger.Fields{
+						"instance_id": instance.InstanceID,
+						"error":       killErr,
+					}).Error("Failed to force stop VM")
+					failed = true
+				}
 			}
 
 			// Wait for the Firecracker process to actually finish
@@ -789,24 +1485,47 @@ func (vm *VMService) Shutdown(ctx context.Context) {
 				"instance_id": instance.InstanceID,
 			}).Debug("Waiting for Firecracker process to exit")
 
-			if err := instance.Machine.Wait(ctx); err != nil {
+			// A force-killed process exits with an error, so only a failed
+			// wait after a graceful shutdown counts as an error
+			if err := instance.Machine.Wait(ctx); err != nil && !forced {
 				vm.logger.WithFields(logger.Fields{
 					"instance_id": instance.InstanceID,
 					"error":       err,
 				}).Error("Failed to wait for Firecracker process to exit")
+				failed = true
 			}
 		}
 
+		switch {
+		case failed:
+			summary.Errored++
+		case forced:
+			summary.ForceKilled++
+		default:
+			summary.StoppedClean++
+		}
+
 		// Static networking cleanup is handled by TAP interface management
 		vm.logger.WithFields(logger.Fields{
 			"instance_id": instance.InstanceID,
 		}).Debug("Static networking cleanup handled by TAP interface management")
+
+		if instance.EgressBlocked {
+			vm.unblockVMEgress(instance.IP, instance.Interfaces)
+		}
+
+		vm.forgetInstance(instance.InstanceID)
 	}
 
 	// Clear all instances from prewarm pool
 	vm.prewarmPool = make(map[string]*PrewarmInstance)
+	vm.warmInstances = make(map[string]string)
+
+	vm.teardownNAT()
+	vm.teardownBridge()
 
-	vm.logger.Info("All VMs stopped successfully")
+	summary.ElapsedMs = time.Since(startTime).Milliseconds()
+	return summary
 }
 
 // Helper functions
@@ -845,6 +1564,10 @@ func (vm *VMService) createTapInterface(pluginSlug string, instanceID string) (s
 		return "", fmt.Errorf("failed to create TAP interface %s: %v", tapName, err)
 	}
 
+	if err := vm.applyTapSettings(tapName); err != nil {
+		return "", err
+	}
+
 	// Set TAP interface up
 	cmd = exec.Command("ip", "link", "set", tapName, "up")
 	if err := cmd.Run(); err != nil {
@@ -852,7 +1575,7 @@ func (vm *VMService) createTapInterface(pluginSlug string, instanceID string) (s
 	}
 
 	// Add TAP interface to the bridge
-	cmd = exec.Command("brctl", "addif", "fcnetbridge0", tapName)
+	cmd = exec.Command("brctl", "addif", pluginBridge, tapName)
 	if err := cmd.Run(); err != nil {
 		vm.logger.WithFields(logger.Fields{
 			"tap_name": tapName,
@@ -864,7 +1587,7 @@ func (vm *VMService) createTapInterface(pluginSlug string, instanceID string) (s
 		"tap_name":    tapName,
 		"plugin_slug": pluginSlug,
 		"instance_id": instanceID,
-		"bridge":      "fcnetbridge0",
+		"bridge":      pluginBridge,
 	}).Info("Created TAP interface and added to bridge")
 
 	return tapName, nil
@@ -876,12 +1599,37 @@ func (vm *VMService) tapExists(tapName string) bool {
 	return cmd.Run() == nil
 }
 
+// applyTapSettings sets the configured MTU and transmit queue length on a TAP
+// interface. Firecracker opens TAPs single-queue, so there is no queue count.
+func (vm *VMService) applyTapSettings(tapName string) error {
+	args := []string{"link", "set", "dev", tapName}
+	if vm.config.TapMTU > 0 {
+		args = append(args, "mtu", strconv.Itoa(vm.config.TapMTU))
+	}
+	if vm.config.TapTxQueueLen > 0 {
+		args = append(args, "txqueuelen", strconv.Itoa(vm.config.TapTxQueueLen))
+	}
+	if len(args) == 4 {
+		return nil
+	}
+
+	if output, err := exec.Command("ip", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to apply settings to TAP interface %s: %v (%s)", tapName, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // ensureTapUp ensures a TAP interface is up and ready
 func (vm *VMService) ensureTapUp(tapName string) error {
 	vm.logger.WithFields(logger.Fields{
 		"tap_name": tapName,
 	}).Debug("Ensuring TAP interface is up")
 
+	// Reapply tuning in case the interface predates the current config
+	if err := vm.applyTapSettings(tapName); err != nil {
+		return err
+	}
+
 	// Check current state
 	cmd := exec.Command("ip", "link", "show", tapName)
 	output, err := cmd.Output()
@@ -916,8 +1664,8 @@ func (vm *VMService) deleteTapInterface(tapName string) error {
 		return nil // Already deleted
 	}
 
-	// Remove TAP interface from bridge first
-	cmd := exec.Command("brctl", "delif", "fcnetbridge0", tapName)
+	// Remove TAP interface from its bridge first
+	cmd := exec.Command("ip", "link", "set", tapName, "nomaster")
 	if err := cmd.Run(); err != nil {
 		vm.logger.WithFields(logger.Fields{
 			"tap_name": tapName,
@@ -933,49 +1681,141 @@ func (vm *VMService) deleteTapInterface(tapName string) error {
 
 	vm.logger.WithFields(logger.Fields{
 		"tap_name": tapName,
-		"bridge":   "fcnetbridge0",
 	}).Info("Deleted TAP interface and removed from bridge")
 
 	return nil
 }
 
-// allocateIP allocates a unique IP address for a VM instance
-func (vm *VMService) allocateIP() string {
+// allocateIP allocates a unique plugin subnet address for a VM's eth0. owner
+// identifies who the address is for, so the sticky strategy can hand out the
+// owner's previous address.
+func (vm *VMService) allocateIP(owner string) string {
+	return vm.allocateIPFrom(vm.ipStrategy, pluginBridge, owner)
+}
+
+// allocateExtraIP allocates a unique address for an additional interface from
+// the subnet of additional interfaces
+func (vm *VMService) allocateExtraIP(owner string) string {
+	return vm.allocateIPFrom(vm.extraIPStrategy, vm.config.ExtraInterfaceBridge, owner)
+}
+
+// allocateIPFrom allocates an address of the subnet of strategy, trying
+// addresses in its order
+func (vm *VMService) allocateIPFrom(strategy ipAllocationStrategy, bridge, owner string) string {
+	// Addresses with a neighbor entry on the bridge may belong to a VM the pool
+	// lost track of, so they are only used when nothing else is free
+	var liveIPs map[string]bool
+	if vm.config.IPLivenessCheck {
+		liveIPs = vm.liveBridgeIPs(bridge)
+	}
+
 	vm.ipPoolMutex.Lock()
 	defer vm.ipPoolMutex.Unlock()
 
-	// Find the next available IP
-	for i := 0; i < 254; i++ { // 192.168.127.2 to 192.168.127.255
-		ipStr := vm.nextIP.String()
-
-		if !vm.ipPool[ipStr] {
-			// Allocate this IP
-			vm.ipPool[ipStr] = true
+	fallbackIP := ""
+	for _, ipStr := range strategy.candidates(vm.lastIPs[owner]) {
+		if vm.ipPool[ipStr] {
+			continue
+		}
 
-			// Move to next IP for future allocations
-			vm.nextIP[3]++ // Increment last octet
-			if vm.nextIP[3] == 0 {
-				vm.nextIP[3] = 2 // Skip .0 and .1, start from .2
+		if liveIPs[ipStr] {
+			if fallbackIP == "" {
+				fallbackIP = ipStr
 			}
-
 			vm.logger.WithFields(logger.Fields{
-				"allocated_ip": ipStr,
-			}).Debug("Allocated IP for VM")
-
-			return ipStr
+				"ip": ipStr,
+			}).Warn("Skipping unallocated IP that appears live on the bridge")
+			continue
 		}
 
-		// Try next IP
-		vm.nextIP[3]++
-		if vm.nextIP[3] == 0 {
-			vm.nextIP[3] = 2 // Skip .0 and .1, start from .2
-		}
+		vm.takeIPUnsafe(strategy, owner, ipStr)
+		vm.logger.WithFields(logger.Fields{
+			"allocated_ip": ipStr,
+			"owner":        owner,
+			"strategy":     vm.config.IPAllocationStrategy,
+		}).Debug("Allocated IP for VM")
+		return ipStr
+	}
+
+	if fallbackIP != "" {
+		vm.takeIPUnsafe(strategy, owner, fallbackIP)
+		vm.logger.WithFields(logger.Fields{
+			"allocated_ip": fallbackIP,
+		}).Warn("All free IPs appear live, allocating one anyway")
+		return fallbackIP
 	}
 
 	vm.logger.Error("No available IPs in pool")
 	return ""
 }
 
+// takeIPUnsafe marks an address allocated to owner
+// Note: Caller must hold vm.ipPoolMutex
+func (vm *VMService) takeIPUnsafe(strategy ipAllocationStrategy, owner, ip string) {
+	vm.ipPool[ip] = true
+	vm.lastIPs[owner] = ip
+	strategy.allocated(ip)
+}
+
+// liveBridgeIPs returns the addresses with a usable neighbor entry on a VM
+// bridge. Entries that failed or are still resolving are ignored.
+func (vm *VMService) liveBridgeIPs(bridge string) map[string]bool {
+	output, err := exec.Command("ip", "neigh", "show", "dev", bridge).Output()
+	if err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"error": err,
+		}).Debug("Failed to read bridge neighbor table, skipping IP liveness check")
+		return nil
+	}
+
+	live := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		state := fields[len(fields)-1]
+		if state == "FAILED" || state == "INCOMPLETE" {
+			continue
+		}
+
+		live[fields[0]] = true
+	}
+
+	return live
+}
+
+// ReserveIP marks a specific eth0 IP as allocated, returning false if it is
+// taken or outside the plugin subnet
+func (vm *VMService) ReserveIP(ip string) bool {
+	return vm.reserveIPIn(pluginSubnet, ip)
+}
+
+// ReserveExtraIP marks a specific additional interface IP as allocated,
+// returning false if it is taken or outside the additional interface subnet
+func (vm *VMService) ReserveExtraIP(ip string) bool {
+	return vm.reserveIPIn(vm.config.ExtraInterfaceSubnet, ip)
+}
+
+// reserveIPIn marks ip allocated if it is free and inside cidr
+func (vm *VMService) reserveIPIn(cidr, ip string) bool {
+	_, subnet, _ := net.ParseCIDR(cidr)
+	parsed := net.ParseIP(ip)
+	if parsed == nil || !subnet.Contains(parsed) {
+		return false
+	}
+
+	vm.ipPoolMutex.Lock()
+	defer vm.ipPoolMutex.Unlock()
+
+	if vm.ipPool[ip] {
+		return false
+	}
+	vm.ipPool[ip] = true
+	return true
+}
+
 // deallocateIP releases an IP address back to the pool
 func (vm *VMService) deallocateIP(ip string) {
 	vm.ipPoolMutex.Lock()
@@ -988,6 +1828,39 @@ func (vm *VMService) deallocateIP(ip string) {
 	}).Debug("Deallocated IP")
 }
 
+// registryAssignment is the part of a registry entry holding its addresses
+type registryAssignment struct {
+	AssignedIP        string                              `json:"assigned_ip"`
+	TapDevice         string                              `json:"tap_device"`
+	NetworkInterfaces []cms_models.PluginNetworkInterface `json:"network_interfaces"`
+}
+
+// parseRegistryAssignments reads the addresses of every plugin from the
+// registry, a map of slug to plugin. The map wrapped as {"plugins": {...}},
+// the shape this loader used to expect, is accepted too; it is told apart from
+// a plugin named "plugins" by the missing slug.
+func parseRegistryAssignments(data []byte) (map[string]registryAssignment, error) {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	if wrapped, ok := entries["plugins"]; ok && len(entries) == 1 {
+		var entry struct {
+			Slug *string `json:"slug"`
+		}
+		if err := json.Unmarshal(wrapped, &entry); err == nil && entry.Slug == nil {
+			data = wrapped
+		}
+	}
+
+	var registry map[string]registryAssignment
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
 // loadExistingIPAssignments loads existing IP assignments from the plugin registry
 func (vm *VMService) loadExistingIPAssignments() error {
 	registryPath := filepath.Join(vm.config.DataDir, "plugins", "plugins.json")
@@ -1005,14 +1878,8 @@ func (vm *VMService) loadExistingIPAssignments() error {
 	}
 
 	// Parse JSON to get existing IP assignments
-	var registry struct {
-		Plugins map[string]struct {
-			AssignedIP string `json:"assigned_ip"`
-			TapDevice  string `json:"tap_device"`
-		} `json:"plugins"`
-	}
-
-	if err := json.Unmarshal(data, &registry); err != nil {
+	registry, err := parseRegistryAssignments(data)
+	if err != nil {
 		return fmt.Errorf("failed to parse plugin registry: %v", err)
 	}
 
@@ -1020,18 +1887,28 @@ func (vm *VMService) loadExistingIPAssignments() error {
 	vm.ipPoolMutex.Lock()
 	defer vm.ipPoolMutex.Unlock()
 
-	for _, plugin := range registry.Plugins {
+	// Persisted addresses are also each owner's last address for sticky allocation
+	for slug, plugin := range registry {
 		if plugin.AssignedIP != "" {
 			vm.ipPool[plugin.AssignedIP] = true
+			vm.lastIPs[slug] = plugin.AssignedIP
 			vm.logger.WithFields(logger.Fields{
 				"assigned_ip": plugin.AssignedIP,
 				"tap_device":  plugin.TapDevice,
 			}).Debug("Loaded existing IP assignment")
 		}
+		for _, iface := range plugin.NetworkInterfaces {
+			// Addresses from the plugin subnet predate the separate subnet
+			// and are replaced when the plugin is loaded
+			if vm.extraSubnetContains(iface.AssignedIP) {
+				vm.ipPool[iface.AssignedIP] = true
+				vm.lastIPs[extraInterfaceOwner(slug, iface.Name)] = iface.AssignedIP
+			}
+		}
 	}
 
 	vm.logger.WithFields(logger.Fields{
-		"loaded_assignments": len(registry.Plugins),
+		"loaded_assignments": len(registry),
 	}).Info("Loaded existing IP assignments from plugin registry")
 
 	return nil
@@ -1053,17 +1930,24 @@ func (vm *VMService) cleanupAndValidateState() error {
 		vm.logger.Info("🧹 Production mode: Conservative cleanup for stability")
 	}
 
-	// Step 1: Clean up orphaned TAP interfaces (only network cleanup needed)
+	// Step 1: Reconcile instances recorded by a previous run (processes, sockets, TAPs, IPs)
+	if err := vm.reconcileInstanceRegistry(); err != nil {
+		vm.logger.WithFields(logger.Fields{
+			"error": err,
+		}).Warn("Failed to reconcile instance registry")
+	}
+
+	// Step 2: Clean up orphaned TAP interfaces not covered by the instance registry
 	if err := vm.cleanupOrphanedTapInterfaces(); err != nil {
 		vm.logger.WithFields(logger.Fields{
 			"error": err,
 		}).Warn("Failed to cleanup orphaned TAP interfaces")
 	}
 
-	// Step 2: Firecracker SDK handles process and socket cleanup automatically
+	// Step 3: Firecracker SDK handles process and socket cleanup automatically
 	vm.logger.Debug("Firecracker SDK handles process and socket cleanup automatically")
 
-	// Step 3: Validate and clean up plugin registry state
+	// Step 4: Validate and clean up plugin registry state
 	if err := vm.validatePluginRegistryState(); err != nil {
 		vm.logger.WithFields(logger.Fields{
 			"error": err,
@@ -1201,8 +2085,8 @@ func (vm *VMService) validatePluginState(plugin *cms_models.Plugin) bool {
 			return false
 		}
 
-		// Check if snapshot exists
-		if !vm.HasSnapshot(plugin.Slug) {
+		// Check if snapshot exists, unless running in always-warm mode
+		if vm.capabilities.Snapshots && !vm.HasSnapshot(plugin.Slug) {
 			vm.logger.WithFields(logger.Fields{
 				"plugin_slug": plugin.Slug,
 			}).Debug("Plugin snapshot not found, marking as invalid")
@@ -1255,8 +2139,9 @@ func (vm *VMService) getActivePluginTapDevices() map[string]bool {
 
 	// Parse JSON to get active plugins
 	var registry map[string]struct {
-		Status    string `json:"status"`
-		TapDevice string `json:"tap_device"`
+		Status            string                              `json:"status"`
+		TapDevice         string                              `json:"tap_device"`
+		NetworkInterfaces []cms_models.PluginNetworkInterface `json:"network_interfaces"`
 	}
 
 	if err := json.Unmarshal(data, &registry); err != nil {
@@ -1275,6 +2160,13 @@ func (vm *VMService) getActivePluginTapDevices() map[string]bool {
 				"tap_device":  plugin.TapDevice,
 			}).Debug("Found active plugin with TAP device to preserve")
 		}
+		if plugin.Status == "active" {
+			for _, iface := range plugin.NetworkInterfaces {
+				if iface.TapDevice != "" {
+					activeTapDevices[iface.TapDevice] = true
+				}
+			}
+		}
 	}
 
 	return activeTapDevices
@@ -1298,7 +2190,7 @@ func (vm *VMService) getOrCreateTapInterface(plugin *cms_models.Plugin, instance
 			}).Info("TAP device not found, recreating with same name")
 
 			// Recreate TAP device with the same name
-			if err := vm.recreateTapInterface(tapName); err != nil {
+			if err := vm.recreateTapInterface(tapName, pluginBridge); err != nil {
 				return "", fmt.Errorf("failed to recreate TAP device %s: %v", tapName, err)
 			}
 		}
@@ -1326,7 +2218,7 @@ func (vm *VMService) getOrAllocateIP(plugin *cms_models.Plugin) (string, error)
 		return plugin.AssignedIP, nil
 	} else {
 		// Allocate new IP
-		allocatedIP := vm.allocateIP()
+		allocatedIP := vm.allocateIP(plugin.Slug)
 		if allocatedIP == "" {
 			return "", fmt.Errorf("failed to allocate IP for VM")
 		}
@@ -1338,8 +2230,8 @@ func (vm *VMService) getOrAllocateIP(plugin *cms_models.Plugin) (string, error)
 	}
 }
 
-// recreateTapInterface recreates a TAP interface with the same name
-func (vm *VMService) recreateTapInterface(tapName string) error {
+// recreateTapInterface recreates a TAP interface with the same name on bridge
+func (vm *VMService) recreateTapInterface(tapName, bridge string) error {
 	vm.logger.WithFields(logger.Fields{
 		"tap_name": tapName,
 	}).Info("Recreating TAP interface with same name")
@@ -1350,6 +2242,10 @@ func (vm *VMService) recreateTapInterface(tapName string) error {
 		return fmt.Errorf("failed to create TAP interface %s: %v", tapName, err)
 	}
 
+	if err := vm.applyTapSettings(tapName); err != nil {
+		return err
+	}
+
 	// Set TAP interface up
 	cmd = exec.Command("ip", "link", "set", tapName, "up")
 	if err := cmd.Run(); err != nil {
@@ -1357,7 +2253,7 @@ func (vm *VMService) recreateTapInterface(tapName string) error {
 	}
 
 	// Add TAP interface to the bridge
-	cmd = exec.Command("brctl", "addif", "fcnetbridge0", tapName)
+	cmd = exec.Command("brctl", "addif", bridge, tapName)
 	if err := cmd.Run(); err != nil {
 		vm.logger.WithFields(logger.Fields{
 			"tap_name": tapName,
@@ -1367,7 +2263,7 @@ func (vm *VMService) recreateTapInterface(tapName string) error {
 
 	vm.logger.WithFields(logger.Fields{
 		"tap_name": tapName,
-		"bridge":   "fcnetbridge0",
+		"bridge":   bridge,
 	}).Info("Recreated TAP interface and added to bridge")
 
 	return nil
@@ -0,0 +1,144 @@
+/*
+ * Firecracker CMS - VM Service Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/config"
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// newTestVMService returns a VMService with an empty pool and no host
+// capabilities, so pause and resume are no-ops
+func newTestVMService() *VMService {
+	return &VMService{
+		config:           config.NewConfig(),
+		logger:           logger.GetDefault(),
+		prewarmPool:      make(map[string]*PrewarmInstance),
+		warmInstances:    make(map[string]string),
+		evictedPlugins:   make(map[string]bool),
+		pluginFootprints: make(map[string]int),
+		activeFootprints: make(map[string]bool),
+		maxPoolSize:      1,
+	}
+}
+
+// addTestInstance pools a warm instance for a plugin
+func addTestInstance(vm *VMService, pluginSlug, instanceID string) *PrewarmInstance {
+	instance := &PrewarmInstance{InstanceID: instanceID, PluginSlug: pluginSlug, LastUsed: time.Now()}
+	vm.prewarmPool[instanceID] = instance
+	vm.warmInstances[pluginSlug] = instanceID
+	return instance
+}
+
+func TestGetPrewarmInstanceClaims(t *testing.T) {
+	vm := newTestVMService()
+	instance := addTestInstance(vm, "blog", "blog-1")
+
+	first := vm.GetPrewarmInstance("blog")
+	second := vm.GetPrewarmInstance("blog")
+	if first != instance || second != instance {
+		t.Fatalf("expected the pooled instance to be shared")
+	}
+	if got := instance.users.Load(); got != 2 {
+		t.Fatalf("users = %d, want 2", got)
+	}
+
+	if err := vm.SnapshotInstance("blog-1", t.TempDir()); !errors.Is(err, ErrInstanceBusy) {
+		t.Fatalf("SnapshotInstance on a claimed instance = %v, want ErrInstanceBusy", err)
+	}
+
+	paused, err := vm.ReleasePrewarmInstance(first)
+	if err != nil || paused {
+		t.Fatalf("release with another user = (%v, %v), want (false, nil)", paused, err)
+	}
+	paused, err = vm.ReleasePrewarmInstance(second)
+	if err != nil || !paused {
+		t.Fatalf("last release = (%v, %v), want (true, nil)", paused, err)
+	}
+	if got := instance.users.Load(); got != 0 {
+		t.Fatalf("users = %d after release, want 0", got)
+	}
+}
+
+func TestAbandonPrewarmInstance(t *testing.T) {
+	vm := newTestVMService()
+	instance := addTestInstance(vm, "blog", "blog-1")
+
+	vm.GetPrewarmInstance("blog")
+	vm.AbandonPrewarmInstance(instance)
+	if instance.busy() {
+		t.Fatalf("instance still busy after abandoning the claim")
+	}
+}
+
+func TestGetPrewarmInstanceWaitsForOperation(t *testing.T) {
+	vm := newTestVMService()
+	instance := addTestInstance(vm, "blog", "blog-1")
+
+	instance.opMutex.Lock()
+	claimed := make(chan struct{})
+	go func() {
+		vm.GetPrewarmInstance("blog")
+		close(claimed)
+	}()
+
+	select {
+	case <-claimed:
+		t.Fatalf("claim returned while a snapshot was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	instance.opMutex.Unlock()
+	select {
+	case <-claimed:
+	case <-time.After(time.Second):
+		t.Fatalf("claim did not return after the snapshot finished")
+	}
+}
+
+func TestProbeInstanceSkipsClaimedInstance(t *testing.T) {
+	vm := newTestVMService()
+	instance := addTestInstance(vm, "blog", "blog-1")
+	vm.GetPrewarmInstance("blog")
+
+	probed := false
+	err := vm.ProbeInstance(instance, func(*PrewarmInstance) error {
+		probed = true
+		return nil
+	})
+	if !errors.Is(err, ErrInstanceBusy) || probed {
+		t.Fatalf("ProbeInstance on a claimed instance = %v (probed %v), want ErrInstanceBusy", err, probed)
+	}
+}
+
+func TestParseRegistryAssignments(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry string
+		wantSlug string
+	}{
+		{"plain map", `{"blog": {"slug": "blog", "assigned_ip": "192.168.127.2"}}`, "blog"},
+		{"wrapped map", `{"plugins": {"blog": {"slug": "blog", "assigned_ip": "192.168.127.2"}}}`, "blog"},
+		{"plugin named plugins", `{"plugins": {"slug": "plugins", "assigned_ip": "192.168.127.2"}}`, "plugins"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, err := parseRegistryAssignments([]byte(tt.registry))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(registry) != 1 || registry[tt.wantSlug].AssignedIP != "192.168.127.2" {
+				t.Fatalf("parsed %+v, want %s at 192.168.127.2", registry, tt.wantSlug)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Firecracker CMS - VM Start Retries
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+	cms_models "github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// transientStartError marks a createVM failure caused by host resources
+// (tap, IP, socket, jail) that may succeed when retried
+type transientStartError struct {
+	err error
+}
+
+func (e *transientStartError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transientStartError) Unwrap() error {
+	return e.err
+}
+
+// isTransientStartError reports whether a VM start failure is worth retrying.
+// Everything else, like a missing kernel or rootfs, is permanent.
+func isTransientStartError(err error) bool {
+	var transientErr *transientStartError
+	return errors.As(err, &transientErr)
+}
+
+// createVMWithRetry runs createVM, retrying transient failures with exponential backoff
+func (vm *VMService) createVMWithRetry(instanceID string, plugin *cms_models.Plugin, useSnapshot bool, memPath, statePath string) error {
+	backoff := time.Duration(vm.config.VMStartRetryBackoffMs) * time.Millisecond
+
+	// Make room first, so the caps bound memory even while the VM boots
+	vm.enforcePoolCaps(plugin.Slug, instanceID)
+
+	for attempt := 0; ; attempt++ {
+		err := vm.createVM(instanceID, plugin, useSnapshot, memPath, statePath)
+		if err == nil || attempt >= vm.config.VMStartRetries || !isTransientStartError(err) {
+			return err
+		}
+
+		vm.logger.WithFields(logger.Fields{
+			"instance_id": instanceID,
+			"plugin_slug": plugin.Slug,
+			"attempt":     attempt + 1,
+			"backoff_ms":  backoff.Milliseconds(),
+			"error":       err,
+		}).Warn("VM start failed transiently, retrying")
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// releaseFailedStart frees the tap, IP and socket a failed createVM attempt set up,
+// keeping those the plugin already owns in the registry for the next start
+func (vm *VMService) releaseFailedStart(plugin *cms_models.Plugin, tapName, ip, socketPath string, jailed bool) {
+	if tapName != "" && plugin.TapDevice == "" {
+		if err := vm.deleteTapInterface(tapName); err != nil {
+			vm.logger.WithFields(logger.Fields{
+				"plugin_slug": plugin.Slug,
+				"tap_name":    tapName,
+				"error":       err,
+			}).Warn("Failed to delete TAP interface after failed VM start")
+		}
+	}
+
+	if ip != "" && plugin.AssignedIP == "" {
+		vm.deallocateIP(ip)
+	}
+
+	// The jail, including its socket, is removed separately
+	if !jailed {
+		os.Remove(socketPath)
+	}
+}
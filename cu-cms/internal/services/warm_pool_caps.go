@@ -0,0 +1,144 @@
+/*
+ * Firecracker CMS - Warm Instance Caps
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"sort"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/logger"
+)
+
+// enforcePoolCaps stops least recently used parked instances until the pool
+// fits the per-plugin, global and memory caps. A non-empty pluginSlug reserves room for
+// an instance of that plugin about to be started as instanceID.
+func (vm *VMService) enforcePoolCaps(pluginSlug, instanceID string) {
+	if vm.InMaintenanceMode() {
+		return
+	}
+
+	vm.poolMutex.Lock()
+	evicted := vm.pickEvictionsUnsafe(pluginSlug, instanceID)
+	vm.poolMutex.Unlock()
+
+	// StopVM takes the pool lock itself
+	for _, instance := range evicted {
+		vm.logger.WithFields(logger.Fields{
+			"plugin_slug": instance.PluginSlug,
+			"instance_id": instance.InstanceID,
+			"last_used":   instance.LastUsed,
+		}).Info("Evicting least recently used warm instance")
+
+		if err := vm.StopVM(instance.InstanceID); err != nil {
+			vm.logger.WithFields(logger.Fields{
+				"instance_id": instance.InstanceID,
+				"error":       err,
+			}).Error("Failed to stop evicted warm instance")
+		}
+	}
+}
+
+// pickEvictionsUnsafe unclaims and returns the instances to evict. Only parked
+// instances no execution holds are evicted; instances serving an execution or
+// still booting count towards the caps but are left alone. Executions claim
+// instances under the pool lock, so the choice holds until it is released.
+// Note: Caller must hold vm.poolMutex.Lock()
+func (vm *VMService) pickEvictionsUnsafe(pluginSlug, instanceID string) []*PrewarmInstance {
+	perPlugin := make(map[string]int)
+	var parked []*PrewarmInstance
+	for _, instance := range vm.prewarmPool {
+		if instance.InstanceID == instanceID {
+			continue
+		}
+		perPlugin[instance.PluginSlug]++
+		// A claimed instance may still be paused until its execution resumes it
+		if !instance.PausedAt().IsZero() && instance.users.Load() == 0 {
+			parked = append(parked, instance)
+		}
+	}
+
+	total := len(vm.prewarmPool)
+	usedMib := vm.poolMemoryUnsafe()
+	if pluginSlug != "" {
+		perPlugin[pluginSlug]++
+		if previous, replaced := vm.prewarmPool[instanceID]; !replaced {
+			total++
+		} else {
+			usedMib -= vm.footprintUnsafe(previous.PluginSlug)
+		}
+		usedMib += vm.footprintUnsafe(pluginSlug)
+	}
+
+	sort.Slice(parked, func(i, j int) bool {
+		return parked[i].LastUsed.Before(parked[j].LastUsed)
+	})
+
+	var evicted []*PrewarmInstance
+	gone := make(map[*PrewarmInstance]bool)
+	evict := func(instance *PrewarmInstance) {
+		gone[instance] = true
+		if vm.warmInstances[instance.PluginSlug] == instance.InstanceID {
+			vm.evictedPlugins[instance.PluginSlug] = true
+		}
+		vm.unclaimWarmInstanceUnsafe(instance)
+		perPlugin[instance.PluginSlug]--
+		total--
+		usedMib -= vm.footprintUnsafe(instance.PluginSlug)
+		evicted = append(evicted, instance)
+	}
+
+	var remaining []*PrewarmInstance
+	for _, instance := range parked {
+		if perPlugin[instance.PluginSlug] > vm.pluginPoolCapUnsafe(instance.PluginSlug) {
+			evict(instance)
+		} else {
+			remaining = append(remaining, instance)
+		}
+	}
+
+	if vm.config.MaxWarmInstances > 0 {
+		for _, instance := range remaining {
+			if total <= vm.config.MaxWarmInstances {
+				break
+			}
+			evict(instance)
+		}
+		if total > vm.config.MaxWarmInstances {
+			vm.logger.WithFields(logger.Fields{
+				"instances":          total,
+				"max_warm_instances": vm.config.MaxWarmInstances,
+			}).Warn("Warm instance cap exceeded, no parked instance left to evict")
+		}
+	}
+
+	if vm.memoryBudgetMib > 0 {
+		for _, instance := range remaining {
+			if usedMib <= vm.memoryBudgetMib {
+				break
+			}
+			if !gone[instance] {
+				evict(instance)
+			}
+		}
+		if usedMib > vm.memoryBudgetMib {
+			vm.logger.WithFields(logger.Fields{
+				"memory_used_mib":   usedMib,
+				"memory_budget_mib": vm.memoryBudgetMib,
+			}).Warn("Warm memory budget exceeded, no parked instance left to evict")
+		}
+	}
+
+	return evicted
+}
+
+// WasEvicted reports whether a plugin's warm instance was evicted to honour
+// the pool caps and not replaced since
+func (vm *VMService) WasEvicted(pluginSlug string) bool {
+	vm.poolMutex.RLock()
+	defer vm.poolMutex.RUnlock()
+
+	return vm.evictedPlugins[pluginSlug]
+}
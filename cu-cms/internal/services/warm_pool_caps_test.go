@@ -0,0 +1,109 @@
+/*
+ * Firecracker CMS - Warm Instance Caps Tests
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/centraunit/cu-firecracker-cms/internal/models"
+)
+
+// parkTestInstance pools a paused instance last used at lastUsed
+func parkTestInstance(vm *VMService, pluginSlug, instanceID string, lastUsed time.Time) *PrewarmInstance {
+	instance := addTestInstance(vm, pluginSlug, instanceID)
+	instance.LastUsed = lastUsed
+	instance.setPausedAt(lastUsed)
+	return instance
+}
+
+func TestPickEvictionsLeastRecentlyUsed(t *testing.T) {
+	vm := newTestVMService()
+	vm.config.MaxWarmInstances = 2
+	now := time.Now()
+	oldest := parkTestInstance(vm, "a", "a-1", now.Add(-3*time.Minute))
+	parkTestInstance(vm, "b", "b-1", now.Add(-2*time.Minute))
+	parkTestInstance(vm, "c", "c-1", now.Add(-1*time.Minute))
+
+	evicted := vm.pickEvictionsUnsafe("", "")
+	if len(evicted) != 1 || evicted[0] != oldest {
+		t.Fatalf("evicted %v, want only the least recently used instance", evicted)
+	}
+	if _, claimable := vm.warmInstances["a"]; claimable {
+		t.Fatalf("evicted instance is still claimable")
+	}
+	if !vm.WasEvicted("a") {
+		t.Fatalf("plugin of the evicted instance not marked evicted")
+	}
+}
+
+func TestPickEvictionsSkipsClaimedInstances(t *testing.T) {
+	vm := newTestVMService()
+	vm.config.MaxWarmInstances = 1
+	now := time.Now()
+	claimed := parkTestInstance(vm, "a", "a-1", now.Add(-2*time.Minute))
+	idle := parkTestInstance(vm, "b", "b-1", now.Add(-1*time.Minute))
+
+	// Claimed but not yet resumed, so still parked
+	claimed.users.Add(1)
+
+	evicted := vm.pickEvictionsUnsafe("", "")
+	if len(evicted) != 1 || evicted[0] != idle {
+		t.Fatalf("evicted %v, want only the unclaimed instance", evicted)
+	}
+	if vm.warmInstances["a"] != "a-1" {
+		t.Fatalf("claimed instance was unclaimed from the pool")
+	}
+}
+
+func TestPickEvictionsReservesRoomForNewInstance(t *testing.T) {
+	vm := newTestVMService()
+	vm.config.MaxWarmInstances = 2
+	now := time.Now()
+	parkTestInstance(vm, "a", "a-1", now.Add(-2*time.Minute))
+	parkTestInstance(vm, "b", "b-1", now.Add(-1*time.Minute))
+
+	evicted := vm.pickEvictionsUnsafe("c", "c-1")
+	if len(evicted) != 1 || evicted[0].InstanceID != "a-1" {
+		t.Fatalf("evicted %v, want a-1 to make room for c-1", evicted)
+	}
+}
+
+func TestNeedsWarmInstance(t *testing.T) {
+	ps := newTestPluginService(t)
+	plugin := &models.Plugin{Slug: "blog", Status: models.PluginStatusActive}
+
+	if ps.needsWarmInstanceUnsafe(plugin) {
+		t.Fatalf("unregistered plugin needs a warm instance")
+	}
+
+	ps.plugins["blog"] = plugin
+	if !ps.needsWarmInstanceUnsafe(plugin) {
+		t.Fatalf("active plugin without an instance does not need one")
+	}
+
+	addTestInstance(ps.vmService, "blog", "blog-1")
+	if ps.needsWarmInstanceUnsafe(plugin) {
+		t.Fatalf("plugin with a warm instance needs another")
+	}
+}
+
+func TestColdStartEvictedSkipsRemovedPlugin(t *testing.T) {
+	ps := newTestPluginService(t)
+	ps.coldStartEvicted(&models.Plugin{Slug: "blog", Status: models.PluginStatusActive})
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, running := ps.coldStarts.Load("blog"); !running {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cold start of a removed plugin still marked running")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,26 @@
+/*
+ * Firecracker CMS - Build Information
+ * Copyright (c) 2025 CentraUnit Organization
+ * All rights reserved.
+ */
+
+package version
+
+// Set at build time with -ldflags "-X github.com/centraunit/cu-firecracker-cms/internal/version.Version=..."
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+}
+
+// Get returns the build information
+func Get() Info {
+	return Info{Version: Version, Commit: Commit, BuildDate: BuildDate}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/centraunit/cu-firecracker-cms/internal/logger"
 	"github.com/centraunit/cu-firecracker-cms/internal/server"
 	"github.com/centraunit/cu-firecracker-cms/internal/services"
+	"github.com/centraunit/cu-firecracker-cms/internal/version"
 )
 
 func main() {
@@ -39,17 +40,19 @@ func main() {
 	}
 
 	// Initialize logging
-	if err := logger.Init(cfg.GetLogLevel(), cfg.LogDir); err != nil {
+	if err := logger.Init(cfg.GetLogLevel(), cfg.LogDir, cfg.LogFormat, cfg.LogComponentLevels); err != nil {
 		log.Fatalf("Failed to initialize logging: %v", err)
 	}
 
 	log_instance := logger.GetDefault()
 	log_instance.WithFields(logger.Fields{
-		"version": "1.0.0",
-		"mode":    cfg.GetModeString(),
-		"debug":   cfg.IsDebugMode(),
-		"verbose": cfg.Verbose,
-		"port":    cfg.Port,
+		"version":    version.Version,
+		"commit":     version.Commit,
+		"build_date": version.BuildDate,
+		"mode":       cfg.GetModeString(),
+		"debug":      cfg.IsDebugMode(),
+		"verbose":    cfg.Verbose,
+		"address":    cfg.ListenAddr(),
 	}).Info("Starting CMS application")
 
 	// Print environment-specific startup banner
@@ -74,10 +77,10 @@ func main() {
 	}
 
 	// Initialize plugin service
-	pluginService := services.NewPluginService(cfg, log_instance, vmService)
+	pluginService := services.NewPluginService(cfg, log_instance.Component("plugin"), vmService)
 
 	// Initialize server
-	srv := server.New(cfg, log_instance, vmService, pluginService)
+	srv := server.New(cfg, log_instance.Component("server"), vmService, pluginService)
 
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -95,6 +98,24 @@ func main() {
 		cancel()
 	}()
 
+	// SIGUSR1 snapshots every active plugin for backup workflows
+	snapshotChan := make(chan os.Signal, 1)
+	signal.Notify(snapshotChan, syscall.SIGUSR1)
+
+	go func() {
+		for range snapshotChan {
+			log_instance.Info("Received SIGUSR1, snapshotting all active plugins")
+			for _, result := range pluginService.SnapshotAllActive() {
+				log_instance.WithFields(logger.Fields{
+					"plugin_slug": result.PluginSlug,
+					"success":     result.Success,
+					"error":       result.Error,
+					"duration_ms": result.DurationMs,
+				}).Info("Plugin snapshot result")
+			}
+		}
+	}()
+
 	// Start server in goroutine
 	serverErrChan := make(chan error, 1)
 	go func() {
@@ -122,9 +143,31 @@ func main() {
 			}).Error("Server shutdown failed")
 		}
 
-		// Stop VM service
-		vmService.Shutdown(shutdownCtx)
+		// Let background executions finish before their VMs are stopped
+		if pending := pluginService.WaitAsyncExecutions(shutdownCtx); pending > 0 {
+			log_instance.WithFields(logger.Fields{
+				"pending": pending,
+			}).Warn("Asynchronous executions still running at shutdown")
+		}
+
+		// Persist action counters and execution history recorded since the last flush
+		pluginService.FlushActionUsage()
+		pluginService.FlushExecutionHistory()
 
-		log_instance.Info("Graceful shutdown completed")
+		// Stop VM service
+		summary := vmService.Shutdown(shutdownCtx)
+
+		shutdownLog := log_instance.WithFields(logger.Fields{
+			"total":         summary.Total,
+			"stopped_clean": summary.StoppedClean,
+			"force_killed":  summary.ForceKilled,
+			"errored":       summary.Errored,
+			"elapsed_ms":    summary.ElapsedMs,
+		})
+		if summary.ForceKilled > 0 || summary.Errored > 0 {
+			shutdownLog.Warn("Shutdown completed with VMs that did not stop cleanly")
+		} else {
+			shutdownLog.Info("Graceful shutdown completed")
+		}
 	}
 }
@@ -253,6 +253,22 @@ try {
         case '/actions/cache':
             echo json_encode($cm->handleCacheAction($input));
             break;
+
+        case '/time-sync':
+            // Set the system clock, which stood still while the VM was paused
+            if (!isset($input['unix_ms']) || !is_int($input['unix_ms'])) {
+                http_response_code(400);
+                echo json_encode(['success' => false, 'error' => 'unix_ms is required']);
+                break;
+            }
+            exec('date -s @' . intdiv($input['unix_ms'], 1000) . ' 2>&1', $output, $status);
+            if ($status !== 0) {
+                http_response_code(500);
+                echo json_encode(['success' => false, 'error' => implode("\n", $output)]);
+                break;
+            }
+            echo json_encode(['success' => true, 'paused_ms' => $input['paused_ms'] ?? 0]);
+            break;
             
         case '/':
             echo json_encode([
@@ -248,6 +248,19 @@ def ping():
         "response_time_ms": 1
     })
 
+@app.route('/time-sync', methods=['POST'])
+def time_sync():
+    """Set the system clock, which stood still while the VM was paused"""
+    body = request.get_json(silent=True) or {}
+    unix_ms = body.get("unix_ms")
+    if not isinstance(unix_ms, int):
+        return jsonify({"success": False, "error": "unix_ms is required"}), 400
+    try:
+        time.clock_settime(time.CLOCK_REALTIME, unix_ms / 1000)
+    except OSError as e:
+        return jsonify({"success": False, "error": str(e)}), 500
+    return jsonify({"success": True, "paused_ms": body.get("paused_ms", 0)})
+
 @app.route('/benchmark', methods=['GET', 'POST'])
 def benchmark():
     """Pure computation benchmark"""
@@ -1,4 +1,5 @@
 import * as fs from 'fs';
+import { execFile } from 'child_process';
 import * as path from 'path';
 import express from 'express';
 import { Request, Response } from 'express';
@@ -244,6 +245,22 @@ app.post('/actions/seo', (req: Request, res: Response) => {
         }
 });
 
+// Set the system clock, which stood still while the VM was paused
+app.post('/time-sync', (req: Request, res: Response) => {
+    const unixMs = req.body?.unix_ms;
+    if (!Number.isInteger(unixMs)) {
+        res.status(400).json({ success: false, error: 'unix_ms is required' });
+        return;
+    }
+    execFile('date', ['-s', `@${Math.floor(unixMs / 1000)}`], (error) => {
+        if (error) {
+            res.status(500).json({ success: false, error: error.message });
+            return;
+        }
+        res.json({ success: true, paused_ms: req.body.paused_ms ?? 0 });
+    });
+});
+
 // Root endpoint
 app.get('/', (req: Request, res: Response) => {
     res.json({